@@ -33,6 +33,26 @@ func main() {
 	migrations := []string{
 		"migrations/001_initial_schema.sql",
 		"migrations/002_redis_removal.sql",
+		"migrations/003_user_activity_cache.sql",
+		"migrations/004_scheduled_regeneration.sql",
+		"migrations/005_template_variables.sql",
+		"migrations/006_audit_logs.sql",
+		"migrations/007_profile_search.sql",
+		"migrations/008_analysis_cache_scope.sql",
+		"migrations/009_typing_svg_config.sql",
+		"migrations/010_webhook_secrets.sql",
+		"migrations/011_generation_cooldowns.sql",
+		"migrations/012_ab_tests.sql",
+		"migrations/013_wizard_sessions.sql",
+		"migrations/014_email_demo_accounts.sql",
+		"migrations/015_system_metrics.sql",
+		"migrations/016_layout_config.sql",
+		"migrations/017_profile_cached_at.sql",
+		"migrations/018_profile_stale_at.sql",
+		"migrations/019_encrypted_tokens.sql",
+		"migrations/020_profile_showcase.sql",
+		"migrations/021_full_analysis_cache.sql",
+		"migrations/022_email_login_limits.sql",
 	}
 
 	for _, path := range migrations {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,17 +12,22 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	apidocs "github.com/krauzx/gitright/internal/api"
 	"github.com/krauzx/gitright/internal/config"
 	"github.com/krauzx/gitright/internal/github"
 	"github.com/krauzx/gitright/internal/handlers"
+	v2 "github.com/krauzx/gitright/internal/handlers/v2"
 	"github.com/krauzx/gitright/internal/llm"
+	"github.com/krauzx/gitright/internal/preview"
 	"github.com/krauzx/gitright/internal/repository"
 	"github.com/krauzx/gitright/internal/routes"
 	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/validators"
 	"github.com/krauzx/gitright/pkg/logger"
+	"github.com/krauzx/gitright/pkg/telemetry"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"golang.org/x/time/rate"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
 func main() {
@@ -44,21 +50,47 @@ func main() {
 		"port", cfg.Port,
 	)
 
-	db, err := repository.NewPostgresDB(cfg.Database)
+	shutdownTelemetry, err := telemetry.Setup(cfg.Telemetry)
+	if err != nil {
+		slog.Error("Failed to initialize telemetry", "error", err)
+		os.Exit(1)
+	}
+
+	db, dbMonitor, err := repository.NewPostgresDB(cfg.Database)
 	if err != nil {
 		slog.Error("Failed to connect to database", "error", err)
 		os.Exit(1)
 	}
 	defer db.Close()
+	defer dbMonitor.Stop()
+
+	tokenEncryptor, err := repository.NewTokenEncryptor(cfg.Session.EncryptionKey)
+	if err != nil {
+		slog.Error("Failed to initialize token encryption", "error", err)
+		os.Exit(1)
+	}
 
-	userRepo := repository.NewUserRepository(db)
+	userRepo := repository.NewUserRepository(db, tokenEncryptor)
+	// Tokens stored before encryption are otherwise only encrypted on login
+	if n, err := userRepo.EncryptLegacyTokens(context.Background()); err != nil {
+		slog.Error("Failed to encrypt plain text tokens", "error", err)
+	} else if n > 0 {
+		slog.Info("Encrypted plain text tokens", "users", n)
+	}
 	projectRepo := repository.NewProjectRepository(db)
 	sessionRepo := repository.NewSessionRepository(db)
 	profileCacheRepo := repository.NewProfileCacheRepository(db)
 	repoCacheRepo := repository.NewRepositoryCacheRepository(db)
+	prefsRepo := repository.NewPreferencesRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	cooldownRepo := repository.NewCooldownRepository(db)
+	abTestRepo := repository.NewABTestRepository(db)
+	wizardRepo := repository.NewWizardSessionRepository(db)
+	emailLoginRepo := repository.NewEmailLoginRepository(db)
+	metricsRepo := repository.NewMetricsRepository(db)
 
 	githubClient := github.NewClient(cfg.GitHub)
-	githubAnalyzer := github.NewAnalyzer(githubClient)
+	githubAnalyzer := github.NewAnalyzer(githubClient, cfg.Analysis)
 
 	contentGenerator, err := llm.NewContentGenerator(cfg.GoogleAI)
 	if err != nil {
@@ -66,9 +98,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	authService := services.NewAuthService(githubClient, userRepo, sessionRepo)
-	githubService := services.NewGitHubService(githubClient, githubAnalyzer, repoCacheRepo)
-	profileService := services.NewProfileService(contentGenerator, projectRepo, githubService, profileCacheRepo)
+	auditService := services.NewAuditService(auditRepo)
+	githubService := services.NewGitHubService(githubClient, githubAnalyzer, repoCacheRepo, auditService, cfg.Analysis)
+	emailService := services.NewEmailService(cfg.Email, cfg.FrontendURL+"/dashboard")
+	authService := services.NewAuthService(githubClient, userRepo, sessionRepo, auditService, githubService, emailService, emailLoginRepo)
+	profileService := services.NewProfileService(contentGenerator, projectRepo, githubService, profileCacheRepo, prefsRepo, emailService, auditService, cooldownRepo, abTestRepo, metricsRepo, cfg.Generation, cfg.Security.AdminUsernames)
+	scheduler := services.NewRegenerationScheduler(prefsRepo, userRepo, profileCacheRepo, profileService, githubService, cfg.GoogleAI.APIKey)
+	preferencesService := services.NewPreferencesService(prefsRepo, profileCacheRepo, scheduler)
+	wizardService := services.NewWizardService(wizardRepo)
 
 	authHandler := handlers.NewAuthHandler(
 		authService,
@@ -78,17 +115,49 @@ func main() {
 		cfg.FrontendURL,
 		cfg.Session.Secret,
 		cfg.GitHub.Scopes,
+		cfg.Session.CookieDomain,
+		cfg.Session.MaxAge,
 	)
 	githubHandler := handlers.NewGitHubHandler(githubService)
-	profileHandler := handlers.NewProfileHandler(profileService)
-	healthHandler := handlers.NewHealthHandler(db)
-	wsHandler := handlers.NewWebSocketHandler(profileService, cfg.CORS.AllowedOrigins)
+	previewRenderer := preview.NewRenderer(profileService.AssetHosts())
+	if !previewRenderer.Available() {
+		slog.Info("Chromium not found, profile image previews disabled")
+	}
+	validate := validators.New()
+	profileHandler := handlers.NewProfileHandler(profileService, githubService, wizardService, previewRenderer, validate)
+	profileHandlerV2 := v2.NewProfileHandlerV2(profileService, validate)
+	healthHandler := handlers.NewHealthHandler(dbMonitor, map[string]*github.CircuitBreaker{
+		"github": githubClient.CircuitBreaker(),
+		"gemini": llm.CircuitBreaker(),
+	}, []handlers.HealthCheck{
+		{Name: "database", Timeout: 2 * time.Second, Critical: true, Check: db.PingContext},
+		{Name: "gemini", Timeout: 3 * time.Second, Check: contentGenerator.Ping},
+		{Name: "github", Timeout: 2 * time.Second, Check: githubClient.Ping},
+	})
+	defer healthHandler.Stop()
+	wsHandler := handlers.NewWebSocketHandler(profileService, githubService, validate, cfg.CORS.AllowedOrigins)
+	preferencesHandler := handlers.NewPreferencesHandler(preferencesService)
+	metricsService := services.NewMetricsService(metricsRepo, githubService)
+	adminHandler := handlers.NewAdminHandler(profileCacheRepo, emailService, auditService, metricsService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+
+	var docsHandler *apidocs.DocsHandler
+	if cfg.Environment != "production" || cfg.EnableAPIDocs {
+		docsHandler, err = apidocs.NewDocsHandler()
+		if err != nil {
+			slog.Error("Failed to initialize API docs", "error", err)
+			os.Exit(1)
+		}
+	}
 
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
+	e.IPExtractor = ipExtractor(cfg.Security.TrustedProxyCIDRs)
 
 	e.Use(middleware.RequestID())
+	e.Use(logger.ContextMiddleware())
+	e.Use(otelecho.Middleware(cfg.Telemetry.ServiceName))
 	e.Use(middleware.Recover())
 	e.Use(logger.Middleware())
 
@@ -107,9 +176,6 @@ func main() {
 		AllowHeaders:     cfg.CORS.AllowedHeaders,
 		AllowCredentials: true,
 	}))
-	e.Use(middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
-		Store: middleware.NewRateLimiterMemoryStore(rate.Limit(cfg.RateLimit.RequestsPerMinute)),
-	}))
 	e.Use(middleware.SecureWithConfig(middleware.SecureConfig{
 		XSSProtection:         "1; mode=block",
 		ContentTypeNosniff:    "nosniff",
@@ -118,7 +184,15 @@ func main() {
 		ContentSecurityPolicy: "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data: https:; font-src 'self' data:;",
 	}))
 
-	routes.RegisterRoutes(e, authHandler, githubHandler, profileHandler, healthHandler, wsHandler, userRepo, sessionRepo, cfg.Session.Secret)
+	routes.RegisterRoutes(
+		e, authHandler, githubHandler, profileHandler, profileHandlerV2, healthHandler, wsHandler, preferencesHandler, adminHandler, auditHandler, docsHandler, handlers.NewPrometheusHandler(metricsService),
+		userRepo, sessionRepo, cfg.Session.Secret, cfg.APIVersions, cfg.RateLimit, cfg.Security,
+	)
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		slog.Error("Failed to start regeneration scheduler", "error", err)
+		os.Exit(1)
+	}
 
 	go func() {
 		addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
@@ -146,10 +220,27 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	scheduler.Stop(ctx)
+
 	if err := e.Shutdown(ctx); err != nil {
 		slog.Error("Server forced to shutdown", "error", err)
+		shutdownTelemetry()
 		os.Exit(1)
 	}
 
+	shutdownTelemetry()
+
 	slog.Info("Server exited gracefully")
 }
+
+// ipExtractor reads the client IP from X-Forwarded-For, trusting hops from
+// loopback, private ranges and the configured proxies only.
+func ipExtractor(trustedProxyCIDRs []string) echo.IPExtractor {
+	var opts []echo.TrustOption
+	for _, cidr := range trustedProxyCIDRs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			opts = append(opts, echo.TrustIPRange(ipNet))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed docs.html
+var docsFS embed.FS
+
+// docsCSP relaxes the global CSP for the docs page only, since Swagger UI is
+// loaded from unpkg.
+const docsCSP = "default-src 'self'; script-src 'self' 'unsafe-inline' https://unpkg.com; style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data: https:;"
+
+type DocsHandler struct {
+	specJSON []byte
+	docsHTML []byte
+}
+
+// NewDocsHandler converts the embedded YAML spec to JSON once at startup so a
+// malformed spec fails fast instead of on first request.
+func NewDocsHandler() (*DocsHandler, error) {
+	var spec map[string]interface{}
+	if err := yaml.Unmarshal(specYAML, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+
+	docsHTML, err := docsFS.ReadFile("docs.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docs page: %w", err)
+	}
+
+	return &DocsHandler{specJSON: specJSON, docsHTML: docsHTML}, nil
+}
+
+func (h *DocsHandler) Spec(c echo.Context) error {
+	return c.JSONBlob(http.StatusOK, h.specJSON)
+}
+
+func (h *DocsHandler) Docs(c echo.Context) error {
+	c.Response().Header().Set("Content-Security-Policy", docsCSP)
+	return c.HTMLBlob(http.StatusOK, h.docsHTML)
+}
@@ -0,0 +1,21 @@
+// Package apierr holds the error payloads shared by API responses.
+package apierr
+
+// FieldError is one invalid field of a request body. Field is the JSON path,
+// e.g. "projects" or "custom_sections[0].title".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationFailed is the body of a 400 response to a request that failed
+// validation, listing every invalid field.
+func ValidationFailed(fields []FieldError) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    "validation_failed",
+			"message": "Request validation failed",
+			"fields":  fields,
+		},
+	}
+}
@@ -0,0 +1,36 @@
+// Package audit defines audit log action names and carries the client's IP
+// and user agent through request contexts to where entries are recorded.
+package audit
+
+import "context"
+
+const (
+	ActionProfileGenerated  = "profile.generated"
+	ActionProfileDeployed   = "profile.deployed"
+	ActionProfileRolledBack = "profile.rolled_back"
+	ActionProjectAdded      = "project.added"
+	ActionProjectRemoved    = "project.removed"
+	ActionAccountLogin      = "account.login"
+	ActionAccountLogout     = "account.logout"
+	ActionAccountDeleted    = "account.deleted"
+	ActionCacheCleared      = "cache.cleared"
+)
+
+type clientKey struct{}
+
+type client struct {
+	ip        string
+	userAgent string
+}
+
+// WithClient returns a copy of ctx carrying the request's client details.
+func WithClient(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client{ip: ip, userAgent: userAgent})
+}
+
+// ClientFromContext returns the client details stored by WithClient, or empty
+// strings for background work such as scheduled regeneration.
+func ClientFromContext(ctx context.Context) (ip, userAgent string) {
+	c, _ := ctx.Value(clientKey{}).(client)
+	return c.ip, c.userAgent
+}
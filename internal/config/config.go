@@ -3,7 +3,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,14 +19,23 @@ type Config struct {
 	LogLevel    string
 	FrontendURL string
 	HTTPTimeout time.Duration
+	// EnableAPIDocs exposes the OpenAPI spec and Swagger UI in production.
+	// They are always available in other environments.
+	EnableAPIDocs bool
+	// APIVersions lists the route prefixes to serve, e.g. /api/v1 and /api/v2.
+	APIVersions []string
 
-	GitHub    GitHubConfig
-	GoogleAI  GoogleAIConfig
-	Database  DatabaseConfig
-	Session   SessionConfig
-	CORS      CORSConfig
-	RateLimit RateLimitConfig
-	Security  SecurityConfig
+	GitHub     GitHubConfig
+	GoogleAI   GoogleAIConfig
+	Database   DatabaseConfig
+	Session    SessionConfig
+	CORS       CORSConfig
+	RateLimit  RateLimitConfig
+	Security   SecurityConfig
+	Generation GenerationConfig
+	Email      EmailConfig
+	Analysis   AnalysisConfig
+	Telemetry  TelemetryConfig
 }
 
 type GitHubConfig struct {
@@ -39,6 +51,9 @@ type GoogleAIConfig struct {
 	UseGrounding bool
 	Timeout      time.Duration
 	MaxRetries   int
+	// EstimationAPIKey is used for token counting by the cost estimate
+	// endpoint instead of APIKey when set.
+	EstimationAPIKey string
 }
 
 type DatabaseConfig struct {
@@ -52,6 +67,8 @@ type SessionConfig struct {
 	Secret        string
 	MaxAge        int
 	EncryptionKey string
+	// CookieDomain scopes the gitright_session cookie; empty means the API host.
+	CookieDomain string
 }
 
 type CORSConfig struct {
@@ -63,12 +80,95 @@ type CORSConfig struct {
 type RateLimitConfig struct {
 	RequestsPerMinute int
 	Burst             int
+	// VersionRequestsPerMinute holds optional per-API-version limits applied
+	// in addition to the global one, keyed by version (e.g. "v2").
+	VersionRequestsPerMinute map[string]int
 }
 
 type SecurityConfig struct {
 	EnableHTTPS bool
 	CertFile    string
 	KeyFile     string
+	// AdminAllowedCIDRs restricts /api/v1/admin to these networks. Empty
+	// allows any IP.
+	AdminAllowedCIDRs []string
+	// AdminUsernames are the GitHub usernames allowed to call admin routes.
+	AdminUsernames []string
+	// TrustedProxyCIDRs are proxies whose X-Forwarded-For entries are trusted
+	// in addition to loopback and private ranges.
+	TrustedProxyCIDRs []string
+	// InternalSecret, sent in X-Internal-Secret, exempts internal callers
+	// from per-IP rate limits. Empty disables the exemption.
+	InternalSecret string
+	// MetricsScrapeToken, sent as a bearer token, lets Prometheus scrape
+	// /metrics from outside AdminAllowedCIDRs. /metrics is closed when both
+	// are empty.
+	MetricsScrapeToken string
+}
+
+// TelemetryConfig configures OpenTelemetry export. Tracing and metrics are
+// disabled when OTLPEndpoint is empty.
+type TelemetryConfig struct {
+	ServiceName  string
+	OTLPEndpoint string
+	Insecure     bool
+}
+
+type AnalysisConfig struct {
+	// MaxFileDepth is how many directory levels below the root are listed
+	// (0 = root only). Each directory costs one GitHub API call, capped by
+	// MaxFileListAPICalls per repository.
+	MaxFileDepth        int
+	MaxFileListAPICalls int
+	// EnableStarTimeline fetches stargazer timestamps for repositories with
+	// more than 50 stars to compute star growth. It costs up to 10 extra API
+	// calls per repository.
+	EnableStarTimeline bool
+	// EnableIssueStats samples recently closed issues to measure maintainer
+	// response time. It costs up to 12 extra API calls per repository.
+	EnableIssueStats bool
+	// EnableVulnerabilityCheck reads each repository's open Dependabot
+	// alerts. It costs 2 extra API calls per repository and needs the
+	// security_events OAuth scope.
+	EnableVulnerabilityCheck bool
+	// EnableLanguageHistory snapshots the language breakdown monthly over
+	// the past year to spot language migrations. It costs up to 24 extra API
+	// calls per repository.
+	EnableLanguageHistory bool
+	// MaxConcurrentAnalysesPerUser bounds the repository analyses one user
+	// runs at once; 0 disables the limit. An analysis waits up to
+	// PerRepoTimeout for a free slot.
+	MaxConcurrentAnalysesPerUser int
+	PerRepoTimeout               time.Duration
+}
+
+// EmailConfig configures SMTP delivery. An empty Host disables email.
+type EmailConfig struct {
+	Host        string
+	Port        string
+	Username    string
+	Password    string
+	FromAddress string
+}
+
+type GenerationConfig struct {
+	// DefaultTemplateVars are server-wide values for markdown template
+	// placeholders; users can override them individually.
+	DefaultTemplateVars map[string]string
+	// MaxBadgesPerCategory caps each Tech Stack group; MaxTotalBadges caps
+	// the section overall. Zero disables a limit.
+	MaxBadgesPerCategory int
+	MaxTotalBadges       int
+	// MinStarsForFeatured drops repositories with fewer stars from generation
+	// unless they are marked personal_favorite. Zero disables the filter.
+	MinStarsForFeatured int
+	// ShieldsBaseURL, GithubStatsBaseURL and StreakStatsBaseURL are where
+	// generated profiles load badges, stats cards and the streak card from,
+	// so air-gapped installs can point them at self-hosted instances. They
+	// must be https URLs and are stored without a trailing slash.
+	ShieldsBaseURL     string
+	GithubStatsBaseURL string
+	StreakStatsBaseURL string
 }
 
 // Load reads all configuration from environment variables. Returns a joined
@@ -102,6 +202,9 @@ func Load() (*Config, error) {
 		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
 		HTTPTimeout: getEnvAsDuration("HTTP_TIMEOUT", 5*time.Minute),
 
+		EnableAPIDocs: getEnvAsBool("ENABLE_API_DOCS", false),
+		APIVersions:   getEnvAsList("API_VERSIONS", "v1,v2"),
+
 		GitHub: GitHubConfig{
 			ClientID:     githubClientID,
 			ClientSecret: githubClientSecret,
@@ -110,11 +213,12 @@ func Load() (*Config, error) {
 		},
 
 		GoogleAI: GoogleAIConfig{
-			APIKey:       getEnv("GOOGLE_AI_API_KEY", ""),
-			Model:        getEnv("GOOGLE_AI_MODEL", "gemini-2.5-flash-preview-0409-2025"),
-			UseGrounding: getEnvAsBool("GOOGLE_AI_USE_GROUNDING", false),
-			Timeout:      getEnvAsDuration("GOOGLE_AI_TIMEOUT", 5*time.Minute),
-			MaxRetries:   getEnvAsInt("GOOGLE_AI_MAX_RETRIES", 2),
+			APIKey:           getEnv("GOOGLE_AI_API_KEY", ""),
+			Model:            getEnv("GOOGLE_AI_MODEL", "gemini-2.5-flash-preview-0409-2025"),
+			UseGrounding:     getEnvAsBool("GOOGLE_AI_USE_GROUNDING", false),
+			Timeout:          getEnvAsDuration("GOOGLE_AI_TIMEOUT", 5*time.Minute),
+			MaxRetries:       getEnvAsInt("GOOGLE_AI_MAX_RETRIES", 2),
+			EstimationAPIKey: getEnv("GOOGLE_AI_ESTIMATION_API_KEY", ""),
 		},
 
 		Database: DatabaseConfig{
@@ -128,6 +232,7 @@ func Load() (*Config, error) {
 			Secret:        sessionSecret,
 			MaxAge:        getEnvAsInt("SESSION_MAX_AGE", 86400),
 			EncryptionKey: tokenEncryptionKey,
+			CookieDomain:  getEnv("COOKIE_DOMAIN", ""),
 		},
 
 		CORS: CORSConfig{
@@ -145,9 +250,56 @@ func Load() (*Config, error) {
 			EnableHTTPS: getEnvAsBool("ENABLE_HTTPS", false),
 			CertFile:    getEnv("CERT_FILE", ""),
 			KeyFile:     getEnv("KEY_FILE", ""),
+
+			AdminAllowedCIDRs: getEnvAsList("ADMIN_ALLOWED_CIDRS", ""),
+			AdminUsernames:    getEnvAsList("ADMIN_USERNAMES", ""),
+			TrustedProxyCIDRs: getEnvAsList("TRUSTED_PROXY_CIDRS", ""),
+			InternalSecret:    getEnv("INTERNAL_SECRET", ""),
+
+			MetricsScrapeToken: getEnv("METRICS_SCRAPE_TOKEN", ""),
+		},
+
+		Generation: GenerationConfig{
+			DefaultTemplateVars:  getTemplateVarsFromEnv(),
+			MaxBadgesPerCategory: getEnvAsInt("MAX_BADGES_PER_CATEGORY", 10),
+			MaxTotalBadges:       getEnvAsInt("MAX_TOTAL_BADGES", 30),
+			MinStarsForFeatured:  getEnvAsInt("MIN_STARS_FEATURED", 0),
+
+			ShieldsBaseURL:     strings.TrimSuffix(getEnv("SHIELDS_BASE_URL", "https://img.shields.io"), "/"),
+			GithubStatsBaseURL: strings.TrimSuffix(getEnv("GITHUB_STATS_BASE_URL", "https://github-readme-stats.vercel.app"), "/"),
+			StreakStatsBaseURL: strings.TrimSuffix(getEnv("STREAK_STATS_BASE_URL", "https://streak-stats.demolab.com"), "/"),
+		},
+
+		Telemetry: TelemetryConfig{
+			ServiceName:  getEnv("OTEL_SERVICE_NAME", "gitright"),
+			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+			Insecure:     getEnvAsBool("OTEL_EXPORTER_OTLP_INSECURE", false),
+		},
+
+		Analysis: AnalysisConfig{
+			MaxFileDepth:        getEnvAsInt("ANALYSIS_MAX_FILE_DEPTH", 2),
+			MaxFileListAPICalls: getEnvAsInt("ANALYSIS_MAX_FILE_LIST_API_CALLS", 15),
+			EnableStarTimeline:  getEnvAsBool("ANALYSIS_ENABLE_STAR_TIMELINE", false),
+			EnableIssueStats:    getEnvAsBool("ANALYSIS_ENABLE_ISSUE_STATS", false),
+
+			EnableVulnerabilityCheck: getEnvAsBool("ANALYSIS_ENABLE_VULNERABILITY_CHECK", false),
+			EnableLanguageHistory:    getEnvAsBool("ANALYSIS_ENABLE_LANGUAGE_HISTORY", false),
+
+			MaxConcurrentAnalysesPerUser: getEnvAsInt("MAX_CONCURRENT_ANALYSES_PER_USER", 3),
+			PerRepoTimeout:               getEnvAsDuration("ANALYSIS_PER_REPO_TIMEOUT", 30*time.Second),
+		},
+
+		Email: EmailConfig{
+			Host:        getEnv("SMTP_HOST", ""),
+			Port:        getEnv("SMTP_PORT", "587"),
+			Username:    getEnv("SMTP_USERNAME", ""),
+			Password:    getEnv("SMTP_PASSWORD", ""),
+			FromAddress: getEnv("SMTP_FROM_ADDRESS", "noreply@gitright.dev"),
 		},
 	}
 
+	cfg.RateLimit.VersionRequestsPerMinute = getVersionRateLimitsFromEnv(cfg.APIVersions)
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -160,15 +312,60 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("TOKEN_ENCRYPTION_KEY must be exactly 32 bytes for AES-256")
 	}
 
+	if !slices.Contains(c.APIVersions, "v1") {
+		return fmt.Errorf("API_VERSIONS must include v1")
+	}
+
+	for _, cidr := range slices.Concat(c.Security.AdminAllowedCIDRs, c.Security.TrustedProxyCIDRs) {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+	}
+
 	if c.Security.EnableHTTPS {
 		if c.Security.CertFile == "" || c.Security.KeyFile == "" {
 			return fmt.Errorf("CERT_FILE and KEY_FILE must be set when HTTPS is enabled")
 		}
 	}
 
+	for _, base := range []struct{ env, value string }{
+		{"SHIELDS_BASE_URL", c.Generation.ShieldsBaseURL},
+		{"GITHUB_STATS_BASE_URL", c.Generation.GithubStatsBaseURL},
+		{"STREAK_STATS_BASE_URL", c.Generation.StreakStatsBaseURL},
+	} {
+		u, err := url.Parse(base.value)
+		if err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("%s must be an https URL without query or fragment, got %q", base.env, base.value)
+		}
+	}
+
 	return nil
 }
 
+// getVersionRateLimitsFromEnv reads RATE_LIMIT_<VERSION>_REQUESTS_PER_MINUTE
+// for each served API version, e.g. RATE_LIMIT_V2_REQUESTS_PER_MINUTE.
+func getVersionRateLimitsFromEnv(versions []string) map[string]int {
+	limits := make(map[string]int)
+	for _, v := range versions {
+		if rpm := getEnvAsInt("RATE_LIMIT_"+strings.ToUpper(v)+"_REQUESTS_PER_MINUTE", 0); rpm > 0 {
+			limits[v] = rpm
+		}
+	}
+	return limits
+}
+
+// getTemplateVarsFromEnv reads TEMPLATE_VAR_<NAME> for each supported
+// template variable, e.g. TEMPLATE_VAR_FOOTER_TEXT.
+func getTemplateVarsFromEnv() map[string]string {
+	vars := make(map[string]string)
+	for _, name := range []string{"COMPANY_LOGO", "FOOTER_TEXT", "CUSTOM_BADGE_URL"} {
+		if v := os.Getenv("TEMPLATE_VAR_" + name); v != "" {
+			vars[name] = v
+		}
+	}
+	return vars
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -176,6 +373,18 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvAsList splits a comma-separated variable, or defaultValue when it is
+// unset, trimming entries and dropping empty ones.
+func getEnvAsList(key, defaultValue string) []string {
+	var list []string
+	for _, v := range strings.Split(getEnv(key, defaultValue), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
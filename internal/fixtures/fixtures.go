@@ -0,0 +1,42 @@
+// Package fixtures holds the sample repositories served to email demo
+// accounts, which have no GitHub identity to analyze.
+package fixtures
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// SampleOwner owns every sample repository.
+const SampleOwner = "gitright-demo"
+
+//go:embed sample_repos.json
+var sampleRepos []byte
+
+// SampleRepositories returns the sample repository analyses. Each call
+// decodes a fresh copy, so callers may modify the result.
+func SampleRepositories() ([]models.RepositoryAnalysis, error) {
+	var analyses []models.RepositoryAnalysis
+	if err := json.Unmarshal(sampleRepos, &analyses); err != nil {
+		return nil, fmt.Errorf("failed to decode sample repositories: %w", err)
+	}
+	return analyses, nil
+}
+
+// SampleRepository returns the analysis of the sample repository name, or
+// nil when there is none.
+func SampleRepository(name string) (*models.RepositoryAnalysis, error) {
+	analyses, err := SampleRepositories()
+	if err != nil {
+		return nil, err
+	}
+	for i := range analyses {
+		if analyses[i].Repository.Name == name {
+			return &analyses[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -4,79 +4,481 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v60/github"
+	"github.com/krauzx/gitright/internal/config"
 	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/pkg/logger"
+	"golang.org/x/sync/errgroup"
 )
 
 type Analyzer struct {
 	client *Client
+	cfg    config.AnalysisConfig
 }
 
-func NewAnalyzer(client *Client) *Analyzer {
-	return &Analyzer{client: client}
+func NewAnalyzer(client *Client, cfg config.AnalysisConfig) *Analyzer {
+	return &Analyzer{client: client, cfg: cfg}
+}
+
+// skippedDirs are never descended into when listing files, except that a
+// root dist/ is checked for committed .wasm builds.
+var skippedDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	".git":         true,
+	"dist":         true,
+	"build":        true,
+}
+
+// AnalysisStep identifies a stage of AnalyzeRepositoryWithProgress.
+type AnalysisStep int
+
+const (
+	StepFetchRepo AnalysisStep = iota
+	StepFetchLanguages
+	StepListFiles
+	StepFetchKeyFiles
+	StepExtractDeps
+	StepCountCommits
+	StepCountContributors
+	StepCommitConvention
+	StepStarTimeline
+	StepIssueStats
+	StepCommitFrequency
+	StepVulnerabilityAlerts
+	StepLanguageHistory
+
+	totalAnalysisSteps = int(StepLanguageHistory) + 1
+)
+
+const (
+	// starTimelineMinStars is the star count below which the stargazer
+	// timeline isn't fetched; growth on small repos isn't a useful signal.
+	starTimelineMinStars = 50
+	starGrowthWindow     = 90 * 24 * time.Hour
+	starPeakWindow       = 7 * 24 * time.Hour
+)
+
+// commitMessageSampleSize is how many recent commits are checked for
+// Conventional Commits conformance.
+const commitMessageSampleSize = 50
+
+// conventionalCommitThreshold is the conformance rate above which a
+// repository is considered to use Conventional Commits.
+const conventionalCommitThreshold = 0.7
+
+var conventionalCommitPattern = regexp.MustCompile(`^(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([^)]*\))?!?: \S`)
+
+func (s AnalysisStep) String() string {
+	switch s {
+	case StepFetchRepo:
+		return "fetch_repo"
+	case StepFetchLanguages:
+		return "fetch_languages"
+	case StepListFiles:
+		return "list_files"
+	case StepFetchKeyFiles:
+		return "fetch_key_files"
+	case StepExtractDeps:
+		return "extract_dependencies"
+	case StepCountCommits:
+		return "count_commits"
+	case StepCountContributors:
+		return "count_contributors"
+	case StepCommitConvention:
+		return "commit_convention"
+	case StepStarTimeline:
+		return "star_timeline"
+	case StepIssueStats:
+		return "issue_stats"
+	case StepCommitFrequency:
+		return "commit_frequency"
+	case StepVulnerabilityAlerts:
+		return "vulnerability_alerts"
+	case StepLanguageHistory:
+		return "language_history"
+	default:
+		return "unknown"
+	}
 }
 
 func (a *Analyzer) AnalyzeRepository(ctx context.Context, token, owner, repo string) (*models.RepositoryAnalysis, error) {
+	return a.AnalyzeRepositoryWithProgress(ctx, token, owner, repo, nil)
+}
+
+// AnalyzeRepositoryWithProgress behaves like AnalyzeRepository but invokes cb
+// after each step completes with the fraction of steps done so far. cb may be
+// nil. Steps after fetching the repository run concurrently, so they complete
+// in no fixed order; cb calls are serialized.
+func (a *Analyzer) AnalyzeRepositoryWithProgress(ctx context.Context, token, owner, repo string, cb func(AnalysisStep, float64)) (*models.RepositoryAnalysis, error) {
+	var mu sync.Mutex
+	completed := 0
+	done := func(step AnalysisStep) {
+		if cb == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		completed++
+		cb(step, float64(completed)/float64(totalAnalysisSteps))
+	}
+
 	repository, err := a.client.GetRepository(ctx, token, owner, repo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repository: %w", err)
 	}
+	done(StepFetchRepo)
 
-	languages, err := a.client.GetRepositoryLanguages(ctx, token, owner, repo)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get languages: %w", err)
-	}
+	var (
+		languages        map[string]int
+		languageHistory  []models.LanguageSnapshot
+		files            []string
+		wasmBytes        int
+		keyFiles         map[string]string
+		dependencies     map[string][]string
+		commitCount      int
+		contributorCount int
+		commitConvention *models.CommitConventionInfo
+		commitFrequency  *models.CommitFrequency
+		goReplaces       []string
+		goWorkModules    []string
+		goBuildTags      []string
+		packageManager   string
+		coAuthorTrailers []models.CoAuthorTrailer
+		runtimeVersions  map[string]string
+		tsConfig         *models.TypeScriptConfig
+		devContainer     *models.DevContainerInfo
+		compilesToWASM   bool
+		usesNix          bool
+		makeTargets      []string
+		iacTools         []string
+		dataTools        []string
+		stats            *models.RepositoryStats
+		issueStats       *models.IssueStats
+		vulnerabilities  []models.VulnerabilityAlert
+		starGrowthRate   float64
+		starPeakDate     time.Time
+	)
 
-	files, err := a.listAllFiles(ctx, token, owner, repo, "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to list files: %w", err)
+	g, gctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		var err error
+		languages, err = a.client.GetRepositoryLanguages(gctx, token, owner, repo)
+		if err != nil {
+			return fmt.Errorf("failed to get languages: %w", err)
+		}
+		done(StepFetchLanguages)
+		return nil
+	})
+
+	// Key files and dependencies depend on the file list
+	g.Go(func() error {
+		var err error
+		files, wasmBytes, err = a.listAllFiles(gctx, token, owner, repo, "")
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+		done(StepListFiles)
+
+		keyFiles, err = a.fetchKeyFiles(gctx, token, owner, repo, files)
+		if err != nil {
+			return fmt.Errorf("failed to fetch key files: %w", err)
+		}
+		// go.work is usually gitignored, so it's a bonus when present
+		if content, ok := keyFiles["go.work"]; ok {
+			goWorkModules, _ = a.extractGoWorkspaceDependencies(content)
+			a.fetchGoWorkspaceModules(gctx, token, owner, repo, goWorkModules, keyFiles)
+		}
+		done(StepFetchKeyFiles)
+
+		dependencies = a.extractDependencies(keyFiles)
+		goReplaces = a.extractGoReplaceDirectives(keyFiles)
+		goBuildTags = a.extractGoBuildTags(files, keyFiles)
+		packageManager = a.detectPackageManager(files)
+		runtimeVersions = a.extractRuntimeVersions(keyFiles)
+		if content, ok := findKeyFile(keyFiles, "tsconfig.json"); ok {
+			cfg := a.extractTypeScriptConfig(content)
+			tsConfig = &cfg
+		}
+		for path, content := range keyFiles {
+			if isDevContainerConfig(path) {
+				info := a.parseDevContainer(content)
+				devContainer = &info
+				break
+			}
+		}
+		compilesToWASM = a.detectWASM(files, keyFiles, goBuildTags)
+		iacTools = a.detectIaCTools(files, keyFiles)
+		dataTools = a.detectDataTools(files, dependencies)
+		_, usesNix = keyFiles["flake.nix"]
+		if content, ok := findMakefile(keyFiles); ok {
+			makeTargets = classifyMakeTargets(a.extractMakeTargets(content))
+		}
+		done(StepExtractDeps)
+		return nil
+	})
+
+	// Counts are best-effort and never fail the analysis
+	g.Go(func() error {
+		count, err := a.client.GetCommitCount(gctx, token, owner, repo)
+		if err == nil {
+			commitCount = count
+		}
+		done(StepCountCommits)
+		return nil
+	})
+
+	g.Go(func() error {
+		count, err := a.client.GetContributorCount(gctx, token, owner, repo)
+		if err == nil {
+			contributorCount = count
+		}
+		done(StepCountContributors)
+		return nil
+	})
+
+	g.Go(func() error {
+		// One listing serves both the convention check and co-author trailers
+		limit := max(commitMessageSampleSize, coAuthorCommitSample)
+		if messages, err := a.client.GetRecentCommitMessages(gctx, token, owner, repo, limit); err == nil {
+			info := a.detectCommitConvention(messages[:min(len(messages), commitMessageSampleSize)])
+			commitConvention = &info
+			coAuthorTrailers = parseCoAuthorTrailers(messages)
+		}
+		done(StepCommitConvention)
+		return nil
+	})
+
+	// The stargazer timeline can take many API calls, so it is opt-in
+	g.Go(func() error {
+		if a.cfg.EnableStarTimeline && repository.GetStargazersCount() > starTimelineMinStars {
+			if events, err := a.client.GetStargazerTimeline(gctx, token, owner, repo); err == nil {
+				starGrowthRate, starPeakDate = computeStarGrowth(events, time.Now())
+			}
+		}
+		done(StepStarTimeline)
+		return nil
+	})
+
+	// Issue sampling costs up to a dozen API calls, so it is opt-in too;
+	// the issue counts take one
+	g.Go(func() error {
+		if s, err := a.client.GetIssueStats(gctx, token, owner, repo); err == nil {
+			issueStats = &s
+		}
+		if a.cfg.EnableIssueStats {
+			if s, err := a.client.GetRepositoryStats(gctx, token, owner, repo); err == nil {
+				stats = &s
+			}
+		}
+		done(StepIssueStats)
+		return nil
+	})
+
+	g.Go(func() error {
+		if freq := a.classifyCommitFrequency(gctx, token, owner, repo); freq.Cadence != "" {
+			commitFrequency = &freq
+		}
+		done(StepCommitFrequency)
+		return nil
+	})
+
+	// Alerts need the security_events scope, so they are opt-in
+	g.Go(func() error {
+		if a.cfg.EnableVulnerabilityCheck {
+			if alerts, err := a.client.GetVulnerabilityAlerts(gctx, token, owner, repo); err == nil {
+				vulnerabilities = alerts
+			}
+		}
+		done(StepVulnerabilityAlerts)
+		return nil
+	})
+
+	// Snapshots take two API calls a month, so they are opt-in
+	g.Go(func() error {
+		if a.cfg.EnableLanguageHistory {
+			if history, err := a.client.GetLanguageHistory(gctx, token, owner, repo, languageHistorySnapshots); err == nil {
+				languageHistory = history
+			}
+		}
+		done(StepLanguageHistory)
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
 
-	keyFiles, err := a.fetchKeyFiles(ctx, token, owner, repo, files)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch key files: %w", err)
+	if compilesToWASM {
+		if languages == nil {
+			languages = make(map[string]int)
+		}
+		if _, ok := languages[webAssemblyLanguage]; !ok {
+			languages[webAssemblyLanguage] = wasmBytes
+		}
 	}
 
-	dependencies := a.extractDependencies(keyFiles)
+	languageWeights := a.normalizeLanguageWeights(languages)
 
-	commitCount, err := a.client.GetCommitCount(ctx, token, owner, repo)
-	if err != nil {
-		commitCount = 0
+	converted := a.convertRepository(repository)
+	activityScore := a.ComputeActivityScore(converted)
+	if commitFrequency != nil {
+		activityScore = activityScoreWithRecency(converted, commitRecency(*commitFrequency))
 	}
-
-	contributorCount, err := a.client.GetContributorCount(ctx, token, owner, repo)
-	if err != nil {
-		contributorCount = 0
+	if tsConfig != nil && tsConfig.Strict {
+		activityScore *= strictTypeScriptBoost
 	}
 
 	return &models.RepositoryAnalysis{
-		Repository:       a.convertRepository(repository),
-		Languages:        languages,
-		Files:            files,
-		Dependencies:     dependencies,
-		KeyFiles:         keyFiles,
-		CommitCount:      commitCount,
-		ContributorCount: contributorCount,
+		Repository:          converted,
+		Languages:           languages,
+		LanguageWeights:     languageWeights,
+		LanguageHistory:     languageHistory,
+		Files:               files,
+		Dependencies:        dependencies,
+		KeyFiles:            keyFiles,
+		CommitCount:         commitCount,
+		ContributorCount:    contributorCount,
+		CommitConvention:    commitConvention,
+		CommitFrequency:     commitFrequency,
+		GoReplaceDirectives: goReplaces,
+		GoWorkspaceModules:  goWorkModules,
+		GoBuildTags:         goBuildTags,
+		PackageManager:      packageManager,
+		CoAuthorTrailers:    coAuthorTrailers,
+		StarGrowthRate:      starGrowthRate,
+		StarPeakDate:        starPeakDate,
+		RuntimeVersions:     runtimeVersions,
+		TypeScriptConfig:    tsConfig,
+		DevContainer:        devContainer,
+		CompilesToWASM:      compilesToWASM,
+		UsesNix:             usesNix,
+		HasMakefile:         len(makeTargets) > 0,
+		MakeTargets:         makeTargets,
+		IaCTools:            iacTools,
+		DataTools:           dataTools,
+		Stats:               stats,
+		IssueStats:          issueStats,
+		VulnerabilityAlerts: vulnerabilities,
+		ActivityScore:       activityScore,
 	}, nil
 }
 
-func (a *Analyzer) listAllFiles(ctx context.Context, token, owner, repo, path string) ([]string, error) {
-	contents, err := a.client.ListRepositoryContents(ctx, token, owner, repo, path)
-	if err != nil {
-		return nil, err
+// detectCommitConvention measures how many commit subjects follow the
+// Conventional Commits format. Merge commits are ignored.
+func (a *Analyzer) detectCommitConvention(messages []string) models.CommitConventionInfo {
+	var total, conforming int
+	seen := make(map[string]bool)
+	types := []string{}
+
+	for _, msg := range messages {
+		subject, _, _ := strings.Cut(msg, "\n")
+		subject = strings.TrimSpace(subject)
+		if subject == "" || strings.HasPrefix(subject, "Merge ") {
+			continue
+		}
+		total++
+
+		m := conventionalCommitPattern.FindStringSubmatch(subject)
+		if m == nil {
+			continue
+		}
+		conforming++
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			types = append(types, m[1])
+		}
+	}
+
+	info := models.CommitConventionInfo{CommitTypes: types}
+	if total > 0 {
+		info.ConformanceRate = float64(conforming) / float64(total)
+	}
+	info.UsesConventionalCommits = info.ConformanceRate > conventionalCommitThreshold
+	return info
+}
+
+// listAllFiles lists files breadth-first from path down to cfg.MaxFileDepth
+// directories deep (0 = path only). It stops descending once
+// cfg.MaxFileListAPICalls directory listings have been made. Only a failure
+// to list path itself is returned as an error. wasmBytes totals the size of
+// .wasm files directly in the repository root or dist/.
+func (a *Analyzer) listAllFiles(ctx context.Context, token, owner, repo, path string) (files []string, wasmBytes int, err error) {
+	seen := make(map[string]bool)
+
+	dirs := []string{path}
+	apiCalls := 0
+	listDist := false
+
+	for depth := 0; len(dirs) > 0 && depth <= a.cfg.MaxFileDepth; depth++ {
+		var next []string
+		for _, dir := range dirs {
+			if a.cfg.MaxFileListAPICalls > 0 && apiCalls >= a.cfg.MaxFileListAPICalls {
+				logger.FromContext(ctx).Warn("Stopping file listing at API call limit",
+					"repo", owner+"/"+repo, "calls", apiCalls, "depth", depth)
+				return files, wasmBytes, nil
+			}
+			apiCalls++
+
+			contents, err := a.client.ListRepositoryContents(ctx, token, owner, repo, dir)
+			if err != nil {
+				if dir == path {
+					return nil, 0, err
+				}
+				logger.FromContext(ctx).Warn("Skipping unreadable directory", "repo", owner+"/"+repo, "dir", dir, "error", err)
+				continue
+			}
+
+			for _, content := range contents {
+				switch content.GetType() {
+				case "file":
+					if p := content.GetPath(); !seen[p] {
+						seen[p] = true
+						files = append(files, p)
+						if isBuiltWASM(p) {
+							wasmBytes += content.GetSize()
+						}
+					}
+				case "dir":
+					switch {
+					case !skippedDirs[content.GetName()]:
+						next = append(next, content.GetPath())
+					case content.GetPath() == "dist":
+						listDist = true
+					}
+				}
+			}
+		}
+		dirs = next
 	}
 
-	var files []string
-	for _, content := range contents {
-		if content.Type != nil && *content.Type == "file" {
-			files = append(files, *content.Path)
+	// dist/ is skipped as build output, but committed .wasm builds in it
+	// are what isBuiltWASM looks for, so only those are listed
+	if listDist && (a.cfg.MaxFileListAPICalls <= 0 || apiCalls < a.cfg.MaxFileListAPICalls) {
+		contents, err := a.client.ListRepositoryContents(ctx, token, owner, repo, "dist")
+		if err != nil {
+			logger.FromContext(ctx).Warn("Skipping unreadable directory", "repo", owner+"/"+repo, "dir", "dist", "error", err)
+		}
+		for _, content := range contents {
+			if p := content.GetPath(); content.GetType() == "file" && isBuiltWASM(p) && !seen[p] {
+				seen[p] = true
+				files = append(files, p)
+				wasmBytes += content.GetSize()
+			}
 		}
 	}
 
-	return files, nil
+	return files, wasmBytes, nil
 }
 
 // fetchKeyFiles grabs the content of well-known dependency and config files.
@@ -87,21 +489,40 @@ func (a *Analyzer) fetchKeyFiles(ctx context.Context, token, owner, repo string,
 		"pom.xml", "build.gradle", "composer.json", "Dockerfile",
 		".dockerignore", "docker-compose.yml", "README.md",
 		"tsconfig.json", "vite.config.ts", "webpack.config.js",
+		"Package.swift", "emscripten.json", "wasm-pack.toml",
+		"mix.exs", "mix.lock", "flake.nix", "flake.lock", "pnpm-workspace.yaml",
+		"deno.json", "deno.jsonc", "bunfig.toml", "Makefile", "makefile",
+		"go.work", "go.work.sum",
 	}
 
 	keyFiles := make(map[string]string)
+	goFiles, templates := 0, 0
 
 	for _, file := range files {
 		filename := filepath.Base(file)
-		for _, pattern := range keyFilePatterns {
-			if filename == pattern {
-				content, err := a.client.GetRepositoryContent(ctx, token, owner, repo, file)
-				if err != nil {
-					continue
-				}
+		if goFiles < maxGoConstraintFiles && isGoConstraintFile(filename) {
+			if content, err := a.client.GetRepositoryContent(ctx, token, owner, repo, file); err == nil {
 				keyFiles[file] = content
-				break
+				goFiles++
+			}
+			continue
+		}
+		if templates < maxCloudFormationTemplates && isCloudFormationCandidate(filename) {
+			if content, err := a.client.GetRepositoryContent(ctx, token, owner, repo, file); err == nil {
+				keyFiles[file] = content
+				templates++
 			}
+			continue
+		}
+		// Kotlin DSL scripts are matched by extension: build.gradle.kts,
+		// but also e.g. app.gradle.kts in convention plugin setups.
+		// Gemspecs are named after the gem, e.g. rails.gemspec.
+		if !slices.Contains(keyFilePatterns, filename) && !strings.HasSuffix(filename, ".gradle.kts") &&
+			!strings.HasSuffix(filename, ".gemspec") && !isDevContainerConfig(file) {
+			continue
+		}
+		if content, err := a.client.GetRepositoryContent(ctx, token, owner, repo, file); err == nil {
+			keyFiles[file] = content
 		}
 	}
 
@@ -111,29 +532,68 @@ func (a *Analyzer) fetchKeyFiles(ctx context.Context, token, owner, repo string,
 func (a *Analyzer) extractDependencies(keyFiles map[string]string) map[string][]string {
 	dependencies := make(map[string][]string)
 
+	// Monorepos can have several manifests per ecosystem; merge them.
+	merge := func(ecosystem string, deps []string) {
+		for _, dep := range deps {
+			if !slices.Contains(dependencies[ecosystem], dep) {
+				dependencies[ecosystem] = append(dependencies[ecosystem], dep)
+			}
+		}
+	}
+
 	for path, content := range keyFiles {
 		filename := filepath.Base(path)
 
+		if strings.HasSuffix(filename, ".gradle.kts") {
+			merge("gradle", a.extractKotlinGradleDependencies(content))
+			continue
+		}
+		// A gem's own dependencies, kept apart from an application's Gemfile
+		if strings.HasSuffix(filename, ".gemspec") {
+			merge("gemspec", a.extractGemspecDependencies(content))
+			continue
+		}
+
 		switch filename {
 		case "package.json":
-			if deps := a.extractNpmDependencies(content); len(deps) > 0 {
-				dependencies["npm"] = deps
-			}
+			merge("npm", a.extractNpmDependencies(content))
 		case "requirements.txt":
-			if deps := a.extractPipDependencies(content); len(deps) > 0 {
-				dependencies["pip"] = deps
-			}
+			merge("pip", a.extractPipDependencies(content))
 		case "go.mod":
-			if deps := a.extractGoModDependencies(content); len(deps) > 0 {
-				dependencies["go"] = deps
-			}
+			goDeps := a.extractGoModDependencies(content)
+			merge("go", goDeps.Direct)
+			merge("go-indirect", goDeps.Indirect)
+		case "go.work":
+			_, workDeps := a.extractGoWorkspaceDependencies(content)
+			merge("go", workDeps)
 		case "Cargo.toml":
-			if deps := a.extractCargoDependencies(content); len(deps) > 0 {
-				dependencies["cargo"] = deps
-			}
+			merge("cargo", a.extractCargoDependencies(content))
 		case "Gemfile":
-			if deps := a.extractGemDependencies(content); len(deps) > 0 {
-				dependencies["gem"] = deps
+			merge("gem", a.extractGemDependencies(content))
+		case "composer.json":
+			merge("composer", a.extractComposerDependencies(content))
+		case "Package.swift":
+			merge("spm", a.extractSPMDependencies(content))
+		case "pom.xml":
+			merge("maven", a.extractMavenDependencies(content))
+		case "build.gradle":
+			merge("gradle", a.extractGradleDependencies(content))
+		case "mix.exs":
+			merge("mix", a.extractMixDependencies(content))
+		case "flake.nix":
+			merge("nix", a.extractNixFlakeDependencies(content))
+		case "deno.json", "deno.jsonc":
+			merge("deno", a.extractDenoImports(content))
+		case "bunfig.toml":
+			merge("bun", a.extractBunConfig(content))
+		}
+	}
+
+	if content, ok := keyFiles["pnpm-workspace.yaml"]; ok {
+		patterns := a.extractPnpmWorkspacePackages(content)
+		for path, content := range keyFiles {
+			if filepath.Base(path) == "package.json" && pnpmWorkspaceContains(patterns, filepath.Dir(path)) {
+				merge("pnpm-workspace", a.extractNpmDependencies(content))
 			}
 		}
 	}
@@ -162,6 +622,155 @@ func (a *Analyzer) extractNpmDependencies(content string) []string {
 	return deps
 }
 
+// extractComposerDependencies returns the vendor/package names required by
+// a composer.json, leaving out platform requirements such as php and ext-*.
+// The vendor is kept since names like laravel/framework mean nothing
+// without it.
+func (a *Analyzer) extractComposerDependencies(content string) []string {
+	var composerJSON struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+
+	if err := json.Unmarshal([]byte(content), &composerJSON); err != nil {
+		return nil
+	}
+
+	var deps []string
+	for _, require := range []map[string]string{composerJSON.Require, composerJSON.RequireDev} {
+		for dep := range require {
+			if strings.Contains(dep, "/") && !slices.Contains(deps, dep) {
+				deps = append(deps, dep)
+			}
+		}
+	}
+	return deps
+}
+
+// extractDenoImports returns the packages mapped in a deno.json(c) import
+// map. npm: and jsr: specifiers are reduced to the package name, e.g.
+// "npm:express@^4" to "express" and "jsr:@std/path@^1" to "@std/path";
+// URL imports are named by their key, and local paths are skipped.
+func (a *Analyzer) extractDenoImports(content string) []string {
+	var denoJSON struct {
+		Imports map[string]string `json:"imports"`
+	}
+	if err := json.Unmarshal(stripJSONC(content), &denoJSON); err != nil {
+		return nil
+	}
+
+	var deps []string
+	for key, specifier := range denoJSON.Imports {
+		var dep string
+		if name, ok := strings.CutPrefix(specifier, "npm:"); ok {
+			dep = stripPackageVersion(name)
+		} else if name, ok := strings.CutPrefix(specifier, "jsr:"); ok {
+			dep = stripPackageVersion(name)
+		} else if strings.HasPrefix(specifier, "http://") || strings.HasPrefix(specifier, "https://") {
+			dep = strings.TrimSuffix(key, "/")
+		}
+		if dep != "" && !slices.Contains(deps, dep) {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// stripPackageVersion drops the version and any subpath from a package
+// specifier: "@std/path@^1/posix" becomes "@std/path". Deno allows a leading
+// slash, as in "npm:/preact@10/".
+func stripPackageVersion(spec string) string {
+	spec = strings.TrimPrefix(spec, "/")
+	scope := ""
+	if strings.HasPrefix(spec, "@") {
+		i := strings.Index(spec, "/")
+		if i < 0 {
+			return spec
+		}
+		scope, spec = spec[:i+1], spec[i+1:]
+	}
+	if i := strings.IndexAny(spec, "@/"); i >= 0 {
+		spec = spec[:i]
+	}
+	return scope + spec
+}
+
+// extractBunConfig returns the packages a bunfig.toml names: the trusted
+// dependencies under [install.trustedDependencies] or in an [install]
+// trustedDependencies array, and the plugin packages preloaded by [run].
+// Relative preload paths are scripts, not packages, and are skipped.
+func (a *Analyzer) extractBunConfig(content string) []string {
+	var deps []string
+	add := func(dep string) {
+		dep = strings.Trim(strings.TrimSpace(dep), `"'`)
+		if dep != "" && !strings.HasPrefix(dep, ".") && !strings.HasPrefix(dep, "/") && !slices.Contains(deps, dep) {
+			deps = append(deps, dep)
+		}
+	}
+	addArray := func(value string) {
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "[") {
+			for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+				add(item)
+			}
+		} else {
+			add(value)
+		}
+	}
+
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		switch {
+		case section == "install.trustedDependencies":
+			add(key)
+		case section == "install" && key == "trustedDependencies":
+			addArray(value)
+		case section == "run" && key == "preload":
+			addArray(value)
+		}
+	}
+	return deps
+}
+
+// detectPackageManager names the JavaScript package manager from the files
+// present. A deno.json wins over any lockfile, since Deno projects may keep
+// a package-lock.json for npm compatibility; Bun comes next.
+func (a *Analyzer) detectPackageManager(files []string) string {
+	found := make(map[string]bool)
+	for _, f := range files {
+		found[path.Base(f)] = true
+	}
+	switch {
+	case found["deno.json"] || found["deno.jsonc"]:
+		return "deno"
+	case found["bun.lockb"] || found["bun.lock"] || found["bunfig.toml"]:
+		return "bun"
+	case found["pnpm-lock.yaml"]:
+		return "pnpm"
+	case found["yarn.lock"]:
+		return "yarn"
+	case found["package-lock.json"]:
+		return "npm"
+	}
+	return ""
+}
+
 func (a *Analyzer) extractPipDependencies(content string) []string {
 	lines := strings.Split(content, "\n")
 	var deps []string
@@ -182,33 +791,453 @@ func (a *Analyzer) extractPipDependencies(content string) []string {
 	return deps
 }
 
-func (a *Analyzer) extractGoModDependencies(content string) []string {
-	lines := strings.Split(content, "\n")
-	var deps []string
-	inRequireBlock := false
+// GoModDependencies splits go.mod requirements into those the module imports
+// directly and those marked "// indirect".
+type GoModDependencies struct {
+	Direct   []string
+	Indirect []string
+}
 
-	for _, line := range lines {
+func (a *Analyzer) extractGoModDependencies(content string) GoModDependencies {
+	var deps GoModDependencies
+
+	for _, line := range goModDirectiveLines(content, "require") {
+		parts := strings.Fields(line)
+		if len(parts) == 0 {
+			continue
+		}
+		if strings.HasSuffix(line, "// indirect") {
+			deps.Indirect = append(deps.Indirect, parts[0])
+		} else {
+			deps.Direct = append(deps.Direct, parts[0])
+		}
+	}
+
+	return deps
+}
+
+// extractGoReplaceDirectives collects "old => new" replace directives from
+// every go.mod in keyFiles.
+func (a *Analyzer) extractGoReplaceDirectives(keyFiles map[string]string) []string {
+	var replaces []string
+	for path, content := range keyFiles {
+		if filepath.Base(path) != "go.mod" {
+			continue
+		}
+		for _, line := range goModDirectiveLines(content, "replace") {
+			if !strings.Contains(line, "=>") {
+				continue
+			}
+			if r := strings.Join(strings.Fields(line), " "); !slices.Contains(replaces, r) {
+				replaces = append(replaces, r)
+			}
+		}
+	}
+	slices.Sort(replaces)
+	return replaces
+}
+
+// goModDirectiveLines returns the arguments of every use of directive in a
+// go.mod file, from both the single-line and the parenthesized block form.
+// Trailing comments are kept so callers can check for "// indirect".
+func goModDirectiveLines(content, directive string) []string {
+	var lines []string
+	inBlock := false
+
+	for _, line := range strings.Split(content, "\n") {
 		line = strings.TrimSpace(line)
 
-		if strings.HasPrefix(line, "require (") {
-			inRequireBlock = true
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock:
+			if line != "" && !strings.HasPrefix(line, "//") {
+				lines = append(lines, line)
+			}
+		case strings.HasPrefix(line, directive+" ("), line == directive+"(":
+			inBlock = true
+		case strings.HasPrefix(line, directive+" "):
+			lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, directive+" ")))
+		}
+	}
+
+	return lines
+}
+
+var (
+	spmPackagePattern  = regexp.MustCompile(`\.package\(\s*(?:name:\s*"[^"]*",\s*)?url:\s*"([^"]+)"`)
+	spmImportPattern   = regexp.MustCompile(`(?m)^\s*import\s+(SwiftUI|Combine)\b`)
+	spmIOSTargetRegexp = regexp.MustCompile(`\.iOS\(\s*(?:\.v(\d+(?:_\d+)*)|"([\d.]+)")\s*\)`)
+)
+
+// extractSPMDependencies returns the repository names of the packages a
+// Package.swift depends on, plus SwiftUI and Combine when imported.
+func (a *Analyzer) extractSPMDependencies(content string) []string {
+	var deps []string
+	for _, m := range spmPackagePattern.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimSuffix(path.Base(strings.TrimSuffix(m[1], "/")), ".git")
+		if name != "" && name != "." && !slices.Contains(deps, name) {
+			deps = append(deps, name)
+		}
+	}
+	for _, m := range spmImportPattern.FindAllStringSubmatch(content, -1) {
+		if !slices.Contains(deps, m[1]) {
+			deps = append(deps, m[1])
+		}
+	}
+	return deps
+}
+
+// extractRuntimeVersions reads minimum platform versions from key files.
+// Currently only the iOS deployment target in Package.swift is detected, e.g.
+// platforms: [.iOS(.v16)] gives {"ios": "16"}.
+func (a *Analyzer) extractRuntimeVersions(keyFiles map[string]string) map[string]string {
+	versions := make(map[string]string)
+	for file, content := range keyFiles {
+		if filepath.Base(file) != "Package.swift" {
 			continue
 		}
+		if m := spmIOSTargetRegexp.FindStringSubmatch(content); m != nil {
+			version := m[2]
+			if m[1] != "" {
+				version = strings.ReplaceAll(m[1], "_", ".")
+			}
+			versions["ios"] = version
+		}
+	}
+	if len(versions) == 0 {
+		return nil
+	}
+	return versions
+}
+
+// findKeyFile returns the key file named name closest to the repository root,
+// so a root tsconfig.json wins over one in a nested package.
+func findKeyFile(keyFiles map[string]string, name string) (string, bool) {
+	var matches []string
+	for file := range keyFiles {
+		if filepath.Base(file) == name {
+			matches = append(matches, file)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	best := slices.MinFunc(matches, func(x, y string) int {
+		if d := strings.Count(x, "/") - strings.Count(y, "/"); d != 0 {
+			return d
+		}
+		return strings.Compare(x, y)
+	})
+	return keyFiles[best], true
+}
+
+// extractTypeScriptConfig reads compilerOptions from a tsconfig.json. The
+// file is JSONC, so comments and trailing commas are stripped first; an
+// unparseable file yields the zero config.
+func (a *Analyzer) extractTypeScriptConfig(content string) models.TypeScriptConfig {
+	var tsconfig struct {
+		CompilerOptions struct {
+			Target      string              `json:"target"`
+			Strict      bool                `json:"strict"`
+			Lib         []string            `json:"lib"`
+			Paths       map[string][]string `json:"paths"`
+			Declaration bool                `json:"declaration"`
+		} `json:"compilerOptions"`
+	}
+	if err := json.Unmarshal(stripJSONC(content), &tsconfig); err != nil {
+		return models.TypeScriptConfig{}
+	}
 
-		if inRequireBlock && line == ")" {
-			inRequireBlock = false
+	opts := tsconfig.CompilerOptions
+	return models.TypeScriptConfig{
+		Target:            opts.Target,
+		Strict:            opts.Strict,
+		Libs:              opts.Lib,
+		HasPathAliases:    len(opts.Paths) > 0,
+		IsDeclarationFile: opts.Declaration,
+	}
+}
+
+// isDevContainerConfig reports whether path is one of the dev container
+// config locations GitHub Codespaces reads.
+func isDevContainerConfig(path string) bool {
+	return path == ".devcontainer/devcontainer.json" || path == ".devcontainer.json"
+}
+
+// parseDevContainer reads a devcontainer.json, which is JSONC. Extensions
+// come from customizations.vscode.extensions or the older top-level
+// extensions; forwardPorts entries given as "host:port" strings are skipped.
+// An unparseable file yields the zero info.
+func (a *Analyzer) parseDevContainer(content string) models.DevContainerInfo {
+	var config struct {
+		Image          string                     `json:"image"`
+		Features       map[string]json.RawMessage `json:"features"`
+		Extensions     []string                   `json:"extensions"`
+		Customizations struct {
+			VSCode struct {
+				Extensions []string `json:"extensions"`
+			} `json:"vscode"`
+		} `json:"customizations"`
+		ForwardPorts []json.RawMessage `json:"forwardPorts"`
+	}
+	if err := json.Unmarshal(stripJSONC(content), &config); err != nil {
+		return models.DevContainerInfo{}
+	}
+
+	info := models.DevContainerInfo{Image: config.Image}
+	for feature := range config.Features {
+		info.Features = append(info.Features, feature)
+	}
+	slices.Sort(info.Features)
+	for _, ext := range append(config.Customizations.VSCode.Extensions, config.Extensions...) {
+		if ext = strings.ToLower(ext); !slices.Contains(info.Extensions, ext) {
+			info.Extensions = append(info.Extensions, ext)
+		}
+	}
+	for _, raw := range config.ForwardPorts {
+		var port int
+		if err := json.Unmarshal(raw, &port); err == nil {
+			info.ForwardedPorts = append(info.ForwardedPorts, port)
+		}
+	}
+	return info
+}
+
+// stripJSONC removes // and /* */ comments and trailing commas so JSONC can
+// be decoded with encoding/json. String literals are copied untouched.
+func stripJSONC(content string) []byte {
+	return dropTrailingCommas(stripJSONComments(content))
+}
+
+func stripJSONComments(content string) []byte {
+	out := make([]byte, 0, len(content))
+	for i := 0; i < len(content); i++ {
+		switch {
+		case content[i] == '"':
+			end := jsonStringEnd(content, i)
+			out = append(out, content[i:end]...)
+			i = end - 1
+		case strings.HasPrefix(content[i:], "//"):
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			out = append(out, '\n')
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		default:
+			out = append(out, content[i])
+		}
+	}
+	return out
+}
+
+func dropTrailingCommas(content []byte) []byte {
+	s := string(content)
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			end := jsonStringEnd(s, i)
+			out = append(out, s[i:end]...)
+			i = end - 1
+		case ',':
+			rest := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if rest == "" || rest[0] == '}' || rest[0] == ']' {
+				continue
+			}
+			out = append(out, ',')
+		default:
+			out = append(out, s[i])
+		}
+	}
+	return out
+}
+
+// jsonStringEnd returns the index just past the string literal opening at
+// start, or len(s) when it is unterminated.
+func jsonStringEnd(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+var (
+	mavenDependencyPattern = regexp.MustCompile(`(?s)<dependency>(.*?)</dependency>`)
+	mavenArtifactPattern   = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+	gradleDependencyRegexp = regexp.MustCompile(`(?m)^\s*(?:implementation|api|compileOnly|testImplementation)\s*\(?\s*['"]([^:'"\s]+):([^:'"\s]+)[^'"]*['"]`)
+	// kotlinGradleDependencyRegexp also accepts BOM-managed coordinates
+	// without a version and platform(...) wrappers.
+	kotlinGradleDependencyRegexp = regexp.MustCompile(`(?m)^\s*(?:implementation|api|compileOnly|runtimeOnly|testImplementation|androidTestImplementation|kapt|ksp)\s*\(\s*(?:platform\(\s*)?"([^:"\s]+):([^:"\s]+)(?::[^"]*)?"`)
+)
+
+// extractMavenDependencies returns the artifactId of every <dependency> in a
+// pom.xml. Plugins and the project's own artifactId are skipped.
+func (a *Analyzer) extractMavenDependencies(content string) []string {
+	var deps []string
+	for _, block := range mavenDependencyPattern.FindAllStringSubmatch(content, -1) {
+		if m := mavenArtifactPattern.FindStringSubmatch(block[1]); m != nil && !slices.Contains(deps, m[1]) {
+			deps = append(deps, m[1])
+		}
+	}
+	return deps
+}
+
+// extractGradleDependencies returns the artifact of every "group:artifact:version"
+// declared with implementation, api, compileOnly or testImplementation, in
+// either the Groovy or the Kotlin DSL.
+func (a *Analyzer) extractGradleDependencies(content string) []string {
+	var deps []string
+	for _, m := range gradleDependencyRegexp.FindAllStringSubmatch(content, -1) {
+		if !slices.Contains(deps, m[2]) {
+			deps = append(deps, m[2])
+		}
+	}
+	return deps
+}
+
+var (
+	mixDepsBlockPattern = regexp.MustCompile(`(?s)defp\s+deps(?:\(\))?\s+do\b(.*?)\n\s*end\b`)
+	mixDepPattern       = regexp.MustCompile(`\{\s*:([a-z_][a-z0-9_]*)`)
+)
+
+// extractMixDependencies returns the package names of the {:dep, ...} tuples
+// in the "defp deps do ... end" block of a mix.exs.
+func (a *Analyzer) extractMixDependencies(content string) []string {
+	block := mixDepsBlockPattern.FindStringSubmatch(content)
+	if block == nil {
+		return nil
+	}
+	var deps []string
+	for _, m := range mixDepPattern.FindAllStringSubmatch(block[1], -1) {
+		if !slices.Contains(deps, m[1]) {
+			deps = append(deps, m[1])
+		}
+	}
+	return deps
+}
+
+// nixInputPattern matches flake input URLs, both as inputs.name.url = "...";
+// and as name.url = "..."; inside an inputs = { ... } block.
+var nixInputPattern = regexp.MustCompile(`(?m)^\s*(?:inputs\.)?([A-Za-z_][\w-]*)\.url\s*=\s*"[^"]*"\s*;`)
+
+// extractNixFlakeDependencies returns the input names declared in a flake.nix.
+func (a *Analyzer) extractNixFlakeDependencies(content string) []string {
+	var deps []string
+	for _, m := range nixInputPattern.FindAllStringSubmatch(content, -1) {
+		if !slices.Contains(deps, m[1]) {
+			deps = append(deps, m[1])
+		}
+	}
+	return deps
+}
+
+// extractPnpmWorkspacePackages returns the globs in the packages field of a
+// pnpm-workspace.yaml, in either block ("- apps/*") or flow (["apps/*"])
+// style. Only that field is read, so a full YAML parser isn't needed.
+func (a *Analyzer) extractPnpmWorkspacePackages(content string) []string {
+	var patterns []string
+	add := func(item string) {
+		if i := strings.Index(item, " #"); i >= 0 {
+			item = item[:i]
+		}
+		if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+			patterns = append(patterns, item)
+		}
+	}
+
+	inPackages := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "packages:"); ok {
+			rest = strings.TrimSpace(rest)
+			if flow, ok := strings.CutPrefix(rest, "["); ok {
+				flow, _, _ = strings.Cut(flow, "]")
+				for _, item := range strings.Split(flow, ",") {
+					add(item)
+				}
+				return patterns
+			}
+			inPackages = true
+			continue
+		}
+		if !inPackages {
 			continue
 		}
+		// The list ends at the next top-level key
+		item, ok := strings.CutPrefix(trimmed, "-")
+		if !ok || line == trimmed {
+			break
+		}
+		add(item)
+	}
+	return patterns
+}
 
-		if inRequireBlock || strings.HasPrefix(line, "require ") {
-			parts := strings.Fields(line)
-			if len(parts) >= 1 {
-				dep := strings.TrimPrefix(parts[0], "require ")
-				deps = append(deps, dep)
+// pnpmWorkspaceContains reports whether dir, relative to the repository
+// root, is a workspace package. Later patterns win, and "!" patterns
+// exclude.
+func pnpmWorkspaceContains(patterns []string, dir string) bool {
+	if dir == "." {
+		return false
+	}
+	matched := false
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./"), "/")
+		if matchWorkspaceGlob(strings.Split(pattern, "/"), strings.Split(dir, "/")) {
+			matched = !exclude
+		}
+	}
+	return matched
+}
+
+// matchWorkspaceGlob matches path segments with filepath.Match, except that
+// a "**" segment matches any number of segments.
+func matchWorkspaceGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchWorkspaceGlob(pattern[1:], segments[i:]) {
+				return true
 			}
 		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
 	}
+	ok, _ := filepath.Match(pattern[0], segments[0])
+	return ok && matchWorkspaceGlob(pattern[1:], segments[1:])
+}
 
+// extractKotlinGradleDependencies returns "group:artifact" for every
+// dependency declared in a Kotlin DSL script. Unlike extractGradleDependencies
+// it keeps the group, which is all that identifies e.g. Jetpack Compose
+// artifacts such as androidx.compose.ui:ui.
+func (a *Analyzer) extractKotlinGradleDependencies(content string) []string {
+	var deps []string
+	for _, m := range kotlinGradleDependencyRegexp.FindAllStringSubmatch(content, -1) {
+		if coord := m[1] + ":" + m[2]; !slices.Contains(deps, coord) {
+			deps = append(deps, coord)
+		}
+	}
 	return deps
 }
 
@@ -240,6 +1269,82 @@ func (a *Analyzer) extractCargoDependencies(content string) []string {
 	return deps
 }
 
+// normalizeLanguageWeights converts byte counts to each language's share of
+// the total, between 0 and 1. It returns nil when there are no bytes.
+func (a *Analyzer) normalizeLanguageWeights(languages map[string]int) map[string]float64 {
+	total := 0
+	for _, b := range languages {
+		total += b
+	}
+	if total == 0 {
+		return nil
+	}
+	weights := make(map[string]float64, len(languages))
+	for lang, b := range languages {
+		weights[lang] = float64(b) / float64(total)
+	}
+	return weights
+}
+
+// webAssemblyLanguage is the language key added when a repository compiles to
+// WebAssembly, matching GitHub's linguist name.
+const webAssemblyLanguage = "WebAssembly"
+
+// isBuiltWASM reports whether p is a .wasm file in the repository root or
+// dist/, where build output is usually committed.
+func isBuiltWASM(p string) bool {
+	if path.Ext(p) != ".wasm" {
+		return false
+	}
+	dir := path.Dir(p)
+	return dir == "." || dir == "dist"
+}
+
+// detectWASM reports whether the repository targets WebAssembly: it ships
+// built .wasm files, has an Emscripten or wasm-pack config, its Cargo.toml
+// builds a cdylib, as wasm-pack crates do, or it has Go files built for
+// js/wasm alongside wasm-named files such as main_wasm.go or wasm_exec.js.
+func (a *Analyzer) detectWASM(files []string, keyFiles map[string]string, goBuildTags []string) bool {
+	goWASMTags := slices.Contains(goBuildTags, "js") || slices.Contains(goBuildTags, "wasm")
+	for _, f := range files {
+		if isBuiltWASM(f) {
+			return true
+		}
+		if goWASMTags && strings.Contains(strings.ToLower(path.Base(f)), "wasm") {
+			return true
+		}
+	}
+	for file, content := range keyFiles {
+		switch path.Base(file) {
+		case "emscripten.json", "wasm-pack.toml":
+			return true
+		case "Cargo.toml":
+			if cargoBuildsCdylib(content) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cargoBuildsCdylib reports whether the [lib] section lists "cdylib" in
+// crate-type.
+func cargoBuildsCdylib(content string) bool {
+	inLib := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inLib = line == "[lib]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if inLib && ok && strings.TrimSpace(key) == "crate-type" && strings.Contains(value, `"cdylib"`) {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *Analyzer) extractGemDependencies(content string) []string {
 	lines := strings.Split(content, "\n")
 	var deps []string
@@ -257,25 +1362,111 @@ func (a *Analyzer) extractGemDependencies(content string) []string {
 	return deps
 }
 
+// gemspecDependencyRegexp matches spec.add_dependency "name", including the
+// runtime and development variants and any block variable name.
+var gemspecDependencyRegexp = regexp.MustCompile(`(?m)^\s*\w+\.add_(?:runtime_|development_)?dependency\s*\(?\s*['"]([^'"]+)['"]`)
+
+// extractGemspecDependencies returns the gem names declared in a .gemspec.
+func (a *Analyzer) extractGemspecDependencies(content string) []string {
+	var deps []string
+	for _, m := range gemspecDependencyRegexp.FindAllStringSubmatch(content, -1) {
+		if !slices.Contains(deps, m[1]) {
+			deps = append(deps, m[1])
+		}
+	}
+	return deps
+}
+
 func (a *Analyzer) convertRepository(repo *github.Repository) *models.Repository {
 	return &models.Repository{
-		ID:              repo.GetID(),
-		GitHubID:        repo.GetID(),
-		Name:            repo.GetName(),
-		FullName:        repo.GetFullName(),
-		Description:     repo.GetDescription(),
-		Private:         repo.GetPrivate(),
-		Fork:            repo.GetFork(),
-		Language:        repo.GetLanguage(),
-		StargazersCount: repo.GetStargazersCount(),
-		ForksCount:      repo.GetForksCount(),
-		OpenIssuesCount: repo.GetOpenIssuesCount(),
-		DefaultBranch:   repo.GetDefaultBranch(),
-		Topics:          repo.Topics,
-		HTMLURL:         repo.GetHTMLURL(),
-		CloneURL:        repo.GetCloneURL(),
-		CreatedAt:       repo.GetCreatedAt().Time,
-		UpdatedAt:       repo.GetUpdatedAt().Time,
-		PushedAt:        repo.GetPushedAt().Time,
+		ID:               repo.GetID(),
+		GitHubID:         repo.GetID(),
+		Name:             repo.GetName(),
+		FullName:         repo.GetFullName(),
+		Description:      repo.GetDescription(),
+		Private:          repo.GetPrivate(),
+		Fork:             repo.GetFork(),
+		Language:         repo.GetLanguage(),
+		StargazersCount:  repo.GetStargazersCount(),
+		ForksCount:       repo.GetForksCount(),
+		WatchersCount:    repo.GetWatchersCount(),
+		SubscribersCount: repo.GetSubscribersCount(),
+		OpenIssuesCount:  repo.GetOpenIssuesCount(),
+		DefaultBranch:    repo.GetDefaultBranch(),
+		Topics:           repo.Topics,
+		HTMLURL:          repo.GetHTMLURL(),
+		CloneURL:         repo.GetCloneURL(),
+		CreatedAt:        repo.GetCreatedAt().Time,
+		UpdatedAt:        repo.GetUpdatedAt().Time,
+		PushedAt:         repo.GetPushedAt().Time,
+	}
+}
+
+// activityHalfLife is how long after the last push a repository's recency
+// weight halves.
+const activityHalfLife = 90 * 24 * time.Hour
+
+// strictTypeScriptBoost scales the activity score of repositories compiled
+// with TypeScript strict mode, a signal of careful typing.
+const strictTypeScriptBoost = 1.1
+
+// computeStarGrowth returns the average stars per day over the last
+// starGrowthWindow and the start of the starPeakWindow-long window that
+// gained the most stars.
+func computeStarGrowth(events []StargazerEvent, now time.Time) (float64, time.Time) {
+	if len(events) == 0 {
+		return 0, time.Time{}
+	}
+
+	times := make([]time.Time, len(events))
+	recent := 0
+	for i, e := range events {
+		times[i] = e.StarredAt
+		if now.Sub(e.StarredAt) <= starGrowthWindow {
+			recent++
+		}
 	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+
+	// Sliding window over the sorted timestamps
+	var peakDate time.Time
+	peak := 0
+	for start, end := 0, 0; start < len(times); start++ {
+		for end < len(times) && times[end].Sub(times[start]) < starPeakWindow {
+			end++
+		}
+		if end-start > peak {
+			peak = end - start
+			peakDate = times[start]
+		}
+	}
+
+	return float64(recent) / starGrowthWindow.Hours() * 24, peakDate
+}
+
+// ComputeActivityScore rates a repository from its metadata alone, so it can
+// be applied to every repository without extra API calls. Stars and forks are
+// log-scaled to keep one viral repository from dominating; recency decays
+// with a 90-day half-life.
+func (a *Analyzer) ComputeActivityScore(repo *models.Repository) float64 {
+	recency := 0.0
+	if !repo.PushedAt.IsZero() {
+		age := time.Since(repo.PushedAt)
+		recency = math.Pow(0.5, float64(age)/float64(activityHalfLife))
+	}
+	return activityScoreWithRecency(repo, recency)
+}
+
+// activityScoreWithRecency combines popularity with a recency signal from 0
+// (dormant) to 1 (active right now).
+func activityScoreWithRecency(repo *models.Repository, recency float64) float64 {
+	popularity := 1 + math.Log1p(float64(repo.StargazersCount)) + 0.5*math.Log1p(float64(repo.ForksCount))
+	return popularity * (0.25 + recency)
+}
+
+// commitRecency is the recency signal of an analyzed repository: the owner's
+// commits over the last 4 weeks, saturating at the daily cadence. Unlike
+// PushedAt it is not reset by bots or a single drive-by push.
+func commitRecency(freq models.CommitFrequency) float64 {
+	return min(1, float64(freq.OwnerCommitsLast4Weeks)/(4*dailyCadenceCommitsPerWeek))
 }
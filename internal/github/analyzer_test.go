@@ -0,0 +1,114 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/krauzx/gitright/internal/config"
+)
+
+// redirectTransport sends every request to target instead of api.github.com.
+type redirectTransport struct{ target *url.URL }
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+type contentEntry struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int    `json:"size"`
+}
+
+// newContentsClient returns a Client whose contents API serves dirs, keyed
+// by directory path ("" for the root), and 404s for anything else. It
+// records every path requested.
+func newContentsClient(t *testing.T, dirs map[string][]contentEntry) (*Client, *[]string) {
+	t.Helper()
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dir, ok := strings.CutPrefix(r.URL.Path, "/repos/octocat/app/contents")
+		dir = strings.Trim(dir, "/")
+		requested = append(requested, dir)
+		entries, found := dirs[dir]
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}))
+	t.Cleanup(srv.Close)
+
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{httpClient: &http.Client{Transport: redirectTransport{target}}}, &requested
+}
+
+func TestListAllFilesFindsBuiltWASMInDist(t *testing.T) {
+	client, listed := newContentsClient(t, map[string][]contentEntry{
+		"": {
+			{Type: "file", Name: "go.mod", Path: "go.mod"},
+			{Type: "dir", Name: "dist", Path: "dist"},
+			{Type: "dir", Name: "node_modules", Path: "node_modules"},
+			{Type: "dir", Name: "web", Path: "web"},
+		},
+		"dist": {
+			{Type: "file", Name: "app.wasm", Path: "dist/app.wasm", Size: 4096},
+			{Type: "file", Name: "bundle.js", Path: "dist/bundle.js", Size: 2048},
+			{Type: "dir", Name: "assets", Path: "dist/assets"},
+		},
+		"web": {
+			{Type: "file", Name: "main.go", Path: "web/main.go"},
+			{Type: "dir", Name: "dist", Path: "web/dist"},
+		},
+	})
+	a := NewAnalyzer(client, config.AnalysisConfig{MaxFileDepth: 2, MaxFileListAPICalls: 15})
+
+	files, wasmBytes, err := a.listAllFiles(context.Background(), "token", "octocat", "app", "")
+	if err != nil {
+		t.Fatalf("listAllFiles: %v", err)
+	}
+	want := []string{"go.mod", "web/main.go", "dist/app.wasm"}
+	if !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	if wasmBytes != 4096 {
+		t.Errorf("wasmBytes = %d, want 4096", wasmBytes)
+	}
+	if !a.detectWASM(files, nil, nil) {
+		t.Error("detectWASM = false for a committed dist/app.wasm")
+	}
+	if want := []string{"", "web", "dist"}; !slices.Equal(*listed, want) {
+		t.Errorf("listed directories %q, want %q", *listed, want)
+	}
+}
+
+func TestFetchKeyFilesRequestsEachFileOnce(t *testing.T) {
+	client, requested := newContentsClient(t, nil)
+	a := NewAnalyzer(client, config.AnalysisConfig{})
+
+	files := []string{"package.json", "app.gradle.kts", "demo.gemspec", ".devcontainer/devcontainer.json", "notes.txt"}
+	keyFiles, err := a.fetchKeyFiles(context.Background(), "token", "octocat", "app", files)
+	if err != nil {
+		t.Fatalf("fetchKeyFiles: %v", err)
+	}
+	if len(keyFiles) != 0 {
+		t.Errorf("keyFiles = %v, want none when every fetch fails", keyFiles)
+	}
+	if want := files[:4]; !slices.Equal(*requested, want) {
+		t.Errorf("requested %q, want each key file once: %q", *requested, want)
+	}
+}
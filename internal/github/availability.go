@@ -0,0 +1,179 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+const (
+	// availabilityWindow is how far back calls count toward the success rate.
+	availabilityWindow = 5 * time.Minute
+	maxTrackedCalls    = 200
+
+	// Success rates at or above these are reported as available and
+	// degraded respectively; anything lower is unavailable.
+	availableSuccessRate = 0.9
+	degradedSuccessRate  = 0.5
+)
+
+// Availability values reported by APIAvailability.Status.
+const (
+	AvailabilityAvailable   = "available"
+	AvailabilityDegraded    = "degraded"
+	AvailabilityUnavailable = "unavailable"
+)
+
+// APIAvailability summarizes recent GitHub API calls.
+type APIAvailability struct {
+	Status         string               `json:"status"`
+	SuccessRate    float64              `json:"success_rate"`
+	Calls          int                  `json:"calls"`
+	WindowSeconds  int                  `json:"window_seconds"`
+	CircuitBreaker CircuitBreakerStatus `json:"circuit_breaker"`
+}
+
+type callResult struct {
+	at time.Time
+	ok bool
+}
+
+// availabilityTracker keeps the outcome of the most recent API calls and
+// counts the calls made on the current UTC day.
+type availabilityTracker struct {
+	mu    sync.Mutex
+	calls []callResult // oldest first, at most maxTrackedCalls
+
+	day      string // UTC date of dayCalls
+	dayCalls int
+}
+
+func (t *availabilityTracker) record(ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if len(t.calls) == maxTrackedCalls {
+		t.calls = t.calls[1:]
+	}
+	t.calls = append(t.calls, callResult{at: now, ok: ok})
+
+	if day := now.UTC().Format(time.DateOnly); day != t.day {
+		t.day, t.dayCalls = day, 0
+	}
+	t.dayCalls++
+}
+
+// callsToday returns the calls made on the current UTC day.
+func (t *availabilityTracker) callsToday() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.day != time.Now().UTC().Format(time.DateOnly) {
+		return 0
+	}
+	return t.dayCalls
+}
+
+// successRate returns the share of calls within availabilityWindow that
+// succeeded, and how many there were. No calls counts as fully available.
+func (t *availabilityTracker) successRate() (float64, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := time.Now().Add(-availabilityWindow)
+	calls, succeeded := 0, 0
+	for _, c := range t.calls {
+		if c.at.Before(cutoff) {
+			continue
+		}
+		calls++
+		if c.ok {
+			succeeded++
+		}
+	}
+	if calls == 0 {
+		return 1, 0
+	}
+	return float64(succeeded) / float64(calls), calls
+}
+
+// transport records the outcome of every request. Network errors, 5xx and
+// rate-limited responses are failures; canceled requests are not counted.
+func (t *availabilityTracker) transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		switch {
+		case err != nil:
+			if req.Context().Err() == nil {
+				t.record(false)
+			}
+		case resp.StatusCode >= http.StatusInternalServerError,
+			resp.StatusCode == http.StatusTooManyRequests,
+			resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+			t.record(false)
+		default:
+			t.record(true)
+		}
+		return resp, err
+	})
+}
+
+// Availability reports how GitHub API calls have fared over the last few
+// minutes. An open circuit is always unavailable.
+func (c *Client) Availability() APIAvailability {
+	rate, calls := c.availability.successRate()
+	breaker := c.breaker.Status()
+
+	status := AvailabilityUnavailable
+	switch {
+	case breaker.State == Open.String():
+	case rate >= availableSuccessRate:
+		status = AvailabilityAvailable
+	case rate >= degradedSuccessRate:
+		status = AvailabilityDegraded
+	}
+	return APIAvailability{
+		Status:         status,
+		SuccessRate:    rate,
+		Calls:          calls,
+		WindowSeconds:  int(availabilityWindow / time.Second),
+		CircuitBreaker: breaker,
+	}
+}
+
+// CallsToday returns the GitHub API calls this process made on the current
+// UTC day. It starts over on restart.
+func (c *Client) CallsToday() int {
+	return c.availability.callsToday()
+}
+
+// IsUnavailable reports whether err means GitHub could not be reached or
+// refused to serve the call: an open circuit, a rate limit, a 5xx response
+// or a network error. Errors such as 404 mean GitHub answered and are not
+// unavailability.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+
+	var rateLimit *github.RateLimitError
+	var abuseLimit *github.AbuseRateLimitError
+	if errors.As(err, &rateLimit) || errors.As(err, &abuseLimit) {
+		return true
+	}
+
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) {
+		return errResp.Response != nil && errResp.Response.StatusCode >= http.StatusInternalServerError
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
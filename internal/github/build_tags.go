@@ -0,0 +1,153 @@
+package github
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// maxGoConstraintFiles bounds how many .go files are fetched looking for
+// build constraints; each costs a request.
+const maxGoConstraintFiles = 10
+
+// goConstraintHeaderLines is how far into a .go file build constraints are
+// looked for. They must precede the package clause.
+const goConstraintHeaderLines = 10
+
+// knownGOOS and knownGOARCH are the values Go recognizes as implicit
+// constraints in file name suffixes, e.g. poll_windows_amd64.go.
+var (
+	knownGOOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true,
+		"freebsd": true, "illumos": true, "ios": true, "js": true,
+		"linux": true, "netbsd": true, "openbsd": true, "plan9": true,
+		"solaris": true, "wasip1": true, "windows": true,
+	}
+	knownGOARCH = map[string]bool{
+		"386": true, "amd64": true, "arm": true, "arm64": true,
+		"loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "ppc64": true, "ppc64le": true, "riscv64": true,
+		"s390x": true, "wasm": true,
+	}
+)
+
+// isGoConstraintFile reports whether a .go file is worth fetching for its
+// build constraints: its name carries a GOOS or GOARCH suffix or mentions
+// wasm. Test files are skipped.
+func isGoConstraintFile(filename string) bool {
+	if path.Ext(filename) != ".go" || strings.HasSuffix(filename, "_test.go") {
+		return false
+	}
+	return len(goFilenameTags(filename)) > 0 || strings.Contains(strings.ToLower(filename), "wasm")
+}
+
+// goFilenameTags returns the implicit constraints of a .go file name, per
+// the go/build rules: name_GOOS.go, name_GOARCH.go or name_GOOS_GOARCH.go.
+func goFilenameTags(filename string) []string {
+	parts := strings.Split(strings.TrimSuffix(path.Base(filename), ".go"), "_")
+	if len(parts) < 2 {
+		return nil
+	}
+	last := parts[len(parts)-1]
+	if len(parts) >= 3 && knownGOOS[parts[len(parts)-2]] && knownGOARCH[last] {
+		return []string{parts[len(parts)-2], last}
+	}
+	if knownGOOS[last] || knownGOARCH[last] {
+		return []string{last}
+	}
+	return nil
+}
+
+// extractGoBuildTags collects the build tags a repository's Go code is
+// constrained by, from the //go:build and // +build lines at the top of the
+// fetched .go files and from GOOS/GOARCH file name suffixes. Negated tags,
+// "ignore" and Go version tags say nothing about supported targets and are
+// left out.
+func (a *Analyzer) extractGoBuildTags(files []string, keyFiles map[string]string) []string {
+	var tags []string
+	add := func(tag string) {
+		if tag == "" || tag == "ignore" || strings.HasPrefix(tag, "go1.") || strings.HasPrefix(tag, "!") {
+			return
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, f := range files {
+		if path.Ext(f) != ".go" || strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		for _, tag := range goFilenameTags(f) {
+			add(tag)
+		}
+	}
+
+	for file, content := range keyFiles {
+		if path.Ext(file) != ".go" {
+			continue
+		}
+		lines := strings.SplitN(content, "\n", goConstraintHeaderLines+1)
+		for _, line := range lines[:min(len(lines), goConstraintHeaderLines)] {
+			line = strings.TrimSpace(line)
+			if expr, ok := strings.CutPrefix(line, "//go:build "); ok {
+				for _, term := range goBuildExprTerms(expr) {
+					add(term)
+				}
+			} else if expr, ok := strings.CutPrefix(line, "// +build "); ok {
+				for _, term := range strings.FieldsFunc(expr, func(r rune) bool { return r == ' ' || r == ',' }) {
+					add(term)
+				}
+			}
+		}
+	}
+
+	slices.Sort(tags)
+	return tags
+}
+
+// goBuildExprTerms splits a //go:build expression such as
+// "(linux || darwin) && !cgo" into its terms. Terms under a "!", including
+// those in a negated group, are returned with a "!" prefix.
+func goBuildExprTerms(expr string) []string {
+	var terms []string
+	var negated []bool // per open parenthesis
+	negate := false
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '!':
+			negate = !negate
+			i++
+		case c == '(':
+			outer := len(negated) > 0 && negated[len(negated)-1]
+			negated = append(negated, outer != negate)
+			negate = false
+			i++
+		case c == ')':
+			if len(negated) > 0 {
+				negated = negated[:len(negated)-1]
+			}
+			i++
+		case isGoTagByte(c):
+			j := i
+			for j < len(expr) && isGoTagByte(expr[j]) {
+				j++
+			}
+			term := expr[i:j]
+			if (len(negated) > 0 && negated[len(negated)-1]) != negate {
+				term = "!" + term
+			}
+			terms = append(terms, term)
+			negate = false
+			i = j
+		default:
+			i++
+		}
+	}
+	return terms
+}
+
+func isGoTagByte(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
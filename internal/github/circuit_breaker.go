@@ -0,0 +1,191 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling upstream while a circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerTimeout   = 30 * time.Second
+
+	// breakerFailureWindow is how long a failure counts towards Threshold.
+	breakerFailureWindow = 60 * time.Second
+)
+
+type CircuitState int
+
+const (
+	Closed CircuitState = iota
+	Open
+	HalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops calling an upstream after Threshold failures within
+// a minute. Once Timeout has passed since it opened, a single trial call is
+// let through: success closes the circuit, failure opens it again. Each
+// upstream (GitHub, Gemini) has its own breaker. The exported fields are
+// guarded by an internal mutex; read them through Status while in use.
+type CircuitBreaker struct {
+	State       CircuitState
+	Failures    int
+	LastFailure time.Time
+	Threshold   int
+	Timeout     time.Duration
+
+	mu      sync.Mutex
+	probing bool // a half-open trial call is in flight
+}
+
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: defaultBreakerThreshold,
+		Timeout:   defaultBreakerTimeout,
+	}
+}
+
+// Call runs fn unless the circuit is open, recording whether it failed.
+func (cb *CircuitBreaker) Call(fn func() error) error {
+	probe, err := cb.before()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	cb.after(probe, err)
+	return err
+}
+
+// before reports whether the call is the half-open trial, or ErrCircuitOpen
+// when it must not run.
+func (cb *CircuitBreaker) before() (probe bool, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.State {
+	case Open:
+		// The circuit opens on a failure, so LastFailure is when it opened
+		if time.Since(cb.LastFailure) < cb.Timeout {
+			return false, ErrCircuitOpen
+		}
+		cb.State = HalfOpen
+	case HalfOpen:
+		if cb.probing {
+			return false, ErrCircuitOpen
+		}
+	default:
+		return false, nil
+	}
+	cb.probing = true
+	return true, nil
+}
+
+func (cb *CircuitBreaker) after(probe bool, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if probe {
+		cb.probing = false
+	}
+
+	if err == nil {
+		if probe {
+			cb.State = Closed
+			cb.Failures = 0
+		}
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(cb.LastFailure) > breakerFailureWindow {
+		cb.Failures = 0
+	}
+	cb.Failures++
+	cb.LastFailure = now
+
+	if probe || cb.Failures >= cb.Threshold {
+		cb.State = Open
+	}
+}
+
+// release ends a call that neither succeeded nor failed, leaving the state
+// as it was. A half-open circuit lets the next call through as its trial.
+func (cb *CircuitBreaker) release(probe bool) {
+	if !probe {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+}
+
+// CircuitBreakerStatus is a point-in-time copy of a breaker for reporting.
+type CircuitBreakerStatus struct {
+	State       string    `json:"state"`
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure,omitzero"`
+}
+
+func (cb *CircuitBreaker) Status() CircuitBreakerStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state := cb.State
+	// Report an expired open circuit as ready for a trial call
+	if state == Open && time.Since(cb.LastFailure) >= cb.Timeout {
+		state = HalfOpen
+	}
+	return CircuitBreakerStatus{
+		State:       state.String(),
+		Failures:    cb.Failures,
+		LastFailure: cb.LastFailure,
+	}
+}
+
+// Transport wraps next so every HTTP request goes through the breaker. Only
+// network errors and 5xx responses count as failures; 4xx responses are the
+// caller's problem, and canceled requests say nothing about the upstream.
+func (cb *CircuitBreaker) Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		probe, err := cb.before()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(req)
+		switch {
+		case err != nil && req.Context().Err() != nil:
+			cb.release(probe)
+		case err != nil:
+			cb.after(probe, err)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			cb.after(probe, fmt.Errorf("upstream returned %d", resp.StatusCode))
+		default:
+			cb.after(probe, nil)
+		}
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
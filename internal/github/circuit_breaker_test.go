@@ -0,0 +1,71 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// A probe the caller gives up on says nothing about the upstream: the
+// circuit stays half-open, with the trial slot free for the next call.
+func TestTransportCanceledProbeIsNeutral(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cb.State = Open
+	cb.Failures = cb.Threshold
+	cb.LastFailure = time.Now().Add(-cb.Timeout)
+
+	started := make(chan struct{})
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	transport := cb.Transport(next)
+
+	for _, stop := range []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+	}{
+		{"canceled", func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) }},
+		{"deadline exceeded", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 10*time.Millisecond)
+		}},
+	} {
+		started = make(chan struct{})
+		ctx, cancel := stop.ctx()
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/", nil)
+		go func() {
+			<-started
+			cancel()
+		}()
+		if _, err := transport.RoundTrip(req); err == nil {
+			t.Fatalf("%s probe: want an error", stop.name)
+		}
+		cancel()
+
+		status := cb.Status()
+		if status.State != "half_open" || status.Failures != cb.Threshold {
+			t.Errorf("after a %s probe: state %s with %d failures, want half_open with %d", stop.name, status.State, status.Failures, cb.Threshold)
+		}
+	}
+
+	// The released slot goes to the next call, whose success closes the circuit
+	transport = cb.Transport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	}))
+	req := httptest.NewRequest(http.MethodGet, "https://api.github.com/", nil)
+	resp, err := transport.RoundTrip(req)
+	if errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("trial call after a canceled probe was rejected")
+	}
+	if err != nil {
+		t.Fatalf("trial call: %v", err)
+	}
+	resp.Body.Close()
+	if state := cb.Status().State; state != "closed" {
+		t.Errorf("state after a successful trial %s, want closed", state)
+	}
+}
@@ -2,11 +2,16 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/google/go-github/v60/github"
 	"github.com/krauzx/gitright/internal/config"
+	"github.com/krauzx/gitright/internal/models"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/oauth2"
 	goauth "golang.org/x/oauth2/github"
 )
@@ -14,11 +19,23 @@ import (
 type Client struct {
 	config      *config.GitHubConfig
 	oauthConfig *oauth2.Config
+	breaker     *CircuitBreaker
+	httpClient  *http.Client
+	// availability sees every call, including those the open circuit rejects
+	availability *availabilityTracker
 }
 
 func NewClient(cfg config.GitHubConfig) *Client {
+	breaker := NewCircuitBreaker()
+	availability := &availabilityTracker{}
 	return &Client{
-		config: &cfg,
+		config:       &cfg,
+		breaker:      breaker,
+		availability: availability,
+		// Trace every GitHub API call as a child of the caller's span
+		httpClient: &http.Client{
+			Transport: availability.transport(breaker.Transport(otelhttp.NewTransport(http.DefaultTransport))),
+		},
 		oauthConfig: &oauth2.Config{
 			ClientID:     cfg.ClientID,
 			ClientSecret: cfg.ClientSecret,
@@ -29,12 +46,28 @@ func NewClient(cfg config.GitHubConfig) *Client {
 	}
 }
 
+// CircuitBreaker returns the breaker shared by all GitHub API calls.
+func (c *Client) CircuitBreaker() *CircuitBreaker {
+	return c.breaker
+}
+
+// Ping fetches the unauthenticated rate limits, which don't count against
+// them, checking that the GitHub API is reachable. Probes bypass the breaker
+// and availability tracking, so they can't trip the circuit or count as API
+// calls.
+func (c *Client) Ping(ctx context.Context) error {
+	if _, _, err := github.NewClient(webHTTPClient).RateLimit.Get(ctx); err != nil {
+		return fmt.Errorf("failed to get rate limits: %w", err)
+	}
+	return nil
+}
+
 func (c *Client) GetAuthorizationURL(state string) string {
 	return c.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
 }
 
 func (c *Client) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	token, err := c.oauthConfig.Exchange(ctx, code)
+	token, err := c.oauthConfig.Exchange(context.WithValue(ctx, oauth2.HTTPClient, c.httpClient), code)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
@@ -42,6 +75,7 @@ func (c *Client) ExchangeCode(ctx context.Context, code string) (*oauth2.Token,
 }
 
 func (c *Client) NewAuthenticatedClient(ctx context.Context, token string) *github.Client {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.httpClient)
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
 	tc := oauth2.NewClient(ctx, ts)
 	return github.NewClient(tc)
@@ -116,6 +150,29 @@ func (c *Client) GetRepository(ctx context.Context, token, owner, repo string) (
 	return repository, nil
 }
 
+// IsNotFound reports whether err is GitHub's 404 response, which it also
+// returns for private repositories the token can't see.
+func IsNotFound(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound
+}
+
+// CreateRepository creates a public repository owned by the token's user,
+// initialized with a README commit.
+func (c *Client) CreateRepository(ctx context.Context, token, name, description string) (*github.Repository, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+	repository, _, err := client.Repositories.Create(ctx, "", &github.Repository{
+		Name:        github.String(name),
+		Description: github.String(description),
+		AutoInit:    github.Bool(true),
+		Private:     github.Bool(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository: %w", err)
+	}
+	return repository, nil
+}
+
 func (c *Client) GetRepositoryLanguages(ctx context.Context, token, owner, repo string) (map[string]int, error) {
 	client := c.NewAuthenticatedClient(ctx, token)
 	languages, resp, err := client.Repositories.ListLanguages(ctx, owner, repo)
@@ -181,6 +238,78 @@ func (c *Client) GetCommitCount(ctx context.Context, token, owner, repo string)
 	return len(commits), nil
 }
 
+// GetRecentCommitMessages returns up to limit commit messages from the default
+// branch, newest first.
+func (c *Client) GetRecentCommitMessages(ctx context.Context, token, owner, repo string, limit int) ([]string, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+	commits, resp, err := client.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+		ListOptions: github.ListOptions{PerPage: min(limit, 100)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	messages := make([]string, 0, len(commits))
+	for _, commit := range commits {
+		messages = append(messages, commit.GetCommit().GetMessage())
+	}
+	return messages, nil
+}
+
+// maxStargazerPages bounds GetStargazerTimeline to this many pages of 100.
+const maxStargazerPages = 10
+
+// StargazerEvent is a single star on a repository.
+type StargazerEvent struct {
+	Login     string
+	StarredAt time.Time
+}
+
+// GetStargazerTimeline returns when each star was given, newest first.
+// GitHub lists stargazers oldest first, so pages are walked backwards from
+// the last one and at most maxStargazerPages pages are read; for very popular
+// repositories only the most recent stars are returned.
+func (c *Client) GetStargazerTimeline(ctx context.Context, token, owner, repo string) ([]StargazerEvent, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+
+	list := func(page int) ([]*github.Stargazer, *github.Response, error) {
+		stargazers, resp, err := client.Activity.ListStargazers(ctx, owner, repo, &github.ListOptions{Page: page, PerPage: 100})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list stargazers: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		return stargazers, resp, nil
+	}
+
+	stargazers, resp, err := list(1)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []StargazerEvent
+	last := max(resp.LastPage, 1)
+	for page := last; page >= 1 && page > last-maxStargazerPages; page-- {
+		if page != 1 {
+			if stargazers, _, err = list(page); err != nil {
+				return nil, err
+			}
+		}
+		for i := len(stargazers) - 1; i >= 0; i-- {
+			events = append(events, StargazerEvent{
+				Login:     stargazers[i].GetUser().GetLogin(),
+				StarredAt: stargazers[i].GetStarredAt().Time,
+			})
+		}
+	}
+
+	return events, nil
+}
+
 func (c *Client) GetContributorCount(ctx context.Context, token, owner, repo string) (int, error) {
 	client := c.NewAuthenticatedClient(ctx, token)
 	contributors, resp, err := client.Repositories.ListContributors(ctx, owner, repo, &github.ListContributorsOptions{
@@ -195,6 +324,110 @@ func (c *Client) GetContributorCount(ctx context.Context, token, owner, repo str
 	return len(contributors), nil
 }
 
+// issueStatsSampleSize is how many recently closed issues GetRepositoryStats
+// inspects.
+const issueStatsSampleSize = 10
+
+// GetRepositoryStats returns watcher counts and the median days until a
+// recently closed issue got its first comment from someone besides its
+// author. Pull requests are skipped. It makes up to issueStatsSampleSize+2
+// API calls.
+func (c *Client) GetRepositoryStats(ctx context.Context, token, owner, repo string) (models.RepositoryStats, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return models.RepositoryStats{}, fmt.Errorf("failed to get repository: %w", err)
+	}
+	stats := models.RepositoryStats{
+		WatchersCount:    repository.GetWatchersCount(),
+		SubscribersCount: repository.GetSubscribersCount(),
+	}
+
+	issues, _, err := client.Issues.ListByRepo(ctx, owner, repo, &github.IssueListByRepoOptions{
+		State:       "closed",
+		ListOptions: github.ListOptions{PerPage: issueStatsSampleSize},
+	})
+	if err != nil {
+		return models.RepositoryStats{}, fmt.Errorf("failed to list issues: %w", err)
+	}
+
+	var responseDays []float64
+	for _, issue := range issues {
+		if issue.IsPullRequest() || issue.GetComments() == 0 {
+			continue
+		}
+		comments, _, err := client.Issues.ListComments(ctx, owner, repo, issue.GetNumber(), &github.IssueListCommentsOptions{
+			ListOptions: github.ListOptions{PerPage: issueStatsSampleSize},
+		})
+		if err != nil {
+			return models.RepositoryStats{}, fmt.Errorf("failed to list issue comments: %w", err)
+		}
+		for _, comment := range comments {
+			if comment.GetUser().GetLogin() == issue.GetUser().GetLogin() {
+				continue
+			}
+			elapsed := comment.GetCreatedAt().Sub(issue.GetCreatedAt().Time)
+			responseDays = append(responseDays, elapsed.Hours()/24)
+			break
+		}
+	}
+
+	stats.IssuesSampled = len(responseDays)
+	if len(responseDays) > 0 {
+		slices.Sort(responseDays)
+		mid := len(responseDays) / 2
+		if len(responseDays)%2 == 0 {
+			stats.AvgIssueResponseDays = (responseDays[mid-1] + responseDays[mid]) / 2
+		} else {
+			stats.AvgIssueResponseDays = responseDays[mid]
+		}
+	}
+	return stats, nil
+}
+
+// issueCountSampleSize is how many of the most recent issues and pull
+// requests GetIssueStats counts, one page.
+const issueCountSampleSize = 100
+
+// GetIssueStats counts open and closed issues and open and merged pull
+// requests among the issueCountSampleSize most recently created, and the
+// mean days until a closed issue was closed. It makes one API call.
+func (c *Client) GetIssueStats(ctx context.Context, token, owner, repo string) (models.IssueStats, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+
+	issues, _, err := client.Issues.ListByRepo(ctx, owner, repo, &github.IssueListByRepoOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: issueCountSampleSize},
+	})
+	if err != nil {
+		return models.IssueStats{}, fmt.Errorf("failed to list issues: %w", err)
+	}
+
+	var stats models.IssueStats
+	var closeDays float64
+	for _, issue := range issues {
+		open := issue.GetState() == "open"
+		switch {
+		case issue.IsPullRequest() && open:
+			stats.OpenPRs++
+		case issue.IsPullRequest():
+			if issue.GetPullRequestLinks().MergedAt != nil {
+				stats.MergedPRs++
+			}
+		case open:
+			stats.OpenIssues++
+		default:
+			stats.ClosedIssues++
+			closeDays += issue.GetClosedAt().Sub(issue.GetCreatedAt().Time).Hours() / 24
+		}
+	}
+	if stats.ClosedIssues > 0 {
+		stats.AvgCloseDays = closeDays / float64(stats.ClosedIssues)
+	}
+	return stats, nil
+}
+
 func (c *Client) CreateOrUpdateFile(ctx context.Context, token, owner, repo, path, message, content, sha string) error {
 	client := c.NewAuthenticatedClient(ctx, token)
 
@@ -219,14 +452,16 @@ func (c *Client) CreateOrUpdateFile(ctx context.Context, token, owner, repo, pat
 	return nil
 }
 
-func (c *Client) GetProfileReadmeSHA(ctx context.Context, token, username string) (string, error) {
+// GetFileContentSHA returns the blob SHA of path in the user's profile
+// repository, or "" when the file doesn't exist.
+func (c *Client) GetFileContentSHA(ctx context.Context, token, username, path string) (string, error) {
 	client := c.NewAuthenticatedClient(ctx, token)
-	fileContent, _, resp, err := client.Repositories.GetContents(ctx, username, username, "README.md", nil)
+	fileContent, _, resp, err := client.Repositories.GetContents(ctx, username, username, path, nil)
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			return "", nil
 		}
-		return "", fmt.Errorf("failed to get README.md: %w", err)
+		return "", fmt.Errorf("failed to get %s: %w", path, err)
 	}
 
 	if fileContent != nil && fileContent.SHA != nil {
@@ -236,6 +471,120 @@ func (c *Client) GetProfileReadmeSHA(ctx context.Context, token, username string
 	return "", nil
 }
 
+// GetGist fetches a gist with its file contents. GitHub truncates files
+// over 1 MB.
+func (c *Client) GetGist(ctx context.Context, token, gistID string) (*models.Gist, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+	gist, _, err := client.Gists.Get(ctx, gistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gist: %w", err)
+	}
+
+	files := make(map[string]models.GistFile, len(gist.Files))
+	for name, f := range gist.Files {
+		files[string(name)] = models.GistFile{
+			Filename: f.GetFilename(),
+			Language: f.GetLanguage(),
+			Content:  f.GetContent(),
+		}
+	}
+
+	return &models.Gist{
+		ID:          gist.GetID(),
+		Description: gist.GetDescription(),
+		Public:      gist.GetPublic(),
+		Owner:       gist.GetOwner().GetLogin(),
+		HTMLURL:     gist.GetHTMLURL(),
+		Files:       files,
+		CreatedAt:   gist.GetCreatedAt().Time,
+		UpdatedAt:   gist.GetUpdatedAt().Time,
+	}, nil
+}
+
+// GetUserActivitySummary aggregates the user's public event feed (GitHub keeps
+// roughly the last 90 days / 300 events) and starred repository count into a
+// summary of cross-repo activity.
+func (c *Client) GetUserActivitySummary(ctx context.Context, token, username string) (*models.UserActivitySummary, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+
+	summary := &models.UserActivitySummary{}
+	recentCutoff := time.Now().AddDate(0, 0, -30)
+	recentRepos := make(map[string]bool)
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		events, resp, err := client.Activity.ListEventsPerformedByUser(ctx, username, true, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list user events: %w", err)
+		}
+
+		for _, event := range events {
+			payload, err := event.ParsePayload()
+			if err != nil {
+				continue
+			}
+
+			switch p := payload.(type) {
+			case *github.PushEvent:
+				summary.PublicContributions += p.GetSize()
+				repoName := event.GetRepo().GetName()
+				if repoName != "" && event.GetCreatedAt().After(recentCutoff) && !recentRepos[repoName] {
+					recentRepos[repoName] = true
+					summary.RecentActiveRepos = append(summary.RecentActiveRepos, repoName)
+				}
+			case *github.PullRequestEvent:
+				switch p.GetAction() {
+				case "opened":
+					summary.PublicContributions++
+				case "closed":
+					if p.GetPullRequest().GetMerged() {
+						summary.PullRequestsMerged++
+					}
+				}
+			case *github.IssuesEvent:
+				switch p.GetAction() {
+				case "opened":
+					summary.PublicContributions++
+				case "closed":
+					summary.IssuesClosed++
+				}
+			case *github.PullRequestReviewEvent, *github.IssueCommentEvent:
+				summary.PublicContributions++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	starred, resp, err := client.Activity.ListStarred(ctx, username, &github.ActivityListStarredOptions{
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starred repositories: %w", err)
+	}
+	if resp.LastPage > 0 {
+		summary.PublicReposStarred = resp.LastPage
+	} else {
+		summary.PublicReposStarred = len(starred)
+	}
+
+	return summary, nil
+}
+
+// IsOrganizationMember reports whether username belongs to org. Private
+// memberships are visible because the request is made as the user.
+func (c *Client) IsOrganizationMember(ctx context.Context, token, org, username string) (bool, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+	member, _, err := client.Organizations.IsMember(ctx, org, username)
+	if err != nil {
+		return false, fmt.Errorf("failed to check organization membership: %w", err)
+	}
+	return member, nil
+}
+
 func (c *Client) ValidateToken(ctx context.Context, token string) (bool, error) {
 	_, err := c.GetUser(ctx, token)
 	return err == nil, err
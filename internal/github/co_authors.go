@@ -0,0 +1,95 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// coAuthorCommitSample is how many recent commits are read for
+// Co-authored-by trailers. The listing is shared with the commit convention
+// check.
+const coAuthorCommitSample = 100
+
+// maxCoAuthors is how many co-authors an analysis keeps.
+const maxCoAuthors = 5
+
+// maxCoAuthorLookups bounds the user searches made to resolve co-author
+// emails; the search API allows 30 requests a minute.
+const maxCoAuthorLookups = 5
+
+var (
+	coAuthorTrailerPattern = regexp.MustCompile(`(?mi)^co-authored-by:\s*(.*?)\s*<([^>]+)>\s*$`)
+	// noreplyEmailPattern matches GitHub's private commit emails, with or
+	// without the user ID prefix: 123+octocat@users.noreply.github.com.
+	noreplyEmailPattern = regexp.MustCompile(`(?i)^(?:\d+\+)?([a-z0-9-]+)@users\.noreply\.github\.com$`)
+)
+
+// parseCoAuthorTrailers returns the Co-authored-by trailers in messages,
+// deduplicated by email.
+func parseCoAuthorTrailers(messages []string) []models.CoAuthorTrailer {
+	var trailers []models.CoAuthorTrailer
+	seen := make(map[string]bool)
+	for _, message := range messages {
+		for _, m := range coAuthorTrailerPattern.FindAllStringSubmatch(message, -1) {
+			email := strings.ToLower(strings.TrimSpace(m[2]))
+			if email == "" || seen[email] {
+				continue
+			}
+			seen[email] = true
+			trailers = append(trailers, models.CoAuthorTrailer{Name: m[1], Email: email})
+		}
+	}
+	return trailers
+}
+
+// ResolveCoAuthors returns up to maxCoAuthors of the collaborators in
+// trailers. Collaborators whose GitHub login can be resolved from their
+// email are returned as "@login", the rest by the name in the trailer. The
+// repository owner is left out.
+func (c *Client) ResolveCoAuthors(ctx context.Context, token, owner string, trailers []models.CoAuthorTrailer) []string {
+	client := c.NewAuthenticatedClient(ctx, token)
+	lookups := 0
+	var coAuthors []string
+	seen := make(map[string]bool)
+	for _, t := range trailers {
+		if len(coAuthors) >= maxCoAuthors {
+			break
+		}
+		login := ""
+		if m := noreplyEmailPattern.FindStringSubmatch(t.Email); m != nil {
+			login = m[1]
+		} else if lookups < maxCoAuthorLookups {
+			lookups++
+			login = loginByEmail(ctx, client, t.Email)
+		}
+
+		if strings.EqualFold(login, owner) {
+			continue
+		}
+		display := t.Name
+		if login != "" {
+			display = "@" + login
+		}
+		if display == "" || seen[strings.ToLower(display)] {
+			continue
+		}
+		seen[strings.ToLower(display)] = true
+		coAuthors = append(coAuthors, display)
+	}
+	return coAuthors
+}
+
+func loginByEmail(ctx context.Context, client *github.Client, email string) string {
+	result, _, err := client.Search.Users(ctx, fmt.Sprintf("%s in:email", email), &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil || len(result.Users) == 0 {
+		return ""
+	}
+	return result.Users[0].GetLogin()
+}
@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// participationRetryDelay is how long to wait before asking again when
+// GitHub answers 202 while it computes participation statistics.
+const participationRetryDelay = 2 * time.Second
+
+// Commit cadence thresholds, in average owner commits per week over the
+// last 12 weeks. Monthly is one commit in each of the three months.
+const (
+	dailyCadenceCommitsPerWeek  = 5
+	weeklyCadenceCommitsPerWeek = 1
+	monthlyCadenceCommits       = 3
+)
+
+// GetOwnerWeeklyCommits returns the repository owner's commit counts for the
+// last 52 weeks, oldest first. GitHub computes the statistics on first
+// request, so a 202 is retried once.
+func (c *Client) GetOwnerWeeklyCommits(ctx context.Context, token, owner, repo string) ([]int, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+	for attempt := 0; ; attempt++ {
+		participation, _, err := client.Repositories.ListParticipation(ctx, owner, repo)
+		var accepted *github.AcceptedError
+		if errors.As(err, &accepted) && attempt == 0 {
+			select {
+			case <-time.After(participationRetryDelay):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get participation stats: %w", err)
+		}
+		return participation.Owner, nil
+	}
+}
+
+// classifyCommitFrequency measures how recently and how often the owner
+// commits to a repository. It returns the zero CommitFrequency, with an empty
+// Cadence, when the statistics are unavailable.
+func (a *Analyzer) classifyCommitFrequency(ctx context.Context, token, owner, repo string) models.CommitFrequency {
+	weeks, err := a.client.GetOwnerWeeklyCommits(ctx, token, owner, repo)
+	if err != nil || len(weeks) == 0 {
+		return models.CommitFrequency{}
+	}
+	return commitFrequency(weeks)
+}
+
+// commitFrequency classifies weekly commit counts, oldest first.
+func commitFrequency(weeks []int) models.CommitFrequency {
+	sumLast := func(n int) int {
+		total := 0
+		for _, count := range weeks[max(0, len(weeks)-n):] {
+			total += count
+		}
+		return total
+	}
+	freq := models.CommitFrequency{
+		OwnerCommitsLast4Weeks:  sumLast(4),
+		OwnerCommitsLast12Weeks: sumLast(12),
+	}
+
+	perWeek := float64(freq.OwnerCommitsLast12Weeks) / 12
+	switch {
+	case perWeek >= dailyCadenceCommitsPerWeek:
+		freq.Cadence = models.CadenceDaily
+	case perWeek >= weeklyCadenceCommitsPerWeek:
+		freq.Cadence = models.CadenceWeekly
+	case freq.OwnerCommitsLast12Weeks >= monthlyCadenceCommits:
+		freq.Cadence = models.CadenceMonthly
+	default:
+		freq.Cadence = models.CadenceOccasional
+	}
+	return freq
+}
@@ -0,0 +1,60 @@
+package github
+
+import (
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// dataToolPackages maps pip package names, normalized to lowercase with
+// hyphens, to the data pipeline tools they provide.
+var dataToolPackages = map[string]string{
+	"apache-airflow":     models.DataToolAirflow,
+	"dbt-core":           models.DataToolDBT,
+	"prefect":            models.DataToolPrefect,
+	"dagster":            models.DataToolDagster,
+	"great-expectations": models.DataToolGreatExpectations,
+}
+
+// airflowDirs are directory names that hold Airflow DAG definitions.
+var airflowDirs = map[string]bool{"airflow": true, "dags": true}
+
+// normalizePipPackage reduces a requirement name to its PEP 503 form and
+// drops extras, so "Apache_Airflow[postgres]" reads as "apache-airflow".
+func normalizePipPackage(name string) string {
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("_", "-", ".", "-").Replace(name)
+}
+
+// detectDataTools lists the data pipeline tools a repository uses, from its
+// pip requirements and, for Airflow, from an airflow/ or dags/ directory.
+func (a *Analyzer) detectDataTools(files []string, dependencies map[string][]string) []string {
+	found := make(map[string]bool)
+	for _, dep := range dependencies["pip"] {
+		if tool, ok := dataToolPackages[normalizePipPackage(dep)]; ok {
+			found[tool] = true
+		}
+	}
+	for _, f := range files {
+		dirs := strings.Split(f, "/")
+		for _, dir := range dirs[:len(dirs)-1] {
+			if airflowDirs[strings.ToLower(dir)] {
+				found[models.DataToolAirflow] = true
+			}
+		}
+	}
+
+	var tools []string
+	for _, tool := range []string{
+		models.DataToolAirflow, models.DataToolDBT, models.DataToolPrefect,
+		models.DataToolDagster, models.DataToolGreatExpectations,
+	} {
+		if found[tool] {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
@@ -0,0 +1,52 @@
+package github
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// maxGoWorkspaceModules bounds how many workspace members' go.mod files are
+// fetched when the file listing didn't reach them.
+const maxGoWorkspaceModules = 20
+
+// extractGoWorkspaceDependencies parses a go.work file: modules are the
+// cleaned paths of its use directives, e.g. "services/api", and deps the
+// module paths of its require directives, which go.work rarely has. Paths
+// leaving the repository are dropped.
+func (a *Analyzer) extractGoWorkspaceDependencies(content string) (modules []string, deps []string) {
+	for _, line := range goModDirectiveLines(content, "use") {
+		dir, _, _ := strings.Cut(line, "//")
+		dir = path.Clean(strings.Trim(strings.TrimSpace(dir), `"`+"`"))
+		if dir == ".." || strings.HasPrefix(dir, "../") || path.IsAbs(dir) {
+			continue
+		}
+		modules = append(modules, dir)
+	}
+	for _, line := range goModDirectiveLines(content, "require") {
+		if parts := strings.Fields(line); len(parts) > 0 {
+			deps = append(deps, parts[0])
+		}
+	}
+	return modules, deps
+}
+
+// fetchGoWorkspaceModules adds the go.mod of each workspace module missing
+// from keyFiles, when the file listing stopped short of it, so dependencies
+// are inferred from every member. Unreadable ones are skipped.
+func (a *Analyzer) fetchGoWorkspaceModules(ctx context.Context, token, owner, repo string, modules []string, keyFiles map[string]string) {
+	fetched := 0
+	for _, dir := range modules {
+		file := path.Join(dir, "go.mod")
+		if _, ok := keyFiles[file]; ok {
+			continue
+		}
+		if fetched >= maxGoWorkspaceModules {
+			return
+		}
+		fetched++
+		if content, err := a.client.GetRepositoryContent(ctx, token, owner, repo, file); err == nil {
+			keyFiles[file] = content
+		}
+	}
+}
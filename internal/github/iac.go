@@ -0,0 +1,59 @@
+package github
+
+import (
+	"path"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// maxCloudFormationTemplates bounds how many candidate templates are fetched
+// to check for the CloudFormation version key.
+const maxCloudFormationTemplates = 5
+
+// cloudFormationMarker is the top-level key that identifies a CloudFormation
+// or SAM template; other JSON and YAML files named template.* lack it.
+const cloudFormationMarker = "AWSTemplateFormatVersion"
+
+// isCloudFormationCandidate reports whether a file is named like a
+// CloudFormation template: template.yaml, template.json or *.template.json
+// and its YAML variants.
+func isCloudFormationCandidate(filename string) bool {
+	name := strings.ToLower(path.Base(filename))
+	switch path.Ext(name) {
+	case ".json", ".yaml", ".yml":
+	default:
+		return false
+	}
+	base := strings.TrimSuffix(name, path.Ext(name))
+	return base == "template" || strings.HasSuffix(base, ".template")
+}
+
+// detectIaCTools lists the infrastructure-as-code tools a repository uses:
+// Terraform from .tf files, Azure Bicep from .bicep files and CloudFormation
+// from fetched templates declaring AWSTemplateFormatVersion.
+func (a *Analyzer) detectIaCTools(files []string, keyFiles map[string]string) []string {
+	found := make(map[string]bool)
+	for _, f := range files {
+		switch path.Ext(f) {
+		case ".tf":
+			found[models.IaCTerraform] = true
+		case ".bicep":
+			found[models.IaCAzureBicep] = true
+		}
+	}
+	for file, content := range keyFiles {
+		if isCloudFormationCandidate(file) && strings.Contains(content, cloudFormationMarker) {
+			found[models.IaCCloudFormation] = true
+			break
+		}
+	}
+
+	var tools []string
+	for _, tool := range []string{models.IaCTerraform, models.IaCAzureBicep, models.IaCCloudFormation} {
+		if found[tool] {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
@@ -0,0 +1,135 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// languageHistorySnapshots is how many monthly snapshots an analysis takes,
+// covering the past year.
+const languageHistorySnapshots = 12
+
+// languageExtensions maps file extensions to the linguist language names
+// GitHub reports in GetRepositoryLanguages, so snapshots compare with it.
+var languageExtensions = map[string]string{
+	".go":     "Go",
+	".py":     "Python",
+	".js":     "JavaScript",
+	".jsx":    "JavaScript",
+	".mjs":    "JavaScript",
+	".ts":     "TypeScript",
+	".tsx":    "TypeScript",
+	".java":   "Java",
+	".kt":     "Kotlin",
+	".kts":    "Kotlin",
+	".scala":  "Scala",
+	".rs":     "Rust",
+	".rb":     "Ruby",
+	".php":    "PHP",
+	".c":      "C",
+	".h":      "C",
+	".cc":     "C++",
+	".cpp":    "C++",
+	".hpp":    "C++",
+	".cs":     "C#",
+	".fs":     "F#",
+	".swift":  "Swift",
+	".m":      "Objective-C",
+	".dart":   "Dart",
+	".ex":     "Elixir",
+	".exs":    "Elixir",
+	".erl":    "Erlang",
+	".hs":     "Haskell",
+	".clj":    "Clojure",
+	".lua":    "Lua",
+	".r":      "R",
+	".jl":     "Julia",
+	".zig":    "Zig",
+	".nix":    "Nix",
+	".sh":     "Shell",
+	".ps1":    "PowerShell",
+	".vue":    "Vue",
+	".svelte": "Svelte",
+	".html":   "HTML",
+	".css":    "CSS",
+	".scss":   "SCSS",
+	".ipynb":  "Jupyter Notebook",
+	".hcl":    "HCL",
+	".tf":     "HCL",
+	".sol":    "Solidity",
+}
+
+// GetLanguageHistory returns the repository's language breakdown at the
+// last commit before each of the past snapshots monthly marks, oldest
+// first. Months without new commits are left out. GitHub's languages
+// endpoint has no ref parameter, so each snapshot sums blob sizes by file
+// extension in that commit's tree; it makes up to two API calls a month.
+func (c *Client) GetLanguageHistory(ctx context.Context, token, owner, repo string, snapshots int) ([]models.LanguageSnapshot, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+
+	now := time.Now()
+	var history []models.LanguageSnapshot
+	seen := make(map[string]bool)
+	for i := snapshots - 1; i >= 0; i-- {
+		commits, resp, err := client.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+			Until:       now.AddDate(0, -i, 0),
+			ListOptions: github.ListOptions{PerPage: 1},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		if len(commits) == 0 || seen[commits[0].GetSHA()] {
+			continue
+		}
+		sha := commits[0].GetSHA()
+		seen[sha] = true
+
+		tree, resp, err := client.Git.GetTree(ctx, owner, repo, sha, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tree: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		history = append(history, models.LanguageSnapshot{
+			Date:      commits[0].GetCommit().GetCommitter().GetDate().Time,
+			Languages: treeLanguages(tree.Entries),
+		})
+	}
+	return history, nil
+}
+
+// treeLanguages sums the bytes of each language's blobs in a tree, skipping
+// the directories file listing skips. Truncated trees count what they hold.
+func treeLanguages(entries []*github.TreeEntry) map[string]int {
+	languages := make(map[string]int)
+	for _, entry := range entries {
+		if entry.GetType() != "blob" || inSkippedDir(entry.GetPath()) {
+			continue
+		}
+		if lang, ok := languageExtensions[strings.ToLower(path.Ext(entry.GetPath()))]; ok {
+			languages[lang] += entry.GetSize()
+		}
+	}
+	return languages
+}
+
+func inSkippedDir(p string) bool {
+	dirs := strings.Split(p, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if skippedDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
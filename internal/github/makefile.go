@@ -0,0 +1,66 @@
+package github
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// makeKeyTargets are the Makefile targets that show the repository automates
+// its workflow with make, in the order they are reported.
+var makeKeyTargets = []string{"build", "test", "lint", "deploy"}
+
+// makeRulePattern matches a rule's target at the start of a line. Variable
+// assignments such as "CC := gcc" are told apart by the "=" after the colon.
+var makeRulePattern = regexp.MustCompile(`^([a-zA-Z0-9_-]+)\s*::?(?:[^=]|$)`)
+
+// findMakefile returns the shallowest fetched Makefile, by either of the
+// names GNU make looks for.
+func findMakefile(keyFiles map[string]string) (string, bool) {
+	if content, ok := findKeyFile(keyFiles, "Makefile"); ok {
+		return content, true
+	}
+	return findKeyFile(keyFiles, "makefile")
+}
+
+// extractMakeTargets lists a Makefile's targets in order of appearance: those
+// declared .PHONY and those of explicit rules. Pattern rules such as
+// "%.o: %.c" and special targets such as ".DEFAULT" are skipped.
+func (a *Analyzer) extractMakeTargets(content string) []string {
+	var targets []string
+	add := func(target string) {
+		if target != "" && !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		// Recipe lines start with a tab and never declare targets
+		if strings.HasPrefix(line, "\t") {
+			continue
+		}
+		line = strings.TrimRight(line, "\r")
+		if phony, ok := strings.CutPrefix(line, ".PHONY:"); ok {
+			phony, _, _ = strings.Cut(phony, "#")
+			for _, target := range strings.Fields(phony) {
+				add(target)
+			}
+			continue
+		}
+		if m := makeRulePattern.FindStringSubmatch(line); m != nil {
+			add(m[1])
+		}
+	}
+	return targets
+}
+
+// classifyMakeTargets returns the makeKeyTargets among targets.
+func classifyMakeTargets(targets []string) []string {
+	var found []string
+	for _, key := range makeKeyTargets {
+		if slices.Contains(targets, key) {
+			found = append(found, key)
+		}
+	}
+	return found
+}
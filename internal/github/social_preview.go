@@ -0,0 +1,90 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/net/html"
+	"golang.org/x/oauth2"
+)
+
+// maxRepositoryPageSize bounds how much of a repository page is read looking
+// for its og:image tag, which sits in the head.
+const maxRepositoryPageSize = 1 << 20
+
+// webHTTPClient fetches github.com pages and health probes. It bypasses the
+// API breaker and availability tracking, which describe API calls made for
+// users.
+var webHTTPClient = &http.Client{
+	Transport: otelhttp.NewTransport(http.DefaultTransport),
+}
+
+// GetRepositorySocialPreview returns the og:image URL of a repository's page
+// on github.com, the social preview image, or "" when the page has none. The
+// REST API does not expose it.
+func (c *Client) GetRepositorySocialPreview(ctx context.Context, token, owner, repo string) (string, error) {
+	pageURL := "https://github.com/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, webHTTPClient)
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch repository page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return ogImage(io.LimitReader(resp.Body, maxRepositoryPageSize))
+}
+
+// ogImage scans an HTML document's head for <meta property="og:image">.
+func ogImage(r io.Reader) (string, error) {
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return "", nil
+			}
+			return "", fmt.Errorf("failed to parse repository page: %w", z.Err())
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return "", nil
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) == "body" {
+				return "", nil
+			}
+			if string(name) != "meta" || !hasAttr {
+				continue
+			}
+			var property, content string
+			for {
+				key, val, more := z.TagAttr()
+				switch string(key) {
+				case "property":
+					property = string(val)
+				case "content":
+					content = string(val)
+				}
+				if !more {
+					break
+				}
+			}
+			if property == "og:image" && content != "" {
+				return content, nil
+			}
+		}
+	}
+}
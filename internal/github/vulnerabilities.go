@@ -0,0 +1,106 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// maxVulnerabilityAlerts is how many open alerts are read, one GraphQL page.
+const maxVulnerabilityAlerts = 100
+
+const vulnerabilityAlertsQuery = `query($owner: String!, $name: String!, $first: Int!) {
+  repository(owner: $owner, name: $name) {
+    vulnerabilityAlerts(first: $first, states: OPEN) {
+      nodes {
+        securityVulnerability { severity package { name } }
+        securityAdvisory { identifiers { type value } }
+      }
+    }
+  }
+}`
+
+type vulnerabilityAlertsResponse struct {
+	Data struct {
+		Repository struct {
+			VulnerabilityAlerts struct {
+				Nodes []struct {
+					SecurityVulnerability struct {
+						Severity string `json:"severity"`
+						Package  struct {
+							Name string `json:"name"`
+						} `json:"package"`
+					} `json:"securityVulnerability"`
+					SecurityAdvisory struct {
+						Identifiers []struct {
+							Type  string `json:"type"`
+							Value string `json:"value"`
+						} `json:"identifiers"`
+					} `json:"securityAdvisory"`
+				} `json:"nodes"`
+			} `json:"vulnerabilityAlerts"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GetVulnerabilityAlerts returns the repository's open Dependabot alerts, or
+// nil when alerts are disabled. Reading them needs the security_events scope
+// or admin access to the repository. It makes two API calls.
+func (c *Client) GetVulnerabilityAlerts(ctx context.Context, token, owner, repo string) ([]models.VulnerabilityAlert, error) {
+	client := c.NewAuthenticatedClient(ctx, token)
+
+	enabled, _, err := client.Repositories.GetVulnerabilityAlerts(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check vulnerability alerts: %w", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	// The REST client resolves "graphql" against the API base URL, so the
+	// query goes through the same auth and circuit breaker
+	req, err := client.NewRequest("POST", "graphql", map[string]interface{}{
+		"query":     vulnerabilityAlertsQuery,
+		"variables": map[string]interface{}{"owner": owner, "name": repo, "first": maxVulnerabilityAlerts},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	var resp vulnerabilityAlertsResponse
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list vulnerability alerts: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("failed to list vulnerability alerts: %s", resp.Errors[0].Message)
+	}
+
+	var alerts []models.VulnerabilityAlert
+	for _, node := range resp.Data.Repository.VulnerabilityAlerts.Nodes {
+		alert := models.VulnerabilityAlert{
+			PackageName: node.SecurityVulnerability.Package.Name,
+			Severity:    alertSeverity(node.SecurityVulnerability.Severity),
+		}
+		for _, id := range node.SecurityAdvisory.Identifiers {
+			if id.Type == "CVE" {
+				alert.CVE = id.Value
+				break
+			}
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// alertSeverity maps GraphQL's SecurityAdvisorySeverity, which calls medium
+// MODERATE, to the models.Severity constants.
+func alertSeverity(severity string) string {
+	if severity == "MODERATE" {
+		return models.SeverityMedium
+	}
+	return strings.ToUpper(severity)
+}
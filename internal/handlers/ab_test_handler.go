@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/validators"
+	"github.com/labstack/echo/v4"
+)
+
+const maxABTestNameLength = 100
+
+type abTestRequest struct {
+	TestName string                          `json:"test_name"`
+	VariantA models.ContentGenerationRequest `json:"variant_a"`
+	VariantB models.ContentGenerationRequest `json:"variant_b"`
+}
+
+// ABTest generates two profile variants side by side and stores them as a
+// test the user can later pick a winner for.
+func (h *ProfileHandler) ABTest(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req abTestRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	req.TestName = strings.TrimSpace(req.TestName)
+	if req.TestName == "" || len([]rune(req.TestName)) > maxABTestNameLength {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("test_name must be 1 to %d characters", maxABTestNameLength))
+	}
+
+	for _, variant := range []*models.ContentGenerationRequest{&req.VariantA, &req.VariantB} {
+		if err := validators.NormalizeSocialLinks(&variant.ContactPrefs); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err := validators.ValidateCustomSections(variant.CustomSections); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err := validators.ResolveLanguage(variant, c.Request().Header.Get("Accept-Language")); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if len(variant.Projects) == 0 {
+			projects, err := h.autoSelectProjects(ctx, user)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to select projects")
+			}
+			variant.Projects = projects
+		}
+	}
+
+	result, err := h.profileService.RunABTest(ctx, user, req.TestName, &req.VariantA, &req.VariantB)
+	if err != nil {
+		return generationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// SelectABVariant records which variant of a test the user preferred, given
+// as ?variant=a or ?variant=b. Selecting again replaces the earlier choice.
+func (h *ProfileHandler) SelectABVariant(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	testID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid test ID")
+	}
+	variant := strings.ToLower(c.QueryParam("variant"))
+	if variant != "a" && variant != "b" {
+		return echo.NewHTTPError(http.StatusBadRequest, "variant must be a or b")
+	}
+
+	found, err := h.profileService.SelectABVariant(ctx, user, testID, variant)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to record selection")
+	}
+	if !found {
+		return echo.NewHTTPError(http.StatusNotFound, "A/B test not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"test_id":          testID,
+		"selected_variant": variant,
+		"status":           "completed",
+	})
+}
+
+// ListABTests returns the user's A/B tests and their outcomes, newest first.
+func (h *ProfileHandler) ListABTests(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tests, err := h.profileService.ListABTests(c.Request().Context(), user)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load A/B tests")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"tests": tests,
+		"count": len(tests),
+	})
+}
+
+// ABInsights ranks the role and tone combinations the user preferred across
+// their A/B tests.
+func (h *ProfileHandler) ABInsights(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	insights, err := h.profileService.ABInsights(c.Request().Context(), user)
+	if errors.Is(err, services.ErrNotEnoughABTests) {
+		return echo.NewHTTPError(http.StatusNotFound, "Run more than 5 A/B tests to see insights")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to compute A/B insights")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"insights": insights,
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+type AdminHandler struct {
+	profileCacheRepo *repository.ProfileCacheRepository
+	emailService     *services.EmailService
+	auditService     *services.AuditService
+	metricsService   *services.MetricsService
+}
+
+func NewAdminHandler(
+	profileCacheRepo *repository.ProfileCacheRepository,
+	emailService *services.EmailService,
+	auditService *services.AuditService,
+	metricsService *services.MetricsService,
+) *AdminHandler {
+	return &AdminHandler{
+		profileCacheRepo: profileCacheRepo,
+		emailService:     emailService,
+		auditService:     auditService,
+		metricsService:   metricsService,
+	}
+}
+
+func (h *AdminHandler) CacheStats(c echo.Context) error {
+	stats, err := h.profileCacheRepo.GetStats(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load cache stats")
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// Metrics returns a system-wide usage snapshot, refreshed at most once a
+// minute.
+func (h *AdminHandler) Metrics(c echo.Context) error {
+	metrics, err := h.metricsService.SystemMetrics(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load metrics")
+	}
+
+	return c.JSON(http.StatusOK, metrics)
+}
+
+// TestEmail sends a test message to the calling admin's account email.
+func (h *AdminHandler) TestEmail(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if !h.emailService.Enabled() {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Email is not configured")
+	}
+	if user.Email == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Your account has no email address")
+	}
+
+	if err := h.emailService.SendTestEmail(user.Email); err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Test email sent to " + user.Email,
+	})
+}
+
+// ListAudit returns audit entries across all users, optionally filtered by
+// ?user_id=.
+func (h *AdminHandler) ListAudit(c echo.Context) error {
+	var userID int64
+	if v := c.QueryParam("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid user_id")
+		}
+		userID = id
+	}
+
+	page, perPage, err := paginationParams(c)
+	if err != nil {
+		return err
+	}
+
+	entries, total, err := h.auditService.List(c.Request().Context(), userID, perPage, (page-1)*perPage)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load audit log")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"entries":  entries,
+		"page":     page,
+		"per_page": perPage,
+		"total":    total,
+	})
+}
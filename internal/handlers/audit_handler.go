@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// List returns the authenticated user's own audit log.
+func (h *AuditHandler) List(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	page, perPage, err := paginationParams(c)
+	if err != nil {
+		return err
+	}
+
+	entries, total, err := h.auditService.List(c.Request().Context(), userID, perPage, (page-1)*perPage)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load audit log")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"entries":  entries,
+		"page":     page,
+		"per_page": perPage,
+		"total":    total,
+	})
+}
+
+// paginationParams reads ?page= (1-based) and ?per_page= with defaults.
+func paginationParams(c echo.Context) (page, perPage int, err error) {
+	page, perPage = 1, defaultPerPage
+
+	if v := c.QueryParam("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "page must be a positive integer")
+		}
+	}
+	if v := c.QueryParam("per_page"); v != "" {
+		perPage, err = strconv.Atoi(v)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "per_page must be between 1 and 100")
+		}
+	}
+	return page, perPage, nil
+}
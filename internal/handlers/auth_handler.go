@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/krauzx/gitright/internal/audit"
 	"github.com/krauzx/gitright/internal/middleware"
 	"github.com/krauzx/gitright/internal/models"
 	"github.com/krauzx/gitright/internal/services"
@@ -20,12 +22,16 @@ type AuthHandler struct {
 	frontendURL   string
 	jwtSecret     string
 	scopes        []string
+	cookieDomain  string
+	cookieMaxAge  int
 }
 
 func NewAuthHandler(
 	authService *services.AuthService,
 	githubBaseURL, clientID, redirectURI, frontendURL, jwtSecret string,
 	scopes []string,
+	cookieDomain string,
+	cookieMaxAge int,
 ) *AuthHandler {
 	return &AuthHandler{
 		authService:   authService,
@@ -35,6 +41,8 @@ func NewAuthHandler(
 		frontendURL:   frontendURL,
 		jwtSecret:     jwtSecret,
 		scopes:        scopes,
+		cookieDomain:  cookieDomain,
+		cookieMaxAge:  cookieMaxAge,
 	}
 }
 
@@ -59,7 +67,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 }
 
 func (h *AuthHandler) Callback(c echo.Context) error {
-	ctx := c.Request().Context()
+	ctx := audit.WithClient(c.Request().Context(), c.RealIP(), c.Request().UserAgent())
 
 	code := c.QueryParam("code")
 	state := c.QueryParam("state")
@@ -87,12 +95,110 @@ func (h *AuthHandler) Callback(c echo.Context) error {
 		c.Logger().Warn("Failed to delete OAuth state after successful auth: ", err)
 	}
 
+	// Browser clients get the token in an HttpOnly cookie instead of the body
+	if c.QueryParam("response_type") == "cookie" {
+		c.SetCookie(h.sessionCookie(jwtToken, h.cookieMaxAge))
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"user": user,
+		})
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"user":  user,
 		"token": jwtToken,
 	})
 }
 
+// EmailRequest emails a one-time login code for a demo account. Demo
+// accounts can generate and preview profiles from sample repositories but
+// cannot deploy.
+func (h *AuthHandler) EmailRequest(c echo.Context) error {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.authService.RequestEmailLogin(c.Request().Context(), req.Email, c.RealIP()); err != nil {
+		return emailLoginError(err)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message": "Login code sent",
+	})
+}
+
+// EmailVerify exchanges a login code for a JWT that expires with the demo
+// account.
+func (h *AuthHandler) EmailVerify(c echo.Context) error {
+	ctx := audit.WithClient(c.Request().Context(), c.RealIP(), c.Request().UserAgent())
+
+	var req struct {
+		Email string `json:"email"`
+		OTP   string `json:"otp"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Email == "" || req.OTP == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "email and otp are required")
+	}
+
+	user, err := h.authService.VerifyEmailLogin(ctx, req.Email, req.OTP, c.RealIP())
+	if err != nil {
+		return emailLoginError(err)
+	}
+
+	expiresIn := time.Until(user.ExpiresAt)
+	jwtToken, err := middleware.GenerateJWT(user.ID, user.Username, h.jwtSecret, expiresIn)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
+	}
+
+	if c.QueryParam("response_type") == "cookie" {
+		c.SetCookie(h.sessionCookie(jwtToken, min(h.cookieMaxAge, int(expiresIn.Seconds()))))
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"user": user,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"user":  user,
+		"token": jwtToken,
+	})
+}
+
+func emailLoginError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidEmail):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrInvalidLoginCode):
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	case errors.Is(err, services.ErrLoginCodeTooSoon), errors.Is(err, services.ErrLoginCodeLimitReached),
+		errors.Is(err, services.ErrTooManyLoginAttempts):
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, services.ErrEmailLoginUnavailable):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, "Email login failed")
+	}
+}
+
+// sessionCookie builds the session cookie; a negative maxAge deletes it.
+func (h *AuthHandler) sessionCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     middleware.SessionCookieName,
+		Value:    value,
+		Path:     "/",
+		Domain:   h.cookieDomain,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
 func (h *AuthHandler) Me(c echo.Context) error {
 	user, ok := c.Get("user").(*models.User)
 	if !ok || user == nil {
@@ -102,7 +208,7 @@ func (h *AuthHandler) Me(c echo.Context) error {
 }
 
 // Logout revokes the JWT by recording its JTI in the blocklist until it would
-// have naturally expired.
+// have naturally expired, and clears the session cookie.
 func (h *AuthHandler) Logout(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -116,10 +222,15 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 	}
 
-	if err := h.authService.RevokeSession(ctx, jti, time.Unix(exp, 0)); err != nil {
+	userID, _ := c.Get("user_id").(int64)
+
+	if err := h.authService.RevokeSession(ctx, userID, jti, time.Unix(exp, 0)); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke session")
 	}
 
+	// MaxAge < 0 is sent as Max-Age=0
+	c.SetCookie(h.sessionCookie("", -1))
+
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "Logged out successfully",
 	})
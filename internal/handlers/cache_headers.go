@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/krauzx/gitright/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// setCacheHeaders reports the request's cache read as X-Cache, X-Cache-Age
+// (seconds, hits only) and X-Cache-Key (hashed). Nothing is set when the
+// cache wasn't consulted or the caller isn't authenticated.
+func setCacheHeaders(c echo.Context, lookup *repository.CacheLookup) {
+	if _, ok := c.Get("user_id").(int64); !ok || !lookup.Consulted {
+		return
+	}
+
+	header := c.Response().Header()
+	if lookup.Hit {
+		header.Set("X-Cache", "HIT")
+		header.Set("X-Cache-Age", strconv.Itoa(int(lookup.Age.Seconds())))
+	} else {
+		header.Set("X-Cache", "MISS")
+	}
+	header.Set("X-Cache-Key", lookup.HashedKey())
+}
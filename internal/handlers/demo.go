@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/krauzx/gitright/internal/fixtures"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// isDemo reports whether the request is from an email demo account, which
+// is served the sample repositories instead of calling GitHub.
+func isDemo(c echo.Context) bool {
+	user, _ := c.Get("user").(*models.User)
+	return user != nil && user.Source == models.UserSourceEmail
+}
+
+// demoRepositories lists the sample repositories, at most limit of them
+// when limit is positive.
+func demoRepositories(limit int) ([]*models.Repository, error) {
+	analyses, err := fixtures.SampleRepositories()
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(analyses) > limit {
+		analyses = analyses[:limit]
+	}
+	repos := make([]*models.Repository, len(analyses))
+	for i := range analyses {
+		repos[i] = analyses[i].Repository
+	}
+	return repos, nil
+}
+
+// demoAnalysis returns the sample analysis of owner/repo, or a 404 for
+// anything else.
+func demoAnalysis(owner, repo string) (*models.RepositoryAnalysis, error) {
+	if owner != fixtures.SampleOwner {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Repository not found")
+	}
+	analysis, err := fixtures.SampleRepository(repo)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load sample repositories")
+	}
+	if analysis == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Repository not found")
+	}
+	return analysis, nil
+}
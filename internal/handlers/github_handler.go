@@ -1,9 +1,19 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
 	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/validators"
 	"github.com/labstack/echo/v4"
 )
 
@@ -30,7 +40,52 @@ func (h *GitHubHandler) ListRepositories(c echo.Context) error {
 
 	includePrivate := c.QueryParam("include_private") == "true"
 
-	repos, err := h.githubService.ListUserRepositories(ctx, userID, accessToken, includePrivate)
+	var repos []*models.Repository
+	var err error
+	if isDemo(c) {
+		repos, err = demoRepositories(0)
+	} else {
+		repos, err = h.githubService.ListUserRepositories(ctx, userID, accessToken, includePrivate)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch repositories")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"repositories": repos,
+		"count":        len(repos),
+	})
+}
+
+func (h *GitHubHandler) GetRecommendedRepositories(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	accessToken, ok := c.Get("access_token").(string)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	count := 5
+	if v := c.QueryParam("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 20 {
+			return echo.NewHTTPError(http.StatusBadRequest, "count must be between 1 and 20")
+		}
+		count = n
+	}
+
+	var repos []*models.Repository
+	var err error
+	if isDemo(c) {
+		repos, err = demoRepositories(count)
+	} else {
+		repos, err = h.githubService.GetRecommendedRepositories(ctx, userID, accessToken, count)
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch repositories")
 	}
@@ -49,11 +104,17 @@ func (h *GitHubHandler) GetRepository(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 	}
 
-	owner := c.Param("owner")
-	repo := c.Param("repo")
+	owner, repo, err := h.ownerRepoParams(c, accessToken)
+	if err != nil {
+		return err
+	}
 
-	if owner == "" || repo == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Owner and repo are required")
+	if isDemo(c) {
+		analysis, err := demoAnalysis(owner, repo)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, analysis.Repository)
 	}
 
 	repository, err := h.githubService.GetRepository(ctx, accessToken, owner, repo)
@@ -67,29 +128,126 @@ func (h *GitHubHandler) GetRepository(c echo.Context) error {
 func (h *GitHubHandler) AnalyzeRepository(c echo.Context) error {
 	ctx := c.Request().Context()
 
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
 	accessToken, ok := c.Get("access_token").(string)
 	if !ok {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 	}
 
-	owner := c.Param("owner")
-	repo := c.Param("repo")
+	owner, repo, err := h.ownerRepoParams(c, accessToken)
+	if err != nil {
+		return err
+	}
 
-	if owner == "" || repo == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Owner and repo are required")
+	if isDemo(c) {
+		analysis, err := demoAnalysis(owner, repo)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, analysis)
 	}
 
-	analysis, err := h.githubService.AnalyzeRepository(ctx, accessToken, owner, repo)
+	ctx, lookup := repository.WithCacheLookup(ctx)
+	analysis, err := h.githubService.AnalyzeRepository(ctx, userID, accessToken, owner, repo)
+	setCacheHeaders(c, lookup)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to analyze repository")
+		return analysisError(err, "Failed to analyze repository")
 	}
 
 	return c.JSON(http.StatusOK, analysis)
 }
 
+// ExportAnalysis downloads the repository analysis as JSON (the full
+// analysis, key file contents included) or CSV (language byte counts).
+// redact_content=true blanks key file contents; force=true bypasses the cache.
+func (h *GitHubHandler) ExportAnalysis(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	accessToken, ok := c.Get("access_token").(string)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be json or csv")
+	}
+
+	owner, repo, err := h.ownerRepoParams(c, accessToken)
+	if err != nil {
+		return err
+	}
+
+	var analysis *models.RepositoryAnalysis
+	switch {
+	case isDemo(c):
+		if analysis, err = demoAnalysis(owner, repo); err != nil {
+			return err
+		}
+	case c.QueryParam("force") == "true":
+		analysis, err = h.githubService.RefreshRepositoryAnalysis(ctx, userID, accessToken, owner, repo)
+	default:
+		analysis, err = h.githubService.AnalyzeRepository(ctx, userID, accessToken, owner, repo)
+	}
+	if err != nil {
+		return analysisError(err, "Failed to analyze repository")
+	}
+
+	if c.QueryParam("redact_content") == "true" {
+		redacted := *analysis
+		redacted.KeyFiles = make(map[string]string, len(analysis.KeyFiles))
+		for file := range analysis.KeyFiles {
+			redacted.KeyFiles[file] = "[REDACTED]"
+		}
+		analysis = &redacted
+	}
+
+	filename := fmt.Sprintf("%s-%s-analysis.%s", owner, repo, format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		return c.JSON(http.StatusOK, analysis)
+	}
+
+	languages := make([]string, 0, len(analysis.Languages))
+	for lang := range analysis.Languages {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		return analysis.Languages[languages[i]] > analysis.Languages[languages[j]]
+	})
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"language", "bytes"})
+	for _, lang := range languages {
+		_ = w.Write([]string{lang, strconv.Itoa(analysis.Languages[lang])})
+	}
+	w.Flush()
+
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
 func (h *GitHubHandler) BatchAnalyze(c echo.Context) error {
 	ctx := c.Request().Context()
 
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
 	accessToken, ok := c.Get("access_token").(string)
 	if !ok {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
@@ -111,14 +269,75 @@ func (h *GitHubHandler) BatchAnalyze(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Maximum 10 repositories allowed")
 	}
 
-	results, err := h.githubService.BatchAnalyzeRepositories(ctx, accessToken, req.Repositories)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to analyze repositories")
+	username, _ := c.Get("username").(string)
+	checkedOwners := make(map[string]bool)
+	for _, fullName := range req.Repositories {
+		owner, repo, ok := strings.Cut(fullName, "/")
+		if !ok {
+			return echo.NewHTTPError(http.StatusBadRequest, "Repositories must be in owner/repo form")
+		}
+		if err := validators.ValidateOwnerRepo(owner, repo); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if !checkedOwners[owner] {
+			if err := h.authorizeOwner(c, accessToken, username, owner); err != nil {
+				return err
+			}
+			checkedOwners[owner] = true
+		}
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
+	var results map[string]*models.RepositoryAnalysis
+	if isDemo(c) {
+		results = make(map[string]*models.RepositoryAnalysis, len(req.Repositories))
+		for _, fullName := range req.Repositories {
+			owner, repo, _ := strings.Cut(fullName, "/")
+			analysis, err := demoAnalysis(owner, repo)
+			if err != nil {
+				return err
+			}
+			results[fullName] = analysis
+		}
+	} else {
+		var err error
+		results, err = h.githubService.BatchAnalyzeRepositories(ctx, userID, accessToken, req.Repositories)
+		if err != nil {
+			return analysisError(err, "Failed to analyze repositories")
+		}
+	}
+
+	response := map[string]interface{}{
 		"analyses": results,
-	})
+	}
+	// Stale analyses are served when GitHub is unavailable
+	var stale []string
+	for fullName, analysis := range results {
+		if analysis.Stale {
+			stale = append(stale, fullName)
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		response["degraded_mode"] = true
+		response["stale_repositories"] = stale
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// analysisError converts an analysis error into a response: 429 when the
+// user's concurrent analysis slots stayed full, otherwise 500 with message.
+func analysisError(err error, message string) error {
+	if errors.Is(err, services.ErrTooManyConcurrentAnalyses) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, "Too many analyses running; try again shortly")
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
+
+// Status reports GitHub API availability from the success rate of recent
+// calls made by the server.
+func (h *GitHubHandler) Status(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.githubService.GitHubAvailability())
 }
 
 func (h *GitHubHandler) ClearCache(c echo.Context) error {
@@ -137,3 +356,35 @@ func (h *GitHubHandler) ClearCache(c echo.Context) error {
 		"message": "Cache cleared successfully",
 	})
 }
+
+// ownerRepoParams validates the :owner and :repo path params and checks that
+// the user may access owner.
+func (h *GitHubHandler) ownerRepoParams(c echo.Context, accessToken string) (string, string, error) {
+	owner := c.Param("owner")
+	repo := c.Param("repo")
+
+	if err := validators.ValidateOwnerRepo(owner, repo); err != nil {
+		return "", "", echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	username, _ := c.Get("username").(string)
+	if err := h.authorizeOwner(c, accessToken, username, owner); err != nil {
+		return "", "", err
+	}
+	return owner, repo, nil
+}
+
+func (h *GitHubHandler) authorizeOwner(c echo.Context, accessToken, username, owner string) error {
+	// Demo accounts only see the sample repositories, checked on lookup
+	if isDemo(c) {
+		return nil
+	}
+	allowed, err := h.githubService.CanAccessOwner(c.Request().Context(), accessToken, username, owner)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "Failed to verify repository owner")
+	}
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Repository owner must be you or an organization you belong to")
+	}
+	return nil
+}
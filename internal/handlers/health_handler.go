@@ -1,35 +1,163 @@
 package handlers
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/krauzx/gitright/internal/github"
+	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/pkg/telemetry"
 	"github.com/labstack/echo/v4"
+	"golang.org/x/sync/errgroup"
 )
 
+// healthCheckDeadline bounds one round of health checks, so a hung
+// dependency can't stall the next round.
+const healthCheckDeadline = 5 * time.Second
+
+// healthCheckInterval is how often the checks run. GET /health is public and
+// not rate limited, so it serves the latest results instead of probing
+// Gemini and GitHub on every request.
+const healthCheckInterval = 30 * time.Second
+
 type HealthHandler struct {
-	db HealthChecker
+	db       HealthChecker
+	breakers map[string]*github.CircuitBreaker // upstream name -> breaker
+	checks   []HealthCheck
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu        sync.RWMutex
+	results   []error // by index in checks; valid once checkedAt is set
+	checkedAt time.Time
 }
 
+// HealthChecker reports the database connection state from a background
+// monitor, so readiness checks don't ping the database themselves.
 type HealthChecker interface {
-	Ping() error
+	State() repository.DBConnectionState
+}
+
+// HealthCheck probes one dependency for GET /health. Checks run
+// concurrently, each within its own Timeout.
+type HealthCheck struct {
+	Name    string
+	Timeout time.Duration
+	// Critical checks fail the health check; the rest are only reported,
+	// since a failing upstream doesn't mean we are failing.
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+// NewHealthHandler starts running checks in the background, right away and
+// then every healthCheckInterval, until Stop.
+func NewHealthHandler(db HealthChecker, breakers map[string]*github.CircuitBreaker, checks []HealthCheck) *HealthHandler {
+	h := &HealthHandler{
+		db:       db,
+		breakers: breakers,
+		checks:   checks,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// Stop ends the background checks and waits for a round in progress.
+func (h *HealthHandler) Stop() {
+	h.stopOnce.Do(func() { close(h.stop) })
+	<-h.done
 }
 
-func NewHealthHandler(db HealthChecker) *HealthHandler {
-	return &HealthHandler{db: db}
+func (h *HealthHandler) run() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		h.runChecks()
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
+// runChecks runs every check once and records the results.
+func (h *HealthHandler) runChecks() {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckDeadline)
+	defer cancel()
+
+	// Not errgroup.WithContext: one failing check must not cancel the rest
+	errs := make([]error, len(h.checks))
+	var g errgroup.Group
+	for i, check := range h.checks {
+		g.Go(func() error {
+			errs[i] = runHealthCheck(ctx, check)
+			if errs[i] != nil {
+				slog.Warn("Health check failed", "service", check.Name, "error", errs[i])
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.results = errs
+	h.checkedAt = time.Now()
+}
+
+// Health reports the latest background check results. Until the first
+// round finishes, checks are reported as "pending" and don't fail it.
 func (h *HealthHandler) Health(c echo.Context) error {
+	h.mu.RLock()
+	errs, checkedAt := h.results, h.checkedAt
+	h.mu.RUnlock()
+
+	services := make(map[string]interface{})
+	// An open circuit means an upstream is failing, not that we are, so it
+	// is reported without failing the check
+	for name, breaker := range h.breakers {
+		services[name] = map[string]interface{}{
+			"circuit_breaker": breaker.Status(),
+		}
+	}
+
 	health := map[string]interface{}{
-		"status": "healthy",
-		"services": map[string]string{
-			"database": "healthy",
+		"status":   "healthy",
+		"services": services,
+		// Telemetry export problems are reported but never fail the check
+		"telemetry": map[string]string{
+			"status": telemetry.Status(),
 		},
 	}
+	if !checkedAt.IsZero() {
+		health["checked_at"] = checkedAt
+	}
 
-	if h.db != nil {
-		if err := h.db.Ping(); err != nil {
-			health["status"] = "unhealthy"
-			health["services"].(map[string]string)["database"] = "unhealthy"
+	for i, check := range h.checks {
+		status := "pending"
+		if errs != nil {
+			status = "healthy"
+			if errs[i] != nil {
+				status = "unhealthy"
+				if check.Critical {
+					health["status"] = "unhealthy"
+				}
+			}
+		}
+		if upstream, ok := services[check.Name].(map[string]interface{}); ok {
+			upstream["status"] = status
+		} else {
+			services[check.Name] = status
 		}
 	}
 
@@ -41,9 +169,25 @@ func (h *HealthHandler) Health(c echo.Context) error {
 	return c.JSON(statusCode, health)
 }
 
+// runHealthCheck runs check within its timeout and ctx. It returns once
+// either expires, even if the check ignores its context.
+func runHealthCheck(ctx context.Context, check HealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- check.Check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (h *HealthHandler) Ready(c echo.Context) error {
 	if h.db != nil {
-		if err := h.db.Ping(); err != nil {
+		if !h.db.State().Connected {
 			return c.JSON(http.StatusServiceUnavailable, map[string]string{
 				"status": "not ready",
 				"reason": "database unavailable",
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsCollectTimeout bounds the snapshot query during a scrape.
+const metricsCollectTimeout = 10 * time.Second
+
+// systemMetricsCollector exposes models.SystemMetrics as gauges. Values come
+// from MetricsService, so scrapes within its cache window share one query.
+type systemMetricsCollector struct {
+	metricsService *services.MetricsService
+	gauges         []systemMetricGauge
+}
+
+type systemMetricGauge struct {
+	desc  *prometheus.Desc
+	value func(*models.SystemMetrics) float64
+}
+
+func newSystemMetricsCollector(metricsService *services.MetricsService) *systemMetricsCollector {
+	gauge := func(name, help string, value func(*models.SystemMetrics) float64) systemMetricGauge {
+		return systemMetricGauge{desc: prometheus.NewDesc("gitright_"+name, help, nil, nil), value: value}
+	}
+	return &systemMetricsCollector{
+		metricsService: metricsService,
+		gauges: []systemMetricGauge{
+			gauge("users_total", "Registered users.", func(m *models.SystemMetrics) float64 { return float64(m.TotalUsers) }),
+			gauge("active_users_7d", "Users who logged in within the last 7 days.", func(m *models.SystemMetrics) float64 { return float64(m.ActiveUsersLast7Days) }),
+			gauge("profiles_generated_today", "Profiles generated by the LLM today (UTC).", func(m *models.SystemMetrics) float64 { return float64(m.ProfilesGeneratedToday) }),
+			gauge("profiles_deployed_today", "Profiles deployed to GitHub today (UTC).", func(m *models.SystemMetrics) float64 { return float64(m.ProfilesDeployedToday) }),
+			gauge("profile_cache_hit_rate_percent", "Share of today's profile requests served from cache.", func(m *models.SystemMetrics) float64 { return m.CacheHitRatePct }),
+			gauge("generation_duration_avg_milliseconds", "Average LLM generation time today (UTC).", func(m *models.SystemMetrics) float64 { return m.AvgGenerationTimeMs }),
+			gauge("llm_tokens_today", "LLM prompt and output tokens used today (UTC).", func(m *models.SystemMetrics) float64 { return float64(m.LLMTokensUsedToday) }),
+			gauge("github_api_calls_today", "GitHub API calls made by this process today (UTC).", func(m *models.SystemMetrics) float64 { return float64(m.GitHubAPICallsToday) }),
+			gauge("generation_error_rate_1h_percent", "Share of generations that failed in the last hour.", func(m *models.SystemMetrics) float64 { return m.ErrorRate1hPct }),
+		},
+	}
+}
+
+func (c *systemMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, g := range c.gauges {
+		ch <- g.desc
+	}
+}
+
+// Collect reports nothing when the snapshot fails, so the scrape still
+// succeeds and the gauges go absent rather than zero.
+func (c *systemMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsCollectTimeout)
+	defer cancel()
+
+	snapshot, err := c.metricsService.SystemMetrics(ctx)
+	if err != nil {
+		slog.Warn("Failed to collect system metrics", "error", err)
+		return
+	}
+	for _, g := range c.gauges {
+		ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, g.value(snapshot))
+	}
+}
+
+// NewPrometheusHandler serves the system metrics in the Prometheus
+// exposition format.
+func NewPrometheusHandler(metricsService *services.MetricsService) echo.HandlerFunc {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newSystemMetricsCollector(metricsService))
+	return echo.WrapHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+}
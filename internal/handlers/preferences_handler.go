@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+type PreferencesHandler struct {
+	preferencesService *services.PreferencesService
+}
+
+func NewPreferencesHandler(preferencesService *services.PreferencesService) *PreferencesHandler {
+	return &PreferencesHandler{preferencesService: preferencesService}
+}
+
+func (h *PreferencesHandler) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	prefs, err := h.preferencesService.GetPreferences(ctx, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load preferences")
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
+
+func (h *PreferencesHandler) UpdateSchedule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req struct {
+		RegenerationSchedule string `json:"regeneration_schedule"`
+		AutoDeploy           bool   `json:"auto_deploy"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if req.RegenerationSchedule != "" {
+		if err := services.ValidateSchedule(req.RegenerationSchedule); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	if err := h.preferencesService.UpdateSchedule(ctx, userID, req.RegenerationSchedule, req.AutoDeploy); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update schedule")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Schedule updated successfully",
+	})
+}
+
+func (h *PreferencesHandler) UpdateTemplateVariables(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req struct {
+		TemplateVariables map[string]string `json:"template_variables"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if req.TemplateVariables == nil {
+		req.TemplateVariables = map[string]string{}
+	}
+
+	if err := services.ValidateTemplateVariables(req.TemplateVariables); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.preferencesService.UpdateTemplateVariables(ctx, userID, req.TemplateVariables); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update template variables")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Template variables updated successfully",
+	})
+}
+
+// UpdateShowcase sets whether the user's deployed profile is listed in the
+// public showcase.
+func (h *PreferencesHandler) UpdateShowcase(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req struct {
+		ShowcaseOptIn bool `json:"showcase_opt_in"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.preferencesService.UpdateShowcaseOptIn(ctx, userID, req.ShowcaseOptIn); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update showcase opt-in")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":         "Showcase opt-in updated successfully",
+		"showcase_opt_in": req.ShowcaseOptIn,
+	})
+}
+
+// UpdateTypingSVG patches the typing SVG configuration: fields present in the
+// body replace the stored values, and zero or empty values reset a field to
+// its default.
+func (h *PreferencesHandler) UpdateTypingSVG(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	prefs, err := h.preferencesService.GetPreferences(ctx, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load preferences")
+	}
+
+	cfg := prefs.TypingSVG
+	if err := json.NewDecoder(c.Request().Body).Decode(&cfg); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := services.ValidateTypingSVGConfig(cfg); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.preferencesService.UpdateTypingSVG(ctx, userID, cfg); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update typing SVG config")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":    "Typing SVG config updated successfully",
+		"typing_svg": cfg,
+	})
+}
+
+// UpdateLayout patches the section layout: groups present in the body
+// replace the stored ones. Sections not listed in any stored group keep
+// their default group.
+func (h *PreferencesHandler) UpdateLayout(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := c.Get("user_id").(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	prefs, err := h.preferencesService.GetPreferences(ctx, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load preferences")
+	}
+
+	layout := prefs.Layout
+	if err := json.NewDecoder(c.Request().Body).Decode(&layout); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := services.ValidateProfileLayout(layout); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.preferencesService.UpdateLayout(ctx, userID, layout); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update layout")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Layout updated successfully",
+		"layout":  layout,
+	})
+}
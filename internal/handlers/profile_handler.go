@@ -1,19 +1,52 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/krauzx/gitright/internal/apierr"
+	"github.com/krauzx/gitright/internal/fixtures"
 	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/preview"
+	"github.com/krauzx/gitright/internal/repository"
 	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/validators"
+	"github.com/krauzx/gitright/pkg/logger"
 	"github.com/labstack/echo/v4"
 )
 
+// autoSelectCount is how many recommended repositories are analyzed when a
+// generation request arrives without projects.
+const autoSelectCount = 5
+
+// Bounds for the ?width= of PreviewImage, in CSS pixels.
+const (
+	defaultPreviewWidth = 800
+	minPreviewWidth     = 320
+	maxPreviewWidth     = 1600
+)
+
 type ProfileHandler struct {
-	profileService *services.ProfileService
+	profileService  *services.ProfileService
+	githubService   *services.GitHubService
+	wizardService   *services.WizardService
+	previewRenderer *preview.Renderer
+	validate        *validator.Validate
 }
 
-func NewProfileHandler(profileService *services.ProfileService) *ProfileHandler {
-	return &ProfileHandler{profileService: profileService}
+func NewProfileHandler(profileService *services.ProfileService, githubService *services.GitHubService, wizardService *services.WizardService, previewRenderer *preview.Renderer, validate *validator.Validate) *ProfileHandler {
+	return &ProfileHandler{
+		profileService:  profileService,
+		githubService:   githubService,
+		wizardService:   wizardService,
+		previewRenderer: previewRenderer,
+		validate:        validate,
+	}
 }
 
 func (h *ProfileHandler) Generate(c echo.Context) error {
@@ -28,23 +61,237 @@ func (h *ProfileHandler) Generate(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
+	if err := validators.NormalizeSocialLinks(&req.ContactPrefs); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ValidateCustomSections(req.CustomSections); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ResolveLanguage(&req, c.Request().Header.Get("Accept-Language")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
+	if len(req.Projects) == 0 && !(req.PinToCache && req.DryRun) {
+		projects, err := h.autoSelectProjects(ctx, user)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to select projects")
+		}
+		req.Projects = projects
+	}
+	// Validated after auto-selection, which fills in missing projects
+	if err := h.validate.StructCtx(ctx, &req); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx, lookup := repository.WithCacheLookup(ctx)
 	response, err := h.profileService.GenerateProfile(ctx, &req, user)
+	setCacheHeaders(c, lookup)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return generationError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
-func (h *ProfileHandler) Deploy(c echo.Context) error {
+// validationError responds 400 with every field that failed h.validate.
+func validationError(c echo.Context, err error) error {
+	fields := validators.FieldErrors(err)
+	if fields == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusBadRequest, apierr.ValidationFailed(fields))
+}
+
+// generationError converts a GenerateProfile error into a response. A pinned
+// cache miss and a cooldown get machine-readable codes so clients can tell
+// them apart.
+func generationError(c echo.Context, err error) error {
+	var cooldown *services.CooldownError
+	if errors.As(err, &cooldown) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(cooldown.RetryAfterSeconds()))
+		return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":                "cooldown",
+				"message":             "Profile generated too recently; try again later",
+				"retry_after_seconds": cooldown.RetryAfterSeconds(),
+			},
+		})
+	}
+	if errors.Is(err, services.ErrPinnedCacheMiss) {
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error": map[string]string{
+				"code":    "cache_miss_pinned",
+				"message": "No cached profile matches this pinned request; generate it without pin_to_cache first",
+			},
+		})
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+// Estimate returns the expected token usage, cost and duration of a Generate
+// call with the same body. No API key is needed.
+func (h *ProfileHandler) Estimate(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req models.ContentGenerationRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if len(req.Projects) == 0 {
+		projects, err := h.autoSelectProjects(ctx, user)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to select projects")
+		}
+		req.Projects = projects
+	}
+
+	estimate, err := h.profileService.EstimateProfile(ctx, &req, user)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, estimate)
+}
+
+const maxSearchQueryLength = 200
+
+// Search finds earlier generated profiles mentioning ?q=, e.g. a technology.
+func (h *ProfileHandler) Search(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	accessToken, ok := c.Get("access_token").(string)
+	userID, ok := c.Get("user_id").(int64)
 	if !ok {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 	}
 
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" || len(query) > maxSearchQueryLength {
+		return echo.NewHTTPError(http.StatusBadRequest, "q must be between 1 and 200 characters")
+	}
+
+	limit := 10
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 50 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 50")
+		}
+		limit = n
+	}
+
+	profiles, err := h.profileService.SearchHistory(ctx, userID, query, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to search profiles")
+	}
+
+	results := make([]map[string]interface{}, 0, len(profiles))
+	for _, p := range profiles {
+		results = append(results, map[string]interface{}{
+			"id":          p.ID,
+			"version":     p.Version,
+			"target_role": p.TargetRole,
+			"deployed":    p.Deployed,
+			"created_at":  p.CreatedAt,
+			"excerpt":     p.Excerpt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+const maxShowcaseLanguageLength = 50
+
+// Showcase lists deployed profiles of users who opted in to the public
+// showcase. It needs no authentication.
+func (h *ProfileHandler) Showcase(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	sortBy := c.QueryParam("sort")
+	if sortBy == "" {
+		sortBy = models.ShowcaseSortRecent
+	}
+	if sortBy != models.ShowcaseSortRecent && sortBy != models.ShowcaseSortPopular {
+		return echo.NewHTTPError(http.StatusBadRequest, "sort must be recent or popular")
+	}
+
+	page := 1
+	if v := c.QueryParam("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			return echo.NewHTTPError(http.StatusBadRequest, "page must be between 1 and 100")
+		}
+		page = n
+	}
+
+	perPage := 20
+	if v := c.QueryParam("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 50 {
+			return echo.NewHTTPError(http.StatusBadRequest, "per_page must be between 1 and 50")
+		}
+		perPage = n
+	}
+
+	language := strings.TrimSpace(c.QueryParam("language"))
+	if len(language) > maxShowcaseLanguageLength {
+		return echo.NewHTTPError(http.StatusBadRequest, "language must be at most 50 characters")
+	}
+
+	result, err := h.profileService.ListShowcase(ctx, sortBy, language, c.QueryParam("cursor"), page, perPage)
+	if errors.Is(err, services.ErrInvalidShowcaseCursor) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load showcase")
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// autoSelectProjects analyzes the user's top recommended repositories.
+// Repositories that fail to analyze are skipped. Demo accounts get the
+// sample repositories.
+func (h *ProfileHandler) autoSelectProjects(ctx context.Context, user *models.User) ([]models.RepositoryAnalysis, error) {
+	if user.Source == models.UserSourceEmail {
+		projects, err := fixtures.SampleRepositories()
+		if err != nil {
+			return nil, err
+		}
+		return projects[:min(len(projects), autoSelectCount)], nil
+	}
+
+	repos, err := h.githubService.GetRecommendedRepositories(ctx, user.ID, user.AccessToken, autoSelectCount)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make([]models.RepositoryAnalysis, 0, len(repos))
+	for _, repo := range repos {
+		owner, name, ok := strings.Cut(repo.FullName, "/")
+		if !ok {
+			continue
+		}
+		analysis, err := h.githubService.AnalyzeRepository(ctx, user.ID, user.AccessToken, owner, name)
+		if err != nil {
+			logger.FromContext(ctx).Warn("Skipping auto-selected repository", "repo", repo.FullName, "error", err)
+			continue
+		}
+		projects = append(projects, *analysis)
+	}
+	return projects, nil
+}
+
+func (h *ProfileHandler) Deploy(c echo.Context) error {
+	ctx := c.Request().Context()
+
 	username, ok := c.Get("username").(string)
 	if !ok || username == "" {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
@@ -59,20 +306,184 @@ func (h *ProfileHandler) Deploy(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
+	if err := h.validate.StructCtx(ctx, &req); err != nil {
+		return validationError(c, err)
+	}
+	if err := validators.NormalizeSocialLinks(&req.ContactPrefs); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ValidateCustomSections(req.CustomSections); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ResolveLanguage(&req, c.Request().Header.Get("Accept-Language")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
 	response, err := h.profileService.GenerateProfile(ctx, &req, user)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return generationError(c, err)
 	}
 
-	if err := h.profileService.DeployProfile(ctx, accessToken, username, response.Markdown); err != nil {
+	if req.PinToCache && req.DryRun {
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "Dry run: profile not deployed",
+			"url":     "https://github.com/" + username,
+		})
+	}
+
+	targetPath := services.TargetFilePath(req.TargetFilePath)
+	deployed, err := h.profileService.DeployProfile(ctx, user, response.Markdown, targetPath)
+	if err != nil {
+		var contentErr *validators.MarkdownContentError
+		if errors.As(err, &contentErr) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Profile deployed successfully",
-		"url":     "https://github.com/" + username,
-	})
+	result := map[string]interface{}{
+		"message":         "Profile deployed successfully",
+		"url":             "https://github.com/" + username + "/" + username + "/blob/main/" + targetPath,
+		"content_changed": deployed.ContentChanged,
+	}
+	if !deployed.ContentChanged {
+		result["message"] = "Profile already up to date"
+		result["already_up_to_date"] = true
+	}
+	if len(deployed.StructureWarnings) > 0 {
+		result["structure_warnings"] = deployed.StructureWarnings
+	}
+	if deployed.RepoCreated {
+		result["repo_created"] = true
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// PreviewDeploy diffs the submitted markdown against the current content of
+// the target file, the profile README by default. Nothing is written to
+// GitHub.
+func (h *ProfileHandler) PreviewDeploy(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req struct {
+		Markdown string `json:"markdown"`
+		// TargetFilePath is the file the deploy would write; empty means
+		// models.DefaultTargetFilePath.
+		TargetFilePath string `json:"target_file_path,omitempty" validate:"omitempty,profile_path"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if strings.TrimSpace(req.Markdown) == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "markdown is required")
+	}
+	if err := h.validate.StructCtx(ctx, &req); err != nil {
+		return validationError(c, err)
+	}
+
+	targetPath := services.TargetFilePath(req.TargetFilePath)
+	preview, err := h.githubService.PreviewDeploy(ctx, user.AccessToken, user.Username, targetPath, req.Markdown)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "Failed to fetch current README")
+	}
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// PreviewImage renders the user's latest generated profile as a PNG, as it
+// would appear on GitHub.
+func (h *ProfileHandler) PreviewImage(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if format := c.QueryParam("format"); format != "" && format != "png" {
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be png")
+	}
+
+	width := defaultPreviewWidth
+	if w := c.QueryParam("width"); w != "" {
+		n, err := strconv.Atoi(w)
+		if err != nil || n < minPreviewWidth || n > maxPreviewWidth {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("width must be between %d and %d", minPreviewWidth, maxPreviewWidth))
+		}
+		width = n
+	}
+
+	if !h.previewRenderer.Available() {
+		return echo.NewHTTPError(http.StatusNotImplemented, "Image previews are not available on this server")
+	}
+
+	profile, cacheKey, err := h.profileService.LatestProfile(ctx, user)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load profile")
+	}
+	if profile == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "No generated profile to preview")
+	}
+
+	png, err := h.previewRenderer.RenderPNG(ctx, cacheKey, profile.Markdown, width)
+	if errors.Is(err, preview.ErrUnavailable) {
+		return echo.NewHTTPError(http.StatusNotImplemented, "Image previews are not available on this server")
+	}
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to render profile preview", "username", user.Username, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to render preview")
+	}
+
+	return c.Blob(http.StatusOK, "image/png", png)
+}
+
+// ExportBundle serves the latest generated profile as a ZIP of README.md and
+// its downloaded badge and stats images.
+func (h *ProfileHandler) ExportBundle(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	bundle, err := h.profileService.ExportBundle(ctx, user)
+	if errors.Is(err, services.ErrNoProfile) {
+		return echo.NewHTTPError(http.StatusNotFound, "No generated profile to export")
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to export profile bundle", "username", user.Username, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to export profile")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", user.Username+"-profile.zip"))
+	return c.Blob(http.StatusOK, "application/zip", bundle)
+}
+
+// SEOMetadata returns page metadata for the latest generated profile.
+func (h *ProfileHandler) SEOMetadata(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	meta, err := h.profileService.LatestSEOMetadata(ctx, user)
+	if errors.Is(err, services.ErrNoProfile) {
+		return echo.NewHTTPError(http.StatusNotFound, "No generated profile")
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to load SEO metadata", "username", user.Username, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load SEO metadata")
+	}
+
+	return c.JSON(http.StatusOK, meta)
 }
 
 func (h *ProfileHandler) Preview(c echo.Context) error {
@@ -87,14 +498,26 @@ func (h *ProfileHandler) Preview(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
+	if err := validators.NormalizeSocialLinks(&req.ContactPrefs); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ValidateCustomSections(req.CustomSections); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ResolveLanguage(&req, c.Request().Header.Get("Accept-Language")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
 	response, err := h.profileService.GenerateProfile(ctx, &req, user)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return generationError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"markdown": response.Markdown,
-		"preview":  true,
+		"markdown":               response.Markdown,
+		"confidence":             response.Confidence,
+		"confidence_level":       response.ConfidenceLevel,
+		"confidence_explanation": response.ConfidenceExplanation,
+		"preview":                true,
 	})
 }
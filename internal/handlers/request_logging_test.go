@@ -0,0 +1,167 @@
+package handlers_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	gogithub "github.com/google/go-github/v60/github"
+	"github.com/krauzx/gitright/internal/config"
+	"github.com/krauzx/gitright/internal/github"
+	"github.com/krauzx/gitright/internal/handlers"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/testutil"
+	"github.com/krauzx/gitright/internal/validators"
+	"github.com/krauzx/gitright/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// downDB is a database that can't be reached, so every repository call
+// fails and the services log and carry on.
+type downDB struct{}
+
+func (downDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, errors.New("database down")
+}
+func (downDB) Driver() driver.Driver { return nil }
+
+// redirectTransport sends every request to target instead of api.github.com.
+type redirectTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return t.next.RoundTrip(req)
+}
+
+// newGitHubAPI serves octocat/app, whose src directory can't be listed, and
+// 404s for everything else, octocat/gone included.
+func newGitHubAPI(t *testing.T) *url.URL {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/octocat/app", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id": 1, "name": "app", "full_name": "octocat/app", "owner": map[string]string{"login": "octocat"},
+		})
+	})
+	mux.HandleFunc("GET /repos/octocat/app/languages", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]int{"Go": 1000})
+	})
+	mux.HandleFunc("GET /repos/octocat/app/contents/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octocat/app/contents/" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]string{
+			{"type": "file", "name": "go.mod", "path": "go.mod"},
+			{"type": "dir", "name": "src", "path": "src"},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+// Log lines written while handling a request carry its request ID in every
+// layer: here the profile handler skipping a repository, the GitHub service
+// failing to cache an analysis and the analyzer skipping a directory.
+func TestRequestIDPropagatesToEveryLayer(t *testing.T) {
+	var logs bytes.Buffer
+	prevLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(prevLogger) })
+
+	// The client keeps the transport it is built with
+	prevTransport := http.DefaultTransport
+	http.DefaultTransport = redirectTransport{target: newGitHubAPI(t), next: prevTransport}
+	client := github.NewClient(config.GitHubConfig{})
+	http.DefaultTransport = prevTransport
+
+	conn := sql.OpenDB(downDB{})
+	t.Cleanup(func() { conn.Close() })
+	fakeGitHub := testutil.NewFakeGitHub(
+		&gogithub.Repository{ID: gogithub.Int64(1), Name: gogithub.String("app"), FullName: gogithub.String("octocat/app")},
+		&gogithub.Repository{ID: gogithub.Int64(2), Name: gogithub.String("gone"), FullName: gogithub.String("octocat/gone")},
+	)
+	auditService := services.NewAuditService(repository.NewAuditRepository(conn))
+	githubService := services.NewGitHubService(fakeGitHub, github.NewAnalyzer(client, config.AnalysisConfig{MaxFileDepth: 1}),
+		repository.NewRepositoryCacheRepository(conn), auditService, config.AnalysisConfig{})
+	profileService := services.NewProfileService(
+		testutil.NewFakeLLM(),
+		repository.NewProjectRepository(conn),
+		githubService,
+		repository.NewProfileCacheRepository(conn),
+		repository.NewPreferencesRepository(conn),
+		nil,
+		auditService,
+		repository.NewCooldownRepository(conn),
+		repository.NewABTestRepository(conn),
+		repository.NewMetricsRepository(conn),
+		config.GenerationConfig{},
+		nil,
+	)
+	handler := handlers.NewProfileHandler(profileService, githubService, nil, nil, validators.New())
+
+	e := echo.New()
+	e.Use(middleware.RequestID(), logger.ContextMiddleware())
+	e.POST("/generate", handler.Generate, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", &models.User{ID: 1, Username: "octocat", AccessToken: "token"})
+			return next(c)
+		}
+	})
+
+	body := `{"target_role": "Backend Engineer", "tone_of_voice": "professional", "user_api_key": "AIza` + strings.Repeat("x", 35) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderXRequestID, "req-140")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]bool{
+		"Skipping auto-selected repository":   false, // handler
+		"Failed to cache repository analysis": false, // service
+		"Skipping unreadable directory":       false, // analyzer
+	}
+	scanner := bufio.NewScanner(&logs)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("failed to decode log line %q: %v", scanner.Text(), err)
+		}
+		if entry["request_id"] != "req-140" {
+			t.Errorf("log line without the request ID: %s", scanner.Text())
+		}
+		if msg, ok := entry["msg"].(string); ok {
+			if _, tracked := want[msg]; tracked {
+				want[msg] = true
+			}
+		}
+	}
+	for msg, seen := range want {
+		if !seen {
+			t.Errorf("no %q log line; got:\n%s", msg, logs.String())
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Package v2 holds handler variants for API version 2. Handlers here are only
+// registered for routes whose response shape differs from v1.
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/krauzx/gitright/internal/apierr"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/validators"
+	"github.com/labstack/echo/v4"
+)
+
+// ContentGenerationResponse replaces v1's monolithic markdown string with the
+// README split into sections.
+type ContentGenerationResponse struct {
+	Sections              []models.RenderedSection `json:"sections"`
+	ExtractedSkills       []string                 `json:"extracted_skills"`
+	SuggestedBadges       []models.Badge           `json:"suggested_badges"`
+	BadgesOmitted         int                      `json:"badges_omitted"`
+	OmittedRepositories   []string                 `json:"omitted_repositories,omitempty"`
+	Confidence            float64                  `json:"confidence"`
+	ConfidenceLevel       string                   `json:"confidence_level"`
+	ConfidenceExplanation string                   `json:"confidence_explanation"`
+	ReadabilityScore      *models.ReadabilityScore `json:"readability_score,omitempty"`
+	ReadabilityWarnings   []string                 `json:"readability_warnings,omitempty"`
+	StructureWarnings     []string                 `json:"structure_warnings,omitempty"`
+	VulnerabilityWarnings []string                 `json:"vulnerability_warnings,omitempty"`
+	AIGrounded            bool                     `json:"ai_grounded,omitempty"`
+	SEOMetadata           *models.SEOMetadata      `json:"seo_metadata,omitempty"`
+	Layout                *models.ProfileLayout    `json:"layout,omitempty"`
+}
+
+type ProfileHandlerV2 struct {
+	profileService *services.ProfileService
+	validate       *validator.Validate
+}
+
+func NewProfileHandlerV2(profileService *services.ProfileService, validate *validator.Validate) *ProfileHandlerV2 {
+	return &ProfileHandlerV2{profileService: profileService, validate: validate}
+}
+
+func (h *ProfileHandlerV2) Generate(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req models.ContentGenerationRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validators.NormalizeSocialLinks(&req.ContactPrefs); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ValidateCustomSections(req.CustomSections); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ResolveLanguage(&req, c.Request().Header.Get("Accept-Language")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validate.StructCtx(ctx, &req); err != nil {
+		fields := validators.FieldErrors(err)
+		if fields == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return c.JSON(http.StatusBadRequest, apierr.ValidationFailed(fields))
+	}
+
+	response, err := h.profileService.GenerateProfile(ctx, &req, user)
+	var cooldown *services.CooldownError
+	if errors.As(err, &cooldown) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(cooldown.RetryAfterSeconds()))
+		return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":                "cooldown",
+				"message":             "Profile generated too recently; try again later",
+				"retry_after_seconds": cooldown.RetryAfterSeconds(),
+			},
+		})
+	}
+	if errors.Is(err, services.ErrPinnedCacheMiss) {
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error": map[string]string{
+				"code":    "cache_miss_pinned",
+				"message": "No cached profile matches this pinned request; generate it without pin_to_cache first",
+			},
+		})
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, ContentGenerationResponse{
+		Sections:              services.SplitMarkdownSections(response.Markdown),
+		ExtractedSkills:       response.ExtractedSkills,
+		SuggestedBadges:       response.SuggestedBadges,
+		BadgesOmitted:         response.BadgesOmitted,
+		OmittedRepositories:   response.OmittedRepositories,
+		Confidence:            response.Confidence,
+		ConfidenceLevel:       response.ConfidenceLevel,
+		ConfidenceExplanation: response.ConfidenceExplanation,
+		ReadabilityScore:      response.ReadabilityScore,
+		ReadabilityWarnings:   response.ReadabilityWarnings,
+		StructureWarnings:     response.StructureWarnings,
+		VulnerabilityWarnings: response.VulnerabilityWarnings,
+		AIGrounded:            response.AIGrounded,
+		SEOMetadata:           response.SEOMetadata,
+		Layout:                response.Layout,
+	})
+}
@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/websocket"
+	"github.com/krauzx/gitright/internal/apierr"
+	"github.com/krauzx/gitright/internal/github"
+	"github.com/krauzx/gitright/internal/llm"
 	"github.com/krauzx/gitright/internal/models"
 	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/validators"
 	"github.com/labstack/echo/v4"
 )
 
@@ -17,14 +23,18 @@ type ProgressUpdate struct {
 	Progress float64 `json:"progress"`
 	Message  string  `json:"message"`
 	Error    string  `json:"error,omitempty"`
+	// Fields lists the invalid fields when the request failed validation
+	Fields []apierr.FieldError `json:"fields,omitempty"`
 }
 
 type WebSocketHandler struct {
 	profileService *services.ProfileService
+	githubService  *services.GitHubService
+	validate       *validator.Validate
 	upgrader       websocket.Upgrader
 }
 
-func NewWebSocketHandler(profileService *services.ProfileService, allowedOrigins []string) *WebSocketHandler {
+func NewWebSocketHandler(profileService *services.ProfileService, githubService *services.GitHubService, validate *validator.Validate, allowedOrigins []string) *WebSocketHandler {
 	originSet := make(map[string]struct{}, len(allowedOrigins))
 	for _, o := range allowedOrigins {
 		originSet[o] = struct{}{}
@@ -32,6 +42,8 @@ func NewWebSocketHandler(profileService *services.ProfileService, allowedOrigins
 
 	return &WebSocketHandler{
 		profileService: profileService,
+		githubService:  githubService,
+		validate:       validate,
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
@@ -66,8 +78,26 @@ func (h *WebSocketHandler) HandleProfileGeneration(c echo.Context) error {
 		h.sendError(ws, "Invalid request format")
 		return nil
 	}
+	if err := validators.NormalizeSocialLinks(&req.ContactPrefs); err != nil {
+		h.sendError(ws, err.Error())
+		return nil
+	}
+	if err := validators.ValidateCustomSections(req.CustomSections); err != nil {
+		h.sendError(ws, err.Error())
+		return nil
+	}
+	if err := validators.ResolveLanguage(&req, c.Request().Header.Get("Accept-Language")); err != nil {
+		h.sendError(ws, err.Error())
+		return nil
+	}
+
+	accessToken, _ := c.Get("access_token").(string)
 
 	ctx := c.Request().Context()
+	if err := h.validate.StructCtx(ctx, &req); err != nil {
+		h.sendValidationError(ws, err)
+		return nil
+	}
 
 	progressCh := make(chan ProgressUpdate, 10)
 	done := make(chan struct{})
@@ -90,7 +120,7 @@ func (h *WebSocketHandler) HandleProfileGeneration(c echo.Context) error {
 		}
 	}()
 
-	response, err := h.generateWithProgress(ctx, &req, user, progressCh)
+	response, err := h.generateWithProgress(ctx, &req, user, accessToken, progressCh)
 	close(progressCh)
 	<-done
 
@@ -115,6 +145,7 @@ func (h *WebSocketHandler) generateWithProgress(
 	ctx context.Context,
 	req *models.ContentGenerationRequest,
 	user *models.User,
+	accessToken string,
 	progressCh chan<- ProgressUpdate,
 ) (*models.ContentGenerationResponse, error) {
 	send := func(stage string, pct float64, msg string) {
@@ -126,8 +157,54 @@ func (h *WebSocketHandler) generateWithProgress(
 
 	send("init", 0.0, "Starting profile generation...")
 	send("analyzing", 0.1, fmt.Sprintf("Preparing %d project(s) for analysis...", len(req.Projects)))
+
+	// Projects sent without analysis data are analyzed here so the client
+	// sees per-step progress instead of a single long "analyzing" phase.
+	// Analysis occupies the 0.1–0.4 band of overall progress.
+	for i := range req.Projects {
+		// Pinned dry runs make no GitHub calls
+		if req.PinToCache && req.DryRun {
+			break
+		}
+		project := &req.Projects[i]
+		if project.Repository == nil || project.Languages != nil || project.Files != nil {
+			continue
+		}
+		parts := strings.SplitN(project.Repository.FullName, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if user.Source == models.UserSourceEmail {
+			analysis, err := demoAnalysis(parts[0], parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to analyze %s: repository not found", project.Repository.FullName)
+			}
+			*project = *analysis
+			continue
+		}
+
+		base := 0.1 + 0.3*float64(i)/float64(len(req.Projects))
+		span := 0.3 / float64(len(req.Projects))
+		analysis, err := h.githubService.AnalyzeRepositoryWithProgress(ctx, user.ID, accessToken, parts[0], parts[1],
+			func(step github.AnalysisStep, pct float64) {
+				send("analyzing", base+span*pct, fmt.Sprintf("%s: %s", project.Repository.FullName, step))
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze %s: %w", project.Repository.FullName, err)
+		}
+		if analysis.Repository == nil {
+			analysis.Repository = project.Repository
+		}
+		*project = *analysis
+	}
+
 	send("generating", 0.4, "Sending to AI — this may take up to 30 seconds...")
 
+	ctx = llm.WithRetryNotifier(ctx, func(attempt, total int) {
+		send("retrying", 0.4, fmt.Sprintf("LLM service busy, retrying (attempt %d/%d)...", attempt, total))
+	})
 	response, err := h.profileService.GenerateProfile(ctx, req, user)
 	if err != nil {
 		return nil, err
@@ -144,3 +221,16 @@ func (h *WebSocketHandler) sendError(ws *websocket.Conn, message string) {
 		slog.Error("Failed to send error via WebSocket", "error", err)
 	}
 }
+
+func (h *WebSocketHandler) sendValidationError(ws *websocket.Conn, err error) {
+	fields := validators.FieldErrors(err)
+	if fields == nil {
+		h.sendError(ws, err.Error())
+		return
+	}
+	const message = "Request validation failed"
+	update := ProgressUpdate{Stage: "error", Message: message, Error: message, Fields: fields}
+	if err := ws.WriteJSON(update); err != nil {
+		slog.Error("Failed to send error via WebSocket", "error", err)
+	}
+}
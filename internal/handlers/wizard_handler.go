@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/validators"
+	"github.com/labstack/echo/v4"
+)
+
+// CreateWizardSession starts a profile wizard session.
+func (h *ProfileHandler) CreateWizardSession(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	session, err := h.wizardService.CreateSession(c.Request().Context(), user)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create wizard session")
+	}
+
+	return c.JSON(http.StatusCreated, session)
+}
+
+// GetWizardSession returns the session's step and accumulated state.
+func (h *ProfileHandler) GetWizardSession(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	session, err := h.wizardService.GetSession(c.Request().Context(), user, c.Param("id"))
+	if err != nil {
+		return wizardError(err)
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+// UpdateWizardSession records the current step and merges its fields into
+// the session state.
+func (h *ProfileHandler) UpdateWizardSession(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req struct {
+		Step  int             `json:"step"`
+		State json.RawMessage `json:"state"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	session, err := h.wizardService.UpdateSession(c.Request().Context(), user, c.Param("id"), req.Step, req.State)
+	if err != nil {
+		return wizardError(err)
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+// GenerateFromWizard generates a profile from the session state, as Generate
+// would from the same request. The API key is sent here rather than stored.
+func (h *ProfileHandler) GenerateFromWizard(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := c.Get("user").(*models.User)
+	if !ok || user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var body struct {
+		UserAPIKey string `json:"user_api_key"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	req, err := h.wizardService.GenerationRequest(ctx, user, c.Param("id"))
+	if err != nil {
+		return wizardError(err)
+	}
+	req.UserAPIKey = body.UserAPIKey
+
+	if err := validators.NormalizeSocialLinks(&req.ContactPrefs); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ValidateCustomSections(req.CustomSections); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validators.ResolveLanguage(req, c.Request().Header.Get("Accept-Language")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if len(req.Projects) == 0 && !(req.PinToCache && req.DryRun) {
+		projects, err := h.autoSelectProjects(ctx, user)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to select projects")
+		}
+		req.Projects = projects
+	}
+
+	response, err := h.profileService.GenerateProfile(ctx, req, user)
+	if err != nil {
+		return generationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func wizardError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrWizardSessionNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "Wizard session not found")
+	case errors.Is(err, services.ErrInvalidWizardState):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load wizard session")
+	}
+}
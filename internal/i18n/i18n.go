@@ -0,0 +1,95 @@
+// Package i18n holds the translations of the static text in generated
+// profile READMEs.
+package i18n
+
+import (
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used when a request names no language.
+const DefaultLocale = "en"
+
+// Locales are the supported README languages, as base language subtags.
+var Locales = []string{"en", "es", "fr", "de", "pt", "ja"}
+
+// localeNames are the English names given to the LLM so the prose matches
+// the headings.
+var localeNames = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"pt": "Portuguese",
+	"ja": "Japanese",
+}
+
+var matcher = language.NewMatcher(localeTags())
+
+func localeTags() []language.Tag {
+	tags := make([]language.Tag, len(Locales))
+	for i, l := range Locales {
+		tags[i] = language.MustParse(l)
+	}
+	return tags
+}
+
+// Match returns the supported locale closest to the preferences in an
+// Accept-Language header, or "" when the header is missing, malformed or
+// names nothing close to a supported locale.
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	_, i, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return ""
+	}
+	return Locales[i]
+}
+
+// Supported reports whether locale is one of Locales.
+func Supported(locale string) bool {
+	_, ok := messages[locale]
+	return ok
+}
+
+// Normalize returns locale if it is supported and DefaultLocale otherwise.
+func Normalize(locale string) string {
+	if Supported(locale) {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Name returns the English name of a locale, e.g. "Spanish" for "es".
+func Name(locale string) string {
+	return localeNames[Normalize(locale)]
+}
+
+// T returns the message for key in locale, falling back to English. Some
+// messages are format strings; see the message keys.
+func T(locale, key string) string {
+	if msg, ok := messages[locale][key]; ok {
+		return msg
+	}
+	return messages[DefaultLocale][key]
+}
+
+// SectionID maps a README heading in any supported locale back to its
+// section ID, so sections keep the same ID whatever language they are
+// rendered in.
+func SectionID(heading string) (string, bool) {
+	id, ok := sectionHeadings[heading]
+	return id, ok
+}
+
+var sectionHeadings = func() map[string]string {
+	headings := make(map[string]string)
+	for _, msgs := range messages {
+		for _, id := range sectionIDs {
+			headings[msgs[id]] = id
+		}
+	}
+	return headings
+}()
@@ -0,0 +1,208 @@
+package i18n
+
+// Section heading keys. They double as section IDs, see SectionID.
+const (
+	AboutMe              = "about-me"
+	Connect              = "connect"
+	TechStack            = "tech-stack"
+	GitHubStats          = "github-stats"
+	FeaturedProjects     = "featured-projects"
+	ContributionActivity = "contribution-activity"
+)
+
+var sectionIDs = []string{AboutMe, Connect, TechStack, GitHubStats, FeaturedProjects, ContributionActivity}
+
+// Message keys for the rest of the README. Bullet messages take one %s,
+// the repository stat messages one %d.
+const (
+	GrowingAs          = "growing-as"
+	CurrentlyBuilding  = "currently-building"
+	DeepeningExpertise = "deepening-expertise"
+	AskMeAbout         = "ask-me-about"
+	ReachMeAt          = "reach-me-at"
+
+	// Tech Stack categories
+	Languages  = "languages"
+	Frameworks = "frameworks"
+	Databases  = "databases"
+	DataML     = "data-ml"
+	Tools      = "tools"
+
+	Tech         = "tech"
+	Stars        = "stars"
+	Forks        = "forks"
+	Commits      = "commits"
+	Contributors = "contributors"
+
+	OpenIssues     = "open-issues"
+	ResolvedIssues = "resolved-issues"
+
+	CoAuthoredWith = "co-authored-with" // takes the comma-separated names
+
+	GeneratedWith = "generated-with"
+)
+
+var messages = map[string]map[string]string{
+	"en": {
+		AboutMe:              "👨‍💻 About Me",
+		Connect:              "🌐 Connect",
+		TechStack:            "🛠️ Tech Stack",
+		GitHubStats:          "📊 GitHub Stats",
+		FeaturedProjects:     "🚀 Featured Projects",
+		ContributionActivity: "📈 Contribution Activity",
+		GrowingAs:            "🎯 Growing as a **%s**",
+		CurrentlyBuilding:    "🔭 Currently building **%s**",
+		DeepeningExpertise:   "🌱 Deepening expertise in **%s**",
+		AskMeAbout:           "💬 Ask me about **%s**",
+		ReachMeAt:            "📫 Reach me at **%s**",
+		Languages:            "Languages",
+		Frameworks:           "Frameworks & Libraries",
+		Databases:            "Databases",
+		DataML:               "Data & ML",
+		Tools:                "Tools & Platforms",
+		Tech:                 "Tech:",
+		Stars:                "⭐ %d stars",
+		Forks:                "🍴 %d forks",
+		Commits:              "📝 %d commits",
+		Contributors:         "👥 %d contributors",
+		OpenIssues:           "🔴 %d open issues",
+		ResolvedIssues:       "✅ %d resolved",
+		CoAuthoredWith:       "🤝 Co-authored with %s",
+		GeneratedWith:        "Generated with",
+	},
+	"es": {
+		AboutMe:              "👨‍💻 Sobre mí",
+		Connect:              "🌐 Contacto",
+		TechStack:            "🛠️ Tecnologías",
+		GitHubStats:          "📊 Estadísticas de GitHub",
+		FeaturedProjects:     "🚀 Proyectos destacados",
+		ContributionActivity: "📈 Actividad de contribuciones",
+		GrowingAs:            "🎯 Creciendo como **%s**",
+		CurrentlyBuilding:    "🔭 Actualmente desarrollando **%s**",
+		DeepeningExpertise:   "🌱 Profundizando en **%s**",
+		AskMeAbout:           "💬 Pregúntame sobre **%s**",
+		ReachMeAt:            "📫 Escríbeme a **%s**",
+		Languages:            "Lenguajes",
+		Frameworks:           "Frameworks y librerías",
+		Databases:            "Bases de datos",
+		DataML:               "Datos y ML",
+		Tools:                "Herramientas y plataformas",
+		Tech:                 "Tecnologías:",
+		Stars:                "⭐ %d estrellas",
+		Forks:                "🍴 %d forks",
+		Commits:              "📝 %d commits",
+		Contributors:         "👥 %d colaboradores",
+		OpenIssues:           "🔴 %d issues abiertos",
+		ResolvedIssues:       "✅ %d resueltos",
+		CoAuthoredWith:       "🤝 En coautoría con %s",
+		GeneratedWith:        "Generado con",
+	},
+	"fr": {
+		AboutMe:              "👨‍💻 À propos de moi",
+		Connect:              "🌐 Me contacter",
+		TechStack:            "🛠️ Stack technique",
+		GitHubStats:          "📊 Statistiques GitHub",
+		FeaturedProjects:     "🚀 Projets phares",
+		ContributionActivity: "📈 Activité de contribution",
+		GrowingAs:            "🎯 En progression comme **%s**",
+		CurrentlyBuilding:    "🔭 Je développe actuellement **%s**",
+		DeepeningExpertise:   "🌱 J'approfondis **%s**",
+		AskMeAbout:           "💬 Parlez-moi de **%s**",
+		ReachMeAt:            "📫 Contactez-moi à **%s**",
+		Languages:            "Langages",
+		Frameworks:           "Frameworks et bibliothèques",
+		Databases:            "Bases de données",
+		DataML:               "Données et ML",
+		Tools:                "Outils et plateformes",
+		Tech:                 "Technos :",
+		Stars:                "⭐ %d étoiles",
+		Forks:                "🍴 %d forks",
+		Commits:              "📝 %d commits",
+		Contributors:         "👥 %d contributeurs",
+		OpenIssues:           "🔴 %d issues ouvertes",
+		ResolvedIssues:       "✅ %d résolues",
+		CoAuthoredWith:       "🤝 Co-écrit avec %s",
+		GeneratedWith:        "Généré avec",
+	},
+	"de": {
+		AboutMe:              "👨‍💻 Über mich",
+		Connect:              "🌐 Kontakt",
+		TechStack:            "🛠️ Tech-Stack",
+		GitHubStats:          "📊 GitHub-Statistiken",
+		FeaturedProjects:     "🚀 Ausgewählte Projekte",
+		ContributionActivity: "📈 Beitragsaktivität",
+		GrowingAs:            "🎯 Auf dem Weg als **%s**",
+		CurrentlyBuilding:    "🔭 Arbeite gerade an **%s**",
+		DeepeningExpertise:   "🌱 Vertiefe mein Wissen in **%s**",
+		AskMeAbout:           "💬 Frag mich zu **%s**",
+		ReachMeAt:            "📫 Erreichbar unter **%s**",
+		Languages:            "Sprachen",
+		Frameworks:           "Frameworks & Bibliotheken",
+		Databases:            "Datenbanken",
+		DataML:               "Daten & ML",
+		Tools:                "Tools & Plattformen",
+		Tech:                 "Technologien:",
+		Stars:                "⭐ %d Sterne",
+		Forks:                "🍴 %d Forks",
+		Commits:              "📝 %d Commits",
+		Contributors:         "👥 %d Mitwirkende",
+		OpenIssues:           "🔴 %d offene Issues",
+		ResolvedIssues:       "✅ %d gelöst",
+		CoAuthoredWith:       "🤝 Gemeinsam mit %s",
+		GeneratedWith:        "Erstellt mit",
+	},
+	"pt": {
+		AboutMe:              "👨‍💻 Sobre mim",
+		Connect:              "🌐 Contato",
+		TechStack:            "🛠️ Tecnologias",
+		GitHubStats:          "📊 Estatísticas do GitHub",
+		FeaturedProjects:     "🚀 Projetos em destaque",
+		ContributionActivity: "📈 Atividade de contribuições",
+		GrowingAs:            "🎯 Crescendo como **%s**",
+		CurrentlyBuilding:    "🔭 Atualmente desenvolvendo **%s**",
+		DeepeningExpertise:   "🌱 Aprofundando conhecimentos em **%s**",
+		AskMeAbout:           "💬 Pergunte-me sobre **%s**",
+		ReachMeAt:            "📫 Fale comigo em **%s**",
+		Languages:            "Linguagens",
+		Frameworks:           "Frameworks e bibliotecas",
+		Databases:            "Bancos de dados",
+		DataML:               "Dados e ML",
+		Tools:                "Ferramentas e plataformas",
+		Tech:                 "Tecnologias:",
+		Stars:                "⭐ %d estrelas",
+		Forks:                "🍴 %d forks",
+		Commits:              "📝 %d commits",
+		Contributors:         "👥 %d colaboradores",
+		OpenIssues:           "🔴 %d issues abertas",
+		ResolvedIssues:       "✅ %d resolvidas",
+		CoAuthoredWith:       "🤝 Em coautoria com %s",
+		GeneratedWith:        "Gerado com",
+	},
+	"ja": {
+		AboutMe:              "👨‍💻 自己紹介",
+		Connect:              "🌐 連絡先",
+		TechStack:            "🛠️ 技術スタック",
+		GitHubStats:          "📊 GitHub 統計",
+		FeaturedProjects:     "🚀 注目のプロジェクト",
+		ContributionActivity: "📈 コントリビューション",
+		GrowingAs:            "🎯 **%s** を目指して成長中",
+		CurrentlyBuilding:    "🔭 **%s** を開発中",
+		DeepeningExpertise:   "🌱 **%s** を深く学んでいます",
+		AskMeAbout:           "💬 **%s** について聞いてください",
+		ReachMeAt:            "📫 連絡先: **%s**",
+		Languages:            "言語",
+		Frameworks:           "フレームワーク・ライブラリ",
+		Databases:            "データベース",
+		DataML:               "データ・機械学習",
+		Tools:                "ツール・プラットフォーム",
+		Tech:                 "技術:",
+		Stars:                "⭐ スター %d",
+		Forks:                "🍴 フォーク %d",
+		Commits:              "📝 コミット %d",
+		Contributors:         "👥 コントリビューター %d",
+		OpenIssues:           "🔴 未解決の Issue %d",
+		ResolvedIssues:       "✅ 解決済み %d",
+		CoAuthoredWith:       "🤝 共同作成者: %s",
+		GeneratedWith:        "作成:",
+	},
+}
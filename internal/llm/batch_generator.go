@@ -3,14 +3,26 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/krauzx/gitright/internal/models"
 )
 
+// highStarGrowthRate is the stars-per-day rate above which a project's recent
+// star growth is mentioned in the prompt.
+const highStarGrowthRate = 1.0
+
+// activeMaintenanceDays is the median issue response time below which a
+// project is described as actively maintained.
+const activeMaintenanceDays = 7.0
+
 type BatchProfileRequest struct {
 	Username         string
 	Bio              string
@@ -18,8 +30,11 @@ type BatchProfileRequest struct {
 	Company          string
 	TargetRole       string
 	ToneOfVoice      string
+	Language         string // English name, e.g. "Spanish"
 	EmphasizedSkills []string
 	Projects         []models.RepositoryAnalysis
+	Activity         *models.UserActivitySummary
+	Parameters       models.GenerationParameters
 }
 
 type BatchProfileResponse struct {
@@ -27,6 +42,9 @@ type BatchProfileResponse struct {
 	ProjectSummaries []ProjectSummaryData `json:"project_summaries"`
 	ExtractedSkills  []string             `json:"extracted_skills"`
 	Confidence       float64              `json:"confidence"`
+	// GroundingChunks are the web sources Gemini cited when grounding was
+	// used; they come from response metadata, not the model's JSON.
+	GroundingChunks []string `json:"-"`
 }
 
 type ProjectSummaryData struct {
@@ -44,39 +62,56 @@ func (cg *ContentGenerator) GenerateBatchedProfile(ctx context.Context, apiKey s
 	systemInstruction := buildBatchedSystemInstruction(req)
 	userPrompt := buildBatchedUserPrompt(req)
 
-	responseText, err := tempClient.GenerateStructuredContent(ctx, systemInstruction, userPrompt)
+	var responseText string
+	var grounding *GroundingResult
+	err = withLLMRetry(ctx, cg.client.config.MaxRetries, fullJitterBackoff, func() error {
+		var err error
+		responseText, grounding, err = tempClient.GenerateStructuredContent(ctx, systemInstruction, userPrompt, req.Parameters)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("generation failed: %w", err)
+		if isAuthError(err) {
+			cg.pool.remove(apiKey)
+		}
+		return nil, err
 	}
 
 	// Extract JSON from response (handles markdown blocks and extra text)
 	jsonStr := extractJSON(responseText)
 	if jsonStr == "" {
 		slog.Error("No JSON found in response", "response", responseText)
-		return nil, fmt.Errorf("invalid response: no JSON content found")
+		return nil, invalidResponse(errors.New("no JSON content found"), responseText)
 	}
 
-	var response BatchProfileResponse
-	if err := json.Unmarshal([]byte(jsonStr), &response); err != nil {
-		slog.Error("Failed to parse JSON", "error", err, "json", jsonStr[:min(500, len(jsonStr))])
-		return nil, fmt.Errorf("invalid response format: %w", err)
+	response, err := parseBatchResponse(jsonStr)
+	if err != nil {
+		slog.Error("LLM response failed schema validation", "error", err)
+		return nil, err
 	}
 
-	// Validate response
-	if response.ProfilePitch == "" {
-		return nil, fmt.Errorf("missing profile_pitch in response")
-	}
-	if len(response.ProjectSummaries) == 0 {
-		return nil, fmt.Errorf("missing project_summaries in response")
+	if grounding != nil {
+		response.GroundingChunks = grounding.GroundingChunks
 	}
 
+	return response, nil
+}
+
+// parseBatchResponse validates the model's JSON against the response schema
+// and decodes it. Either failure wraps ErrInvalidLLMResponse.
+func parseBatchResponse(raw string) (*BatchProfileResponse, error) {
+	if err := validateBatchResponse(raw); err != nil {
+		return nil, err
+	}
+	var response BatchProfileResponse
+	if err := json.Unmarshal([]byte(raw), &response); err != nil {
+		return nil, invalidResponse(err, raw)
+	}
 	return &response, nil
 }
 
+// createClientWithAPIKey returns a pooled client for the user's API key.
 func (cg *ContentGenerator) createClientWithAPIKey(apiKey string) (*GeminiClient, error) {
-	config := cg.client.config
-	config.APIKey = apiKey
-	return NewGeminiClient(config)
+	return cg.pool.get(cg.client.config, apiKey)
 }
 
 //  creates comprehensive system instruction for batch generation
@@ -87,6 +122,9 @@ func buildBatchedSystemInstruction(req BatchProfileRequest) string {
 
 	sb.WriteString(fmt.Sprintf("TARGET ROLE: %s\n", req.TargetRole))
 	sb.WriteString(fmt.Sprintf("TONE: %s\n", req.ToneOfVoice))
+	if req.Language != "" && req.Language != "English" {
+		sb.WriteString(fmt.Sprintf("LANGUAGE: write profile_pitch and every summary in %s; keep project names and technology names as they are\n", req.Language))
+	}
 
 	if len(req.EmphasizedSkills) > 0 {
 		sb.WriteString(fmt.Sprintf("EMPHASIZE SKILLS: %s\n", strings.Join(req.EmphasizedSkills, ", ")))
@@ -135,6 +173,18 @@ func buildBatchedUserPrompt(req BatchProfileRequest) string {
 	}
 	sb.WriteString("\n")
 
+	if req.Activity != nil {
+		sb.WriteString("=== GITHUB ACTIVITY SUMMARY ===\n")
+		sb.WriteString(fmt.Sprintf("Public contributions (recent): %d\n", req.Activity.PublicContributions))
+		sb.WriteString(fmt.Sprintf("Pull requests merged: %d\n", req.Activity.PullRequestsMerged))
+		sb.WriteString(fmt.Sprintf("Issues closed: %d\n", req.Activity.IssuesClosed))
+		sb.WriteString(fmt.Sprintf("Repositories starred: %d\n", req.Activity.PublicReposStarred))
+		if len(req.Activity.RecentActiveRepos) > 0 {
+			sb.WriteString(fmt.Sprintf("Active in the last 30 days: %s\n", strings.Join(req.Activity.RecentActiveRepos, ", ")))
+		}
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString(fmt.Sprintf("=== %d PROJECTS TO ANALYZE ===\n\n", len(req.Projects)))
 
 	for i, project := range req.Projects {
@@ -158,10 +208,15 @@ func buildBatchedUserPrompt(req BatchProfileRequest) string {
 			sb.WriteString("\n")
 		}
 
+		if m, ok := detectLanguageMigration(project.LanguageHistory); ok {
+			sb.WriteString(fmt.Sprintf("Language migration: moved from %s to %s since %s (%s is now %.0f%% of the code)\n",
+				m.from, m.to, m.since.Format("January 2006"), m.to, m.share*100))
+		}
+
 		if len(project.Dependencies) > 0 {
 			sb.WriteString("Dependencies:\n")
 			for ecosystem, deps := range project.Dependencies {
-				if len(deps) > 0 {
+				if len(deps) > 0 && ecosystem != "go-indirect" {
 					// Limit to first 5 deps per ecosystem to avoid token bloat
 					depsSlice := deps
 					if len(deps) > 5 {
@@ -176,6 +231,74 @@ func buildBatchedUserPrompt(req BatchProfileRequest) string {
 			sb.WriteString(fmt.Sprintf("Topics: %s\n", strings.Join(project.Repository.Topics, ", ")))
 		}
 
+		if cc := project.CommitConvention; cc != nil {
+			uses := "no"
+			if cc.UsesConventionalCommits {
+				uses = "yes"
+			}
+			sb.WriteString(fmt.Sprintf("Uses Conventional Commits: %s (%.0f%% conformance)\n", uses, cc.ConformanceRate*100))
+		}
+
+		if cf := project.CommitFrequency; cf != nil && cf.Cadence == models.CadenceDaily {
+			sb.WriteString(fmt.Sprintf("Commit cadence: I commit daily (%d commits in the last 4 weeks)\n", cf.OwnerCommitsLast4Weeks))
+		}
+
+		if project.StarGrowthRate >= highStarGrowthRate {
+			sb.WriteString(fmt.Sprintf("Star growth: gained %d stars in the last month\n", int(math.Round(project.StarGrowthRate*30))))
+		}
+
+		if project.Gist != nil {
+			writeGistFiles(&sb, project.Gist)
+		}
+
+		if ts := project.TypeScriptConfig; ts != nil && (ts.Strict || ts.Target != "") {
+			sb.WriteString(describeTypeScriptConfig(ts) + "\n")
+		}
+
+		if st := project.Stats; st != nil && st.IssuesSampled > 0 && st.AvgIssueResponseDays < activeMaintenanceDays {
+			sb.WriteString(fmt.Sprintf("Maintenance: average issue response time: %.1f days\n", st.AvgIssueResponseDays))
+		}
+		if is := project.IssueStats; is != nil && is.MergedPRs > is.ClosedIssues {
+			sb.WriteString(fmt.Sprintf("Maintenance: high pull request merge rate (%d merged pull requests, %d closed issues)\n", is.MergedPRs, is.ClosedIssues))
+		}
+
+		if project.CompilesToWASM {
+			sb.WriteString("Build target: compiles to WebAssembly\n")
+		}
+
+		if project.UsesNix {
+			sb.WriteString("DevOps: uses Nix for reproducible development environment\n")
+		}
+
+		if tools := iacToolNames(project.IaCTools); len(tools) > 0 {
+			sb.WriteString("Infrastructure: " + strings.Join(tools, " + ") + "\n")
+		}
+
+		if tools := dataToolNames(project.DataTools); len(tools) > 0 {
+			sb.WriteString("Data pipeline: " + strings.Join(tools, " + ") + "\n")
+		}
+
+		if project.HasMakefile {
+			sb.WriteString("Tooling: automates " + strings.Join(project.MakeTargets, "/") + " via Makefile\n")
+		}
+
+		if project.DevContainer != nil {
+			sb.WriteString("Developer experience: includes a dev container for one-click development\n")
+		}
+
+		if platforms := goPlatforms(project.GoBuildTags); len(platforms) > 0 {
+			sb.WriteString("Platforms: supports " + strings.Join(platforms, ", ") + "\n")
+		}
+
+		if n := len(project.GoWorkspaceModules); n > 0 {
+			sb.WriteString(fmt.Sprintf("Multi-module Go workspace with %d local modules\n", n))
+		}
+
+		if len(project.GoReplaceDirectives) > 0 {
+			sb.WriteString(fmt.Sprintf("Go replace directives (local paths suggest a multi-module workspace): %s\n",
+				strings.Join(project.GoReplaceDirectives, "; ")))
+		}
+
 		sb.WriteString("\n")
 	}
 
@@ -205,3 +328,148 @@ func extractJSON(response string) string {
 
 	return ""
 }
+
+// goPlatformNames maps GOOS build tags to display names, in the order
+// platforms are listed in the prompt.
+var goPlatformNames = []struct{ tag, name string }{
+	{"windows", "Windows"},
+	{"linux", "Linux"},
+	{"darwin", "macOS"},
+	{"freebsd", "FreeBSD"},
+	{"openbsd", "OpenBSD"},
+	{"netbsd", "NetBSD"},
+	{"android", "Android"},
+	{"ios", "iOS"},
+	{"js", "the browser"},
+	{"wasip1", "WASI"},
+}
+
+var iacToolDisplayNames = map[string]string{
+	models.IaCTerraform:      "Terraform",
+	models.IaCAzureBicep:     "Bicep",
+	models.IaCCloudFormation: "CloudFormation",
+}
+
+// iacToolNames names the IaC tools for the prompt, e.g. ["Terraform", "Bicep"].
+func iacToolNames(tools []string) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if name, ok := iacToolDisplayNames[tool]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+var dataToolDisplayNames = map[string]string{
+	models.DataToolAirflow:           "Airflow",
+	models.DataToolDBT:               "dbt",
+	models.DataToolPrefect:           "Prefect",
+	models.DataToolDagster:           "Dagster",
+	models.DataToolGreatExpectations: "Great Expectations",
+}
+
+// dataToolNames names the data pipeline tools for the prompt, e.g.
+// ["Airflow", "dbt"].
+func dataToolNames(tools []string) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if name, ok := dataToolDisplayNames[tool]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// languageMigration is a change of a repository's main language.
+type languageMigration struct {
+	from, to string
+	since    time.Time
+	share    float64 // of the code in to, latest snapshot
+}
+
+// detectLanguageMigration compares the main language of the oldest and
+// latest snapshots, the one with the most bytes.
+func detectLanguageMigration(history []models.LanguageSnapshot) (languageMigration, bool) {
+	if len(history) < 2 {
+		return languageMigration{}, false
+	}
+	oldest, latest := history[0], history[len(history)-1]
+	from, _ := mainLanguage(oldest.Languages)
+	to, share := mainLanguage(latest.Languages)
+	if from == "" || to == "" || from == to {
+		return languageMigration{}, false
+	}
+	return languageMigration{from: from, to: to, since: oldest.Date, share: share}, true
+}
+
+// mainLanguage returns the language with the most bytes, ties broken by
+// name, and its share of all bytes.
+func mainLanguage(languages map[string]int) (string, float64) {
+	var main string
+	total := 0
+	for lang, bytes := range languages {
+		total += bytes
+		if main == "" || bytes > languages[main] || (bytes == languages[main] && lang < main) {
+			main = lang
+		}
+	}
+	if total == 0 {
+		return "", 0
+	}
+	return main, float64(languages[main]) / float64(total)
+}
+
+// goPlatforms names the operating systems among a repository's Go build
+// tags, e.g. ["Windows", "Linux", "macOS"].
+func goPlatforms(tags []string) []string {
+	var platforms []string
+	for _, p := range goPlatformNames {
+		if slices.Contains(tags, p.tag) {
+			platforms = append(platforms, p.name)
+		}
+	}
+	return platforms
+}
+
+// describeTypeScriptConfig renders compiler options as a short phrase, e.g.
+// "TypeScript strict mode, targeting ES2022".
+func describeTypeScriptConfig(ts *models.TypeScriptConfig) string {
+	parts := []string{}
+	if ts.Strict {
+		parts = append(parts, "TypeScript strict mode")
+	}
+	if ts.Target != "" {
+		parts = append(parts, "targeting "+ts.Target)
+	}
+	if ts.HasPathAliases {
+		parts = append(parts, "path aliases configured")
+	}
+	if ts.IsDeclarationFile {
+		parts = append(parts, "emits type declarations")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maxGistFileContent caps how much of each gist file is quoted in the prompt.
+const maxGistFileContent = 500
+
+// writeGistFiles quotes the gist's files, in name order, so the model can
+// describe what the snippet does.
+func writeGistFiles(sb *strings.Builder, gist *models.Gist) {
+	names := make([]string, 0, len(gist.Files))
+	for name := range gist.Files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	sb.WriteString("Type: GitHub Gist (code sample)\n")
+	for _, name := range names {
+		f := gist.Files[name]
+		content := f.Content
+		if len(content) > maxGistFileContent {
+			content = strings.ToValidUTF8(content[:maxGistFileContent], "") + "..."
+		}
+		sb.WriteString(fmt.Sprintf("Gist file %s (%s):\n%s\n", name, f.Language, content))
+	}
+}
@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseBatchResponse(t *testing.T) {
+	valid := `{"profile_pitch": "I build APIs.", "project_summaries": [{"project_name": "api", "summary": "An API.", "skills": ["Go"]}], "confidence": 0.9}`
+	got, err := parseBatchResponse(valid)
+	if err != nil {
+		t.Fatalf("parseBatchResponse(valid): %v", err)
+	}
+	if got.ProfilePitch != "I build APIs." || len(got.ProjectSummaries) != 1 || got.Confidence != 0.9 {
+		t.Errorf("parseBatchResponse(valid) = %+v", got)
+	}
+
+	for name, raw := range map[string]string{
+		"malformed":         `{"profile_pitch": "I build APIs.",`,
+		"wrong type":        `{"profile_pitch": 1, "project_summaries": [{"project_name": "api", "summary": "", "skills": []}], "confidence": 0.9}`,
+		"missing field":     `{"profile_pitch": "I build APIs.", "confidence": 0.9}`,
+		"out of range":      `{"profile_pitch": "I build APIs.", "project_summaries": [{"project_name": "api", "summary": "", "skills": []}], "confidence": 2}`,
+		"not an object":     `["profile_pitch"]`,
+		"skills not a list": `{"profile_pitch": "I build APIs.", "project_summaries": [{"project_name": "api", "summary": "", "skills": "Go"}], "confidence": 0.9}`,
+	} {
+		if _, err := parseBatchResponse(raw); !errors.Is(err, ErrInvalidLLMResponse) {
+			t.Errorf("%s: parseBatchResponse error = %v, want ErrInvalidLLMResponse", name, err)
+		}
+	}
+}
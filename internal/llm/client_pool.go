@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/krauzx/gitright/internal/config"
+	"google.golang.org/genai"
+)
+
+const maxPooledClients = 100
+
+// clientPool reuses GeminiClient instances across requests for the same user
+// API key so sequential generations don't pay for a new TLS connection each
+// time. Keys are stored as truncated SHA-256 digests, never in plain text.
+type clientPool struct {
+	entries sync.Map // poolKey -> *list.Element (value: *pooledClient)
+
+	mu  sync.Mutex
+	lru *list.List // front = most recently used
+}
+
+type pooledClient struct {
+	key    string
+	client *GeminiClient
+}
+
+func newClientPool() *clientPool {
+	return &clientPool{lru: list.New()}
+}
+
+func poolKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:16])
+}
+
+// get returns the pooled client for apiKey, creating and storing one on miss.
+// The least recently used entry is evicted once the pool is full.
+func (p *clientPool) get(cfg config.GoogleAIConfig, apiKey string) (*GeminiClient, error) {
+	key := poolKey(apiKey)
+
+	if v, ok := p.entries.Load(key); ok {
+		elem := v.(*list.Element)
+		p.mu.Lock()
+		p.lru.MoveToFront(elem)
+		p.mu.Unlock()
+		return elem.Value.(*pooledClient).client, nil
+	}
+
+	cfg.APIKey = apiKey
+	client, err := NewGeminiClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Another request may have populated the entry while we were connecting.
+	if v, ok := p.entries.Load(key); ok {
+		elem := v.(*list.Element)
+		p.lru.MoveToFront(elem)
+		return elem.Value.(*pooledClient).client, nil
+	}
+
+	elem := p.lru.PushFront(&pooledClient{key: key, client: client})
+	p.entries.Store(key, elem)
+
+	for p.lru.Len() > maxPooledClients {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		p.entries.Delete(oldest.Value.(*pooledClient).key)
+	}
+
+	return client, nil
+}
+
+// remove drops the pooled client for apiKey, e.g. after an auth failure.
+func (p *clientPool) remove(apiKey string) {
+	key := poolKey(apiKey)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if v, ok := p.entries.LoadAndDelete(key); ok {
+		p.lru.Remove(v.(*list.Element))
+	}
+}
+
+// isAuthError reports whether err indicates the API key was rejected.
+func isAuthError(err error) bool {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	case http.StatusBadRequest:
+		// Gemini reports invalid keys as 400 INVALID_ARGUMENT
+		return strings.Contains(strings.ToLower(apiErr.Message), "api key")
+	}
+	return false
+}
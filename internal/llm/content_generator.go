@@ -6,6 +6,7 @@ import (
 
 type ContentGenerator struct {
 	client *GeminiClient
+	pool   *clientPool
 }
 
 func NewContentGenerator(cfg config.GoogleAIConfig) (*ContentGenerator, error) {
@@ -13,5 +14,5 @@ func NewContentGenerator(cfg config.GoogleAIConfig) (*ContentGenerator, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &ContentGenerator{client: client}, nil
+	return &ContentGenerator{client: client, pool: newClientPool()}, nil
 }
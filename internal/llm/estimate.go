@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"log/slog"
+	"math"
+)
+
+// Gemini 2.5 Flash list prices in USD per million tokens
+// (https://ai.google.dev/gemini-api/docs/pricing).
+const (
+	inputPricePerMillionTokens  = 0.30
+	outputPricePerMillionTokens = 2.50
+)
+
+// Output size and latency heuristics for the batch profile prompt: a pitch
+// plus one short summary and skill list per project.
+const (
+	baseOutputTokens       = 600
+	outputTokensPerProject = 200
+	outputTokensPerSecond  = 150
+	requestOverheadSeconds = 3
+	charsPerToken          = 4
+)
+
+type CostEstimate struct {
+	InputTokens           int     `json:"input_tokens"`
+	EstimatedOutputTokens int     `json:"estimated_output_tokens"`
+	EstimatedCostUSD      float64 `json:"estimated_cost_usd"`
+	EstimatedTimeSeconds  int     `json:"estimated_time_seconds"`
+}
+
+// EstimateBatchedProfile builds the same prompt GenerateBatchedProfile would
+// send and prices it without generating anything. Tokens are counted with the
+// estimation key (or the server key); if counting fails the prompt length is
+// used instead, so an estimate is always returned.
+func (cg *ContentGenerator) EstimateBatchedProfile(ctx context.Context, req BatchProfileRequest) *CostEstimate {
+	prompt := buildBatchedSystemInstruction(req) + "\n" + buildBatchedUserPrompt(req)
+
+	inputTokens := len(prompt) / charsPerToken
+	if count, err := cg.estimationClient().CountTokens(ctx, prompt); err != nil {
+		slog.Warn("Falling back to approximate token count", "error", err)
+	} else {
+		inputTokens = int(count)
+	}
+
+	outputTokens := baseOutputTokens + outputTokensPerProject*len(req.Projects)
+	cost := float64(inputTokens)*inputPricePerMillionTokens/1e6 +
+		float64(outputTokens)*outputPricePerMillionTokens/1e6
+
+	return &CostEstimate{
+		InputTokens:           inputTokens,
+		EstimatedOutputTokens: outputTokens,
+		EstimatedCostUSD:      math.Round(cost*1e4) / 1e4,
+		EstimatedTimeSeconds:  requestOverheadSeconds + outputTokens/outputTokensPerSecond,
+	}
+}
+
+func (cg *ContentGenerator) estimationClient() *GeminiClient {
+	if key := cg.client.config.EstimationAPIKey; key != "" {
+		if client, err := cg.pool.get(cg.client.config, key); err == nil {
+			return client
+		}
+	}
+	return cg.client
+}
+
+// Ping counts the tokens of a short canary text, checking that Gemini is
+// reachable and accepts the server's key.
+func (cg *ContentGenerator) Ping(ctx context.Context) error {
+	_, err := cg.estimationClient().CountTokens(ctx, "ping")
+	return err
+}
@@ -4,11 +4,29 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 
 	"github.com/krauzx/gitright/internal/config"
+	"github.com/krauzx/gitright/internal/github"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/pkg/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"google.golang.org/genai"
 )
 
+var tracer = otel.Tracer("github.com/krauzx/gitright/internal/llm")
+
+// geminiBreaker is shared by every GeminiClient, pooled per-user clients
+// included, since they all call the same upstream.
+var geminiBreaker = github.NewCircuitBreaker()
+
+// CircuitBreaker returns the breaker guarding Gemini API calls.
+func CircuitBreaker() *github.CircuitBreaker {
+	return geminiBreaker
+}
+
 type GeminiClient struct {
 	client *genai.Client
 	config config.GoogleAIConfig
@@ -20,6 +38,9 @@ func NewGeminiClient(cfg config.GoogleAIConfig) (*GeminiClient, error) {
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey:  cfg.APIKey,
 		Backend: genai.BackendGeminiAPI,
+		HTTPClient: &http.Client{
+			Transport: geminiBreaker.Transport(http.DefaultTransport),
+		},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
@@ -32,7 +53,73 @@ func (g *GeminiClient) Close() error {
 	return nil
 }
 
-func (g *GeminiClient) GenerateContent(ctx context.Context, systemInstruction, userPrompt string) (string, error) {
+// GroundingResult lists the web sources and search queries behind a
+// response generated with Google Search grounding.
+type GroundingResult struct {
+	GroundingChunks []string // source URIs, or titles when a chunk has none
+	SearchQueries   []string
+}
+
+func groundingResult(meta *genai.GroundingMetadata) *GroundingResult {
+	if meta == nil {
+		return nil
+	}
+	result := &GroundingResult{SearchQueries: meta.WebSearchQueries}
+	for _, chunk := range meta.GroundingChunks {
+		if chunk == nil || chunk.Web == nil {
+			continue
+		}
+		source := chunk.Web.URI
+		if source == "" {
+			source = chunk.Web.Title
+		}
+		if source != "" {
+			result.GroundingChunks = append(result.GroundingChunks, source)
+		}
+	}
+	return result
+}
+
+type usageRecorderKey struct{}
+
+// WithUsageRecorder returns a context whose LLM calls report the token counts
+// of each response to record.
+func WithUsageRecorder(ctx context.Context, record func(promptTokens, outputTokens int)) context.Context {
+	return context.WithValue(ctx, usageRecorderKey{}, record)
+}
+
+// applyGenerationParameters overrides cfg with the non-zero fields of params,
+// clamped to the ranges the model accepts.
+func applyGenerationParameters(cfg *genai.GenerateContentConfig, params models.GenerationParameters) {
+	if params.Temperature != 0 {
+		cfg.Temperature = genai.Ptr(min(max(params.Temperature, 0), 1))
+	}
+	if params.TopK != 0 {
+		cfg.TopK = genai.Ptr(min(max(params.TopK, 1), 100))
+	}
+	if params.TopP != 0 {
+		cfg.TopP = genai.Ptr(min(max(params.TopP, 0), 1))
+	}
+	if params.MaxOutputTokens > 0 {
+		cfg.MaxOutputTokens = params.MaxOutputTokens
+	}
+}
+
+// GenerateContent returns the model's text. grounding is set only when
+// UseGrounding is enabled and the response carries grounding metadata.
+// Non-zero params override the default sampling settings.
+func (g *GeminiClient) GenerateContent(ctx context.Context, systemInstruction, userPrompt string, params models.GenerationParameters) (text string, grounding *GroundingResult, err error) {
+	ctx, span := tracer.Start(ctx, "llm.GenerateContent")
+	span.SetAttributes(attribute.String("model", g.config.Model))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			telemetry.LLMErrors.Add(ctx, 1)
+		}
+		span.End()
+	}()
+
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, g.config.Timeout)
 	defer cancel()
 
@@ -45,6 +132,7 @@ func (g *GeminiClient) GenerateContent(ctx context.Context, systemInstruction, u
 		MaxOutputTokens:   int32(8192),
 		SystemInstruction: genai.NewContentFromText(systemInstruction, "system"),
 	}
+	applyGenerationParameters(cfg, params)
 
 	if g.config.UseGrounding {
 		cfg.Tools = []*genai.Tool{
@@ -54,27 +142,44 @@ func (g *GeminiClient) GenerateContent(ctx context.Context, systemInstruction, u
 
 	resp, err := g.client.Models.GenerateContent(ctxWithTimeout, g.config.Model, contents, cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate content: %w", err)
+		return "", nil, fmt.Errorf("failed to generate content: %w", err)
+	}
+
+	if resp.UsageMetadata != nil {
+		span.SetAttributes(
+			attribute.Int("prompt_tokens", int(resp.UsageMetadata.PromptTokenCount)),
+			attribute.Int("completion_tokens", int(resp.UsageMetadata.CandidatesTokenCount)),
+		)
+		if record, ok := ctx.Value(usageRecorderKey{}).(func(int, int)); ok {
+			record(int(resp.UsageMetadata.PromptTokenCount), int(resp.UsageMetadata.CandidatesTokenCount))
+		}
 	}
 
 	if len(resp.Candidates) == 0 {
-		return "", fmt.Errorf("no candidates returned from Gemini")
+		return "", nil, fmt.Errorf("no candidates returned from Gemini")
 	}
 
 	candidate := resp.Candidates[0]
 	if candidate.Content == nil || len(candidate.Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from Gemini")
+		return "", nil, fmt.Errorf("empty response from Gemini")
 	}
 
 	if candidate.Content.Parts[0].Text == "" {
-		return "", fmt.Errorf("unexpected response type from Gemini")
+		return "", nil, fmt.Errorf("unexpected response type from Gemini")
+	}
+
+	if g.config.UseGrounding {
+		grounding = groundingResult(candidate.GroundingMetadata)
+		if grounding != nil {
+			span.SetAttributes(attribute.Int("grounding_sources", len(grounding.GroundingChunks)))
+		}
 	}
 
-	return candidate.Content.Parts[0].Text, nil
+	return candidate.Content.Parts[0].Text, grounding, nil
 }
 
 // GenerateStructuredContent enforces strict JSON-only output from the model.
-func (g *GeminiClient) GenerateStructuredContent(ctx context.Context, systemInstruction, userPrompt string) (string, error) {
+func (g *GeminiClient) GenerateStructuredContent(ctx context.Context, systemInstruction, userPrompt string, params models.GenerationParameters) (string, *GroundingResult, error) {
 	enhancedInstruction := systemInstruction + "\n\n" +
 		"=== CRITICAL OUTPUT RULES ===\n" +
 		"1. Output MUST be ONLY valid JSON - nothing else\n" +
@@ -83,14 +188,14 @@ func (g *GeminiClient) GenerateStructuredContent(ctx context.Context, systemInst
 		"4. Start directly with { and end with }\n" +
 		"5. Ensure all strings are properly escaped\n"
 
-	response, err := g.GenerateContent(ctx, enhancedInstruction, userPrompt)
+	response, grounding, err := g.GenerateContent(ctx, enhancedInstruction, userPrompt, params)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	slog.Debug("Gemini structured response", "preview", response[:min(200, len(response))])
 
-	return response, nil
+	return response, grounding, nil
 }
 
 func (g *GeminiClient) StreamContent(ctx context.Context, systemInstruction, userPrompt string, callback func(string) error) error {
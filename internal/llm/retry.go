@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/krauzx/gitright/internal/github"
+	"google.golang.org/genai"
+)
+
+const (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 20 * time.Second
+)
+
+type retryNotifierKey struct{}
+
+// WithRetryNotifier returns a context whose LLM calls report each retry to
+// notify, e.g. to show progress to a waiting client. attempt counts from 2
+// (the first retry) up to total.
+func WithRetryNotifier(ctx context.Context, notify func(attempt, total int)) context.Context {
+	return context.WithValue(ctx, retryNotifierKey{}, notify)
+}
+
+// fullJitterBackoff waits a random duration in [0, 2^attempt * base), capped
+// at retryMaxDelay.
+func fullJitterBackoff(attempt int) time.Duration {
+	ceiling := min(retryBaseDelay<<attempt, retryMaxDelay)
+	return rand.N(ceiling)
+}
+
+// withLLMRetry calls fn until it succeeds, returns a non-transient error, or
+// has been retried maxRetries times. It waits backoff(attempt) between tries.
+func withLLMRetry(ctx context.Context, maxRetries int, backoff func(attempt int) time.Duration, fn func() error) error {
+	notify, _ := ctx.Value(retryNotifierKey{}).(func(attempt, total int))
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(); err == nil || !isTransient(err) {
+			return err
+		}
+		if attempt >= maxRetries {
+			break
+		}
+
+		delay := backoff(attempt)
+		slog.Warn("LLM request failed, retrying", "attempt", attempt+2, "of", maxRetries+1, "delay", delay, "error", err)
+		if notify != nil {
+			notify(attempt+2, maxRetries+1)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return fmt.Errorf("generation failed after %d retries: %w", maxRetries, err)
+}
+
+// isTransient reports whether retrying err might succeed. Cancellation,
+// deadlines, an open circuit and client errors other than 408 and 429 are
+// final.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, github.ErrCircuitOpen) {
+		return false
+	}
+
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == http.StatusRequestTimeout, apiErr.Code == http.StatusTooManyRequests:
+			return true
+		case apiErr.Code >= 400 && apiErr.Code < 500:
+			return false
+		}
+	}
+	return true
+}
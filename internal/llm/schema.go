@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ErrInvalidLLMResponse is returned when the model's JSON does not match
+// batchProfileResponseSchema.
+var ErrInvalidLLMResponse = errors.New("invalid LLM response")
+
+// maxRawResponseInError caps how much of the raw model output is embedded in
+// validation errors.
+const maxRawResponseInError = 1000
+
+// batchProfileResponseSchema describes the JSON the batch prompt asks for.
+// Extra properties are allowed so prompt additions don't break validation.
+const batchProfileResponseSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["profile_pitch", "project_summaries", "confidence"],
+	"properties": {
+		"profile_pitch": {"type": "string", "minLength": 1},
+		"project_summaries": {
+			"type": "array",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"required": ["project_name", "summary", "skills"],
+				"properties": {
+					"project_name": {"type": "string"},
+					"summary": {"type": "string"},
+					"skills": {"type": "array", "items": {"type": "string"}}
+				}
+			}
+		},
+		"extracted_skills": {"type": "array", "items": {"type": "string"}},
+		"confidence": {"type": "number", "minimum": 0, "maximum": 1}
+	}
+}`
+
+var batchProfileSchema = mustCompileSchema("batch_profile_response.json", batchProfileResponseSchema)
+
+func mustCompileSchema(name, schema string) *jsonschema.Schema {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		panic(fmt.Sprintf("llm: invalid schema %s: %v", name, err))
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(name, doc); err != nil {
+		panic(fmt.Sprintf("llm: invalid schema %s: %v", name, err))
+	}
+	return c.MustCompile(name)
+}
+
+// validateBatchResponse checks raw model JSON against the response schema
+// before it is decoded into BatchProfileResponse.
+func validateBatchResponse(raw string) error {
+	inst, err := jsonschema.UnmarshalJSON(strings.NewReader(raw))
+	if err != nil {
+		return invalidResponse(err, raw)
+	}
+	if err := batchProfileSchema.Validate(inst); err != nil {
+		return invalidResponse(err, raw)
+	}
+	return nil
+}
+
+func invalidResponse(reason error, raw string) error {
+	if len(raw) > maxRawResponseInError {
+		raw = raw[:maxRawResponseInError] + "..."
+	}
+	return fmt.Errorf("%w: %v; raw response: %s", ErrInvalidLLMResponse, reason, raw)
+}
@@ -8,9 +8,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
+	"github.com/krauzx/gitright/internal/audit"
+	"github.com/krauzx/gitright/internal/models"
 	"github.com/krauzx/gitright/internal/repository"
 	"github.com/labstack/echo/v4"
 )
@@ -22,23 +25,21 @@ type JWTClaims struct {
 	ExpiresAt int64  `json:"exp"`
 }
 
+// SessionCookieName is the cookie carrying the JWT for browser clients that
+// log in with response_type=cookie.
+const SessionCookieName = "gitright_session"
+
 // AuthMiddleware validates JWT tokens, checks the JTI blocklist, and populates
-// request context with user data for downstream handlers.
+// request context with user data for downstream handlers. The token comes
+// from the Authorization header, or the session cookie when no header is sent.
 func AuthMiddleware(secret string, userRepo *repository.UserRepository, sessionRepo *repository.SessionRepository) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			authHeader := c.Request().Header.Get("Authorization")
-			if authHeader == "" {
-				return echo.ErrUnauthorized
-			}
-
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
-				return echo.ErrUnauthorized
+			token, err := requestToken(c)
+			if err != nil {
+				return err
 			}
 
-			token := parts[1]
-
 			claims, err := validateJWT(token, secret)
 			if err != nil {
 				return echo.ErrUnauthorized
@@ -61,6 +62,9 @@ func AuthMiddleware(secret string, userRepo *repository.UserRepository, sessionR
 			if err != nil {
 				return echo.ErrUnauthorized
 			}
+			if user.Source == models.UserSourceEmail && time.Now().After(user.ExpiresAt) {
+				return echo.ErrUnauthorized
+			}
 
 			c.Set("user", user)
 			c.Set("user_id", claims.UserID)
@@ -68,12 +72,41 @@ func AuthMiddleware(secret string, userRepo *repository.UserRepository, sessionR
 			c.Set("access_token", user.AccessToken)
 			c.Set("jwt_jti", claims.JTI)
 			c.Set("jwt_exp", claims.ExpiresAt)
+			c.SetRequest(c.Request().WithContext(audit.WithClient(ctx, c.RealIP(), c.Request().UserAgent())))
 
 			return next(c)
 		}
 	}
 }
 
+// RequireGitHubAccount rejects email demo accounts, which have no GitHub
+// token to act with. It must run after AuthMiddleware.
+func RequireGitHubAccount() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if user, _ := c.Get("user").(*models.User); user == nil || user.Source == models.UserSourceEmail {
+				return echo.NewHTTPError(http.StatusForbidden, "This action requires a GitHub account")
+			}
+			return next(c)
+		}
+	}
+}
+
+func requestToken(c echo.Context) (string, error) {
+	if authHeader := c.Request().Header.Get("Authorization"); authHeader != "" {
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return "", echo.ErrUnauthorized
+		}
+		return parts[1], nil
+	}
+
+	if cookie, err := c.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	return "", echo.ErrUnauthorized
+}
+
 // GenerateJWT creates a signed HS256 JWT for the given user with a unique JTI.
 func GenerateJWT(userID int64, username, secret string, expiresIn time.Duration) (string, error) {
 	jtiBytes := make([]byte, 16)
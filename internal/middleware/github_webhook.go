@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krauzx/gitright/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxWebhookPayload matches GitHub's 25 MB cap on webhook payloads.
+	maxWebhookPayload = 25 << 20
+
+	// deliveryReplayWindow is how long a delivery ID is remembered.
+	deliveryReplayWindow = 5 * time.Minute
+	maxTrackedDeliveries = 10000
+)
+
+// ValidateGitHubWebhook verifies the X-Hub-Signature-256 HMAC of GitHub
+// webhook requests and rejects replays of the same X-GitHub-Delivery ID with
+// 409. secretFn receives the GitHub account ID of the payload's repository
+// owner, or of its sender when there is no repository, and returns that
+// user's webhook secret; an empty secret rejects the request with 401. The
+// body is restored so handlers can read it again.
+func ValidateGitHubWebhook(secretFn func(userID int64) (string, error)) echo.MiddlewareFunc {
+	deliveries := newDeliveryCache(maxTrackedDeliveries, deliveryReplayWindow)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookPayload+1))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to read request body")
+			}
+			if len(body) > maxWebhookPayload {
+				return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Payload too large")
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			userID := webhookAccountID(body)
+			if userID == 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, "Payload has no repository owner or sender")
+			}
+
+			secret, err := secretFn(userID)
+			if err != nil {
+				logger.FromContext(req.Context()).Error("Failed to look up webhook secret", "github_id", userID, "error", err)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate webhook")
+			}
+			if secret == "" || !validWebhookSignature(secret, body, req.Header.Get("X-Hub-Signature-256")) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid webhook signature")
+			}
+
+			// Checked after the signature so unsigned requests can't burn IDs
+			delivery := req.Header.Get("X-GitHub-Delivery")
+			if delivery == "" {
+				return echo.NewHTTPError(http.StatusBadRequest, "Missing X-GitHub-Delivery header")
+			}
+			if !deliveries.add(delivery) {
+				return echo.NewHTTPError(http.StatusConflict, "Duplicate webhook delivery")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// webhookAccountID returns the GitHub account ID the webhook belongs to: the
+// repository owner, falling back to the sender. It returns 0 when neither is
+// present or the body is not JSON.
+func webhookAccountID(body []byte) int64 {
+	var payload struct {
+		Repository *struct {
+			Owner struct {
+				ID int64 `json:"id"`
+			} `json:"owner"`
+		} `json:"repository"`
+		Sender struct {
+			ID int64 `json:"id"`
+		} `json:"sender"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return 0
+	}
+	if payload.Repository != nil && payload.Repository.Owner.ID != 0 {
+		return payload.Repository.Owner.ID
+	}
+	return payload.Sender.ID
+}
+
+// validWebhookSignature compares header, "sha256=<hex>", against the
+// HMAC-SHA256 of body in constant time.
+func validWebhookSignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// deliveryCache remembers delivery IDs for ttl, evicting the oldest once it
+// holds capacity entries.
+type deliveryCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently seen
+}
+
+type deliveryEntry struct {
+	id     string
+	seenAt time.Time
+}
+
+func newDeliveryCache(capacity int, ttl time.Duration) *deliveryCache {
+	return &deliveryCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// add records id and reports whether it was not already seen within ttl.
+func (d *deliveryCache) add(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+
+	// Entries are ordered by time seen, so expired ones are at the back
+	for elem := d.order.Back(); elem != nil; elem = d.order.Back() {
+		entry := elem.Value.(*deliveryEntry)
+		if now.Sub(entry.seenAt) < d.ttl {
+			break
+		}
+		d.order.Remove(elem)
+		delete(d.entries, entry.id)
+	}
+
+	if _, ok := d.entries[id]; ok {
+		return false
+	}
+
+	d.entries[id] = d.order.PushFront(&deliveryEntry{id: id, seenAt: now})
+	for d.order.Len() > d.capacity {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*deliveryEntry).id)
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IPAllowlist rejects requests whose client IP is outside allowedCIDRs with
+// 403. An empty list allows every IP so admin routes work in development.
+// The client IP comes from c.RealIP, so the Echo IPExtractor decides which
+// X-Forwarded-For hops are trusted.
+func IPAllowlist(allowedCIDRs []string) echo.MiddlewareFunc {
+	nets := parseAllowlist(allowedCIDRs)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if len(allowedCIDRs) == 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			if !ipAllowed(nets, c.RealIP()) {
+				return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+			}
+			return next(c)
+		}
+	}
+}
+
+// MetricsAccess guards the Prometheus scrape endpoint, which can't send a
+// JWT. A request passes with "Authorization: Bearer <token>" when token is
+// set, or from an IP inside allowedCIDRs. Unlike IPAllowlist it fails
+// closed: with neither configured every request gets 403.
+func MetricsAccess(allowedCIDRs []string, token string) echo.MiddlewareFunc {
+	nets := parseAllowlist(allowedCIDRs)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if token != "" {
+				bearer, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+				if ok && hmac.Equal([]byte(bearer), []byte(token)) {
+					return next(c)
+				}
+			}
+			if ipAllowed(nets, c.RealIP()) {
+				return next(c)
+			}
+			return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+		}
+	}
+}
+
+func parseAllowlist(allowedCIDRs []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range allowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			slog.Warn("Ignoring invalid allowlist CIDR", "cidr", cidr, "error", err)
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+// ipAllowed reports whether realIP falls inside one of nets.
+func ipAllowed(nets []*net.IPNet, realIP string) bool {
+	ip := net.ParseIP(realIP)
+	if ip == nil {
+		return false
+	}
+	// Match ::ffff:a.b.c.d against IPv4 ranges
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAdmin allows only authenticated users whose username is listed.
+// It must run after AuthMiddleware.
+func RequireAdmin(usernames []string) echo.MiddlewareFunc {
+	admins := make(map[string]bool, len(usernames))
+	for _, u := range usernames {
+		admins[u] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			username, _ := c.Get("username").(string)
+			if !admins[username] {
+				return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+			}
+			return next(c)
+		}
+	}
+}
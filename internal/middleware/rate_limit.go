@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/hmac"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// internalSecretHeader carries the shared secret of internal callers such as
+// monitoring.
+const internalSecretHeader = "X-Internal-Secret"
+
+// RateLimitExempt marks requests whose X-Internal-Secret header matches
+// secret with c.Set("rate_limit_exempt", true), which the per-IP limiters
+// skip. An empty secret exempts nothing. It must run before the limiters.
+func RateLimitExempt(secret string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(internalSecretHeader)
+			if secret != "" && hmac.Equal([]byte(header), []byte(secret)) {
+				c.Set("rate_limit_exempt", true)
+			}
+			return next(c)
+		}
+	}
+}
+
+func rateLimitExempt(c echo.Context) bool {
+	exempt, _ := c.Get("rate_limit_exempt").(bool)
+	return exempt
+}
+
+// GlobalRateLimiter is the server-wide per-IP limit, skipped for requests
+// RateLimitExempt let through.
+func GlobalRateLimiter(limit int) echo.MiddlewareFunc {
+	return echomw.RateLimiterWithConfig(echomw.RateLimiterConfig{
+		Skipper: rateLimitExempt,
+		Store:   echomw.NewRateLimiterMemoryStore(rate.Limit(limit)),
+	})
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// UserRateLimiter allows each authenticated user limit requests per window,
+// refilling evenly. It must run after AuthMiddleware.
+func UserRateLimiter(limit int, window time.Duration) echo.MiddlewareFunc {
+	return echomw.RateLimiterWithConfig(echomw.RateLimiterConfig{
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			userID, ok := c.Get("user_id").(int64)
+			if !ok {
+				return "", echo.ErrUnauthorized
+			}
+			return strconv.FormatInt(userID, 10), nil
+		},
+		Store: echomw.NewRateLimiterMemoryStoreWithConfig(echomw.RateLimiterMemoryStoreConfig{
+			Rate:  rate.Limit(float64(limit) / window.Seconds()),
+			Burst: limit,
+			// Forgetting an idle user early would hand them a fresh burst
+			ExpiresIn: window,
+		}),
+	})
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+const apiVersionKey = "api_version"
+
+var acceptVersionPattern = regexp.MustCompile(`application/vnd\.gitright\.(v\d+)\+json`)
+
+// APIVersion negotiates the API version for the request and stores it in the
+// context. The version comes from ?api_version=, then the Accept header
+// (application/vnd.gitright.v2+json), falling back to defaultVersion, which is
+// normally the version in the route prefix.
+func APIVersion(defaultVersion string, supported []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			version := c.QueryParam("api_version")
+			if version == "" {
+				if m := acceptVersionPattern.FindStringSubmatch(c.Request().Header.Get("Accept")); m != nil {
+					version = m[1]
+				}
+			}
+			if version == "" {
+				version = defaultVersion
+			}
+
+			if !slices.Contains(supported, version) {
+				return echo.NewHTTPError(http.StatusNotAcceptable, "Unsupported API version")
+			}
+
+			c.Set(apiVersionKey, version)
+			c.Response().Header().Set("X-API-Version", version)
+			return next(c)
+		}
+	}
+}
+
+// APIVersionFromContext returns the negotiated API version, or "" when the
+// APIVersion middleware did not run.
+func APIVersionFromContext(c echo.Context) string {
+	v, _ := c.Get(apiVersionKey).(string)
+	return v
+}
+
+// Versioned dispatches to the handler registered for the negotiated version,
+// falling back to fallback when no variant exists for it.
+func Versioned(fallback echo.HandlerFunc, variants map[string]echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if h, ok := variants[APIVersionFromContext(c)]; ok {
+			return h(c)
+		}
+		return fallback(c)
+	}
+}
+
+// VersionRateLimiter applies a separate per-IP limit to requests negotiated
+// as version. Requests for other versions, and those RateLimitExempt let
+// through, pass through untouched.
+func VersionRateLimiter(version string, requestsPerMinute, burst int) echo.MiddlewareFunc {
+	return echomw.RateLimiterWithConfig(echomw.RateLimiterConfig{
+		Skipper: func(c echo.Context) bool {
+			return rateLimitExempt(c) || APIVersionFromContext(c) != version
+		},
+		Store: echomw.NewRateLimiterMemoryStoreWithConfig(echomw.RateLimiterMemoryStoreConfig{
+			Rate:  rate.Limit(float64(requestsPerMinute) / 60),
+			Burst: burst,
+		}),
+	})
+}
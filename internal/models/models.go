@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -20,37 +21,267 @@ type User struct {
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 	LastLoginAt    time.Time `json:"last_login_at" db:"last_login_at"`
+	// Source is UserSourceGitHub or UserSourceEmail. Email accounts are
+	// demo accounts: they see sample repositories, cannot deploy, and are
+	// deleted at ExpiresAt.
+	Source    string    `json:"source" db:"source"`
+	ExpiresAt time.Time `json:"expires_at,omitzero" db:"expires_at"`
 }
 
+// User sources.
+const (
+	UserSourceGitHub = "github"
+	UserSourceEmail  = "email"
+)
+
 type Repository struct {
-	ID              int64     `json:"id"`
-	GitHubID        int64     `json:"github_id"`
-	Name            string    `json:"name"`
-	FullName        string    `json:"full_name"`
-	Description     string    `json:"description"`
-	Private         bool      `json:"private"`
-	Fork            bool      `json:"fork"`
-	Language        string    `json:"language"`
-	StargazersCount int       `json:"stargazers_count"`
-	ForksCount      int       `json:"forks_count"`
-	OpenIssuesCount int       `json:"open_issues_count"`
-	DefaultBranch   string    `json:"default_branch"`
-	Topics          []string  `json:"topics"`
-	HTMLURL         string    `json:"html_url"`
-	CloneURL        string    `json:"clone_url"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-	PushedAt        time.Time `json:"pushed_at"`
+	ID               int64     `json:"id"`
+	GitHubID         int64     `json:"github_id"`
+	Name             string    `json:"name"`
+	FullName         string    `json:"full_name"`
+	Description      string    `json:"description"`
+	Private          bool      `json:"private"`
+	Fork             bool      `json:"fork"`
+	Language         string    `json:"language"`
+	StargazersCount  int       `json:"stargazers_count"`
+	ForksCount       int       `json:"forks_count"`
+	WatchersCount    int       `json:"watchers_count"`    // mirrors stars, a GitHub legacy quirk
+	SubscribersCount int       `json:"subscribers_count"` // actual watchers; only set by single-repo fetches
+	OpenIssuesCount  int       `json:"open_issues_count"`
+	DefaultBranch    string    `json:"default_branch"`
+	Topics           []string  `json:"topics"`
+	HTMLURL          string    `json:"html_url"`
+	CloneURL         string    `json:"clone_url"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	PushedAt         time.Time `json:"pushed_at"`
+	// SocialPreviewURL is the repository's og:image, fetched only when a
+	// profile is generated with the social-previews section toggle.
+	SocialPreviewURL string `json:"social_preview_url,omitempty"`
 }
 
 type RepositoryAnalysis struct {
-	Repository       *Repository         `json:"repository"`
-	Languages        map[string]int      `json:"languages"`
-	Files            []string            `json:"files"`
-	Dependencies     map[string][]string `json:"dependencies"`
-	KeyFiles         map[string]string   `json:"key_files"`
-	CommitCount      int                 `json:"commit_count"`
-	ContributorCount int                 `json:"contributor_count"`
+	Repository       *Repository           `json:"repository"`
+	Languages        map[string]int        `json:"languages"`
+	LanguageWeights  map[string]float64    `json:"language_weights,omitempty"` // share of Languages, 0 to 1
+	Files            []string              `json:"files"`
+	Dependencies     map[string][]string   `json:"dependencies"`
+	KeyFiles         map[string]string     `json:"key_files"`
+	CommitCount      int                   `json:"commit_count"`
+	ContributorCount int                   `json:"contributor_count"`
+	CommitConvention *CommitConventionInfo `json:"commit_convention,omitempty"`
+	// LanguageHistory is the language breakdown month by month over the
+	// past year, oldest first; only fetched when the server enables it.
+	LanguageHistory []LanguageSnapshot `json:"language_history,omitempty"`
+	// CommitFrequency is the owner's recent commit activity; nil when
+	// GitHub's participation statistics were unavailable.
+	CommitFrequency *CommitFrequency `json:"commit_frequency,omitempty"`
+	// GoReplaceDirectives holds go.mod replace directives such as
+	// "github.com/foo/bar => ../bar"; local paths hint at a multi-module setup.
+	GoReplaceDirectives []string `json:"go_replace_directives,omitempty"`
+	// GoWorkspaceModules are the local module paths a root go.work uses,
+	// e.g. "services/api"; empty when no go.work is committed.
+	GoWorkspaceModules []string `json:"go_workspace_modules,omitempty"`
+	// GoBuildTags are the build constraints found in the Go code, e.g.
+	// "linux", "windows" or "wasm", from //go:build lines and file name
+	// suffixes.
+	GoBuildTags []string `json:"go_build_tags,omitempty"`
+	// PackageManager is the JavaScript package manager or runtime: "deno",
+	// "bun", "pnpm", "yarn" or "npm", from config files and lockfiles.
+	PackageManager string `json:"package_manager,omitempty"`
+	// CoAuthorTrailers are the Co-authored-by trailers in the repository's
+	// recent commits, deduplicated by email.
+	CoAuthorTrailers []CoAuthorTrailer `json:"co_author_trailers,omitempty"`
+	// CoAuthors are the collaborators credited in CoAuthorTrailers, as
+	// "@login" when their account is known and by name otherwise. They are
+	// resolved at generation, and only when the co-authors section is on.
+	CoAuthors []string `json:"co_authors,omitempty"`
+	// StarGrowthRate is stars per day over the last 90 days and StarPeakDate
+	// starts the 7-day window with the most stars. Both are zero unless the
+	// stargazer timeline was fetched.
+	StarGrowthRate float64   `json:"star_growth_rate,omitzero"`
+	StarPeakDate   time.Time `json:"star_peak_date,omitzero"`
+	// RuntimeVersions maps a platform to its minimum supported version,
+	// e.g. "ios": "16".
+	RuntimeVersions map[string]string `json:"runtime_versions,omitempty"`
+	// TypeScriptConfig summarizes tsconfig.json compiler options; nil when
+	// the repository has no tsconfig.json.
+	TypeScriptConfig *TypeScriptConfig `json:"typescript_config,omitempty"`
+	// DevContainer is set when the repository has a .devcontainer.json or
+	// .devcontainer/devcontainer.json.
+	DevContainer *DevContainerInfo `json:"dev_container,omitempty"`
+	// CompilesToWASM is set when the repository targets WebAssembly, via
+	// Emscripten, wasm-pack or committed .wasm builds.
+	CompilesToWASM bool `json:"compiles_to_wasm,omitempty"`
+	// UsesNix is set when the repository root has a flake.nix.
+	UsesNix bool `json:"uses_nix,omitempty"`
+	// HasMakefile is set when a Makefile defines any of the build, test,
+	// lint or deploy targets, which MakeTargets lists.
+	HasMakefile bool     `json:"has_makefile,omitempty"`
+	MakeTargets []string `json:"make_targets,omitempty"`
+	// IaCTools are the infrastructure-as-code tools in use, IaC constants.
+	IaCTools []string `json:"iac_tools,omitempty"`
+	// DataTools are the data pipeline tools in use, DataTool constants.
+	DataTools []string `json:"data_tools,omitempty"`
+	// Stale is set when GitHub was unavailable and an expired cached
+	// analysis was served instead; StaleAge is how old it is.
+	Stale    bool          `json:"stale,omitempty"`
+	StaleAge time.Duration `json:"stale_age,omitempty"`
+	// Stats holds maintenance signals; nil unless issue stats are enabled.
+	Stats *RepositoryStats `json:"stats,omitempty"`
+	// IssueStats counts issues and pull requests; nil when they couldn't be
+	// listed.
+	IssueStats *IssueStats `json:"issue_stats,omitempty"`
+	// VulnerabilityAlerts are the open Dependabot alerts; only checked when
+	// the server enables vulnerability checks.
+	VulnerabilityAlerts []VulnerabilityAlert `json:"vulnerability_alerts,omitempty"`
+	// ActivityScore is the repository's activity score adjusted by signals
+	// only known after analysis, such as strict TypeScript. Repository
+	// recommendations rank analyzed repositories by it.
+	ActivityScore float64 `json:"activity_score,omitzero"`
+	// GistID selects a GitHub Gist instead of a repository. The gist is
+	// fetched into Gist, and Repository is synthesized from it.
+	GistID string `json:"gist_id,omitempty"`
+	Gist   *Gist  `json:"gist,omitempty"`
+}
+
+// Gist is a GitHub Gist showcased as a project.
+type Gist struct {
+	ID          string              `json:"id"`
+	Description string              `json:"description"`
+	Public      bool                `json:"public"`
+	Owner       string              `json:"owner"`
+	HTMLURL     string              `json:"html_url"`
+	Files       map[string]GistFile `json:"files"`
+	// StarCount is always 0 for now: the REST API has no gist star count.
+	StarCount int       `json:"star_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type GistFile struct {
+	Filename string `json:"filename"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// LanguageSnapshot is a repository's bytes of code per language as of Date.
+type LanguageSnapshot struct {
+	Date      time.Time      `json:"date"`
+	Languages map[string]int `json:"languages"`
+}
+
+// RepositoryStats measures maintainer responsiveness.
+type RepositoryStats struct {
+	WatchersCount    int `json:"watchers_count"`
+	SubscribersCount int `json:"subscribers_count"`
+	// AvgIssueResponseDays is the median days from opening to the first
+	// comment by someone other than the author, over recently closed issues
+	// with such a comment. IssuesSampled counts those issues; when it is 0
+	// the average is meaningless.
+	AvgIssueResponseDays float64 `json:"avg_issue_response_days"`
+	IssuesSampled        int     `json:"issues_sampled"`
+}
+
+// IssueStats summarizes a repository's most recent issues and pull requests,
+// up to 100 of them. AvgCloseDays is the mean days from opening to closing
+// over the closed issues, pull requests excluded.
+type IssueStats struct {
+	OpenIssues   int     `json:"open_issues"`
+	ClosedIssues int     `json:"closed_issues"`
+	OpenPRs      int     `json:"open_prs"`
+	MergedPRs    int     `json:"merged_prs"`
+	AvgCloseDays float64 `json:"avg_close_days"`
+}
+
+// Vulnerability alert severities.
+const (
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+// VulnerabilityAlert is an open Dependabot alert on a dependency.
+type VulnerabilityAlert struct {
+	PackageName string `json:"package_name"`
+	Severity    string `json:"severity"`      // a Severity constant
+	CVE         string `json:"cve,omitempty"` // empty for advisories without one
+}
+
+// TypeScriptConfig holds the tsconfig.json compiler options worth mentioning
+// in a profile.
+type TypeScriptConfig struct {
+	Target            string   `json:"target,omitempty"` // e.g. "ES2022"
+	Strict            bool     `json:"strict"`
+	Libs              []string `json:"libs,omitempty"`
+	HasPathAliases    bool     `json:"has_path_aliases"`
+	IsDeclarationFile bool     `json:"is_declaration_file"` // declaration: true, i.e. a published library
+}
+
+// DevContainerInfo summarizes a devcontainer.json, which lets the
+// repository be opened in GitHub Codespaces.
+type DevContainerInfo struct {
+	Image          string   `json:"image,omitempty"`      // empty when built from a Dockerfile
+	Features       []string `json:"features,omitempty"`   // feature IDs, e.g. "ghcr.io/devcontainers/features/node:1"
+	Extensions     []string `json:"extensions,omitempty"` // VS Code extension IDs, e.g. "golang.go"
+	ForwardedPorts []int    `json:"forwarded_ports,omitempty"`
+}
+
+// CommitConventionInfo describes how closely recent commit messages follow
+// Conventional Commits (https://www.conventionalcommits.org).
+type CommitConventionInfo struct {
+	UsesConventionalCommits bool     `json:"uses_conventional_commits"`
+	CommitTypes             []string `json:"commit_types"`     // unique types seen, e.g. "feat", "fix"
+	ConformanceRate         float64  `json:"conformance_rate"` // 0-1, merge commits excluded
+}
+
+// CoAuthorTrailer is a Co-authored-by commit trailer.
+type CoAuthorTrailer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CommitFrequency summarizes the repository owner's commits over the last
+// weeks, from GitHub's participation statistics.
+type CommitFrequency struct {
+	OwnerCommitsLast4Weeks  int    `json:"owner_commits_last_4_weeks"`
+	OwnerCommitsLast12Weeks int    `json:"owner_commits_last_12_weeks"`
+	Cadence                 string `json:"cadence"` // one of the Cadence constants
+}
+
+// Infrastructure-as-code tools reported in RepositoryAnalysis.IaCTools.
+const (
+	IaCTerraform      = "terraform"
+	IaCAzureBicep     = "azure_bicep"
+	IaCCloudFormation = "cloudformation"
+)
+
+// Data pipeline tools reported in RepositoryAnalysis.DataTools.
+const (
+	DataToolAirflow           = "airflow"
+	DataToolDBT               = "dbt"
+	DataToolPrefect           = "prefect"
+	DataToolDagster           = "dagster"
+	DataToolGreatExpectations = "great_expectations"
+)
+
+// Commit cadences, from the average weekly owner commits over 12 weeks.
+const (
+	CadenceDaily      = "daily"      // 5 or more a week
+	CadenceWeekly     = "weekly"     // 1 or more a week
+	CadenceMonthly    = "monthly"    // 1 or more a month
+	CadenceOccasional = "occasional" // less
+)
+
+// UserActivitySummary aggregates cross-repository GitHub activity signals for
+// a single user, derived from their public event feed and starred list.
+type UserActivitySummary struct {
+	PublicContributions int      `json:"public_contributions"`
+	PullRequestsMerged  int      `json:"pull_requests_merged"`
+	IssuesClosed        int      `json:"issues_closed"`
+	PublicReposStarred  int      `json:"public_repos_starred"`
+	RecentActiveRepos   []string `json:"recent_active_repos"`
 }
 
 type Project struct {
@@ -59,7 +290,7 @@ type Project struct {
 	GitHubID         int64     `json:"github_id" db:"github_id"` // Direct GitHub repo ID
 	FullName         string    `json:"full_name" db:"full_name"` // owner/repo format
 	Priority         int       `json:"priority" db:"priority"`
-	FocusTag         string    `json:"focus_tag" db:"focus_tag"` // "best_performance", "team_project", "personal_favorite"
+	FocusTag         string    `json:"focus_tag" db:"focus_tag"` // "best_performance", "team_project", "personal_favorite", "open_source"
 	CustomSummary    string    `json:"custom_summary" db:"custom_summary"`
 	GeneratedSummary string    `json:"generated_summary" db:"generated_summary"`
 	IncludeInProfile bool      `json:"include_in_profile" db:"include_in_profile"`
@@ -72,12 +303,17 @@ type ProfileConfig struct {
 	UserID           int64              `json:"user_id" db:"user_id"`
 	TargetRole       string             `json:"target_role" db:"target_role"`
 	SkillsEmphasis   []string           `json:"skills_emphasis" db:"skills_emphasis"`
-	ToneOfVoice      string             `json:"tone_of_voice" db:"tone_of_voice"` // "professional", "friendly", "technical"
+	ToneOfVoice      string             `json:"tone_of_voice" db:"tone_of_voice"` // "professional", "friendly", "technical", "casual"
 	TemplateID       string             `json:"template_id" db:"template_id"`     // "technical_deep_dive", "hiring_manager_scan", "community_contributor"
 	ContactPrefs     ContactPreferences `json:"contact_prefs" db:"contact_prefs"`
 	ShowPrivateRepos bool               `json:"show_private_repos" db:"show_private_repos"`
-	CreatedAt        time.Time          `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time          `json:"updated_at" db:"updated_at"`
+	// TemplateVariables are the resolved branding placeholders (server defaults
+	// merged with user overrides) substituted into the rendered markdown.
+	TemplateVariables map[string]string `json:"template_variables,omitempty" db:"-"`
+	TypingSVG         TypingSVGConfig   `json:"typing_svg" db:"-"`
+	Layout            ProfileLayout     `json:"layout" db:"-"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
 }
 
 type ContactPreferences struct {
@@ -85,9 +321,35 @@ type ContactPreferences struct {
 	PersonalWebsite string   `json:"personal_website"`
 	Email           string   `json:"email"`
 	Twitter         string   `json:"twitter"`
+	Mastodon        string   `json:"mastodon"` // user@instance.social, stored as the profile URL
+	Bluesky         string   `json:"bluesky"`  // user.bsky.social, stored as the profile URL
 	PreferredOrder  []string `json:"preferred_order"`
 }
 
+type UserPreferences struct {
+	UserID               int64             `json:"user_id" db:"user_id"`
+	RegenerationSchedule string            `json:"regeneration_schedule" db:"regeneration_schedule"` // cron expression, UTC
+	AutoDeploy           bool              `json:"auto_deploy" db:"auto_deploy"`
+	TemplateVariables    map[string]string `json:"template_variables" db:"template_variables"`
+	TypingSVG            TypingSVGConfig   `json:"typing_svg" db:"typing_svg"`
+	Layout               ProfileLayout     `json:"layout" db:"layout_config"`
+	ShowcaseOptIn        bool              `json:"showcase_opt_in" db:"showcase_opt_in"`
+	CreatedAt            time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// TypingSVGConfig customizes the readme-typing-svg hero banner. Zero or empty
+// fields fall back to the defaults.
+type TypingSVGConfig struct {
+	Font     string `json:"font,omitempty"`      // e.g. "Fira Code"
+	FontSize int    `json:"font_size,omitempty"` // px
+	Duration int    `json:"duration,omitempty"`  // ms to type each line
+	PauseMs  int    `json:"pause_ms,omitempty"`  // ms between lines
+	Color    string `json:"color,omitempty"`     // 6-digit hex without '#'
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+}
+
 type GeneratedProfile struct {
 	ID              int64      `json:"id" db:"id"`
 	UserID          int64      `json:"user_id" db:"user_id"`
@@ -98,22 +360,294 @@ type GeneratedProfile struct {
 	DeployedAt      *time.Time `json:"deployed_at,omitempty" db:"deployed_at"`
 	Version         int        `json:"version" db:"version"`
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+
+	// Set by ProfileCacheRepository.Search only
+	TargetRole string `json:"target_role,omitempty" db:"-"`
+	Excerpt    string `json:"excerpt,omitempty" db:"-"`
+}
+
+// ShowcaseEntry lists a deployed profile in the public showcase. It holds
+// only what the user published in the profile itself.
+type ShowcaseEntry struct {
+	Username     string    `json:"username"`
+	AvatarURL    string    `json:"avatar_url"`
+	TargetRole   string    `json:"target_role"`
+	TopLanguages []string  `json:"top_languages"`
+	DeployedAt   time.Time `json:"deployed_at"`
+	ShareURL     string    `json:"share_url"`
+
+	// Sort keys, to continue the listing after this entry
+	UserID    int64 `json:"-"`
+	CacheHits int   `json:"-"`
+}
+
+// Showcase sort orders.
+const (
+	ShowcaseSortRecent  = "recent"  // latest deploy first
+	ShowcaseSortPopular = "popular" // most cache hits first
+)
+
+// ShowcaseCursor is the position of the last entry of a showcase page; the
+// next page starts after it.
+type ShowcaseCursor struct {
+	DeployedAt time.Time
+	UserID     int64
+	CacheHits  int
 }
 
+// DeployPreview shows what deploying NewContent to the profile README would
+// change. CurrentSHA is empty and IsFirstDeploy true when no README exists.
+type DeployPreview struct {
+	CurrentContent string `json:"current_content"`
+	NewContent     string `json:"new_content"`
+	Diff           string `json:"diff"` // unified diff, empty when unchanged
+	IsFirstDeploy  bool   `json:"is_first_deploy"`
+	CurrentSHA     string `json:"current_sha,omitempty"`
+}
+
+// DefaultTargetFilePath is the profile README GitHub shows on the user's
+// profile page.
+const DefaultTargetFilePath = "README.md"
+
 type ContentGenerationRequest struct {
-	TargetRole       string               `json:"target_role" validate:"required"`
+	TargetRole       string               `json:"target_role" validate:"required,min=1,max=100"`
 	EmphasizedSkills []string             `json:"emphasized_skills"`
-	ToneOfVoice      string               `json:"tone_of_voice" validate:"required"`
+	ToneOfVoice      string               `json:"tone_of_voice" validate:"required,oneof=professional friendly technical casual"`
 	ContactPrefs     ContactPreferences   `json:"contact_prefs"`
-	Projects         []RepositoryAnalysis `json:"projects" validate:"required,min=1"`
-	UserAPIKey       string               `json:"user_api_key" validate:"required"`
+	Projects         []RepositoryAnalysis `json:"projects" validate:"required_unless=DryRun true,omitempty,min=1,max=10"`
+	UserAPIKey       string               `json:"user_api_key" validate:"required_unless=PinToCache true,gemini_api_key"`
+	// MinStars overrides the server's featured-project star threshold; zero
+	// keeps the server default.
+	MinStars int `json:"min_stars,omitempty" validate:"min=0,max=100"`
+	// PinToCache returns only a cached result, failing instead of calling
+	// the LLM on a miss, so repeated runs deploy the same reviewed profile.
+	PinToCache bool `json:"pin_to_cache,omitempty"`
+	// DryRun, together with PinToCache, skips every external call and
+	// returns a placeholder profile for offline testing. It is ignored
+	// without PinToCache.
+	DryRun bool `json:"dry_run,omitempty"`
+	// CustomSections are user-written sections placed between the standard
+	// ones; at most three.
+	CustomSections []CustomSection `json:"custom_sections,omitempty" validate:"max=3,dive"`
+	// SectionToggles forces sections on or off by ID ("connect",
+	// "tech-stack", "contribution-activity", "trophies"), overriding the
+	// checks that hide sections without enough data. "social-previews"
+	// opts in to repository preview images in Featured Projects and
+	// "seo-meta" to SEO metadata comments at the top of the README.
+	SectionToggles map[string]bool `json:"section_toggles,omitempty"`
+	// Language is the README and pitch language, one of i18n.Locales. When
+	// empty the handlers default it from Accept-Language, then English.
+	Language string `json:"language,omitempty"`
+	// Privacy hides personal details from the README and the LLM prompt.
+	// Profiles generated with any privacy setting are not cached.
+	Privacy PrivacySettings `json:"privacy,omitzero"`
+	// OpenToWork sets the is_open_to_work condition of {{#if}} blocks in
+	// custom sections and template variables.
+	OpenToWork bool `json:"open_to_work,omitempty"`
+	// TargetFilePath is where the profile is deployed in the profile
+	// repository; empty means DefaultTargetFilePath.
+	TargetFilePath string `json:"target_file_path,omitempty" validate:"omitempty,profile_path"`
+	// Parameters override the model's sampling settings for this request.
+	Parameters GenerationParameters `json:"generation_parameters,omitzero"`
+}
+
+// GenerationParameters are per-request LLM sampling settings. Zero fields
+// keep the server defaults; out-of-range values are clamped.
+type GenerationParameters struct {
+	Temperature     float32 `json:"temperature,omitempty"` // 0 to 1
+	TopK            float32 `json:"top_k,omitempty"`       // 1 to 100
+	TopP            float32 `json:"top_p,omitempty"`       // 0 to 1
+	MaxOutputTokens int32   `json:"max_output_tokens,omitempty" validate:"min=0,max=8192"`
+}
+
+// PrivacySettings hides GitHub profile details from a generated profile.
+// UseAlias replaces the username in the greeting; widget and profile URLs
+// keep the real username, which they need to resolve.
+type PrivacySettings struct {
+	HideLocation bool   `json:"hide_location,omitempty"`
+	HideCompany  bool   `json:"hide_company,omitempty"`
+	HideEmail    bool   `json:"hide_email,omitempty"` // account and contact email
+	UseAlias     string `json:"use_alias,omitempty" validate:"max=50"`
+}
+
+// CustomSection is a freeform markdown section such as "Currently Reading".
+// Position N places it after the Nth standard section (1 = About Me, 6 =
+// Contribution Activity); 0 places it right after the header, and positions
+// past the last section place it before the footer. Raw HTML is stripped
+// from Content.
+type CustomSection struct {
+	Title     string `json:"title" validate:"required,max=100"`
+	Content   string `json:"content"`
+	Position  int    `json:"position"`
+	MaxLength int    `json:"max_length,omitempty" validate:"min=0,max=2000"` // 0 = server limit
 }
 
 type ContentGenerationResponse struct {
-	Markdown        string   `json:"markdown"`
-	ExtractedSkills []string `json:"extracted_skills"`
-	SuggestedBadges []Badge  `json:"suggested_badges"`
-	Confidence      float64  `json:"confidence"`
+	Markdown              string   `json:"markdown"`
+	ExtractedSkills       []string `json:"extracted_skills"`
+	SuggestedBadges       []Badge  `json:"suggested_badges"`
+	BadgesOmitted         int      `json:"badges_omitted"`                 // left out of the markdown by badge limits
+	OmittedRepositories   []string `json:"omitted_repositories,omitempty"` // below the star threshold
+	Confidence            float64  `json:"confidence"`
+	ConfidenceLevel       string   `json:"confidence_level"`       // "low", "medium", "high"
+	ConfidenceExplanation string   `json:"confidence_explanation"` // derived from repository data, not the LLM
+	// ReadabilityScore rates the generated pitch; ReadabilityWarnings are
+	// advisory and never block generation.
+	ReadabilityScore    *ReadabilityScore `json:"readability_score,omitempty"`
+	ReadabilityWarnings []string          `json:"readability_warnings,omitempty"`
+	// StructureWarnings flag markdown GitHub will render differently than
+	// intended, see validators.ValidateMarkdownStructure.
+	StructureWarnings []string `json:"structure_warnings,omitempty"`
+	// VulnerabilityWarnings name selected repositories with open HIGH or
+	// CRITICAL vulnerability alerts; they never block generation.
+	VulnerabilityWarnings []string `json:"vulnerability_warnings,omitempty"`
+	// AIGrounded is set when the LLM backed its text with Google Search
+	// results.
+	AIGrounded bool `json:"ai_grounded,omitempty"`
+	// SEOMetadata is for sites that republish the profile README.
+	SEOMetadata *SEOMetadata `json:"seo_metadata,omitempty"`
+	// Layout groups the rendered sections by ID for responsive frontends.
+	Layout *ProfileLayout `json:"layout,omitempty"`
+}
+
+// ProfileLayout assigns profile sections, by section ID, to the columns of
+// a responsive layout.
+type ProfileLayout struct {
+	SidebarSections   []string `json:"sidebar_sections"`
+	MainSections      []string `json:"main_sections"`
+	FullWidthSections []string `json:"full_width_sections"`
+}
+
+// SEOMetadata holds page metadata derived from a generated profile.
+// Description is at most 160 characters.
+type SEOMetadata struct {
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	Keywords     []string `json:"keywords"`
+	CanonicalURL string   `json:"canonical_url"`
+}
+
+// ReadabilityScore holds plain-text readability metrics. FleschKincaid is
+// the Flesch reading ease: higher is easier, below 30 is very difficult.
+type ReadabilityScore struct {
+	FleschKincaid       float64  `json:"flesch_kincaid"`
+	SentenceCount       int      `json:"sentence_count"`
+	AvgWordsPerSentence float64  `json:"avg_words_per_sentence"`
+	PassiveVoiceCount   int      `json:"passive_voice_count"`
+	JargonWords         []string `json:"jargon_words"`
+}
+
+// GenerationCooldown is a user's most recent profile generation that reached
+// the LLM, used to throttle repeated generations.
+type GenerationCooldown struct {
+	UserID          int64     `json:"user_id"`
+	LastGeneratedAt time.Time `json:"last_generated_at"`
+	CountToday      int       `json:"count_today"`
+}
+
+// Profile event types recorded for SystemMetrics.
+const (
+	ProfileEventGenerated = "generated"
+	ProfileEventCacheHit  = "cache_hit"
+	ProfileEventDeployed  = "deployed"
+	ProfileEventFailed    = "failed"
+)
+
+// SystemMetrics is a system-wide usage snapshot for operators. "Today" is the
+// current UTC day.
+type SystemMetrics struct {
+	TotalUsers             int     `json:"total_users"`
+	ActiveUsersLast7Days   int     `json:"active_users_last_7_days"`
+	ProfilesGeneratedToday int     `json:"profiles_generated_today"`
+	ProfilesDeployedToday  int     `json:"profiles_deployed_today"`
+	CacheHitRatePct        float64 `json:"cache_hit_rate_pct"`
+	AvgGenerationTimeMs    float64 `json:"avg_generation_time_ms"`
+	LLMTokensUsedToday     int     `json:"llm_tokens_used_today"`
+	GitHubAPICallsToday    int     `json:"github_api_calls_today"`
+	ErrorRate1hPct         float64 `json:"error_rate_1h_pct"`
+}
+
+// EmailLoginCode is a pending one-time code for email login. Only a hash of
+// the code is stored.
+type EmailLoginCode struct {
+	Email     string    `json:"email"`
+	CodeHash  string    `json:"-"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EmailLoginLimit counts the login codes sent, or the codes checked, for an
+// email address or a client IP. Subject is e.g. "send:email:<address>" or
+// "verify:ip:<address>".
+type EmailLoginLimit struct {
+	Subject    string    `json:"subject"`
+	LastAt     time.Time `json:"last_at"`
+	CountToday int       `json:"count_today"`
+}
+
+// ABTest compares two generated profile variants. SelectedVariant is "a" or
+// "b" once the user has picked one, which completes the test.
+type ABTest struct {
+	ID              int64         `json:"id" db:"id"`
+	UserID          int64         `json:"user_id" db:"user_id"`
+	TestName        string        `json:"test_name" db:"test_name"`
+	Status          string        `json:"status" db:"status"` // "pending", "completed"
+	SelectedVariant string        `json:"selected_variant,omitempty" db:"selected_variant"`
+	VariantA        ABTestVariant `json:"variant_a"`
+	VariantB        ABTestVariant `json:"variant_b"`
+	SelectedAt      *time.Time    `json:"selected_at,omitempty" db:"selected_at"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+}
+
+// ABTestVariant summarizes the request behind one side of an A/B test.
+type ABTestVariant struct {
+	ID          int64  `json:"id"`
+	TargetRole  string `json:"target_role"`
+	ToneOfVoice string `json:"tone_of_voice"`
+}
+
+// ABInsight is how often a TargetRole and ToneOfVoice combination won the
+// completed A/B tests it appeared in.
+type ABInsight struct {
+	TargetRole  string  `json:"target_role"`
+	ToneOfVoice string  `json:"tone_of_voice"`
+	Wins        int     `json:"wins"`
+	Appearances int     `json:"appearances"`
+	WinRate     float64 `json:"win_rate"`
+}
+
+// WizardSession holds the profile wizard's progress. State accumulates the
+// ContentGenerationRequest fields set by each step.
+type WizardSession struct {
+	ID        string          `json:"id" db:"id"`
+	UserID    int64           `json:"user_id" db:"user_id"`
+	Step      int             `json:"step" db:"step"`
+	State     json.RawMessage `json:"state" db:"state"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+	ExpiresAt time.Time       `json:"expires_at" db:"expires_at"`
+}
+
+type AuditEntry struct {
+	ID         int64           `json:"id" db:"id"`
+	UserID     int64           `json:"user_id" db:"user_id"`
+	Action     string          `json:"action" db:"action"`
+	EntityType string          `json:"entity_type,omitempty" db:"entity_type"`
+	EntityID   int64           `json:"entity_id,omitempty" db:"entity_id"`
+	OldValue   json.RawMessage `json:"old_value,omitempty" db:"old_value"`
+	NewValue   json.RawMessage `json:"new_value,omitempty" db:"new_value"`
+	IPAddress  string          `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent  string          `json:"user_agent,omitempty" db:"user_agent"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// RenderedSection is one "## " section of a generated README. The content
+// before the first heading is returned as the "hero" section.
+type RenderedSection struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Markdown string `json:"markdown"`
 }
 
 type Badge struct {
@@ -139,6 +673,7 @@ type TemplateData struct {
 type ProjectSummary struct {
 	Project    *Project    `json:"project"`
 	Repository *Repository `json:"repository"`
+	Gist       *Gist       `json:"gist,omitempty"`
 	Summary    string      `json:"summary"`
 	TechStack  []string    `json:"tech_stack"`
 	Highlights []string    `json:"highlights"`
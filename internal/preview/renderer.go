@@ -0,0 +1,225 @@
+// Package preview renders profile markdown to images the way GitHub would
+// display it, using a headless Chromium.
+package preview
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+//go:embed github.css
+var githubCSS string
+
+// ErrUnavailable is returned when no Chromium binary is installed.
+var ErrUnavailable = errors.New("headless browser not available")
+
+const (
+	cacheTTL      = time.Hour
+	renderTimeout = 30 * time.Second
+	// maxConcurrentRenders bounds how many browsers run at once.
+	maxConcurrentRenders = 2
+	// initialViewportHeight only affects layout before the full-page capture.
+	initialViewportHeight = 600
+)
+
+// browserNames are the executables tried, in order, when locating Chromium.
+var browserNames = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "headless-shell"}
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	// Profiles rely on raw HTML such as <div align="center">; renderHTML
+	// passes the output through sanitizeHTML
+	goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+)
+
+type cachedImage struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// Renderer turns markdown into PNG screenshots and caches them in memory.
+type Renderer struct {
+	execPath   string
+	slots      chan struct{}
+	assetHosts map[string]bool
+
+	mu    sync.Mutex
+	cache map[string]cachedImage
+}
+
+// NewRenderer locates a Chromium binary on PATH. Rendering reports
+// ErrUnavailable when none is found. Pages may only load images over https
+// from assetHosts; the markdown is user-controlled, so every other request
+// is blocked to keep it from reaching internal addresses.
+func NewRenderer(assetHosts map[string]bool) *Renderer {
+	r := &Renderer{
+		slots:      make(chan struct{}, maxConcurrentRenders),
+		assetHosts: assetHosts,
+		cache:      make(map[string]cachedImage),
+	}
+	for _, name := range browserNames {
+		if path, err := exec.LookPath(name); err == nil {
+			r.execPath = path
+			break
+		}
+	}
+	return r
+}
+
+func (r *Renderer) Available() bool {
+	return r.execPath != ""
+}
+
+// RenderPNG returns a full-page PNG of md rendered width pixels wide. Results
+// are cached for an hour under cacheKey and width, so callers should pass a
+// key that changes whenever md does.
+func (r *Renderer) RenderPNG(ctx context.Context, cacheKey, md string, width int) ([]byte, error) {
+	if !r.Available() {
+		return nil, ErrUnavailable
+	}
+
+	key := cacheKey + ":" + strconv.Itoa(width)
+	if data, ok := r.cached(key); ok {
+		return data, nil
+	}
+
+	page, err := renderHTML(md)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	data, err := r.screenshot(ctx, page, width)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store(key, data)
+	return data, nil
+}
+
+func (r *Renderer) screenshot(ctx context.Context, page string, width int) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.ExecPath(r.execPath))
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancelAlloc()
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	defer cancelBrowser()
+
+	// Every request pauses until allowed or failed. chromedp disables
+	// site-per-process, so iframes load in the page's process and pause too.
+	chromedp.ListenTarget(browserCtx, func(ev interface{}) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		// Listeners must not block, so the reply is sent from a goroutine
+		go func() {
+			var reply chromedp.Action = fetch.FailRequest(paused.RequestID, network.ErrorReasonBlockedByClient)
+			if r.allowedURL(paused.Request.URL) {
+				reply = fetch.ContinueRequest(paused.RequestID)
+			}
+			_ = chromedp.Run(browserCtx, reply)
+		}()
+	})
+
+	var buf []byte
+	// Navigate waits for the load event, so badge images are in place
+	err := chromedp.Run(browserCtx,
+		fetch.Enable(),
+		emulation.SetScriptExecutionDisabled(true),
+		chromedp.EmulateViewport(int64(width), initialViewportHeight),
+		chromedp.Navigate("data:text/html;base64,"+base64.StdEncoding.EncodeToString([]byte(page))),
+		chromedp.FullScreenshot(&buf, 100),
+	)
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return nil, ErrUnavailable
+		}
+		return nil, fmt.Errorf("failed to render preview: %w", err)
+	}
+	return buf, nil
+}
+
+// allowedURL reports whether a page may load rawURL: a data: URL, as the
+// page itself is, or https from an asset host. IP literals are never allowed, so
+// loopback, private and link-local addresses such as cloud metadata
+// endpoints can't be reached even if listed.
+func (r *Renderer) allowedURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "data" {
+		return true
+	}
+	host := u.Hostname()
+	if u.Scheme != "https" || net.ParseIP(host) != nil {
+		return false
+	}
+	return r.assetHosts[host]
+}
+
+// renderHTML wraps the markdown's sanitized HTML in a page styled like
+// github.com.
+func renderHTML(md string) (string, error) {
+	var body bytes.Buffer
+	if err := markdown.Convert([]byte(md), &body); err != nil {
+		return "", fmt.Errorf("failed to convert markdown: %w", err)
+	}
+	return fmt.Sprintf(
+		"<!DOCTYPE html><html><head><meta charset=\"utf-8\"><style>%s</style></head><body><article class=\"markdown-body\">%s</article></body></html>",
+		githubCSS, sanitizeHTML(body.String()),
+	), nil
+}
+
+func (r *Renderer) cached(key string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.data, true
+}
+
+func (r *Renderer) store(key string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range r.cache {
+		if now.After(entry.expiresAt) {
+			delete(r.cache, k)
+		}
+	}
+	r.cache[key] = cachedImage{data: data, expiresAt: now.Add(cacheTTL)}
+}
@@ -0,0 +1,144 @@
+package preview
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags are the elements kept in rendered markdown, roughly those
+// GitHub keeps in READMEs. Other elements are dropped and their text kept.
+var allowedTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "blockquote": true, "br": true, "caption": true,
+	"code": true, "dd": true, "del": true, "details": true, "div": true, "dl": true,
+	"dt": true, "em": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "hr": true, "i": true, "img": true, "input": true, "ins": true, "kbd": true,
+	"li": true, "ol": true, "p": true, "picture": true, "pre": true, "q": true, "s": true,
+	"samp": true, "source": true, "span": true, "strike": true, "strong": true, "sub": true,
+	"summary": true, "sup": true, "table": true, "tbody": true, "td": true, "tfoot": true,
+	"th": true, "thead": true, "tr": true, "tt": true, "ul": true, "var": true,
+}
+
+// droppedContentTags are removed along with everything inside them.
+var droppedContentTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "noscript": true, "noembed": true, "noframes": true,
+	"template": true, "svg": true, "math": true, "textarea": true, "title": true,
+	"xmp": true, "select": true, "head": true,
+}
+
+// allowedAttrs are kept on any allowed element. URL attributes are checked
+// separately by urlAttrs.
+var allowedAttrs = map[string]bool{
+	"align": true, "alt": true, "title": true, "width": true, "height": true,
+	"colspan": true, "rowspan": true, "open": true, "name": true, "id": true,
+	"type": true, "checked": true, "disabled": true, "media": true,
+}
+
+// urlAttrs hold a URL and are kept only when linkScheme allows it. srcset
+// and style are always dropped: they can load URLs this check can't see.
+var urlAttrs = map[string]bool{"href": true, "src": true}
+
+// sanitizeHTML keeps only allowedTags and allowedAttrs of fragment, so raw
+// HTML in markdown can't embed frames, scripts, forms or CSS that fetch
+// other URLs. The renderer's request filter still guards every fetch.
+func sanitizeHTML(fragment string) string {
+	var out bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	skipping := ""
+	depth := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return ""
+			}
+			return out.String()
+		}
+		tok := z.Token()
+
+		if skipping != "" {
+			switch {
+			case tt == html.StartTagToken && tok.Data == skipping:
+				depth++
+			case tt == html.EndTagToken && tok.Data == skipping:
+				if depth--; depth == 0 {
+					skipping = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			out.WriteString(html.EscapeString(tok.Data))
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			if droppedContentTags[tok.Data] {
+				if tt == html.StartTagToken {
+					skipping, depth = tok.Data, 1
+				}
+				continue
+			}
+			if !allowedTags[tok.Data] || (tok.Data == "input" && !isCheckbox(tok.Attr)) {
+				continue
+			}
+			tok.Attr = sanitizeAttrs(tok.Data, tok.Attr)
+			out.WriteString(tok.String())
+		}
+		// Comments and doctypes are dropped
+	}
+}
+
+func sanitizeAttrs(tag string, attrs []html.Attribute) []html.Attribute {
+	kept := attrs[:0]
+	for _, a := range attrs {
+		key := strings.ToLower(a.Key)
+		switch {
+		case a.Namespace != "":
+			continue
+		case urlAttrs[key]:
+			if !linkScheme(a.Val, key == "href") {
+				continue
+			}
+		case !allowedAttrs[key]:
+			continue
+		case key == "type" && tag != "input":
+			continue
+		}
+		kept = append(kept, html.Attribute{Key: key, Val: a.Val})
+	}
+	return kept
+}
+
+// isCheckbox reports whether an input's attributes make it a checkbox, as
+// GFM task list items are. Other inputs are dropped.
+func isCheckbox(attrs []html.Attribute) bool {
+	for _, a := range attrs {
+		if strings.EqualFold(a.Key, "type") {
+			return strings.EqualFold(a.Val, "checkbox")
+		}
+	}
+	return false
+}
+
+// linkScheme reports whether rawURL may be kept: https, http, or for links
+// also mailto and fragment or relative references.
+func linkScheme(rawURL string, isLink bool) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return isLink
+	case "":
+		return isLink && u.Host == ""
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "layout kept",
+			in:   `<div align="center"><img src="https://img.shields.io/badge/Go-blue" alt="Go" width="40"></div>`,
+			want: `<div align="center"><img src="https://img.shields.io/badge/Go-blue" alt="Go" width="40"></div>`,
+		},
+		{
+			name: "iframe dropped with content",
+			in:   `<p>a<iframe src="http://169.254.169.254/latest/meta-data/">x</iframe>b</p>`,
+			want: `<p>ab</p>`,
+		},
+		{
+			name: "script and style dropped",
+			in:   `<script>fetch("http://10.0.0.1")</script><style>body{background:url(http://10.0.0.1)}</style>ok`,
+			want: `ok`,
+		},
+		{
+			name: "style and srcset attributes dropped",
+			in:   `<span style="background:url(http://10.0.0.1)">a</span><img srcset="http://10.0.0.1/x 2x">`,
+			want: `<span>a</span><img>`,
+		},
+		{
+			name: "javascript link dropped",
+			in:   `<a href="javascript:alert(1)">x</a><a href="#top">y</a>`,
+			want: `<a>x</a><a href="#top">y</a>`,
+		},
+		{
+			name: "protocol-relative image dropped",
+			in:   `<img src="//internal.example/x.png">`,
+			want: `<img>`,
+		},
+		{
+			name: "unknown elements unwrapped",
+			in:   `<form action="http://10.0.0.1"><input type="text" name="q">keep</form><input type="checkbox" checked disabled>`,
+			want: `keep<input type="checkbox" checked="" disabled="">`,
+		},
+		{
+			name: "nested dropped content",
+			in:   `<svg><svg><image href="http://10.0.0.1"/></svg></svg>after<!-- comment -->`,
+			want: `after`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeHTML(tt.in); got != tt.want {
+				t.Errorf("sanitizeHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHTMLSanitizes(t *testing.T) {
+	page, err := renderHTML("# Hi\n\n<iframe src=\"http://169.254.169.254/\"></iframe>\n\n[x](javascript:alert(1))\n")
+	if err != nil {
+		t.Fatalf("renderHTML: %v", err)
+	}
+	for _, bad := range []string{"iframe", "169.254.169.254", "javascript:"} {
+		if strings.Contains(page, bad) {
+			t.Errorf("rendered page contains %q", bad)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+type ABTestRepository struct {
+	db *sql.DB
+}
+
+func NewABTestRepository(db *sql.DB) *ABTestRepository {
+	return &ABTestRepository{db: db}
+}
+
+// Create stores both variants and the test pairing them. The requests' API
+// keys are never persisted.
+func (r *ABTestRepository) Create(
+	ctx context.Context,
+	userID int64,
+	testName string,
+	reqA, reqB *models.ContentGenerationRequest,
+	respA, respB *models.ContentGenerationResponse,
+) (*models.ABTest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	variantA, err := insertABTestVariant(ctx, tx, userID, reqA, respA)
+	if err != nil {
+		return nil, err
+	}
+	variantB, err := insertABTestVariant(ctx, tx, userID, reqB, respB)
+	if err != nil {
+		return nil, err
+	}
+
+	test := &models.ABTest{
+		UserID:   userID,
+		TestName: testName,
+		VariantA: variantA,
+		VariantB: variantB,
+	}
+	query := `
+		INSERT INTO ab_tests (user_id, test_name, variant_a_id, variant_b_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at
+	`
+	err = tx.QueryRowContext(ctx, query, userID, testName, variantA.ID, variantB.ID).Scan(&test.ID, &test.Status, &test.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create A/B test: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit A/B test: %w", err)
+	}
+	return test, nil
+}
+
+func insertABTestVariant(ctx context.Context, tx *sql.Tx, userID int64, req *models.ContentGenerationRequest, resp *models.ContentGenerationResponse) (models.ABTestVariant, error) {
+	stored := *req
+	stored.UserAPIKey = ""
+	requestJSON, err := json.Marshal(stored)
+	if err != nil {
+		return models.ABTestVariant{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	responseJSON, err := json.Marshal(resp)
+	if err != nil {
+		return models.ABTestVariant{}, fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	variant := models.ABTestVariant{TargetRole: req.TargetRole, ToneOfVoice: req.ToneOfVoice}
+	query := `
+		INSERT INTO ab_test_variants (user_id, request, response)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	if err := tx.QueryRowContext(ctx, query, userID, requestJSON, responseJSON).Scan(&variant.ID); err != nil {
+		return models.ABTestVariant{}, fmt.Errorf("failed to create A/B test variant: %w", err)
+	}
+	return variant, nil
+}
+
+// SelectVariant records the user's preferred variant, replacing any earlier
+// choice. It returns false if the user has no such test.
+func (r *ABTestRepository) SelectVariant(ctx context.Context, userID, testID int64, variant string) (bool, error) {
+	query := `
+		UPDATE ab_tests
+		SET selected_variant = $3, status = 'completed', selected_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`
+	result, err := r.db.ExecContext(ctx, query, testID, userID, variant)
+	if err != nil {
+		return false, fmt.Errorf("failed to select A/B test variant: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to select A/B test variant: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// ListByUserID returns the user's tests, newest first.
+func (r *ABTestRepository) ListByUserID(ctx context.Context, userID int64) ([]*models.ABTest, error) {
+	query := `
+		SELECT
+			t.id, t.test_name, t.status, COALESCE(t.selected_variant, ''), t.selected_at, t.created_at,
+			a.id, COALESCE(a.request->>'target_role', ''), COALESCE(a.request->>'tone_of_voice', ''),
+			b.id, COALESCE(b.request->>'target_role', ''), COALESCE(b.request->>'tone_of_voice', '')
+		FROM ab_tests t
+		JOIN ab_test_variants a ON a.id = t.variant_a_id
+		JOIN ab_test_variants b ON b.id = t.variant_b_id
+		WHERE t.user_id = $1
+		ORDER BY t.created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list A/B tests: %w", err)
+	}
+	defer rows.Close()
+
+	tests := []*models.ABTest{}
+	for rows.Next() {
+		t := &models.ABTest{UserID: userID}
+		if err := rows.Scan(
+			&t.ID, &t.TestName, &t.Status, &t.SelectedVariant, &t.SelectedAt, &t.CreatedAt,
+			&t.VariantA.ID, &t.VariantA.TargetRole, &t.VariantA.ToneOfVoice,
+			&t.VariantB.ID, &t.VariantB.TargetRole, &t.VariantB.ToneOfVoice,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan A/B test: %w", err)
+		}
+		tests = append(tests, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list A/B tests: %w", err)
+	}
+	return tests, nil
+}
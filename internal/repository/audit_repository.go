@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Log(ctx context.Context, entry models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_logs (user_id, action, entity_type, entity_id, old_value, new_value, ip_address, user_agent)
+		VALUES (NULLIF($1::bigint, 0), $2, NULLIF($3, ''), NULLIF($4::bigint, 0), $5, $6, $7::inet, NULLIF($8, ''))
+	`
+
+	// Unparseable addresses are stored as NULL rather than failing the insert
+	var ip sql.NullString
+	if net.ParseIP(entry.IPAddress) != nil {
+		ip = sql.NullString{String: entry.IPAddress, Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		entry.UserID,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		nullableJSON(entry.OldValue),
+		nullableJSON(entry.NewValue),
+		ip,
+		entry.UserAgent,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// List returns audit entries newest first, scoped to userID unless it is 0,
+// along with the total number of matching entries.
+func (r *AuditRepository) List(ctx context.Context, userID int64, limit, offset int) ([]*models.AuditEntry, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM audit_logs WHERE $1::bigint = 0 OR user_id = $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	query := `
+		SELECT id, COALESCE(user_id, 0), action, COALESCE(entity_type, ''), COALESCE(entity_id, 0),
+		       old_value, new_value, COALESCE(HOST(ip_address), ''), COALESCE(user_agent, ''), created_at
+		FROM audit_logs
+		WHERE $1::bigint = 0 OR user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		e := &models.AuditEntry{}
+		var oldValue, newValue []byte
+		if err := rows.Scan(
+			&e.ID, &e.UserID, &e.Action, &e.EntityType, &e.EntityID,
+			&oldValue, &newValue, &e.IPAddress, &e.UserAgent, &e.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		e.OldValue = oldValue
+		e.NewValue = newValue
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+func nullableJSON(v []byte) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// CacheLookup is the outcome of the last profile or repository analysis
+// cache read made with a context from WithCacheLookup.
+type CacheLookup struct {
+	Consulted bool
+	Hit       bool
+	Key       string
+	// Age is how long ago the entry was stored; zero on a miss.
+	Age time.Duration
+}
+
+type cacheLookupKey struct{}
+
+// WithCacheLookup returns a context whose cache reads are recorded in the
+// returned CacheLookup, so handlers can tell callers whether they were
+// served from cache.
+func WithCacheLookup(ctx context.Context) (context.Context, *CacheLookup) {
+	lookup := &CacheLookup{}
+	return context.WithValue(ctx, cacheLookupKey{}, lookup), lookup
+}
+
+func recordCacheLookup(ctx context.Context, key string, hit bool, age time.Duration) {
+	lookup, ok := ctx.Value(cacheLookupKey{}).(*CacheLookup)
+	if !ok {
+		return
+	}
+	*lookup = CacheLookup{Consulted: true, Hit: hit, Key: key, Age: max(age, 0)}
+}
+
+// HashedKey returns the key's first two segments, e.g. "profile:v5" or
+// "analysis:global", followed by a hash of the whole key, so it can be shown
+// without revealing the username or IDs it contains.
+func (l *CacheLookup) HashedKey() string {
+	sum := sha256.Sum256([]byte(l.Key))
+	parts := strings.SplitN(l.Key, ":", 3)
+	return strings.Join(parts[:min(len(parts), 2)], ":") + ":" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+type CooldownRepository struct {
+	db *sql.DB
+}
+
+func NewCooldownRepository(db *sql.DB) *CooldownRepository {
+	return &CooldownRepository{db: db}
+}
+
+// Get returns the user's last generation, or nil if they have none.
+func (r *CooldownRepository) Get(ctx context.Context, userID int64) (*models.GenerationCooldown, error) {
+	query := `
+		SELECT user_id, last_generated_at, count_today
+		FROM generation_cooldowns
+		WHERE user_id = $1
+	`
+	cooldown := &models.GenerationCooldown{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&cooldown.UserID, &cooldown.LastGeneratedAt, &cooldown.CountToday)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get generation cooldown: %w", err)
+	}
+	return cooldown, nil
+}
+
+// Record stores a generation made now. count_today starts over on the first
+// generation of a new UTC day.
+func (r *CooldownRepository) Record(ctx context.Context, userID int64) error {
+	query := `
+		INSERT INTO generation_cooldowns (user_id, last_generated_at, count_today)
+		VALUES ($1, NOW(), 1)
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+			count_today = CASE
+				WHEN (generation_cooldowns.last_generated_at AT TIME ZONE 'UTC')::date = (NOW() AT TIME ZONE 'UTC')::date
+				THEN generation_cooldowns.count_today + 1
+				ELSE 1
+			END,
+			last_generated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record generation: %w", err)
+	}
+	return nil
+}
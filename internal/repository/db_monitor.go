@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	dbPingInterval     = 30 * time.Second
+	dbPingTimeout      = 5 * time.Second
+	dbReconnectBackoff = 5 * time.Second
+
+	// dbMaxPingFailures is how many pings in a row must fail before the
+	// connection is considered lost.
+	dbMaxPingFailures = 3
+)
+
+// DBConnectionState is the database connection as last seen by the monitor.
+type DBConnectionState struct {
+	Connected           bool      `json:"connected"`
+	LastPingAt          time.Time `json:"last_ping_at"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// DBHealthMonitor pings the database in the background and reconnects when
+// it goes away.
+type DBHealthMonitor interface {
+	State() DBConnectionState
+	Stop()
+}
+
+type dbMonitor struct {
+	db       *sql.DB
+	maxIdle  int
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu    sync.RWMutex
+	state DBConnectionState
+}
+
+// newDBMonitor starts monitoring db, which must have just been pinged.
+// maxIdle is the pool's configured idle limit, restored after a reconnect.
+func newDBMonitor(db *sql.DB, maxIdle int) *dbMonitor {
+	m := &dbMonitor{
+		db:      db,
+		maxIdle: maxIdle,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		state:   DBConnectionState{Connected: true, LastPingAt: time.Now()},
+	}
+	go m.run()
+	return m
+}
+
+func (m *dbMonitor) State() DBConnectionState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Stop ends monitoring, including any reconnect in progress, and waits for
+// the goroutine to exit.
+func (m *dbMonitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+func (m *dbMonitor) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(dbPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if m.ping() || m.State().ConsecutiveFailures < dbMaxPingFailures {
+			continue
+		}
+
+		slog.Error("Database connection lost, attempting reconnect")
+		if !m.reconnect() {
+			return
+		}
+		slog.Info("Database connection restored")
+	}
+}
+
+// ping checks the database and records the result.
+func (m *dbMonitor) ping() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), dbPingTimeout)
+	defer cancel()
+	err := m.db.PingContext(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state.LastPingAt = time.Now()
+	if err != nil {
+		m.state.ConsecutiveFailures++
+		if m.state.ConsecutiveFailures >= dbMaxPingFailures {
+			m.state.Connected = false
+		}
+		slog.Warn("Database ping failed", "consecutive_failures", m.state.ConsecutiveFailures, "error", err)
+		return false
+	}
+	m.state.Connected = true
+	m.state.ConsecutiveFailures = 0
+	return true
+}
+
+// reconnect retries until the database answers again, returning false if
+// the monitor was stopped first. Every repository shares this *sql.DB, so
+// rather than closing and reopening it, the pool's idle connections (dead
+// after a Postgres restart) are dropped and replaced with fresh ones.
+func (m *dbMonitor) reconnect() bool {
+	for {
+		m.db.SetMaxIdleConns(0)
+		m.db.SetMaxIdleConns(m.maxIdle)
+		if m.ping() {
+			return true
+		}
+
+		select {
+		case <-m.stop:
+			return false
+		case <-time.After(dbReconnectBackoff):
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+type EmailLoginRepository struct {
+	db *sql.DB
+}
+
+func NewEmailLoginRepository(db *sql.DB) *EmailLoginRepository {
+	return &EmailLoginRepository{db: db}
+}
+
+// Get returns the unexpired login code for email, or nil if there is none.
+func (r *EmailLoginRepository) Get(ctx context.Context, email string) (*models.EmailLoginCode, error) {
+	query := `
+		SELECT email, code_hash, attempts, created_at, expires_at
+		FROM email_login_codes
+		WHERE email = $1 AND expires_at > NOW()
+	`
+	code := &models.EmailLoginCode{}
+	err := r.db.QueryRowContext(ctx, query, email).Scan(&code.Email, &code.CodeHash, &code.Attempts, &code.CreatedAt, &code.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login code: %w", err)
+	}
+	return code, nil
+}
+
+// Set replaces any code for email, resetting the attempt count.
+func (r *EmailLoginRepository) Set(ctx context.Context, email, codeHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO email_login_codes (email, code_hash, attempts, created_at, expires_at)
+		VALUES ($1, $2, 0, NOW(), $3)
+		ON CONFLICT (email) DO UPDATE
+		SET code_hash = $2, attempts = 0, created_at = NOW(), expires_at = $3
+	`
+	if _, err := r.db.ExecContext(ctx, query, email, codeHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to store login code: %w", err)
+	}
+	return nil
+}
+
+// ClaimAttempt counts a verification attempt against email's unexpired
+// code and returns the code's hash and the new attempt count. ok is false,
+// and nothing is counted, when there is no such code or maxAttempts were
+// already made. Counting before the code is compared keeps concurrent
+// guesses within maxAttempts.
+func (r *EmailLoginRepository) ClaimAttempt(ctx context.Context, email string, maxAttempts int) (codeHash string, attempts int, ok bool, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		UPDATE email_login_codes SET attempts = attempts + 1
+		WHERE email = $1 AND attempts < $2 AND expires_at > NOW()
+		RETURNING code_hash, attempts
+	`, email, maxAttempts).Scan(&codeHash, &attempts)
+	if err == sql.ErrNoRows {
+		return "", 0, false, nil
+	}
+	if err != nil {
+		return "", 0, false, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return codeHash, attempts, true, nil
+}
+
+func (r *EmailLoginRepository) Delete(ctx context.Context, email string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM email_login_codes WHERE email = $1`, email); err != nil {
+		return fmt.Errorf("failed to delete login code: %w", err)
+	}
+	return nil
+}
+
+// GetLimit returns the events counted for subject, or nil if there are none.
+func (r *EmailLoginRepository) GetLimit(ctx context.Context, subject string) (*models.EmailLoginLimit, error) {
+	query := `
+		SELECT subject, last_at, count_today
+		FROM email_login_limits
+		WHERE subject = $1
+	`
+	limit := &models.EmailLoginLimit{}
+	err := r.db.QueryRowContext(ctx, query, subject).Scan(&limit.Subject, &limit.LastAt, &limit.CountToday)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email login limit: %w", err)
+	}
+	return limit, nil
+}
+
+// RecordLimit counts an event now for subject and returns the day's count,
+// this one included. count_today starts over on the first event of a new
+// UTC day.
+func (r *EmailLoginRepository) RecordLimit(ctx context.Context, subject string) (int, error) {
+	query := `
+		INSERT INTO email_login_limits (subject, last_at, count_today)
+		VALUES ($1, NOW(), 1)
+		ON CONFLICT (subject) DO UPDATE
+		SET
+			count_today = CASE
+				WHEN (email_login_limits.last_at AT TIME ZONE 'UTC')::date = (NOW() AT TIME ZONE 'UTC')::date
+				THEN email_login_limits.count_today + 1
+				ELSE 1
+			END,
+			last_at = NOW()
+		RETURNING count_today
+	`
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, subject).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to record email login limit: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+type MetricsRepository struct {
+	db *sql.DB
+}
+
+func NewMetricsRepository(db *sql.DB) *MetricsRepository {
+	return &MetricsRepository{db: db}
+}
+
+// RecordProfileEvent stores a profile event. duration is only stored for
+// LLM generations.
+func (r *MetricsRepository) RecordProfileEvent(ctx context.Context, userID int64, eventType string, duration time.Duration) error {
+	var durationMs sql.NullInt64
+	if eventType == models.ProfileEventGenerated {
+		durationMs = sql.NullInt64{Int64: duration.Milliseconds(), Valid: true}
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO profile_events (user_id, event_type, duration_ms) VALUES ($1, $2, $3)`,
+		userID, eventType, durationMs,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record profile event: %w", err)
+	}
+	return nil
+}
+
+// RecordLLMUsage stores the token counts of one LLM request.
+func (r *MetricsRepository) RecordLLMUsage(ctx context.Context, userID int64, promptTokens, outputTokens int) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO llm_usage (user_id, prompt_tokens, output_tokens) VALUES ($1, $2, $3)`,
+		userID, promptTokens, outputTokens,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record LLM usage: %w", err)
+	}
+	return nil
+}
+
+// GetSystemMetrics aggregates users, profile events and LLM usage.
+// GitHubAPICallsToday is not stored in the database and is left zero.
+func (r *MetricsRepository) GetSystemMetrics(ctx context.Context) (*models.SystemMetrics, error) {
+	query := `
+		WITH today AS (
+			SELECT date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS start
+		),
+		events_today AS (
+			SELECT
+				COUNT(*) FILTER (WHERE event_type = 'generated') AS generated,
+				COUNT(*) FILTER (WHERE event_type = 'cache_hit') AS cache_hits,
+				COUNT(*) FILTER (WHERE event_type = 'deployed') AS deployed,
+				COALESCE(AVG(duration_ms) FILTER (WHERE event_type = 'generated'), 0) AS avg_duration_ms
+			FROM profile_events, today
+			WHERE created_at >= today.start
+		),
+		events_1h AS (
+			SELECT
+				COUNT(*) FILTER (WHERE event_type = 'failed') AS failed,
+				COUNT(*) FILTER (WHERE event_type IN ('generated', 'failed')) AS attempts
+			FROM profile_events
+			WHERE created_at >= NOW() - INTERVAL '1 hour'
+		)
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM users WHERE last_login_at >= NOW() - INTERVAL '7 days'),
+			e.generated,
+			e.deployed,
+			CASE WHEN e.generated + e.cache_hits > 0
+				THEN e.cache_hits * 100.0 / (e.generated + e.cache_hits) ELSE 0 END,
+			e.avg_duration_ms,
+			(SELECT COALESCE(SUM(prompt_tokens + output_tokens), 0) FROM llm_usage, today WHERE created_at >= today.start),
+			CASE WHEN h.attempts > 0 THEN h.failed * 100.0 / h.attempts ELSE 0 END
+		FROM events_today e, events_1h h
+	`
+
+	m := &models.SystemMetrics{}
+	err := r.db.QueryRowContext(ctx, query).Scan(
+		&m.TotalUsers,
+		&m.ActiveUsersLast7Days,
+		&m.ProfilesGeneratedToday,
+		&m.ProfilesDeployedToday,
+		&m.CacheHitRatePct,
+		&m.AvgGenerationTimeMs,
+		&m.LLMTokensUsedToday,
+		&m.ErrorRate1hPct,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system metrics: %w", err)
+	}
+	return m, nil
+}
@@ -8,10 +8,12 @@ import (
 	_ "github.com/lib/pq"
 )
 
-func NewPostgresDB(cfg config.DatabaseConfig) (*sql.DB, error) {
+// NewPostgresDB opens the connection pool and starts a DBHealthMonitor for it.
+// Stop the monitor before closing the pool.
+func NewPostgresDB(cfg config.DatabaseConfig) (*sql.DB, DBHealthMonitor, error) {
 	db, err := sql.Open("postgres", cfg.URL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
@@ -19,8 +21,8 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*sql.DB, error) {
 	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return db, nil
+	return db, newDBMonitor(db, cfg.MaxIdleConns), nil
 }
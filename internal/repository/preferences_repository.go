@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+type PreferencesRepository struct {
+	db *sql.DB
+}
+
+func NewPreferencesRepository(db *sql.DB) *PreferencesRepository {
+	return &PreferencesRepository{db: db}
+}
+
+// GetByUserID returns the user's preferences, or defaults when none are stored.
+func (r *PreferencesRepository) GetByUserID(ctx context.Context, userID int64) (*models.UserPreferences, error) {
+	query := `
+		SELECT user_id, COALESCE(regeneration_schedule, ''), COALESCE(auto_deploy, FALSE),
+		       COALESCE(template_variables, '{}'::jsonb), COALESCE(typing_svg, '{}'::jsonb),
+		       COALESCE(layout_config, '{}'::jsonb), showcase_opt_in, created_at, updated_at
+		FROM user_preferences
+		WHERE user_id = $1
+	`
+	prefs := &models.UserPreferences{}
+	var templateVarsJSON, typingSVGJSON, layoutJSON []byte
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&prefs.UserID, &prefs.RegenerationSchedule, &prefs.AutoDeploy, &templateVarsJSON, &typingSVGJSON, &layoutJSON, &prefs.ShowcaseOptIn, &prefs.CreatedAt, &prefs.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &models.UserPreferences{UserID: userID, TemplateVariables: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user preferences: %w", err)
+	}
+
+	if err := json.Unmarshal(templateVarsJSON, &prefs.TemplateVariables); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template variables: %w", err)
+	}
+	if err := json.Unmarshal(typingSVGJSON, &prefs.TypingSVG); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal typing SVG config: %w", err)
+	}
+	if err := json.Unmarshal(layoutJSON, &prefs.Layout); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal layout config: %w", err)
+	}
+	return prefs, nil
+}
+
+func (r *PreferencesRepository) UpsertSchedule(ctx context.Context, userID int64, schedule string, autoDeploy bool) error {
+	query := `
+		INSERT INTO user_preferences (user_id, regeneration_schedule, auto_deploy)
+		VALUES ($1, NULLIF($2, ''), $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+			regeneration_schedule = EXCLUDED.regeneration_schedule,
+			auto_deploy = EXCLUDED.auto_deploy
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, schedule, autoDeploy)
+	if err != nil {
+		return fmt.Errorf("failed to update regeneration schedule: %w", err)
+	}
+	return nil
+}
+
+// UpsertTemplateVariables replaces the user's template variable overrides.
+func (r *PreferencesRepository) UpsertTemplateVariables(ctx context.Context, userID int64, vars map[string]string) error {
+	varsJSON, err := json.Marshal(vars)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template variables: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_preferences (user_id, template_variables)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET template_variables = EXCLUDED.template_variables
+	`
+	_, err = r.db.ExecContext(ctx, query, userID, varsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update template variables: %w", err)
+	}
+	return nil
+}
+
+// UpsertTypingSVG replaces the user's typing SVG configuration.
+func (r *PreferencesRepository) UpsertTypingSVG(ctx context.Context, userID int64, cfg models.TypingSVGConfig) error {
+	cfgJSON, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal typing SVG config: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_preferences (user_id, typing_svg)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET typing_svg = EXCLUDED.typing_svg
+	`
+	_, err = r.db.ExecContext(ctx, query, userID, cfgJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update typing SVG config: %w", err)
+	}
+	return nil
+}
+
+// UpsertLayout replaces the user's section layout.
+func (r *PreferencesRepository) UpsertLayout(ctx context.Context, userID int64, layout models.ProfileLayout) error {
+	layoutJSON, err := json.Marshal(layout)
+	if err != nil {
+		return fmt.Errorf("failed to marshal layout config: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_preferences (user_id, layout_config)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET layout_config = EXCLUDED.layout_config
+	`
+	_, err = r.db.ExecContext(ctx, query, userID, layoutJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update layout config: %w", err)
+	}
+	return nil
+}
+
+// UpsertShowcaseOptIn sets whether the user's deployed profile is listed in
+// the public showcase.
+func (r *PreferencesRepository) UpsertShowcaseOptIn(ctx context.Context, userID int64, optIn bool) error {
+	query := `
+		INSERT INTO user_preferences (user_id, showcase_opt_in)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET showcase_opt_in = EXCLUDED.showcase_opt_in
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, optIn)
+	if err != nil {
+		return fmt.Errorf("failed to update showcase opt-in: %w", err)
+	}
+	return nil
+}
+
+// ListScheduled returns preferences for every user with a regeneration schedule.
+func (r *PreferencesRepository) ListScheduled(ctx context.Context) ([]*models.UserPreferences, error) {
+	query := `
+		SELECT user_id, regeneration_schedule, COALESCE(auto_deploy, FALSE), created_at, updated_at
+		FROM user_preferences
+		WHERE regeneration_schedule IS NOT NULL
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list regeneration schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var prefs []*models.UserPreferences
+	for rows.Next() {
+		p := &models.UserPreferences{}
+		if err := rows.Scan(&p.UserID, &p.RegenerationSchedule, &p.AutoDeploy, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, rows.Err()
+}
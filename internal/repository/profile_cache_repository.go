@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/krauzx/gitright/internal/models"
@@ -18,9 +22,16 @@ func NewProfileCacheRepository(db *sql.DB) *ProfileCacheRepository {
 	return &ProfileCacheRepository{db: db}
 }
 
-func (r *ProfileCacheRepository) Get(ctx context.Context, cacheKey string) (*models.ContentGenerationResponse, error) {
+// ProfileStaleWindow is how long before expiry a cached profile turns stale:
+// it is still served, but should be regenerated in the background.
+const ProfileStaleWindow = 4 * time.Hour
+
+// Get returns the unexpired profile cached under cacheKey, or nil. needsRefresh
+// is set once the profile is past its stale_at.
+func (r *ProfileCacheRepository) Get(ctx context.Context, cacheKey string) (response *models.ContentGenerationResponse, needsRefresh bool, err error) {
 	query := `
-		SELECT content
+		SELECT content, EXTRACT(EPOCH FROM NOW() - COALESCE(cached_at, created_at)),
+		       COALESCE(stale_at <= NOW(), FALSE)
 		FROM generated_profiles
 		WHERE cache_key = $1
 		  AND expires_at > NOW()
@@ -28,46 +39,99 @@ func (r *ProfileCacheRepository) Get(ctx context.Context, cacheKey string) (*mod
 	`
 
 	var contentJSON string
-	err := r.db.QueryRowContext(ctx, query, cacheKey).Scan(&contentJSON)
+	var ageSeconds float64
+	err = r.db.QueryRowContext(ctx, query, cacheKey).Scan(&contentJSON, &ageSeconds, &needsRefresh)
 	if err == sql.ErrNoRows {
-		return nil, nil
+		recordCacheLookup(ctx, cacheKey, false, 0)
+		return nil, false, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cached profile: %w", err)
+		return nil, false, fmt.Errorf("failed to get cached profile: %w", err)
 	}
 
-	var response models.ContentGenerationResponse
 	if err := json.Unmarshal([]byte(contentJSON), &response); err != nil {
-		return nil, nil
+		return nil, false, nil
 	}
+	recordCacheLookup(ctx, cacheKey, true, time.Duration(ageSeconds*float64(time.Second)))
 
 	go r.updateCacheStats(context.Background(), cacheKey)
 
-	return &response, nil
+	return response, needsRefresh, nil
 }
 
-func (r *ProfileCacheRepository) Set(ctx context.Context, userID, configID int64, cacheKey string, response *models.ContentGenerationResponse, ttl time.Duration) error {
+// ClaimRefresh reports whether the caller may regenerate the stale profile
+// under cacheKey. The claim pushes stale_at lease into the future, so other
+// requests and instances serving the same stale profile don't regenerate it
+// too; if the refresh fails, the profile turns stale again after lease.
+func (r *ProfileCacheRepository) ClaimRefresh(ctx context.Context, cacheKey string, lease time.Duration) (bool, error) {
+	query := `
+		UPDATE generated_profiles
+		SET stale_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE cache_key = $1
+		  AND stale_at <= NOW()
+	`
+	result, err := r.db.ExecContext(ctx, query, cacheKey, lease.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim profile refresh: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim profile refresh: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Set stores a generated profile under cacheKey along with the request that
+// produced it and its top languages, for the showcase. The request's API key
+// is never persisted.
+func (r *ProfileCacheRepository) Set(ctx context.Context, userID, configID int64, cacheKey string, req *models.ContentGenerationRequest, response *models.ContentGenerationResponse, topLanguages []string, ttl time.Duration) error {
 	contentJSON, err := json.Marshal(response)
 	if err != nil {
 		return fmt.Errorf("failed to marshal response: %w", err)
 	}
+	if topLanguages == nil {
+		topLanguages = []string{}
+	}
+	languagesJSON, err := json.Marshal(topLanguages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal top languages: %w", err)
+	}
+
+	var requestJSON []byte
+	if req != nil {
+		stored := *req
+		stored.UserAPIKey = ""
+		requestJSON, err = json.Marshal(stored)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
 
-	expiresAt := time.Now().Add(ttl)
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	staleAt := expiresAt.Add(-ProfileStaleWindow)
+	if staleAt.Before(now) {
+		staleAt = now
+	}
 
 	query := `
 		INSERT INTO generated_profiles
-			(user_id, config_id, content, markdown_preview, cache_key, expires_at, version)
+			(user_id, config_id, content, markdown_preview, cache_key, expires_at, stale_at, version, last_generation_request, top_languages)
 		VALUES
-			($1, $2, $3, $4, $5, $6, 1)
+			($1, $2, $3, $4, $5, $6, $7, 1, $8, $9)
 		ON CONFLICT (cache_key) DO UPDATE
 		SET
 			content = EXCLUDED.content,
 			markdown_preview = EXCLUDED.markdown_preview,
 			expires_at = EXCLUDED.expires_at,
+			stale_at = EXCLUDED.stale_at,
+			last_generation_request = EXCLUDED.last_generation_request,
+			top_languages = EXCLUDED.top_languages,
+			cached_at = NOW(),
 			last_accessed_at = NOW()
 	`
 
-	_, err = r.db.ExecContext(ctx, query, userID, configID, string(contentJSON), response.Markdown, cacheKey, expiresAt)
+	_, err = r.db.ExecContext(ctx, query, userID, configID, string(contentJSON), response.Markdown, cacheKey, expiresAt, staleAt, requestJSON, languagesJSON)
 	if err != nil {
 		return fmt.Errorf("failed to set cached profile: %w", err)
 	}
@@ -75,6 +139,197 @@ func (r *ProfileCacheRepository) Set(ctx context.Context, userID, configID int64
 	return nil
 }
 
+// GetLastGenerationRequest returns the most recent stored generation request
+// for the user, or nil if none has been recorded.
+func (r *ProfileCacheRepository) GetLastGenerationRequest(ctx context.Context, userID int64) (*models.ContentGenerationRequest, error) {
+	query := `
+		SELECT last_generation_request
+		FROM generated_profiles
+		WHERE user_id = $1
+		  AND last_generation_request IS NOT NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var requestJSON []byte
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&requestJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last generation request: %w", err)
+	}
+
+	var req models.ContentGenerationRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode last generation request: %w", err)
+	}
+	return &req, nil
+}
+
+// Search returns the user's generated profiles matching query, best match
+// first. Content is not loaded; Excerpt holds a ts_headline snippet of the
+// markdown with matches wrapped in <mark>.
+func (r *ProfileCacheRepository) Search(ctx context.Context, userID int64, query string, limit int) ([]*models.GeneratedProfile, error) {
+	sqlQuery := `
+		SELECT
+			id, version, deployed, created_at,
+			COALESCE(last_generation_request->>'target_role', ''),
+			ts_headline('english', markdown_preview, plainto_tsquery('english', $2),
+				'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=25, MinWords=10')
+		FROM generated_profiles
+		WHERE user_id = $1
+		  AND content_search @@ plainto_tsquery('english', $2)
+		ORDER BY ts_rank(content_search, plainto_tsquery('english', $2)) DESC, created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, userID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []*models.GeneratedProfile
+	for rows.Next() {
+		p := &models.GeneratedProfile{UserID: userID}
+		if err := rows.Scan(&p.ID, &p.Version, &p.Deployed, &p.CreatedAt, &p.TargetRole, &p.Excerpt); err != nil {
+			return nil, fmt.Errorf("failed to scan profile: %w", err)
+		}
+		profiles = append(profiles, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// MarkDeployed records that the user's most recently stored profile with
+// this markdown was deployed. It reports false when no stored profile
+// matches, as for profiles generated with privacy options, which aren't
+// cached.
+func (r *ProfileCacheRepository) MarkDeployed(ctx context.Context, userID int64, markdown string) (bool, error) {
+	query := `
+		UPDATE generated_profiles
+		SET deployed = TRUE, deployed_at = NOW()
+		WHERE id = (
+			SELECT id FROM generated_profiles
+			WHERE user_id = $1 AND markdown_preview = $2
+			ORDER BY COALESCE(cached_at, created_at) DESC
+			LIMIT 1
+		)
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, markdown)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark profile deployed: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark profile deployed: %w", err)
+	}
+	return n > 0, nil
+}
+
+// showcaseOrder is a sort of the showcase listing: its ORDER BY and the
+// keyset condition continuing it after a cursor, whose sort keys are passed
+// as $4 onwards.
+type showcaseOrder struct {
+	orderBy string
+	after   string
+	keys    func(c *models.ShowcaseCursor) []interface{}
+}
+
+var showcaseOrders = map[string]showcaseOrder{
+	models.ShowcaseSortRecent: {
+		orderBy: "l.deployed_at DESC, l.user_id DESC",
+		after:   "(l.deployed_at, l.user_id) < ($4, $5)",
+		keys: func(c *models.ShowcaseCursor) []interface{} {
+			return []interface{}{c.DeployedAt, c.UserID}
+		},
+	},
+	models.ShowcaseSortPopular: {
+		orderBy: "l.cache_hits DESC, l.deployed_at DESC, l.user_id DESC",
+		after:   "(l.cache_hits, l.deployed_at, l.user_id) < ($4, $5, $6)",
+		keys: func(c *models.ShowcaseCursor) []interface{} {
+			return []interface{}{c.CacheHits, c.DeployedAt, c.UserID}
+		},
+	},
+}
+
+// ListShowcase returns up to limit showcase entries in sortBy order: the
+// latest deployed profile of each user who opted in. A non-empty language
+// keeps profiles whose top languages or extracted skills include it,
+// ignoring case. Entries start after cursor when it is set and skip offset
+// entries otherwise.
+func (r *ProfileCacheRepository) ListShowcase(ctx context.Context, sortBy, language string, cursor *models.ShowcaseCursor, offset, limit int) ([]*models.ShowcaseEntry, error) {
+	order, ok := showcaseOrders[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown showcase sort %q", sortBy)
+	}
+
+	after := "TRUE"
+	args := []interface{}{language, limit, offset}
+	if cursor != nil {
+		after = order.after
+		args[2] = 0
+		args = append(args, order.keys(cursor)...)
+	}
+
+	// Email, tokens and the rest of the user row are never selected
+	query := fmt.Sprintf(`
+		WITH latest AS (
+			SELECT DISTINCT ON (gp.user_id)
+				gp.user_id, gp.deployed_at,
+				COALESCE(gp.cache_hit_count, 0) AS cache_hits,
+				COALESCE(gp.last_generation_request->>'target_role', '') AS target_role,
+				COALESCE(gp.top_languages, '[]'::jsonb) AS top_languages,
+				CASE WHEN jsonb_typeof(gp.content::jsonb->'extracted_skills') = 'array'
+					THEN gp.content::jsonb->'extracted_skills' ELSE '[]'::jsonb END AS skills
+			FROM generated_profiles gp
+			JOIN user_preferences up ON up.user_id = gp.user_id AND up.showcase_opt_in
+			WHERE gp.deployed AND gp.deployed_at IS NOT NULL
+			ORDER BY gp.user_id, gp.deployed_at DESC
+		)
+		SELECT l.user_id, u.username, COALESCE(u.avatar_url, ''), l.target_role,
+		       l.top_languages, l.deployed_at, l.cache_hits
+		FROM latest l
+		JOIN users u ON u.id = l.user_id
+		WHERE ($1 = '' OR EXISTS (
+			SELECT 1 FROM jsonb_array_elements_text(l.top_languages || l.skills) AS s(name)
+			WHERE LOWER(s.name) = LOWER($1)
+		))
+		  AND %s
+		ORDER BY %s
+		LIMIT $2 OFFSET $3
+	`, after, order.orderBy)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list showcase: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.ShowcaseEntry
+	for rows.Next() {
+		e := &models.ShowcaseEntry{}
+		var languagesJSON []byte
+		if err := rows.Scan(&e.UserID, &e.Username, &e.AvatarURL, &e.TargetRole, &languagesJSON, &e.DeployedAt, &e.CacheHits); err != nil {
+			return nil, fmt.Errorf("failed to scan showcase entry: %w", err)
+		}
+		if err := json.Unmarshal(languagesJSON, &e.TopLanguages); err != nil {
+			return nil, fmt.Errorf("failed to decode top languages: %w", err)
+		}
+		e.ShareURL = "https://github.com/" + e.Username
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list showcase: %w", err)
+	}
+
+	return entries, nil
+}
+
 func (r *ProfileCacheRepository) Invalidate(ctx context.Context, cacheKey string) error {
 	query := `DELETE FROM generated_profiles WHERE cache_key = $1`
 	_, err := r.db.ExecContext(ctx, query, cacheKey)
@@ -149,6 +404,26 @@ func (r *ProfileCacheRepository) GetStats(ctx context.Context) (map[string]inter
 	}, nil
 }
 
-func GetCacheKey(username, targetRole, toneOfVoice string, projectCount int) string {
-	return fmt.Sprintf("profile:v3:%s:%s:%s:%d", username, targetRole, toneOfVoice, projectCount)
+// GetCacheKey keys a profile by its generation inputs. The inputs beyond
+// role, tone, language and project count are hashed into a suffix, so
+// requests differing in generation parameters, custom sections, section
+// toggles, contact preferences or open to work neither read nor replace each
+// other's profiles. Toggles are hashed in sorted order, so the key doesn't
+// depend on map iteration.
+func GetCacheKey(username, language string, req *models.ContentGenerationRequest) string {
+	key := fmt.Sprintf("profile:v5:%s:%s:%s:%s:%d", username, req.TargetRole, req.ToneOfVoice, language, len(req.Projects))
+
+	h := sha256.New()
+	p := req.Parameters
+	fmt.Fprintf(h, "params:%g:%g:%g:%d\n", p.Temperature, p.TopK, p.TopP, p.MaxOutputTokens)
+	for _, cs := range req.CustomSections {
+		fmt.Fprintf(h, "section:%q:%q:%d:%d\n", cs.Title, cs.Content, cs.Position, cs.MaxLength)
+	}
+	for _, id := range slices.Sorted(maps.Keys(req.SectionToggles)) {
+		fmt.Fprintf(h, "toggle:%q:%t\n", id, req.SectionToggles[id])
+	}
+	c := req.ContactPrefs
+	fmt.Fprintf(h, "contact:%q:%q:%q:%q:%q:%q:%q\n", c.LinkedIn, c.PersonalWebsite, c.Email, c.Twitter, c.Mastodon, c.Bluesky, c.PreferredOrder)
+	fmt.Fprintf(h, "open_to_work:%t\n", req.OpenToWork)
+	return key + ":" + hex.EncodeToString(h.Sum(nil)[:8])
 }
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+func TestGetCacheKey(t *testing.T) {
+	base := func() *models.ContentGenerationRequest {
+		return &models.ContentGenerationRequest{
+			TargetRole:     "Backend Engineer",
+			ToneOfVoice:    "professional",
+			Projects:       make([]models.RepositoryAnalysis, 2),
+			SectionToggles: map[string]bool{"trophies": false, "seo-meta": true, "connect": true},
+		}
+	}
+	want := GetCacheKey("octocat", "en", base())
+
+	// Maps iterate in random order, so repeat to catch order dependence
+	for range 20 {
+		if got := GetCacheKey("octocat", "en", base()); got != want {
+			t.Fatalf("GetCacheKey is not stable: %q, then %q", want, got)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *models.ContentGenerationRequest)
+	}{
+		{"custom sections", func(req *models.ContentGenerationRequest) {
+			req.CustomSections = []models.CustomSection{{Title: "Talks", Content: "GopherCon 2024"}}
+		}},
+		{"section toggle", func(req *models.ContentGenerationRequest) { req.SectionToggles["trophies"] = true }},
+		{"contact preferences", func(req *models.ContentGenerationRequest) {
+			req.ContactPrefs.LinkedIn = "https://www.linkedin.com/in/octocat"
+		}},
+		{"contact order", func(req *models.ContentGenerationRequest) {
+			req.ContactPrefs.PreferredOrder = []string{"email", "linkedin"}
+		}},
+		{"open to work", func(req *models.ContentGenerationRequest) { req.OpenToWork = true }},
+		{"generation parameters", func(req *models.ContentGenerationRequest) { req.Parameters.Temperature = 0.2 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base()
+			tt.modify(req)
+			if got := GetCacheKey("octocat", "en", req); got == want {
+				t.Errorf("changing %s kept the key %q", tt.name, got)
+			}
+		})
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/krauzx/gitright/internal/models"
+	"github.com/lib/pq"
 )
 
 type RepositoryCacheRepository struct {
@@ -88,64 +90,172 @@ func (r *RepositoryCacheRepository) InvalidateAllRepositoryLists(ctx context.Con
 	return nil
 }
 
-func (r *RepositoryCacheRepository) GetRepositoryAnalysis(ctx context.Context, githubID int64) (*models.RepositoryAnalysis, error) {
+// Analysis cache scopes. Public repositories look the same to every user, so
+// their analyses are shared; private ones are cached per user.
+const (
+	AnalysisScopeUser   = "user"
+	AnalysisScopeGlobal = "global"
+)
+
+// GetAnalysisCacheKey returns the cache key and scope for a repository
+// analysis requested by userID.
+func GetAnalysisCacheKey(userID, githubID int64, private bool) (key, scope string) {
+	if private {
+		return fmt.Sprintf("analysis:user:%d:%d", userID, githubID), AnalysisScopeUser
+	}
+	return fmt.Sprintf("analysis:global:%d", githubID), AnalysisScopeGlobal
+}
+
+// analysisColumns are read by scanAnalysis, in this order.
+const analysisColumns = `analysis, languages, dependencies, key_files, commit_count, contributor_count`
+
+// scanAnalysis decodes a row selected with analysisColumns first. Rows
+// cached before the analysis column existed are rebuilt from the original
+// columns, without repository metadata. A row that doesn't decode returns
+// nil, like a miss.
+func scanAnalysis(row *sql.Row, rest ...interface{}) (*models.RepositoryAnalysis, error) {
+	var analysisJSON, languagesJSON, dependenciesJSON, keyFilesJSON []byte
+	var commitCount, contributorCount int
+	dest := append([]interface{}{&analysisJSON, &languagesJSON, &dependenciesJSON, &keyFilesJSON, &commitCount, &contributorCount}, rest...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if analysisJSON != nil {
+		return decodeAnalysis(analysisJSON), nil
+	}
+
+	analysis := &models.RepositoryAnalysis{CommitCount: commitCount, ContributorCount: contributorCount}
+	if err := json.Unmarshal(languagesJSON, &analysis.Languages); err != nil {
+		return nil, nil
+	}
+	if err := json.Unmarshal(dependenciesJSON, &analysis.Dependencies); err != nil {
+		return nil, nil
+	}
+	if err := json.Unmarshal(keyFilesJSON, &analysis.KeyFiles); err != nil {
+		return nil, nil
+	}
+	return analysis, nil
+}
+
+// encodeAnalysis marshals analysis for the analysis column of a scope entry.
+// Staleness describes a served copy, not the analysis, so it isn't stored.
+// Dependabot alerts are only visible to the repository's owner, so they are
+// left out of global entries, which every user reads.
+func encodeAnalysis(analysis *models.RepositoryAnalysis, scope string) ([]byte, error) {
+	stored := *analysis
+	stored.Stale, stored.StaleAge = false, 0
+	if scope == AnalysisScopeGlobal {
+		stored.VulnerabilityAlerts = nil
+	}
+	return json.Marshal(&stored)
+}
+
+// decodeAnalysis unmarshals the analysis column, returning nil when it
+// doesn't decode.
+func decodeAnalysis(data []byte) *models.RepositoryAnalysis {
+	var analysis models.RepositoryAnalysis
+	if err := json.Unmarshal(data, &analysis); err != nil {
+		return nil
+	}
+	return &analysis
+}
+
+func (r *RepositoryCacheRepository) GetRepositoryAnalysis(ctx context.Context, cacheKey string) (*models.RepositoryAnalysis, error) {
 	query := `
-		SELECT
-			full_name,
-			languages,
-			dependencies,
-			key_files,
-			commit_count,
-			contributor_count
+		SELECT ` + analysisColumns + `, EXTRACT(EPOCH FROM NOW() - analyzed_at)
 		FROM repository_analysis_cache
-		WHERE github_id = $1
+		WHERE cache_key = $1
 		  AND expires_at > NOW()
 		LIMIT 1
 	`
 
-	var fullName string
-	var languagesJSON, dependenciesJSON, keyFilesJSON []byte
-	var commitCount, contributorCount int
-
-	err := r.db.QueryRowContext(ctx, query, githubID).Scan(
-		&fullName,
-		&languagesJSON,
-		&dependenciesJSON,
-		&keyFilesJSON,
-		&commitCount,
-		&contributorCount,
-	)
+	var ageSeconds float64
+	analysis, err := scanAnalysis(r.db.QueryRowContext(ctx, query, cacheKey), &ageSeconds)
 	if err == sql.ErrNoRows {
+		recordCacheLookup(ctx, cacheKey, false, 0)
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cached repository analysis: %w", err)
 	}
+	if analysis == nil {
+		return nil, nil
+	}
+	recordCacheLookup(ctx, cacheKey, true, time.Duration(ageSeconds*float64(time.Second)))
 
-	var languages map[string]int
-	var dependencies map[string][]string
-	var keyFiles map[string]string
+	return analysis, nil
+}
 
-	if err := json.Unmarshal(languagesJSON, &languages); err != nil {
-		return nil, nil
+// GetStaleRepositoryAnalysis returns the newest cached analysis of fullName
+// the user may see, even if it has expired, along with when it was made. It
+// is the fallback for when GitHub is unavailable; nil means none exists.
+func (r *RepositoryCacheRepository) GetStaleRepositoryAnalysis(ctx context.Context, userID int64, fullName string) (*models.RepositoryAnalysis, time.Time, error) {
+	query := `
+		SELECT ` + analysisColumns + `, analyzed_at
+		FROM repository_analysis_cache
+		WHERE LOWER(full_name) = LOWER($2)
+		  AND (cache_scope = 'global' OR user_id = $1)
+		ORDER BY analyzed_at DESC
+		LIMIT 1
+	`
+
+	var analyzedAt time.Time
+	analysis, err := scanAnalysis(r.db.QueryRowContext(ctx, query, userID, fullName), &analyzedAt)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, nil
 	}
-	if err := json.Unmarshal(dependenciesJSON, &dependencies); err != nil {
-		return nil, nil
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get stale repository analysis: %w", err)
 	}
-	if err := json.Unmarshal(keyFilesJSON, &keyFiles); err != nil {
-		return nil, nil
+	if analysis == nil {
+		return nil, time.Time{}, nil
 	}
 
-	return &models.RepositoryAnalysis{
-		Languages:        languages,
-		Dependencies:     dependencies,
-		KeyFiles:         keyFiles,
-		CommitCount:      commitCount,
-		ContributorCount: contributorCount,
-	}, nil
+	return analysis, analyzedAt, nil
+}
+
+// GetActivityScores returns the ActivityScore of the freshest cached
+// analysis userID may see of each of githubIDs, by GitHub ID. Repositories
+// without a fresh analysis are left out.
+func (r *RepositoryCacheRepository) GetActivityScores(ctx context.Context, userID int64, githubIDs []int64) (map[int64]float64, error) {
+	query := `
+		SELECT DISTINCT ON (github_id) github_id, (analysis->>'activity_score')::float8
+		FROM repository_analysis_cache
+		WHERE github_id = ANY($2)
+		  AND (cache_scope = 'global' OR user_id = $1)
+		  AND expires_at > NOW()
+		  AND analysis->>'activity_score' IS NOT NULL
+		ORDER BY github_id, analyzed_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(githubIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[int64]float64)
+	for rows.Next() {
+		var githubID int64
+		var score float64
+		if err := rows.Scan(&githubID, &score); err != nil {
+			return nil, err
+		}
+		scores[githubID] = score
+	}
+	return scores, rows.Err()
 }
 
-func (r *RepositoryCacheRepository) SetRepositoryAnalysis(ctx context.Context, githubID int64, fullName string, analysis *models.RepositoryAnalysis) error {
+// SetRepositoryAnalysis caches analysis under cacheKey. Global entries expire
+// after 24 hours and user entries after 7 days; userID is stored for user
+// entries only, and vulnerability alerts are stored in them only.
+func (r *RepositoryCacheRepository) SetRepositoryAnalysis(ctx context.Context, cacheKey, scope string, userID, githubID int64, fullName string, analysis *models.RepositoryAnalysis) error {
+	analysisJSON, err := encodeAnalysis(analysis, scope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal analysis: %w", err)
+	}
+
 	languagesJSON, err := json.Marshal(analysis.Languages)
 	if err != nil {
 		return fmt.Errorf("failed to marshal languages: %w", err)
@@ -161,24 +271,33 @@ func (r *RepositoryCacheRepository) SetRepositoryAnalysis(ctx context.Context, g
 		return fmt.Errorf("failed to marshal key files: %w", err)
 	}
 
+	ttl := "7 days"
+	var ownerID sql.NullInt64
+	if scope == AnalysisScopeGlobal {
+		ttl = "24 hours"
+	} else {
+		ownerID = sql.NullInt64{Int64: userID, Valid: true}
+	}
+
 	query := `
 		INSERT INTO repository_analysis_cache
-			(github_id, full_name, languages, dependencies, key_files, commit_count, contributor_count, expires_at)
+			(cache_key, cache_scope, user_id, github_id, full_name, analysis, languages, dependencies, key_files, commit_count, contributor_count, expires_at)
 		VALUES
-			($1, $2, $3, $4, $5, $6, $7, NOW() + INTERVAL '7 days')
-		ON CONFLICT (github_id) DO UPDATE
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW() + $12::interval)
+		ON CONFLICT (cache_key) DO UPDATE
 		SET
 			full_name = EXCLUDED.full_name,
+			analysis = EXCLUDED.analysis,
 			languages = EXCLUDED.languages,
 			dependencies = EXCLUDED.dependencies,
 			key_files = EXCLUDED.key_files,
 			commit_count = EXCLUDED.commit_count,
 			contributor_count = EXCLUDED.contributor_count,
 			analyzed_at = NOW(),
-			expires_at = NOW() + INTERVAL '7 days'
+			expires_at = EXCLUDED.expires_at
 	`
 
-	_, err = r.db.ExecContext(ctx, query, githubID, fullName, languagesJSON, dependenciesJSON, keyFilesJSON, analysis.CommitCount, analysis.ContributorCount)
+	_, err = r.db.ExecContext(ctx, query, cacheKey, scope, ownerID, githubID, fullName, analysisJSON, languagesJSON, dependenciesJSON, keyFilesJSON, analysis.CommitCount, analysis.ContributorCount, ttl)
 	if err != nil {
 		return fmt.Errorf("failed to set repository analysis cache: %w", err)
 	}
@@ -186,6 +305,8 @@ func (r *RepositoryCacheRepository) SetRepositoryAnalysis(ctx context.Context, g
 	return nil
 }
 
+// InvalidateRepositoryAnalysis drops every cached analysis of the repository,
+// in both scopes.
 func (r *RepositoryCacheRepository) InvalidateRepositoryAnalysis(ctx context.Context, githubID int64) error {
 	query := `DELETE FROM repository_analysis_cache WHERE github_id = $1`
 	_, err := r.db.ExecContext(ctx, query, githubID)
@@ -195,6 +316,58 @@ func (r *RepositoryCacheRepository) InvalidateRepositoryAnalysis(ctx context.Con
 	return nil
 }
 
+func (r *RepositoryCacheRepository) GetUserActivitySummary(ctx context.Context, userID int64) (*models.UserActivitySummary, error) {
+	query := `
+		SELECT summary
+		FROM user_activity_cache
+		WHERE user_id = $1
+		  AND expires_at > NOW()
+		LIMIT 1
+	`
+
+	var summaryJSON []byte
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&summaryJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached activity summary: %w", err)
+	}
+
+	var summary models.UserActivitySummary
+	if err := json.Unmarshal(summaryJSON, &summary); err != nil {
+		return nil, nil
+	}
+
+	return &summary, nil
+}
+
+func (r *RepositoryCacheRepository) SetUserActivitySummary(ctx context.Context, userID int64, summary *models.UserActivitySummary) error {
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity summary: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_activity_cache
+			(user_id, summary, expires_at)
+		VALUES
+			($1, $2, NOW() + INTERVAL '1 hour')
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+			summary = EXCLUDED.summary,
+			cached_at = NOW(),
+			expires_at = NOW() + INTERVAL '1 hour'
+	`
+
+	_, err = r.db.ExecContext(ctx, query, userID, summaryJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set activity summary cache: %w", err)
+	}
+
+	return nil
+}
+
 func (r *RepositoryCacheRepository) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	query := `
 		SELECT
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// fullAnalysis sets every RepositoryAnalysis field, so a field the cache
+// drops fails the round trip.
+func fullAnalysis() *models.RepositoryAnalysis {
+	date := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &models.RepositoryAnalysis{
+		Repository: &models.Repository{
+			ID: 1, GitHubID: 42, Name: "api", FullName: "octocat/api", Description: "An API",
+			Language: "Go", StargazersCount: 120, ForksCount: 8, DefaultBranch: "main",
+			Topics: []string{"go"}, HTMLURL: "https://github.com/octocat/api",
+			CreatedAt: date, UpdatedAt: date, PushedAt: date,
+		},
+		Languages:        map[string]int{"Go": 9000, "Shell": 100},
+		LanguageWeights:  map[string]float64{"Go": 0.99, "Shell": 0.01},
+		Files:            []string{"go.mod", "main.go"},
+		Dependencies:     map[string][]string{"go": {"github.com/labstack/echo/v4"}},
+		KeyFiles:         map[string]string{"go.mod": "module example.com/api"},
+		CommitCount:      321,
+		ContributorCount: 4,
+		CommitConvention: &models.CommitConventionInfo{UsesConventionalCommits: true, CommitTypes: []string{"feat"}, ConformanceRate: 0.9},
+		LanguageHistory: []models.LanguageSnapshot{
+			{Date: date.AddDate(-1, 0, 0), Languages: map[string]int{"Python": 5000}},
+			{Date: date, Languages: map[string]int{"Go": 9000}},
+		},
+		CommitFrequency:     &models.CommitFrequency{OwnerCommitsLast4Weeks: 30, OwnerCommitsLast12Weeks: 80, Cadence: models.CadenceDaily},
+		GoReplaceDirectives: []string{"example.com/lib => ../lib"},
+		GoWorkspaceModules:  []string{"services/api"},
+		GoBuildTags:         []string{"linux"},
+		PackageManager:      "pnpm",
+		CoAuthorTrailers:    []models.CoAuthorTrailer{{Name: "Hubot", Email: "hubot@example.com"}},
+		CoAuthors:           []string{"@hubot"},
+		StarGrowthRate:      1.5,
+		StarPeakDate:        date,
+		RuntimeVersions:     map[string]string{"go": "1.24"},
+		TypeScriptConfig:    &models.TypeScriptConfig{Target: "ES2022", Strict: true, Libs: []string{"dom"}},
+		DevContainer:        &models.DevContainerInfo{Image: "mcr.microsoft.com/devcontainers/go", ForwardedPorts: []int{8080}},
+		CompilesToWASM:      true,
+		UsesNix:             true,
+		HasMakefile:         true,
+		MakeTargets:         []string{"build", "test"},
+		IaCTools:            []string{models.IaCTerraform},
+		DataTools:           []string{models.DataToolDBT},
+		Stale:               true,
+		StaleAge:            time.Hour,
+		Stats:               &models.RepositoryStats{AvgIssueResponseDays: 1.5, IssuesSampled: 10},
+		IssueStats:          &models.IssueStats{OpenIssues: 3, ClosedIssues: 40, MergedPRs: 55},
+		VulnerabilityAlerts: []models.VulnerabilityAlert{{PackageName: "lodash", Severity: models.SeverityHigh, CVE: "CVE-2021-23337"}},
+		ActivityScore:       0.8,
+		GistID:              "aa5a315d61ae9438b18d",
+		Gist:                &models.Gist{ID: "aa5a315d61ae9438b18d", Files: map[string]models.GistFile{"a.go": {Filename: "a.go"}}},
+	}
+}
+
+func TestAnalysisRoundTrip(t *testing.T) {
+	analysis := fullAnalysis()
+
+	v := reflect.ValueOf(analysis).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			t.Fatalf("fullAnalysis leaves %s unset; set it so the round trip covers it", v.Type().Field(i).Name)
+		}
+	}
+
+	data, err := encodeAnalysis(analysis, AnalysisScopeUser)
+	if err != nil {
+		t.Fatalf("encodeAnalysis: %v", err)
+	}
+	got := decodeAnalysis(data)
+	if got == nil {
+		t.Fatal("decodeAnalysis returned nil")
+	}
+
+	want := fullAnalysis()
+	want.Stale, want.StaleAge = false, 0
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", got, want)
+	}
+	if !analysis.Stale {
+		t.Error("encodeAnalysis modified its argument")
+	}
+}
+
+func TestDecodeAnalysisInvalid(t *testing.T) {
+	if got := decodeAnalysis([]byte(`{"languages": 1}`)); got != nil {
+		t.Errorf("decodeAnalysis of invalid JSON = %+v, want nil", got)
+	}
+}
+
+// analysisCacheDB keeps the analysis column written by SetRepositoryAnalysis
+// by cache key and serves it to GetRepositoryAnalysis, standing in for
+// repository_analysis_cache.
+type analysisCacheDB struct {
+	mu   sync.Mutex
+	rows map[string][]byte
+}
+
+func (d *analysisCacheDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return analysisCacheConn{d}, nil
+}
+func (d *analysisCacheDB) Driver() driver.Driver { return nil }
+
+type analysisCacheConn struct{ db *analysisCacheDB }
+
+func (c analysisCacheConn) Prepare(query string) (driver.Stmt, error) {
+	return analysisCacheStmt{c.db, query}, nil
+}
+func (c analysisCacheConn) Close() error              { return nil }
+func (c analysisCacheConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type analysisCacheStmt struct {
+	db    *analysisCacheDB
+	query string
+}
+
+func (s analysisCacheStmt) Close() error  { return nil }
+func (s analysisCacheStmt) NumInput() int { return -1 }
+
+func (s analysisCacheStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "INSERT INTO repository_analysis_cache") {
+		s.db.mu.Lock()
+		s.db.rows[args[0].(string)] = args[5].([]byte)
+		s.db.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s analysisCacheStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	data, ok := s.db.rows[args[0].(string)]
+	if !ok {
+		return &analysisCacheRows{}, nil
+	}
+	return &analysisCacheRows{row: []driver.Value{data, []byte("{}"), []byte("{}"), []byte("{}"), int64(0), int64(0), float64(0)}}, nil
+}
+
+type analysisCacheRows struct{ row []driver.Value }
+
+func (r *analysisCacheRows) Columns() []string { return make([]string, 7) }
+func (r *analysisCacheRows) Close() error      { return nil }
+
+func (r *analysisCacheRows) Next(dest []driver.Value) error {
+	if r.row == nil {
+		return io.EOF
+	}
+	copy(dest, r.row)
+	r.row = nil
+	return nil
+}
+
+func TestVulnerabilityAlertsStayWithOwner(t *testing.T) {
+	ctx := context.Background()
+	db := sql.OpenDB(&analysisCacheDB{rows: make(map[string][]byte)})
+	defer db.Close()
+	repo := NewRepositoryCacheRepository(db)
+
+	const ownerID, otherID, githubID = 1, 2, 42
+	tests := []struct {
+		name       string
+		private    bool
+		wantAlerts bool
+	}{
+		{name: "public repository", private: false, wantAlerts: false},
+		{name: "private repository", private: true, wantAlerts: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, scope := GetAnalysisCacheKey(ownerID, githubID, tt.private)
+			if err := repo.SetRepositoryAnalysis(ctx, key, scope, ownerID, githubID, "octocat/api", fullAnalysis()); err != nil {
+				t.Fatalf("SetRepositoryAnalysis: %v", err)
+			}
+
+			// Another user reads the shared entry of a public repository,
+			// and only the owner's entry of a private one
+			readerID := int64(otherID)
+			if tt.private {
+				readerID = ownerID
+			}
+			readKey, _ := GetAnalysisCacheKey(readerID, githubID, tt.private)
+			got, err := repo.GetRepositoryAnalysis(ctx, readKey)
+			if err != nil || got == nil {
+				t.Fatalf("GetRepositoryAnalysis = %v, %v; want a cached analysis", got, err)
+			}
+			if hasAlerts := len(got.VulnerabilityAlerts) > 0; hasAlerts != tt.wantAlerts {
+				t.Errorf("cached alerts = %v, want alerts: %v", got.VulnerabilityAlerts, tt.wantAlerts)
+			}
+			if got.Repository == nil || got.Repository.FullName != "octocat/api" {
+				t.Errorf("cached analysis lost its repository: %+v", got.Repository)
+			}
+		})
+	}
+}
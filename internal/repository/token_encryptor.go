@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// TokenEncryptor seals OAuth tokens with AES-256-GCM before they are stored.
+// Ciphertexts are base64 of the 12-byte nonce followed by the sealed token.
+type TokenEncryptor struct {
+	key [32]byte
+}
+
+// NewTokenEncryptor returns an encryptor for a 32-byte key, the
+// TOKEN_ENCRYPTION_KEY setting.
+func NewTokenEncryptor(key string) (*TokenEncryptor, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("token encryption key must be 32 bytes, got %d", len(key))
+	}
+	e := &TokenEncryptor{}
+	copy(e.key[:], key)
+	return e, nil
+}
+
+func (e *TokenEncryptor) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(e.key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt seals plain with a random nonce. The empty string, the missing
+// refresh token, stays empty.
+func (e *TokenEncryptor) Encrypt(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	gcm, err := e.aead()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a ciphertext from Encrypt. It fails when the ciphertext was
+// sealed with another key or has been tampered with.
+func (e *TokenEncryptor) Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	gcm, err := e.aead()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("failed to decrypt token: ciphertext too short")
+	}
+	nonce, sealed := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	return string(plain), nil
+}
@@ -7,83 +7,243 @@ import (
 	"time"
 
 	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/pkg/logger"
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	encryptor *TokenEncryptor
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+// NewUserRepository stores access and refresh tokens encrypted with
+// encryptor. Users hold plain tokens; the repository encrypts on write and
+// decrypts on read.
+func NewUserRepository(db *sql.DB, encryptor *TokenEncryptor) *UserRepository {
+	return &UserRepository{db: db, encryptor: encryptor}
+}
+
+// encryptTokens returns user's access and refresh tokens encrypted, leaving
+// user unchanged.
+func (r *UserRepository) encryptTokens(user *models.User) (accessToken, refreshToken string, err error) {
+	if accessToken, err = r.encryptor.Encrypt(user.AccessToken); err != nil {
+		return "", "", fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+	if refreshToken, err = r.encryptor.Encrypt(user.RefreshToken); err != nil {
+		return "", "", fmt.Errorf("failed to encrypt refresh token: %w", err)
+	}
+	return accessToken, refreshToken, nil
+}
+
+// decryptTokens replaces user's stored tokens with their plain text.
+// Tokens written before encryption was introduced are already plain. Tokens
+// that don't decrypt, after a key change, are cleared rather than failing
+// the read, so the user can still log in and store new ones.
+func (r *UserRepository) decryptTokens(ctx context.Context, user *models.User, encrypted bool) {
+	if !encrypted {
+		return
+	}
+	accessToken, err := r.encryptor.Decrypt(user.AccessToken)
+	if err == nil {
+		var refreshToken string
+		if refreshToken, err = r.encryptor.Decrypt(user.RefreshToken); err == nil {
+			user.AccessToken, user.RefreshToken = accessToken, refreshToken
+			return
+		}
+	}
+	logger.FromContext(ctx).Warn("Discarding undecryptable tokens", "userID", user.ID, "error", err)
+	user.AccessToken, user.RefreshToken = "", ""
+}
+
+// EncryptLegacyTokens encrypts the tokens of every user still storing them
+// as plain text and returns how many users were updated. Users who log in
+// meanwhile store encrypted tokens themselves and are left alone.
+func (r *UserRepository) EncryptLegacyTokens(ctx context.Context) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, access_token, refresh_token FROM users WHERE NOT tokens_encrypted`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list plain text tokens: %w", err)
+	}
+	var legacy []*models.User
+	for rows.Next() {
+		var accessToken, refreshToken sql.NullString
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &accessToken, &refreshToken); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan plain text tokens: %w", err)
+		}
+		user.AccessToken, user.RefreshToken = accessToken.String, refreshToken.String
+		legacy = append(legacy, user)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list plain text tokens: %w", err)
+	}
+
+	updated := 0
+	for _, user := range legacy {
+		accessToken, refreshToken, err := r.encryptTokens(user)
+		if err != nil {
+			return updated, err
+		}
+		result, err := r.db.ExecContext(ctx, `
+			UPDATE users
+			SET access_token = $1, refresh_token = $2, tokens_encrypted = TRUE
+			WHERE id = $3 AND NOT tokens_encrypted
+		`, accessToken, refreshToken, user.ID)
+		if err != nil {
+			return updated, fmt.Errorf("failed to encrypt tokens of user %d: %w", user.ID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			updated++
+		}
+	}
+	return updated, nil
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	accessToken, refreshToken, err := r.encryptTokens(user)
+	if err != nil {
+		return err
+	}
 	query := `
-		INSERT INTO users (github_id, username, email, avatar_url, bio, location, company, blog, access_token, refresh_token, token_expires_at, last_login_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO users (github_id, username, email, avatar_url, bio, location, company, blog, access_token, refresh_token, token_expires_at, last_login_at, tokens_encrypted)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, TRUE)
 		RETURNING id, created_at, updated_at
 	`
 	return r.db.QueryRowContext(
 		ctx, query,
 		user.GitHubID, user.Username, user.Email, user.AvatarURL, user.Bio,
-		user.Location, user.Company, user.Blog, user.AccessToken, user.RefreshToken,
+		user.Location, user.Company, user.Blog, accessToken, refreshToken,
 		user.TokenExpiresAt, time.Now(),
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
 	query := `
-		SELECT id, github_id, username, email, avatar_url, bio, location, company, blog,
-		       access_token, refresh_token, token_expires_at, created_at, updated_at, last_login_at
+		SELECT id, COALESCE(github_id, 0), username, COALESCE(email, ''), avatar_url, bio, location, company, blog,
+		       access_token, refresh_token, token_expires_at, created_at, updated_at, last_login_at,
+		       source, expires_at, tokens_encrypted
 		FROM users
 		WHERE id = $1
 	`
 	user := &models.User{}
+	var expiresAt sql.NullTime
+	var tokensEncrypted bool
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.GitHubID, &user.Username, &user.Email, &user.AvatarURL,
 		&user.Bio, &user.Location, &user.Company, &user.Blog, &user.AccessToken,
 		&user.RefreshToken, &user.TokenExpiresAt, &user.CreatedAt, &user.UpdatedAt,
-		&user.LastLoginAt,
+		&user.LastLoginAt, &user.Source, &expiresAt, &tokensEncrypted,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("user not found")
 	}
-	return user, err
+	if err != nil {
+		return user, err
+	}
+	user.ExpiresAt = expiresAt.Time
+	r.decryptTokens(ctx, user, tokensEncrypted)
+	return user, nil
 }
 
 func (r *UserRepository) GetByGitHubID(ctx context.Context, githubID int64) (*models.User, error) {
 	query := `
-		SELECT id, github_id, username, email, avatar_url, bio, location, company, blog,
-		       access_token, refresh_token, token_expires_at, created_at, updated_at, last_login_at
+		SELECT id, COALESCE(github_id, 0), username, COALESCE(email, ''), avatar_url, bio, location, company, blog,
+		       access_token, refresh_token, token_expires_at, created_at, updated_at, last_login_at,
+		       source, expires_at, tokens_encrypted
 		FROM users
 		WHERE github_id = $1
 	`
 	user := &models.User{}
+	var expiresAt sql.NullTime
+	var tokensEncrypted bool
 	err := r.db.QueryRowContext(ctx, query, githubID).Scan(
 		&user.ID, &user.GitHubID, &user.Username, &user.Email, &user.AvatarURL,
 		&user.Bio, &user.Location, &user.Company, &user.Blog, &user.AccessToken,
 		&user.RefreshToken, &user.TokenExpiresAt, &user.CreatedAt, &user.UpdatedAt,
-		&user.LastLoginAt,
+		&user.LastLoginAt, &user.Source, &expiresAt, &tokensEncrypted,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("user not found")
 	}
-	return user, err
+	if err != nil {
+		return user, err
+	}
+	user.ExpiresAt = expiresAt.Time
+	r.decryptTokens(ctx, user, tokensEncrypted)
+	return user, nil
 }
 
+// CreateDemo inserts an email demo account, which has no GitHub identity or
+// token, expiring at user.ExpiresAt. Text columns the scans expect are
+// written as empty strings.
+func (r *UserRepository) CreateDemo(ctx context.Context, user *models.User) error {
+	query := `
+		INSERT INTO users (username, email, avatar_url, bio, location, company, blog,
+		                   access_token, refresh_token, token_expires_at, source, expires_at, last_login_at)
+		VALUES ($1, $2, '', '', '', '', '', '', '', $3, 'email', $3, NOW())
+		RETURNING id, created_at, updated_at, last_login_at
+	`
+	user.Source = models.UserSourceEmail
+	err := r.db.QueryRowContext(ctx, query, user.Username, user.Email, user.ExpiresAt).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt)
+	if err != nil {
+		return fmt.Errorf("failed to create demo user: %w", err)
+	}
+	return nil
+}
+
+// GetActiveDemoByEmail returns the unexpired demo account for email, or nil
+// when there is none.
+func (r *UserRepository) GetActiveDemoByEmail(ctx context.Context, email string) (*models.User, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id FROM users
+		WHERE source = 'email' AND email = $1 AND expires_at > NOW()
+		ORDER BY expires_at DESC
+		LIMIT 1
+	`, email).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get demo user: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Update saves user, encrypting tokens stored as plain text until now.
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	accessToken, refreshToken, err := r.encryptTokens(user)
+	if err != nil {
+		return err
+	}
 	query := `
 		UPDATE users
 		SET username = $1, email = $2, avatar_url = $3, bio = $4, location = $5,
 		    company = $6, blog = $7, access_token = $8, refresh_token = $9,
-		    token_expires_at = $10, updated_at = $11, last_login_at = $12
+		    token_expires_at = $10, updated_at = $11, last_login_at = $12,
+		    tokens_encrypted = TRUE
 		WHERE id = $13
 	`
-	_, err := r.db.ExecContext(
+	_, err = r.db.ExecContext(
 		ctx, query,
 		user.Username, user.Email, user.AvatarURL, user.Bio, user.Location,
-		user.Company, user.Blog, user.AccessToken, user.RefreshToken,
+		user.Company, user.Blog, accessToken, refreshToken,
 		user.TokenExpiresAt, time.Now(), time.Now(), user.ID,
 	)
 	return err
 }
+
+// GetWebhookSecretByGitHubID returns the webhook secret of the user with the
+// given GitHub account ID, or "" when there is no such user or no secret.
+func (r *UserRepository) GetWebhookSecretByGitHubID(ctx context.Context, githubID int64) (string, error) {
+	var secret sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT webhook_secret FROM users WHERE github_id = $1`, githubID).Scan(&secret)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get webhook secret: %w", err)
+	}
+	return secret.String, nil
+}
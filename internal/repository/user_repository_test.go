@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type storedTokens struct {
+	access, refresh string
+	encrypted       bool
+}
+
+// usersDB stands in for the users table's token columns, for
+// EncryptLegacyTokens.
+type usersDB struct {
+	mu    sync.Mutex
+	users map[int64]*storedTokens
+}
+
+func (d *usersDB) Connect(ctx context.Context) (driver.Conn, error) { return usersConn{d}, nil }
+func (d *usersDB) Driver() driver.Driver                            { return nil }
+
+type usersConn struct{ db *usersDB }
+
+func (c usersConn) Prepare(query string) (driver.Stmt, error) { return usersStmt{c.db, query}, nil }
+func (c usersConn) Close() error                              { return nil }
+func (c usersConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type usersStmt struct {
+	db    *usersDB
+	query string
+}
+
+func (s usersStmt) Close() error  { return nil }
+func (s usersStmt) NumInput() int { return -1 }
+
+func (s usersStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if !strings.Contains(s.query, "UPDATE users") {
+		return driver.RowsAffected(0), nil
+	}
+	u := s.db.users[args[2].(int64)]
+	if u == nil || u.encrypted {
+		return driver.RowsAffected(0), nil
+	}
+	u.access, u.refresh, u.encrypted = args[0].(string), args[1].(string), true
+	return driver.RowsAffected(1), nil
+}
+
+func (s usersStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	rows := &usersRows{}
+	for id, u := range s.db.users {
+		if !u.encrypted {
+			rows.values = append(rows.values, []driver.Value{id, u.access, u.refresh})
+		}
+	}
+	return rows, nil
+}
+
+type usersRows struct{ values [][]driver.Value }
+
+func (r *usersRows) Columns() []string { return make([]string, 3) }
+func (r *usersRows) Close() error      { return nil }
+
+func (r *usersRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestEncryptLegacyTokens(t *testing.T) {
+	encryptor, err := NewTokenEncryptor(strings.Repeat("k", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := encryptor.Encrypt("gho_current")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &usersDB{users: map[int64]*storedTokens{
+		1: {access: "gho_legacy", refresh: "ghr_legacy"},
+		2: {access: "gho_no_refresh"},
+		3: {access: sealed, encrypted: true},
+	}}
+	db := sql.OpenDB(users)
+	defer db.Close()
+	repo := NewUserRepository(db, encryptor)
+
+	n, err := repo.EncryptLegacyTokens(context.Background())
+	if err != nil {
+		t.Fatalf("EncryptLegacyTokens: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("EncryptLegacyTokens updated %d users, want 2", n)
+	}
+
+	want := map[int64][2]string{1: {"gho_legacy", "ghr_legacy"}, 2: {"gho_no_refresh", ""}, 3: {"gho_current", ""}}
+	for id, tokens := range want {
+		u := users.users[id]
+		if !u.encrypted {
+			t.Errorf("user %d tokens are still plain text", id)
+			continue
+		}
+		if u.access == tokens[0] {
+			t.Errorf("user %d access token stored unencrypted", id)
+		}
+		access, err := encryptor.Decrypt(u.access)
+		if err != nil || access != tokens[0] {
+			t.Errorf("user %d access token decrypts to %q, %v; want %q", id, access, err, tokens[0])
+		}
+		refresh, err := encryptor.Decrypt(u.refresh)
+		if err != nil || refresh != tokens[1] {
+			t.Errorf("user %d refresh token decrypts to %q, %v; want %q", id, refresh, err, tokens[1])
+		}
+	}
+
+	if n, err := repo.EncryptLegacyTokens(context.Background()); err != nil || n != 0 {
+		t.Errorf("second EncryptLegacyTokens = %d, %v; want 0, nil", n, err)
+	}
+}
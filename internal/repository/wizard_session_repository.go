@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+type WizardSessionRepository struct {
+	db *sql.DB
+}
+
+func NewWizardSessionRepository(db *sql.DB) *WizardSessionRepository {
+	return &WizardSessionRepository{db: db}
+}
+
+const wizardSessionColumns = `id, user_id, step, state, created_at, updated_at, expires_at`
+
+func scanWizardSession(row *sql.Row) (*models.WizardSession, error) {
+	session := &models.WizardSession{}
+	var state []byte
+	err := row.Scan(&session.ID, &session.UserID, &session.Step, &state, &session.CreatedAt, &session.UpdatedAt, &session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	session.State = state
+	return session, nil
+}
+
+// Create starts an empty session at step 1 that expires after ttl.
+func (r *WizardSessionRepository) Create(ctx context.Context, userID int64, ttl time.Duration) (*models.WizardSession, error) {
+	query := `
+		INSERT INTO wizard_sessions (id, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING ` + wizardSessionColumns
+
+	session, err := scanWizardSession(r.db.QueryRowContext(ctx, query, uuid.NewString(), userID, time.Now().Add(ttl)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wizard session: %w", err)
+	}
+	return session, nil
+}
+
+// Get returns the user's unexpired session, or nil if there is none with
+// that ID. Other users' sessions are never returned.
+func (r *WizardSessionRepository) Get(ctx context.Context, id string, userID int64) (*models.WizardSession, error) {
+	query := `
+		SELECT ` + wizardSessionColumns + `
+		FROM wizard_sessions
+		WHERE id = $1 AND user_id = $2 AND expires_at > NOW()
+	`
+	session, err := scanWizardSession(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wizard session: %w", err)
+	}
+	return session, nil
+}
+
+// Update sets the session's step and merges state into the stored state,
+// replacing top-level keys it contains. The session's expiry moves to ttl
+// from now. It returns nil if the user has no unexpired session with that ID.
+func (r *WizardSessionRepository) Update(ctx context.Context, id string, userID int64, step int, state json.RawMessage, ttl time.Duration) (*models.WizardSession, error) {
+	query := `
+		UPDATE wizard_sessions
+		SET step = $3, state = state || $4::jsonb, updated_at = NOW(), expires_at = $5
+		WHERE id = $1 AND user_id = $2 AND expires_at > NOW()
+		RETURNING ` + wizardSessionColumns
+
+	session, err := scanWizardSession(r.db.QueryRowContext(ctx, query, id, userID, step, []byte(state), time.Now().Add(ttl)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update wizard session: %w", err)
+	}
+	return session, nil
+}
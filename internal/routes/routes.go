@@ -1,7 +1,12 @@
 package routes
 
 import (
+	"time"
+
+	apidocs "github.com/krauzx/gitright/internal/api"
+	"github.com/krauzx/gitright/internal/config"
 	"github.com/krauzx/gitright/internal/handlers"
+	v2 "github.com/krauzx/gitright/internal/handlers/v2"
 	"github.com/krauzx/gitright/internal/middleware"
 	"github.com/krauzx/gitright/internal/repository"
 	"github.com/labstack/echo/v4"
@@ -12,38 +17,118 @@ func RegisterRoutes(
 	authHandler *handlers.AuthHandler,
 	githubHandler *handlers.GitHubHandler,
 	profileHandler *handlers.ProfileHandler,
+	profileHandlerV2 *v2.ProfileHandlerV2,
 	healthHandler *handlers.HealthHandler,
 	wsHandler *handlers.WebSocketHandler,
+	preferencesHandler *handlers.PreferencesHandler,
+	adminHandler *handlers.AdminHandler,
+	auditHandler *handlers.AuditHandler,
+	docsHandler *apidocs.DocsHandler,
+	prometheusHandler echo.HandlerFunc,
 	userRepo *repository.UserRepository,
 	sessionRepo *repository.SessionRepository,
 	jwtSecret string,
+	versions []string,
+	rateLimit config.RateLimitConfig,
+	security config.SecurityConfig,
 ) {
+	// Probes are registered outside the rate-limited group, so a busy
+	// instance is never restarted for throttling its own health checks.
 	e.GET("/health", healthHandler.Health)
 	e.GET("/health/ready", healthHandler.Ready)
 	e.GET("/health/live", healthHandler.Live)
 
-	api := e.Group("/api/v1")
+	limited := e.Group("", middleware.RateLimitExempt(security.InternalSecret), middleware.GlobalRateLimiter(rateLimit.RequestsPerMinute))
+
+	// Prometheus cannot send a JWT, so the scrape endpoint takes a bearer
+	// token or an admin network instead, and is closed when neither is set.
+	limited.GET("/metrics", prometheusHandler, middleware.MetricsAccess(security.AdminAllowedCIDRs, security.MetricsScrapeToken))
+
+	// A nil docsHandler means docs are disabled and these paths 404.
+	if docsHandler != nil {
+		limited.GET("/api/v1/openapi.json", docsHandler.Spec)
+		limited.GET("/api/v1/docs", docsHandler.Docs)
+	}
+
+	// The showcase is a public page, linked without an API version.
+	limited.GET("/showcase", profileHandler.Showcase)
+
+	// Built once so a version's limit is shared across every route prefix.
+	var versionLimiters []echo.MiddlewareFunc
+	for v, rpm := range rateLimit.VersionRequestsPerMinute {
+		versionLimiters = append(versionLimiters, middleware.VersionRateLimiter(v, rpm, rateLimit.Burst))
+	}
+
+	// Every version serves the full route set. The negotiated version (path
+	// prefix, Accept header or ?api_version=) selects handler variants where
+	// the response shape differs.
+	for _, version := range versions {
+		api := limited.Group("/api/" + version)
+		api.Use(middleware.APIVersion(version, versions))
+		api.Use(versionLimiters...)
+
+		auth := api.Group("/auth")
+		auth.GET("/login", authHandler.Login)
+		auth.GET("/callback", authHandler.Callback)
+		auth.POST("/email/request", authHandler.EmailRequest)
+		auth.POST("/email/verify", authHandler.EmailVerify)
+
+		api.GET("/showcase", profileHandler.Showcase)
 
-	auth := api.Group("/auth")
-	auth.GET("/login", authHandler.Login)
-	auth.GET("/callback", authHandler.Callback)
+		protected := api.Group("")
+		protected.Use(middleware.AuthMiddleware(jwtSecret, userRepo, sessionRepo))
 
-	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(jwtSecret, userRepo, sessionRepo))
+		protected.POST("/auth/logout", authHandler.Logout)
+		protected.GET("/me", authHandler.Me)
+		protected.GET("/me/preferences", preferencesHandler.Get)
+		protected.PUT("/me/preferences/schedule", preferencesHandler.UpdateSchedule, middleware.RequireGitHubAccount())
+		protected.POST("/me/preferences/template-vars", preferencesHandler.UpdateTemplateVariables)
+		protected.PATCH("/me/preferences/typing-svg", preferencesHandler.UpdateTypingSVG)
+		protected.PATCH("/me/preferences/layout", preferencesHandler.UpdateLayout)
+		protected.PUT("/me/preferences/showcase", preferencesHandler.UpdateShowcase, middleware.RequireGitHubAccount())
+		protected.GET("/me/audit", auditHandler.List)
+		protected.GET("/me/ab-tests", profileHandler.ListABTests)
 
-	protected.POST("/auth/logout", authHandler.Logout)
-	protected.GET("/me", authHandler.Me)
+		gh := protected.Group("/github")
+		gh.GET("/repositories", githubHandler.ListRepositories)
+		gh.GET("/repositories/recommended", githubHandler.GetRecommendedRepositories)
+		gh.GET("/repositories/:owner/:repo", githubHandler.GetRepository)
+		gh.GET("/repositories/:owner/:repo/analyze", githubHandler.AnalyzeRepository)
+		gh.GET("/repositories/:owner/:repo/analyze/export", githubHandler.ExportAnalysis, middleware.UserRateLimiter(10, time.Hour))
+		gh.POST("/repositories/batch-analyze", githubHandler.BatchAnalyze)
+		gh.DELETE("/cache", githubHandler.ClearCache)
+		gh.GET("/status", githubHandler.Status)
 
-	gh := protected.Group("/github")
-	gh.GET("/repositories", githubHandler.ListRepositories)
-	gh.GET("/repositories/:owner/:repo", githubHandler.GetRepository)
-	gh.GET("/repositories/:owner/:repo/analyze", githubHandler.AnalyzeRepository)
-	gh.POST("/repositories/batch-analyze", githubHandler.BatchAnalyze)
-	gh.DELETE("/cache", githubHandler.ClearCache)
+		profile := protected.Group("/profile")
+		profile.POST("/generate", middleware.Versioned(profileHandler.Generate, map[string]echo.HandlerFunc{
+			"v2": profileHandlerV2.Generate,
+		}))
+		profile.POST("/estimate", profileHandler.Estimate)
+		profile.GET("/search", profileHandler.Search)
+		profile.POST("/deploy", profileHandler.Deploy, middleware.RequireGitHubAccount())
+		profile.POST("/deploy/preview", profileHandler.PreviewDeploy, middleware.RequireGitHubAccount())
+		profile.POST("/preview", profileHandler.Preview)
+		profile.GET("/preview/image", profileHandler.PreviewImage, middleware.UserRateLimiter(5, time.Minute))
+		profile.GET("/seo-metadata", profileHandler.SEOMetadata)
+		profile.GET("/export/bundle", profileHandler.ExportBundle, middleware.UserRateLimiter(2, 24*time.Hour))
+		profile.POST("/ab-test", profileHandler.ABTest)
+		profile.POST("/ab-test/:id/select", profileHandler.SelectABVariant)
+		profile.GET("/ab-insights", profileHandler.ABInsights)
+		profile.POST("/wizard/session", profileHandler.CreateWizardSession)
+		profile.GET("/wizard/session/:id", profileHandler.GetWizardSession)
+		profile.PATCH("/wizard/session/:id", profileHandler.UpdateWizardSession)
+		profile.POST("/wizard/session/:id/generate", profileHandler.GenerateFromWizard)
+		profile.GET("/ws", wsHandler.HandleProfileGeneration)
+	}
 
-	profile := protected.Group("/profile")
-	profile.POST("/generate", profileHandler.Generate)
-	profile.POST("/deploy", profileHandler.Deploy)
-	profile.POST("/preview", profileHandler.Preview)
-	profile.GET("/ws", wsHandler.HandleProfileGeneration)
+	// Admin routes are not versioned. The IP check runs before auth so
+	// disallowed networks learn nothing about token validity.
+	admin := limited.Group("/api/v1/admin")
+	admin.Use(middleware.IPAllowlist(security.AdminAllowedCIDRs))
+	admin.Use(middleware.AuthMiddleware(jwtSecret, userRepo, sessionRepo))
+	admin.Use(middleware.RequireAdmin(security.AdminUsernames))
+	admin.GET("/cache/stats", adminHandler.CacheStats)
+	admin.GET("/metrics", adminHandler.Metrics)
+	admin.GET("/email/test", adminHandler.TestEmail)
+	admin.GET("/audit", adminHandler.ListAudit)
 }
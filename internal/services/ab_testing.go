@@ -0,0 +1,140 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/pkg/logger"
+	"golang.org/x/sync/errgroup"
+)
+
+// minABTestsForInsights is the number of A/B tests a user must exceed before
+// ABInsights reports anything.
+const minABTestsForInsights = 5
+
+// ErrNotEnoughABTests is returned by ABInsights until the user has run more
+// than minABTestsForInsights tests.
+var ErrNotEnoughABTests = errors.New("not enough A/B tests for insights")
+
+// ABTestResult is a new A/B test with both generated profiles.
+type ABTestResult struct {
+	TestID   int64                             `json:"test_id"`
+	TestName string                            `json:"test_name"`
+	VariantA *models.ContentGenerationResponse `json:"variant_a"`
+	VariantB *models.ContentGenerationResponse `json:"variant_b"`
+}
+
+// RunABTest generates both variants and stores them as a pending test. The
+// variants neither read nor write the profile cache, so two requests with
+// the same cache key still produce separate profiles and the user's cached
+// profile is left alone. Together they count as one generation toward the
+// cooldown.
+func (s *ProfileService) RunABTest(ctx context.Context, user *models.User, testName string, variantA, variantB *models.ContentGenerationRequest) (*ABTestResult, error) {
+	for _, req := range []*models.ContentGenerationRequest{variantA, variantB} {
+		if req.UserAPIKey == "" {
+			return nil, fmt.Errorf("API key required - get free key: https://aistudio.google.com/app/apikey")
+		}
+		if len(req.Projects) == 0 {
+			return nil, fmt.Errorf("at least one project required")
+		}
+	}
+
+	if err := s.checkCooldown(ctx, user); err != nil {
+		return nil, err
+	}
+
+	var respA, respB *models.ContentGenerationResponse
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		respA, err = s.generate(gctx, variantA, user, "")
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		respB, err = s.generate(gctx, variantB, user, "")
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	if err := s.cooldownRepo.Record(ctx, user.ID); err != nil {
+		logger.FromContext(ctx).Warn("Failed to record generation cooldown", "username", user.Username, "error", err)
+	}
+
+	test, err := s.abTestRepo.Create(ctx, user.ID, testName, variantA, variantB, respA, respB)
+	if err != nil {
+		return nil, err
+	}
+	return &ABTestResult{TestID: test.ID, TestName: test.TestName, VariantA: respA, VariantB: respB}, nil
+}
+
+// SelectABVariant records the variant ("a" or "b") the user preferred. It
+// returns false if the user has no such test.
+func (s *ProfileService) SelectABVariant(ctx context.Context, user *models.User, testID int64, variant string) (bool, error) {
+	return s.abTestRepo.SelectVariant(ctx, user.ID, testID, variant)
+}
+
+func (s *ProfileService) ListABTests(ctx context.Context, user *models.User) ([]*models.ABTest, error) {
+	return s.abTestRepo.ListByUserID(ctx, user.ID)
+}
+
+// ABInsights ranks the TargetRole and ToneOfVoice combinations in the user's
+// completed tests by how often they were preferred. Tests whose variants
+// share a combination say nothing about it and are skipped.
+func (s *ProfileService) ABInsights(ctx context.Context, user *models.User) ([]models.ABInsight, error) {
+	tests, err := s.abTestRepo.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tests) <= minABTestsForInsights {
+		return nil, ErrNotEnoughABTests
+	}
+
+	type combo struct{ role, tone string }
+	stats := make(map[combo]*models.ABInsight)
+	tally := func(v models.ABTestVariant, won bool) {
+		key := combo{v.TargetRole, v.ToneOfVoice}
+		insight, ok := stats[key]
+		if !ok {
+			insight = &models.ABInsight{TargetRole: v.TargetRole, ToneOfVoice: v.ToneOfVoice}
+			stats[key] = insight
+		}
+		insight.Appearances++
+		if won {
+			insight.Wins++
+		}
+	}
+
+	for _, t := range tests {
+		if t.SelectedVariant == "" {
+			continue
+		}
+		a, b := t.VariantA, t.VariantB
+		if a.TargetRole == b.TargetRole && a.ToneOfVoice == b.ToneOfVoice {
+			continue
+		}
+		tally(a, t.SelectedVariant == "a")
+		tally(b, t.SelectedVariant == "b")
+	}
+
+	insights := make([]models.ABInsight, 0, len(stats))
+	for _, insight := range stats {
+		insight.WinRate = float64(insight.Wins) / float64(insight.Appearances)
+		insights = append(insights, *insight)
+	}
+	slices.SortFunc(insights, func(x, y models.ABInsight) int {
+		return cmp.Or(
+			cmp.Compare(y.WinRate, x.WinRate),
+			cmp.Compare(y.Wins, x.Wins),
+			cmp.Compare(x.TargetRole, y.TargetRole),
+			cmp.Compare(x.ToneOfVoice, y.ToneOfVoice),
+		)
+	})
+	return insights, nil
+}
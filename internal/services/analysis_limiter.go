@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrTooManyConcurrentAnalyses is returned when a user already has the
+// maximum number of repository analyses running and none finished in time.
+var ErrTooManyConcurrentAnalyses = errors.New("too many concurrent repository analyses")
+
+// analysisLimiterIdle is how long a user's slots are kept after their last
+// analysis started.
+const analysisLimiterIdle = 5 * time.Minute
+
+// analysisLimiter bounds how many repository analyses each user runs at once,
+// so parallel batch requests cannot fan out into unbounded GitHub API calls.
+type analysisLimiter struct {
+	slots int64
+	wait  time.Duration
+	users sync.Map // int64 user ID -> *userAnalysisSlots
+
+	lastSweep atomic.Int64 // unix nanoseconds
+}
+
+type userAnalysisSlots struct {
+	sem      *semaphore.Weighted
+	lastUsed atomic.Int64 // unix nanoseconds
+}
+
+// newAnalysisLimiter allows slots analyses per user, waiting up to wait for
+// a free slot. A non-positive slots disables the limit.
+func newAnalysisLimiter(slots int, wait time.Duration) *analysisLimiter {
+	return &analysisLimiter{slots: int64(slots), wait: wait}
+}
+
+// acquire takes one of userID's slots, waiting up to l.wait. The returned
+// func releases it.
+func (l *analysisLimiter) acquire(ctx context.Context, userID int64) (func(), error) {
+	if l.slots <= 0 {
+		return func() {}, nil
+	}
+	now := time.Now()
+	l.sweep(now)
+
+	waitCtx, cancel := context.WithTimeout(ctx, l.wait)
+	defer cancel()
+
+	for {
+		v, _ := l.users.LoadOrStore(userID, &userAnalysisSlots{sem: semaphore.NewWeighted(l.slots)})
+		user := v.(*userAnalysisSlots)
+		user.lastUsed.Store(now.UnixNano())
+
+		if err := user.sem.Acquire(waitCtx, 1); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, ErrTooManyConcurrentAnalyses
+		}
+		// The entry may have been swept while this call waited on it
+		if current, ok := l.users.Load(userID); ok && current == v {
+			return func() { user.sem.Release(1) }, nil
+		}
+		user.sem.Release(1)
+	}
+}
+
+// sweep drops the slots of users idle for analysisLimiterIdle, at most once
+// a minute. Entries with an analysis in flight are kept.
+func (l *analysisLimiter) sweep(now time.Time) {
+	last := l.lastSweep.Load()
+	if now.UnixNano()-last < int64(time.Minute) || !l.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	cutoff := now.Add(-analysisLimiterIdle).UnixNano()
+	l.users.Range(func(key, v any) bool {
+		user := v.(*userAnalysisSlots)
+		if user.lastUsed.Load() >= cutoff || !user.sem.TryAcquire(l.slots) {
+			return true
+		}
+		l.users.CompareAndDelete(key, v)
+		user.sem.Release(l.slots)
+		return true
+	})
+}
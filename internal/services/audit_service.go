@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/krauzx/gitright/internal/audit"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/pkg/logger"
+)
+
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// Record writes an audit entry, taking the client IP and user agent from ctx.
+// oldValue and newValue are JSON-encoded; nil leaves the column empty.
+// Failures are logged and never returned, so auditing cannot break the action
+// being audited.
+func (s *AuditService) Record(ctx context.Context, userID int64, action, entityType string, entityID int64, oldValue, newValue interface{}) {
+	ip, userAgent := audit.ClientFromContext(ctx)
+	entry := models.AuditEntry{
+		UserID:     userID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		OldValue:   marshalAuditValue(oldValue),
+		NewValue:   marshalAuditValue(newValue),
+		IPAddress:  ip,
+		UserAgent:  userAgent,
+	}
+
+	if err := s.auditRepo.Log(ctx, entry); err != nil {
+		logger.FromContext(ctx).Warn("Failed to record audit entry", "userID", userID, "action", action, "error", err)
+	}
+}
+
+// List returns userID's entries newest first, or every user's when userID is
+// 0, plus the total count for pagination.
+func (s *AuditService) List(ctx context.Context, userID int64, limit, offset int) ([]*models.AuditEntry, int, error) {
+	return s.auditRepo.List(ctx, userID, limit, offset)
+}
+
+func marshalAuditValue(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		slog.Warn("Failed to encode audit value", "error", err)
+		return nil
+	}
+	return b
+}
@@ -7,26 +7,45 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/krauzx/gitright/internal/audit"
 	"github.com/krauzx/gitright/internal/github"
 	"github.com/krauzx/gitright/internal/models"
 	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/pkg/logger"
 )
 
+// cacheWarmTimeout bounds the background repository list fetch after login.
+const cacheWarmTimeout = 30 * time.Second
+
 type AuthService struct {
-	githubClient *github.Client
-	userRepo     *repository.UserRepository
-	sessionRepo  *repository.SessionRepository
+	githubClient  *github.Client
+	userRepo      *repository.UserRepository
+	sessionRepo   *repository.SessionRepository
+	auditService  *AuditService
+	githubService *GitHubService
+
+	emailService   *EmailService
+	emailLoginRepo *repository.EmailLoginRepository
 }
 
 func NewAuthService(
 	githubClient *github.Client,
 	userRepo *repository.UserRepository,
 	sessionRepo *repository.SessionRepository,
+	auditService *AuditService,
+	githubService *GitHubService,
+	emailService *EmailService,
+	emailLoginRepo *repository.EmailLoginRepository,
 ) *AuthService {
 	return &AuthService{
-		githubClient: githubClient,
-		userRepo:     userRepo,
-		sessionRepo:  sessionRepo,
+		githubClient:  githubClient,
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		auditService:  auditService,
+		githubService: githubService,
+
+		emailService:   emailService,
+		emailLoginRepo: emailLoginRepo,
 	}
 }
 
@@ -102,6 +121,8 @@ func (s *AuthService) HandleCallback(ctx context.Context, code string) (*models.
 		if err := s.userRepo.Update(ctx, existingUser); err != nil {
 			return nil, "", fmt.Errorf("failed to update user: %w", err)
 		}
+		s.auditService.Record(ctx, existingUser.ID, audit.ActionAccountLogin, "user", existingUser.ID, nil, nil)
+		s.warmRepositoryCache(ctx, existingUser.ID, token.AccessToken)
 		return existingUser, token.AccessToken, nil
 	}
 
@@ -124,9 +145,31 @@ func (s *AuthService) HandleCallback(ctx context.Context, code string) (*models.
 		return nil, "", fmt.Errorf("failed to create user: %w", err)
 	}
 
+	s.auditService.Record(ctx, newUser.ID, audit.ActionAccountLogin, "user", newUser.ID, nil, map[string]bool{"new_account": true})
+	s.warmRepositoryCache(ctx, newUser.ID, token.AccessToken)
 	return newUser, token.AccessToken, nil
 }
 
-func (s *AuthService) RevokeSession(ctx context.Context, jti string, expiresAt time.Time) error {
-	return s.sessionRepo.RevokeToken(ctx, jti, expiresAt)
+// warmRepositoryCache fetches the user's public repository list in the
+// background so the dashboard's first load is served from cache. It does not
+// block login; if the dashboard asks first, the normal cache miss path runs.
+// The fetch outlives the login request, so it only keeps ctx's logger.
+func (s *AuthService) warmRepositoryCache(ctx context.Context, userID int64, accessToken string) {
+	log := logger.FromContext(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(logger.WithContext(context.Background(), log), cacheWarmTimeout)
+		defer cancel()
+
+		if _, err := s.githubService.ListUserRepositories(ctx, userID, accessToken, false); err != nil {
+			log.Debug("Repository cache warm-up failed", "userID", userID, "error", err)
+		}
+	}()
+}
+
+func (s *AuthService) RevokeSession(ctx context.Context, userID int64, jti string, expiresAt time.Time) error {
+	if err := s.sessionRepo.RevokeToken(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, userID, audit.ActionAccountLogout, "user", userID, nil, nil)
+	return nil
 }
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// Conditions available to {{#if name}} blocks.
+const (
+	ConditionHasTargetRole = "has_target_role"
+	ConditionHasLocation   = "has_location"
+	ConditionHasCompany    = "has_company"
+	ConditionHasWebsite    = "has_website"
+	ConditionHasEmail      = "has_email"
+	ConditionHasTwitter    = "has_twitter"
+	ConditionHasLinkedIn   = "has_linkedin"
+	ConditionIsOpenToWork  = "is_open_to_work"
+)
+
+var conditionalTagPattern = regexp.MustCompile(`\{\{#if ([a-z_]+)\}\}|\{\{/if\}\}`)
+
+// ApplyConditionalBlocks keeps the content of each {{#if name}}...{{/if}}
+// block whose condition is true in vars and drops the rest, tags included.
+// Blocks nest. There are no expressions: a condition is a bare name, and
+// names missing from vars are false. Unmatched tags are left as-is.
+func ApplyConditionalBlocks(markdown string, vars map[string]bool) string {
+	if !strings.Contains(markdown, "{{") {
+		return markdown
+	}
+
+	type block struct {
+		open string // the opening tag, restored if the block is never closed
+		show bool
+		body strings.Builder
+	}
+	stack := []*block{{show: true}}
+	last := 0
+	for _, m := range conditionalTagPattern.FindAllStringSubmatchIndex(markdown, -1) {
+		top := stack[len(stack)-1]
+		top.body.WriteString(markdown[last:m[0]])
+		last = m[1]
+
+		if m[2] >= 0 {
+			stack = append(stack, &block{open: markdown[m[0]:m[1]], show: vars[markdown[m[2]:m[3]]]})
+			continue
+		}
+		if len(stack) == 1 {
+			top.body.WriteString(markdown[m[0]:m[1]])
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		if top.show {
+			stack[len(stack)-1].body.WriteString(top.body.String())
+		}
+	}
+	stack[len(stack)-1].body.WriteString(markdown[last:])
+
+	for len(stack) > 1 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		stack[len(stack)-1].body.WriteString(top.open + top.body.String())
+	}
+	return stack[0].body.String()
+}
+
+// templateConditions evaluates the conditional block conditions for the
+// profile being built. user is the privacy view of the user.
+func templateConditions(user *models.User, req *models.ContentGenerationRequest, config *models.ProfileConfig) map[string]bool {
+	return map[string]bool{
+		ConditionHasTargetRole: req.TargetRole != "",
+		ConditionHasLocation:   user.Location != "",
+		ConditionHasCompany:    user.Company != "",
+		ConditionHasWebsite:    portfolioURL(config, user) != "",
+		ConditionHasEmail:      config.ContactPrefs.Email != "" || user.Email != "",
+		ConditionHasTwitter:    config.ContactPrefs.Twitter != "",
+		ConditionHasLinkedIn:   config.ContactPrefs.LinkedIn != "",
+		ConditionIsOpenToWork:  req.OpenToWork,
+	}
+}
+
+// applyConditionalTemplateVariables applies conditional blocks to each
+// template variable value.
+func applyConditionalTemplateVariables(vars map[string]string, conditions map[string]bool) map[string]string {
+	applied := make(map[string]string, len(vars))
+	for name, value := range vars {
+		applied[name] = ApplyConditionalBlocks(value, conditions)
+	}
+	return applied
+}
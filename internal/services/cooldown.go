@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/pkg/logger"
+)
+
+const (
+	generationCooldown = 60 * time.Second
+
+	// dailyGenerationCap generations in a day trigger dailyCapCooldown.
+	dailyGenerationCap = 10
+	dailyCapCooldown   = 24 * time.Hour
+)
+
+// CooldownError is returned by GenerateProfile when the user generated a
+// profile too recently.
+type CooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("generation cooldown active, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds.
+func (e *CooldownError) RetryAfterSeconds() int {
+	return int((e.RetryAfter + time.Second - 1) / time.Second)
+}
+
+// checkCooldown returns a *CooldownError while the user's cooldown runs.
+// Admins are exempt. Failing to read the cooldown lets the generation
+// through rather than blocking it.
+func (s *ProfileService) checkCooldown(ctx context.Context, user *models.User) error {
+	if s.admins[user.Username] {
+		return nil
+	}
+
+	cooldown, err := s.cooldownRepo.Get(ctx, user.ID)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to check generation cooldown", "username", user.Username, "error", err)
+		return nil
+	}
+	if cooldown == nil {
+		return nil
+	}
+
+	wait := generationCooldown
+	if cooldown.CountToday >= dailyGenerationCap {
+		wait = dailyCapCooldown
+	}
+	if remaining := time.Until(cooldown.LastGeneratedAt.Add(wait)); remaining > 0 {
+		return &CooldownError{RetryAfter: remaining}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// standardSectionCount is the number of "## " sections buildMarkdown writes
+// between the header and the footer; custom section positions index them.
+const standardSectionCount = 6
+
+var (
+	rawHTMLPattern       = regexp.MustCompile(`<[^>]*>`)
+	headingMarkerPattern = regexp.MustCompile(`(?m)^([ \t]*)#`)
+)
+
+// customBadgePattern matches markdown images served by the shields.io
+// instance at shieldsBaseURL, falling back to img.shields.io.
+func customBadgePattern(shieldsBaseURL string) *regexp.Regexp {
+	base := "img.shields.io"
+	if u, err := url.Parse(shieldsBaseURL); err == nil && u.Host != "" {
+		base = u.Host + strings.TrimSuffix(u.Path, "/")
+	}
+	return regexp.MustCompile(`!\[[^\]]*\]\(https?://` + regexp.QuoteMeta(base) + `/`)
+}
+
+// sanitizeMarkdownInline prepares user-written markdown for embedding in the
+// README: line endings are normalized and line-leading "#" is escaped, so
+// the text can't open headings that would split it into sections of its own.
+func sanitizeMarkdownInline(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = headingMarkerPattern.ReplaceAllString(s, `$1\#`)
+	return strings.TrimSpace(s)
+}
+
+func stripRawHTML(s string) string {
+	return rawHTMLPattern.ReplaceAllString(s, "")
+}
+
+// renderCustomSection returns the section as markdown, or "" when nothing is
+// left of it after sanitizing.
+func renderCustomSection(section models.CustomSection) string {
+	title := strings.Join(strings.Fields(stripRawHTML(section.Title)), " ")
+	content := sanitizeMarkdownInline(stripRawHTML(section.Content))
+	if title == "" || content == "" {
+		return ""
+	}
+	return "## " + title + "\n\n" + content + "\n\n"
+}
+
+// placeCustomSections groups sections by the standard section they follow,
+// clamping positions to 0..standardSectionCount. Sections sharing a position
+// keep their request order.
+func placeCustomSections(sections []models.CustomSection) [][]models.CustomSection {
+	placed := make([][]models.CustomSection, standardSectionCount+1)
+	for _, section := range sections {
+		pos := min(max(section.Position, 0), standardSectionCount)
+		placed[pos] = append(placed[pos], section)
+	}
+	return placed
+}
+
+// countCustomSectionBadges counts the shields.io badges, as matched by
+// badgePattern, embedded in custom sections, which count toward
+// MaxTotalBadges.
+func countCustomSectionBadges(badgePattern *regexp.Regexp, sections []models.CustomSection) int {
+	count := 0
+	for _, section := range sections {
+		count += len(badgePattern.FindAllStringIndex(stripRawHTML(section.Content), -1))
+	}
+	return count
+}
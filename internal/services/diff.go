@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffContextLines is how many unchanged lines surround each hunk.
+const diffContextLines = 3
+
+type diffLine struct {
+	op   diffmatchpatch.Operation
+	text string
+}
+
+// unifiedDiff renders a line-based unified diff of oldText and newText for
+// name. An empty oldText diffs against /dev/null.
+func unifiedDiff(name, oldText, newText string) string {
+	dmp := diffmatchpatch.New()
+	oldChars, newChars, lineArray := dmp.DiffLinesToChars(oldText, newText)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lineArray)
+
+	var lines []diffLine
+	for _, d := range diffs {
+		for _, text := range strings.SplitAfter(d.Text, "\n") {
+			if text != "" {
+				lines = append(lines, diffLine{op: d.Type, text: text})
+			}
+		}
+	}
+
+	var sb strings.Builder
+	if oldText == "" {
+		sb.WriteString("--- /dev/null\n")
+	} else {
+		sb.WriteString("--- a/" + name + "\n")
+	}
+	sb.WriteString("+++ b/" + name + "\n")
+
+	// oldLine and newLine are 1-based positions of lines[i] in each file
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(lines); {
+		if lines[i].op == diffmatchpatch.DiffEqual {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// Extend the hunk back over leading context and forward until a run of
+		// unchanged lines is long enough to separate it from the next change.
+		start := max(0, i-diffContextLines)
+		for j := start; j < i; j++ {
+			oldLine--
+			newLine--
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].op != diffmatchpatch.DiffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].op == diffmatchpatch.DiffEqual {
+				run++
+			}
+			if run == len(lines) || run-end > 2*diffContextLines {
+				end = min(run, end+diffContextLines)
+				break
+			}
+			end = run
+		}
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		for _, l := range lines[start:end] {
+			switch l.op {
+			case diffmatchpatch.DiffEqual:
+				body.WriteString(" ")
+				oldCount++
+				newCount++
+			case diffmatchpatch.DiffDelete:
+				body.WriteString("-")
+				oldCount++
+			case diffmatchpatch.DiffInsert:
+				body.WriteString("+")
+				newCount++
+			}
+			body.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		sb.WriteString(body.String())
+
+		oldLine += oldCount
+		newLine += newCount
+		i = end
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats a hunk's start,count the way diff -u does: an empty range
+// starts at the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/krauzx/gitright/internal/audit"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+const (
+	loginCodeTTL         = 10 * time.Minute
+	loginCodeResendAfter = 60 * time.Second
+	maxLoginCodeAttempts = 5
+
+	// An email address, or an IP requesting codes for any address, that
+	// reaches its daily cap is blocked for loginCodeCapCooldown.
+	dailyLoginCodesPerEmail = 5
+	dailyLoginCodesPerIP    = 20
+	loginCodeCapCooldown    = 24 * time.Hour
+
+	// Codes checked a day, right or wrong, per email address and per IP.
+	// Each code allows maxLoginCodeAttempts, so these bound guesses across
+	// resends.
+	dailyLoginVerifiesPerEmail = 2 * dailyLoginCodesPerEmail * maxLoginCodeAttempts
+	dailyLoginVerifiesPerIP    = 100
+
+	// demoAccountTTL is how long an email demo account lives. Its JWT expires
+	// with it.
+	demoAccountTTL = 24 * time.Hour
+)
+
+var (
+	ErrInvalidEmail          = errors.New("invalid email address")
+	ErrEmailLoginUnavailable = errors.New("email login is not available")
+	ErrLoginCodeTooSoon      = errors.New("a login code was sent recently")
+	ErrLoginCodeLimitReached = errors.New("too many login codes requested, try again tomorrow")
+	ErrInvalidLoginCode      = errors.New("invalid or expired login code")
+	ErrTooManyLoginAttempts  = errors.New("too many failed login attempts")
+)
+
+// RequestEmailLogin emails a 6-digit one-time code to address, replacing any
+// earlier code. Only its hash is stored. Sends are throttled per address and
+// per clientIP, so the endpoint can't be used to flood inboxes.
+func (s *AuthService) RequestEmailLogin(ctx context.Context, address, clientIP string) error {
+	if !s.emailService.Enabled() {
+		return ErrEmailLoginUnavailable
+	}
+	email, err := normalizeEmail(address)
+	if err != nil {
+		return err
+	}
+
+	emailSubject, ipSubject := "send:email:"+email, "send:ip:"+clientIP
+	if err := s.checkLoginCodeSends(ctx, emailSubject, dailyLoginCodesPerEmail); err != nil {
+		return err
+	}
+	if err := s.checkLoginCodeSends(ctx, ipSubject, dailyLoginCodesPerIP); err != nil {
+		return err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return fmt.Errorf("failed to generate login code: %w", err)
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	if err := s.emailLoginRepo.Set(ctx, email, hashLoginCode(code), time.Now().Add(loginCodeTTL)); err != nil {
+		return err
+	}
+	// Recorded before sending, so failed sends count too
+	for _, subject := range []string{emailSubject, ipSubject} {
+		if _, err := s.emailLoginRepo.RecordLimit(ctx, subject); err != nil {
+			return err
+		}
+	}
+	if err := s.emailService.SendLoginCode(email, code, loginCodeTTL); err != nil {
+		return fmt.Errorf("failed to send login code: %w", err)
+	}
+	return nil
+}
+
+// checkLoginCodeSends returns ErrLoginCodeTooSoon within loginCodeResendAfter
+// of the last code sent for subject, and ErrLoginCodeLimitReached within
+// loginCodeCapCooldown of it once dailyCap codes were sent that day.
+func (s *AuthService) checkLoginCodeSends(ctx context.Context, subject string, dailyCap int) error {
+	sends, err := s.emailLoginRepo.GetLimit(ctx, subject)
+	if err != nil {
+		return err
+	}
+	if sends == nil {
+		return nil
+	}
+	if time.Since(sends.LastAt) < loginCodeResendAfter {
+		return ErrLoginCodeTooSoon
+	}
+	if sends.CountToday >= dailyCap && time.Since(sends.LastAt) < loginCodeCapCooldown {
+		return ErrLoginCodeLimitReached
+	}
+	return nil
+}
+
+// VerifyEmailLogin checks a one-time code and returns the email's demo
+// account, creating one that expires after demoAccountTTL if there is none.
+// The code is deleted once used or after maxLoginCodeAttempts wrong guesses.
+// Checks are also capped per address and per clientIP each day.
+func (s *AuthService) VerifyEmailLogin(ctx context.Context, address, code, clientIP string) (*models.User, error) {
+	email, err := normalizeEmail(address)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := []struct {
+		subject  string
+		dailyCap int
+	}{
+		{"verify:email:" + email, dailyLoginVerifiesPerEmail},
+		{"verify:ip:" + clientIP, dailyLoginVerifiesPerIP},
+	}
+	for _, l := range limits {
+		count, err := s.emailLoginRepo.RecordLimit(ctx, l.subject)
+		if err != nil {
+			return nil, err
+		}
+		if count > l.dailyCap {
+			return nil, ErrTooManyLoginAttempts
+		}
+	}
+
+	codeHash, attempts, ok, err := s.emailLoginRepo.ClaimAttempt(ctx, email, maxLoginCodeAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		stored, err := s.emailLoginRepo.Get(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil && stored.Attempts >= maxLoginCodeAttempts {
+			return nil, ErrTooManyLoginAttempts
+		}
+		return nil, ErrInvalidLoginCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashLoginCode(code)), []byte(codeHash)) != 1 {
+		if attempts >= maxLoginCodeAttempts {
+			if err := s.emailLoginRepo.Delete(ctx, email); err != nil {
+				return nil, err
+			}
+			return nil, ErrTooManyLoginAttempts
+		}
+		return nil, ErrInvalidLoginCode
+	}
+
+	if err := s.emailLoginRepo.Delete(ctx, email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetActiveDemoByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		s.auditService.Record(ctx, user.ID, audit.ActionAccountLogin, "user", user.ID, nil, map[string]string{"source": models.UserSourceEmail})
+		return user, nil
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate username: %w", err)
+	}
+	user = &models.User{
+		Username:  "demo-" + hex.EncodeToString(suffix),
+		Email:     email,
+		ExpiresAt: time.Now().Add(demoAccountTTL),
+	}
+	if err := s.userRepo.CreateDemo(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, user.ID, audit.ActionAccountLogin, "user", user.ID, nil, map[string]interface{}{"new_account": true, "source": models.UserSourceEmail})
+	return user, nil
+}
+
+func normalizeEmail(address string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+func hashLoginCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/krauzx/gitright/internal/config"
+)
+
+// EmailService sends plain-text notification emails over SMTP. It is a no-op
+// when no SMTP host is configured.
+type EmailService struct {
+	cfg          config.EmailConfig
+	dashboardURL string
+}
+
+func NewEmailService(cfg config.EmailConfig, dashboardURL string) *EmailService {
+	return &EmailService{
+		cfg:          cfg,
+		dashboardURL: dashboardURL,
+	}
+}
+
+func (s *EmailService) Enabled() bool {
+	return s.cfg.Host != ""
+}
+
+// SendDeployNotification emails the user that their profile README is live.
+// It returns immediately; delivery failures are only logged.
+func (s *EmailService) SendDeployNotification(to, username, profileURL string) {
+	if !s.Enabled() || to == "" {
+		return
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nYour GitHub profile README was deployed successfully.\n\nView it: %s\nManage your profile: %s\n\n- GitRight\n",
+		username, profileURL, s.dashboardURL,
+	)
+
+	go func() {
+		if err := s.send(to, "Your GitHub profile README is live", body); err != nil {
+			slog.Warn("Failed to send deploy notification", "username", username, "error", err)
+		}
+	}()
+}
+
+// SendTestEmail sends a message synchronously so callers can verify the SMTP
+// configuration.
+func (s *EmailService) SendTestEmail(to string) error {
+	if !s.Enabled() {
+		return fmt.Errorf("email is not configured")
+	}
+	return s.send(to, "GitRight SMTP test", "This is a test email from GitRight. SMTP is configured correctly.\n")
+}
+
+// SendLoginCode emails a one-time login code. It is synchronous so the caller
+// can report a delivery failure instead of leaving the user waiting.
+func (s *EmailService) SendLoginCode(to, code string, validFor time.Duration) error {
+	if !s.Enabled() {
+		return fmt.Errorf("email is not configured")
+	}
+	body := fmt.Sprintf(
+		"Your GitRight login code is %s\n\nIt expires in %d minutes. If you did not request it, you can ignore this email.\n\n- GitRight\n",
+		code, int(validFor.Minutes()),
+	)
+	return s.send(to, "Your GitRight login code", body)
+}
+
+func (s *EmailService) send(to, subject, body string) error {
+	// Reject header injection through the recipient
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient address")
+	}
+
+	msg := "From: " + s.cfg.FromAddress + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+	if err := smtp.SendMail(addr, auth, s.cfg.FromAddress, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
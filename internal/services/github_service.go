@@ -2,31 +2,68 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
-	"log/slog"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
+	gogithub "github.com/google/go-github/v60/github"
+	"github.com/krauzx/gitright/internal/audit"
+	"github.com/krauzx/gitright/internal/config"
 	"github.com/krauzx/gitright/internal/github"
 	"github.com/krauzx/gitright/internal/models"
 	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/internal/validators"
+	"github.com/krauzx/gitright/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
+var tracer = otel.Tracer("github.com/krauzx/gitright/internal/services")
+
+// GitHubClientInterface is the GitHub API surface GitHubService uses. It is
+// implemented by *github.Client and, for tests, by testutil.FakeGitHub.
+type GitHubClientInterface interface {
+	ListRepositories(ctx context.Context, token string, includePrivate bool) ([]*gogithub.Repository, error)
+	GetRepository(ctx context.Context, token, owner, repo string) (*gogithub.Repository, error)
+	CreateRepository(ctx context.Context, token, name, description string) (*gogithub.Repository, error)
+	GetRepositoryContent(ctx context.Context, token, owner, repo, path string) (string, error)
+	GetRepositorySocialPreview(ctx context.Context, token, owner, repo string) (string, error)
+	ResolveCoAuthors(ctx context.Context, token, owner string, trailers []models.CoAuthorTrailer) []string
+	GetFileContentSHA(ctx context.Context, token, username, path string) (string, error)
+	CreateOrUpdateFile(ctx context.Context, token, owner, repo, path, message, content, sha string) error
+	GetGist(ctx context.Context, token, gistID string) (*models.Gist, error)
+	GetUserActivitySummary(ctx context.Context, token, username string) (*models.UserActivitySummary, error)
+	IsOrganizationMember(ctx context.Context, token, org, username string) (bool, error)
+	Availability() github.APIAvailability
+	CallsToday() int
+}
+
 type GitHubService struct {
-	githubClient  *github.Client
+	githubClient  GitHubClientInterface
 	analyzer      *github.Analyzer
 	repoCacheRepo *repository.RepositoryCacheRepository
+	auditService  *AuditService
+	limiter       *analysisLimiter
 }
 
 func NewGitHubService(
-	githubClient *github.Client,
+	githubClient GitHubClientInterface,
 	analyzer *github.Analyzer,
 	repoCacheRepo *repository.RepositoryCacheRepository,
+	auditService *AuditService,
+	analysisCfg config.AnalysisConfig,
 ) *GitHubService {
 	return &GitHubService{
 		githubClient:  githubClient,
 		analyzer:      analyzer,
 		repoCacheRepo: repoCacheRepo,
+		auditService:  auditService,
+		limiter:       newAnalysisLimiter(analysisCfg.MaxConcurrentAnalysesPerUser, analysisCfg.PerRepoTimeout),
 	}
 }
 
@@ -48,61 +85,282 @@ func (s *GitHubService) ListUserRepositories(ctx context.Context, userID int64,
 		}
 
 		repo := &models.Repository{
-			ID:              gr.GetID(),
-			GitHubID:        gr.GetID(),
-			Name:            gr.GetName(),
-			FullName:        gr.GetFullName(),
-			Description:     gr.GetDescription(),
-			Private:         gr.GetPrivate(),
-			Fork:            gr.GetFork(),
-			Language:        gr.GetLanguage(),
-			StargazersCount: gr.GetStargazersCount(),
-			ForksCount:      gr.GetForksCount(),
-			OpenIssuesCount: gr.GetOpenIssuesCount(),
-			DefaultBranch:   gr.GetDefaultBranch(),
-			Topics:          gr.Topics,
-			HTMLURL:         gr.GetHTMLURL(),
-			CloneURL:        gr.GetCloneURL(),
-			CreatedAt:       gr.GetCreatedAt().Time,
-			UpdatedAt:       gr.GetUpdatedAt().Time,
-			PushedAt:        gr.GetPushedAt().Time,
+			ID:               gr.GetID(),
+			GitHubID:         gr.GetID(),
+			Name:             gr.GetName(),
+			FullName:         gr.GetFullName(),
+			Description:      gr.GetDescription(),
+			Private:          gr.GetPrivate(),
+			Fork:             gr.GetFork(),
+			Language:         gr.GetLanguage(),
+			StargazersCount:  gr.GetStargazersCount(),
+			ForksCount:       gr.GetForksCount(),
+			WatchersCount:    gr.GetWatchersCount(),
+			SubscribersCount: gr.GetSubscribersCount(),
+			OpenIssuesCount:  gr.GetOpenIssuesCount(),
+			DefaultBranch:    gr.GetDefaultBranch(),
+			Topics:           gr.Topics,
+			HTMLURL:          gr.GetHTMLURL(),
+			CloneURL:         gr.GetCloneURL(),
+			CreatedAt:        gr.GetCreatedAt().Time,
+			UpdatedAt:        gr.GetUpdatedAt().Time,
+			PushedAt:         gr.GetPushedAt().Time,
 		}
 		repos = append(repos, repo)
 	}
 
 	if err := s.repoCacheRepo.SetRepositoryList(ctx, userID, includePrivate, repos); err != nil {
-		slog.Warn("Failed to cache repository list", "userID", userID, "error", err)
+		logger.FromContext(ctx).Warn("Failed to cache repository list", "userID", userID, "error", err)
 	}
 
 	return repos, nil
 }
 
-func (s *GitHubService) AnalyzeRepository(ctx context.Context, accessToken, owner, repo string) (*models.RepositoryAnalysis, error) {
+// GetRecommendedRepositories ranks the user's public repositories by activity
+// score plus metadata bonuses and returns the top count. Repositories with a
+// cached analysis are scored by its ActivityScore, which includes signals
+// such as strict TypeScript that the listing lacks.
+func (s *GitHubService) GetRecommendedRepositories(ctx context.Context, userID int64, accessToken string, count int) ([]*models.Repository, error) {
+	repos, err := s.ListUserRepositories(ctx, userID, accessToken, false)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		ids = append(ids, repo.GitHubID)
+	}
+	analyzed, err := s.repoCacheRepo.GetActivityScores(ctx, userID, ids)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to load analyzed activity scores", "userID", userID, "error", err)
+	}
+
+	scores := make(map[int64]float64, len(repos))
+	for _, repo := range repos {
+		score, ok := analyzed[repo.GitHubID]
+		if !ok {
+			score = s.analyzer.ComputeActivityScore(repo)
+		}
+		bonus := 1.0
+		if repo.Description != "" {
+			bonus += 0.10
+		}
+		if len(repo.Topics) > 0 {
+			bonus += 0.05
+		}
+		if repo.StargazersCount > 10 {
+			bonus += 0.15
+		}
+		if !repo.Fork {
+			bonus += 0.20
+		}
+		scores[repo.GitHubID] = score * bonus
+	}
+
+	sort.SliceStable(repos, func(i, j int) bool {
+		return scores[repos[i].GitHubID] > scores[repos[j].GitHubID]
+	})
+
+	if count < len(repos) {
+		repos = repos[:count]
+	}
+	return repos, nil
+}
+
+// CanAccessOwner reports whether username may analyze repositories owned by
+// owner: either their own account or an organization they belong to.
+func (s *GitHubService) CanAccessOwner(ctx context.Context, accessToken, username, owner string) (bool, error) {
+	if strings.EqualFold(owner, username) {
+		return true, nil
+	}
+	return s.githubClient.IsOrganizationMember(ctx, accessToken, owner, username)
+}
+
+// AnalyzeRepository returns the analysis of owner/repo, from the cache when
+// fresh. Each user runs a bounded number of analyses at once; when all their
+// slots stay busy it returns ErrTooManyConcurrentAnalyses.
+func (s *GitHubService) AnalyzeRepository(ctx context.Context, userID int64, accessToken, owner, repo string) (*models.RepositoryAnalysis, error) {
+	return s.AnalyzeRepositoryWithProgress(ctx, userID, accessToken, owner, repo, nil)
+}
+
+// AnalyzeRepositoryWithProgress is AnalyzeRepository with per-step progress
+// reporting. Cache hits complete immediately without invoking cb. Each GitHub
+// API call made during analysis is traced as a child of the analysis span.
+func (s *GitHubService) AnalyzeRepositoryWithProgress(ctx context.Context, userID int64, accessToken, owner, repo string, cb func(github.AnalysisStep, float64)) (*models.RepositoryAnalysis, error) {
+	return s.analyzeRepository(ctx, userID, accessToken, owner, repo, false, cb)
+}
+
+// RefreshRepositoryAnalysis re-analyzes a repository even when a cached
+// analysis exists, replacing the cache entry.
+func (s *GitHubService) RefreshRepositoryAnalysis(ctx context.Context, userID int64, accessToken, owner, repo string) (*models.RepositoryAnalysis, error) {
+	return s.analyzeRepository(ctx, userID, accessToken, owner, repo, true, nil)
+}
+
+func (s *GitHubService) analyzeRepository(ctx context.Context, userID int64, accessToken, owner, repo string, skipCache bool, cb func(github.AnalysisStep, float64)) (analysis *models.RepositoryAnalysis, err error) {
+	ctx, span := tracer.Start(ctx, "github.AnalyzeRepository")
+	span.SetAttributes(attribute.String("repo", owner+"/"+repo))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	release, err := s.limiter.acquire(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
 	repoInfo, err := s.githubClient.GetRepository(ctx, accessToken, owner, repo)
 	if err != nil {
+		if stale := s.staleAnalysis(ctx, userID, fullName, err); stale != nil {
+			return stale, nil
+		}
 		return nil, fmt.Errorf("failed to get repository info: %w", err)
 	}
 
 	githubID := repoInfo.GetID()
-	fullName := fmt.Sprintf("%s/%s", owner, repo)
 
-	cachedAnalysis, err := s.repoCacheRepo.GetRepositoryAnalysis(ctx, githubID)
-	if err == nil && cachedAnalysis != nil {
-		return cachedAnalysis, nil
+	// Public analyses are shared across users, private ones stay per user
+	cacheKey, cacheScope := repository.GetAnalysisCacheKey(userID, githubID, repoInfo.GetPrivate())
+
+	if !skipCache {
+		cachedAnalysis, err := s.repoCacheRepo.GetRepositoryAnalysis(ctx, cacheKey)
+		if err == nil && cachedAnalysis != nil {
+			return cachedAnalysis, nil
+		}
 	}
 
-	analysis, err := s.analyzer.AnalyzeRepository(ctx, accessToken, owner, repo)
+	analysis, err = s.analyzer.AnalyzeRepositoryWithProgress(ctx, accessToken, owner, repo, cb)
 	if err != nil {
+		if stale := s.staleAnalysis(ctx, userID, fullName, err); stale != nil {
+			return stale, nil
+		}
 		return nil, fmt.Errorf("failed to analyze repository: %w", err)
 	}
 
-	if err := s.repoCacheRepo.SetRepositoryAnalysis(ctx, githubID, fullName, analysis); err != nil {
-		slog.Warn("Failed to cache repository analysis", "repo", fullName, "error", err)
+	if err := s.repoCacheRepo.SetRepositoryAnalysis(ctx, cacheKey, cacheScope, userID, githubID, fullName, analysis); err != nil {
+		logger.FromContext(ctx).Warn("Failed to cache repository analysis", "repo", fullName, "error", err)
 	}
 
 	return analysis, nil
 }
 
+// staleAnalysis returns any cached analysis of fullName, expired or not,
+// marked Stale, when cause shows GitHub is unavailable. It returns nil when
+// cause is some other failure or nothing is cached.
+func (s *GitHubService) staleAnalysis(ctx context.Context, userID int64, fullName string, cause error) *models.RepositoryAnalysis {
+	if !github.IsUnavailable(cause) {
+		return nil
+	}
+	analysis, analyzedAt, err := s.repoCacheRepo.GetStaleRepositoryAnalysis(ctx, userID, fullName)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to load stale repository analysis", "repo", fullName, "error", err)
+		return nil
+	}
+	if analysis == nil {
+		return nil
+	}
+
+	analysis.Stale = true
+	analysis.StaleAge = time.Since(analyzedAt)
+	logger.FromContext(ctx).Warn("GitHub unavailable, serving stale repository analysis",
+		"repo", fullName, "age", analysis.StaleAge.Round(time.Second), "error", cause)
+	return analysis
+}
+
+// GitHubAvailability reports recent GitHub API call success.
+func (s *GitHubService) GitHubAvailability() github.APIAvailability {
+	return s.githubClient.Availability()
+}
+
+// GitHubCallsToday returns the GitHub API calls made today by this process.
+func (s *GitHubService) GitHubCallsToday() int {
+	return s.githubClient.CallsToday()
+}
+
+var gistIDPattern = regexp.MustCompile(`^[0-9a-f]{20,40}$`)
+
+// AnalyzeGist fetches a gist and describes it as a project: Repository is
+// synthesized from the gist and Languages counts bytes per file language.
+func (s *GitHubService) AnalyzeGist(ctx context.Context, accessToken, gistID string) (*models.RepositoryAnalysis, error) {
+	if !gistIDPattern.MatchString(gistID) {
+		return nil, fmt.Errorf("invalid gist ID %q", gistID)
+	}
+
+	gist, err := s.githubClient.GetGist(ctx, accessToken, gistID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(gist.Files))
+	languages := make(map[string]int)
+	for name, f := range gist.Files {
+		files = append(files, name)
+		if f.Language != "" {
+			languages[f.Language] += len(f.Content)
+		}
+	}
+	sort.Strings(files)
+
+	name := gist.Description
+	if name == "" && len(files) > 0 {
+		name = files[0]
+	}
+
+	return &models.RepositoryAnalysis{
+		Repository: &models.Repository{
+			Name:            name,
+			FullName:        gist.Owner + "/" + gist.ID,
+			Description:     gist.Description,
+			Private:         !gist.Public,
+			StargazersCount: gist.StarCount,
+			HTMLURL:         gist.HTMLURL,
+			CreatedAt:       gist.CreatedAt,
+			UpdatedAt:       gist.UpdatedAt,
+			PushedAt:        gist.UpdatedAt,
+		},
+		Languages: languages,
+		Files:     files,
+		GistID:    gist.ID,
+		Gist:      gist,
+	}, nil
+}
+
+// GetRepositorySocialPreview returns the repository's social preview image
+// URL, or "" when it has none.
+func (s *GitHubService) GetRepositorySocialPreview(ctx context.Context, accessToken, owner, repo string) (string, error) {
+	return s.githubClient.GetRepositorySocialPreview(ctx, accessToken, owner, repo)
+}
+
+// ResolveCoAuthors returns the collaborators credited in a repository's
+// co-author trailers; see github.Client.ResolveCoAuthors.
+func (s *GitHubService) ResolveCoAuthors(ctx context.Context, accessToken, owner string, trailers []models.CoAuthorTrailer) []string {
+	return s.githubClient.ResolveCoAuthors(ctx, accessToken, owner, trailers)
+}
+
+func (s *GitHubService) GetUserActivitySummary(ctx context.Context, userID int64, accessToken, username string) (*models.UserActivitySummary, error) {
+	cached, err := s.repoCacheRepo.GetUserActivitySummary(ctx, userID)
+	if err == nil && cached != nil {
+		return cached, nil
+	}
+
+	summary, err := s.githubClient.GetUserActivitySummary(ctx, accessToken, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity summary: %w", err)
+	}
+
+	if err := s.repoCacheRepo.SetUserActivitySummary(ctx, userID, summary); err != nil {
+		logger.FromContext(ctx).Warn("Failed to cache activity summary", "userID", userID, "error", err)
+	}
+
+	return summary, nil
+}
+
 func (s *GitHubService) GetRepository(ctx context.Context, accessToken, owner, repo string) (*models.Repository, error) {
 	gr, err := s.githubClient.GetRepository(ctx, accessToken, owner, repo)
 	if err != nil {
@@ -110,52 +368,155 @@ func (s *GitHubService) GetRepository(ctx context.Context, accessToken, owner, r
 	}
 
 	repository := &models.Repository{
-		ID:              gr.GetID(),
-		GitHubID:        gr.GetID(),
-		Name:            gr.GetName(),
-		FullName:        gr.GetFullName(),
-		Description:     gr.GetDescription(),
-		Private:         gr.GetPrivate(),
-		Fork:            gr.GetFork(),
-		Language:        gr.GetLanguage(),
-		StargazersCount: gr.GetStargazersCount(),
-		ForksCount:      gr.GetForksCount(),
-		OpenIssuesCount: gr.GetOpenIssuesCount(),
-		DefaultBranch:   gr.GetDefaultBranch(),
-		Topics:          gr.Topics,
-		HTMLURL:         gr.GetHTMLURL(),
-		CloneURL:        gr.GetCloneURL(),
-		CreatedAt:       gr.GetCreatedAt().Time,
-		UpdatedAt:       gr.GetUpdatedAt().Time,
-		PushedAt:        gr.GetPushedAt().Time,
+		ID:               gr.GetID(),
+		GitHubID:         gr.GetID(),
+		Name:             gr.GetName(),
+		FullName:         gr.GetFullName(),
+		Description:      gr.GetDescription(),
+		Private:          gr.GetPrivate(),
+		Fork:             gr.GetFork(),
+		Language:         gr.GetLanguage(),
+		StargazersCount:  gr.GetStargazersCount(),
+		ForksCount:       gr.GetForksCount(),
+		WatchersCount:    gr.GetWatchersCount(),
+		SubscribersCount: gr.GetSubscribersCount(),
+		OpenIssuesCount:  gr.GetOpenIssuesCount(),
+		DefaultBranch:    gr.GetDefaultBranch(),
+		Topics:           gr.Topics,
+		HTMLURL:          gr.GetHTMLURL(),
+		CloneURL:         gr.GetCloneURL(),
+		CreatedAt:        gr.GetCreatedAt().Time,
+		UpdatedAt:        gr.GetUpdatedAt().Time,
+		PushedAt:         gr.GetPushedAt().Time,
 	}
 
 	return repository, nil
 }
 
-func (s *GitHubService) DeployProfileREADME(ctx context.Context, accessToken, username, content string) error {
+// profileRepoInitTimeout is how long EnsureProfileRepo waits for a new
+// profile repository's initial README commit, polling every
+// profileRepoPollInterval.
+const (
+	profileRepoInitTimeout  = 5 * time.Second
+	profileRepoPollInterval = time.Second
+)
+
+// EnsureProfileRepo creates the user's profile repository, username/username,
+// when it doesn't exist, and reports whether it did. A new repository is
+// public and initialized with a README; EnsureProfileRepo waits up to
+// profileRepoInitTimeout for that commit, so a deploy right after doesn't
+// race it, and carries on when it doesn't show up in time.
+func (s *GitHubService) EnsureProfileRepo(ctx context.Context, accessToken, username string) (created bool, err error) {
+	_, err = s.githubClient.GetRepository(ctx, accessToken, username, username)
+	if err == nil {
+		return false, nil
+	}
+	if !github.IsNotFound(err) {
+		return false, fmt.Errorf("failed to check profile repository: %w", err)
+	}
+
+	if _, err := s.githubClient.CreateRepository(ctx, accessToken, username, "My GitHub profile README"); err != nil {
+		return false, fmt.Errorf("failed to create profile repository: %w", err)
+	}
+	logger.FromContext(ctx).Info("Created profile repository", "username", username)
+
+	waitCtx, cancel := context.WithTimeout(ctx, profileRepoInitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(profileRepoPollInterval)
+	defer ticker.Stop()
+	for {
+		if sha, err := s.githubClient.GetFileContentSHA(waitCtx, accessToken, username, models.DefaultTargetFilePath); err == nil && sha != "" {
+			return true, nil
+		}
+		select {
+		case <-waitCtx.Done():
+			logger.FromContext(ctx).Warn("Profile repository README not committed in time", "username", username)
+			return true, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeployProfileREADME commits content to targetPath in the user's profile
+// repository, which must pass validators.ValidateProfileFilePath. The
+// repository is created first if needed; repoCreated reports whether it was.
+// Nothing is committed when the file already holds content, compared by
+// SHA-256, and contentChanged is false.
+func (s *GitHubService) DeployProfileREADME(ctx context.Context, accessToken, username, targetPath, content string) (repoCreated, contentChanged bool, err error) {
+	if err := validators.ValidateProfileFilePath(targetPath); err != nil {
+		return false, false, err
+	}
+
+	repoCreated, err = s.EnsureProfileRepo(ctx, accessToken, username)
+	if err != nil {
+		return false, false, err
+	}
+
 	currentSHA := ""
-	sha, err := s.githubClient.GetProfileReadmeSHA(ctx, accessToken, username)
+	sha, err := s.githubClient.GetFileContentSHA(ctx, accessToken, username, targetPath)
 	if err == nil {
 		currentSHA = sha
 	}
+	if currentSHA != "" {
+		if err := validators.ValidateGitSHA(currentSHA); err != nil {
+			return repoCreated, false, fmt.Errorf("failed to get current README: %w", err)
+		}
+		// An unreadable README is overwritten as before
+		current, err := s.githubClient.GetRepositoryContent(ctx, accessToken, username, username, targetPath)
+		if err == nil && sha256.Sum256([]byte(current)) == sha256.Sum256([]byte(content)) {
+			return repoCreated, false, nil
+		}
+	}
 
 	message := "Update profile README via GitRight"
 	if currentSHA == "" {
 		message = "Create profile README via GitRight"
 	}
 
-	if err := s.githubClient.CreateOrUpdateFile(ctx, accessToken, username, username, "README.md", message, content, currentSHA); err != nil {
-		return fmt.Errorf("failed to deploy README: %w", err)
+	if err := s.githubClient.CreateOrUpdateFile(ctx, accessToken, username, username, targetPath, message, content, currentSHA); err != nil {
+		return repoCreated, false, fmt.Errorf("failed to deploy README: %w", err)
 	}
 
-	return nil
+	return repoCreated, true, nil
+}
+
+// PreviewDeploy diffs newContent against the current targetPath in the
+// profile repository without writing anything to GitHub.
+func (s *GitHubService) PreviewDeploy(ctx context.Context, accessToken, username, targetPath, newContent string) (*models.DeployPreview, error) {
+	sha, err := s.githubClient.GetFileContentSHA(ctx, accessToken, username, targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current README: %w", err)
+	}
+	if sha != "" {
+		if err := validators.ValidateGitSHA(sha); err != nil {
+			return nil, fmt.Errorf("failed to get current README: %w", err)
+		}
+	}
+
+	preview := &models.DeployPreview{
+		NewContent:    newContent,
+		IsFirstDeploy: sha == "",
+		CurrentSHA:    sha,
+	}
+	if sha != "" {
+		current, err := s.githubClient.GetRepositoryContent(ctx, accessToken, username, username, targetPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current README: %w", err)
+		}
+		preview.CurrentContent = current
+	}
+
+	if preview.CurrentContent != newContent {
+		preview.Diff = unifiedDiff(targetPath, preview.CurrentContent, newContent)
+	}
+	return preview, nil
 }
 
 func (s *GitHubService) ClearUserCache(ctx context.Context, userID int64) error {
 	if err := s.repoCacheRepo.InvalidateAllRepositoryLists(ctx, userID); err != nil {
-		slog.Warn("Failed to invalidate repository list cache", "userID", userID, "error", err)
+		logger.FromContext(ctx).Warn("Failed to invalidate repository list cache", "userID", userID, "error", err)
 	}
+	s.auditService.Record(ctx, userID, audit.ActionCacheCleared, "repository_list_cache", 0, nil, nil)
 	return nil
 }
 
@@ -170,7 +531,7 @@ func (s *GitHubService) ValidateRepositoryAccess(ctx context.Context, accessToke
 // BatchAnalyzeRepositories analyzes multiple repositories. It returns partial
 // results when individual repositories fail; the caller receives both the
 // successful analyses and a joined error listing every failure.
-func (s *GitHubService) BatchAnalyzeRepositories(ctx context.Context, accessToken string, repos []string) (map[string]*models.RepositoryAnalysis, error) {
+func (s *GitHubService) BatchAnalyzeRepositories(ctx context.Context, userID int64, accessToken string, repos []string) (map[string]*models.RepositoryAnalysis, error) {
 	results := make(map[string]*models.RepositoryAnalysis, len(repos))
 	var errs []error
 
@@ -183,9 +544,9 @@ func (s *GitHubService) BatchAnalyzeRepositories(ctx context.Context, accessToke
 
 		owner, repo := parts[0], parts[1]
 
-		analysis, err := s.AnalyzeRepository(ctx, accessToken, owner, repo)
+		analysis, err := s.AnalyzeRepository(ctx, userID, accessToken, owner, repo)
 		if err != nil {
-			slog.Warn("Failed to analyze repository in batch", "repo", fullName, "error", err)
+			logger.FromContext(ctx).Warn("Failed to analyze repository in batch", "repo", fullName, "error", err)
 			errs = append(errs, fmt.Errorf("repository %q: %w", fullName, err))
 			continue
 		}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v60/github"
+	"github.com/krauzx/gitright/internal/config"
+	"github.com/krauzx/gitright/internal/github"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/testutil"
+)
+
+func TestPreviewDeployTargetFilePath(t *testing.T) {
+	fakeGitHub := testutil.NewFakeGitHub()
+	fakeGitHub.Contents["octocat/octocat/README.md"] = "# README\n"
+	fakeGitHub.Contents["octocat/octocat/docs/index.md"] = "# Docs\n"
+	service := services.NewGitHubService(fakeGitHub, nil, nil, nil, config.AnalysisConfig{})
+
+	preview, err := service.PreviewDeploy(context.Background(), "token", "octocat", "docs/index.md", "# New\n")
+	if err != nil {
+		t.Fatalf("PreviewDeploy: %v", err)
+	}
+	if preview.CurrentContent != "# Docs\n" {
+		t.Errorf("CurrentContent = %q, want the content of docs/index.md", preview.CurrentContent)
+	}
+	if preview.IsFirstDeploy {
+		t.Error("IsFirstDeploy = true for an existing docs/index.md")
+	}
+	if !strings.Contains(preview.Diff, "docs/index.md") {
+		t.Errorf("Diff does not name docs/index.md:\n%s", preview.Diff)
+	}
+
+	preview, err = service.PreviewDeploy(context.Background(), "token", "octocat", "PROFILE.md", "# New\n")
+	if err != nil {
+		t.Fatalf("PreviewDeploy: %v", err)
+	}
+	if !preview.IsFirstDeploy || preview.CurrentContent != "" {
+		t.Errorf("missing PROFILE.md: IsFirstDeploy = %v, CurrentContent = %q, want a first deploy", preview.IsFirstDeploy, preview.CurrentContent)
+	}
+}
+
+func TestGetRecommendedRepositoriesUsesAnalyzedScore(t *testing.T) {
+	pushed := gogithub.Timestamp{Time: time.Now()}
+	popular := &gogithub.Repository{ID: gogithub.Int64(1), Name: gogithub.String("popular"), FullName: gogithub.String("octocat/popular"),
+		StargazersCount: gogithub.Int(12), PushedAt: &pushed}
+	strict := &gogithub.Repository{ID: gogithub.Int64(2), Name: gogithub.String("strict"), FullName: gogithub.String("octocat/strict"),
+		StargazersCount: gogithub.Int(11), PushedAt: &pushed}
+
+	rank := func(db *fakeDB) []string {
+		t.Helper()
+		conn := sql.OpenDB(db)
+		t.Cleanup(func() { conn.Close() })
+		service := services.NewGitHubService(testutil.NewFakeGitHub(popular, strict), nil,
+			repository.NewRepositoryCacheRepository(conn), nil, config.AnalysisConfig{})
+		repos, err := service.GetRecommendedRepositories(context.Background(), testUser.ID, "token", 2)
+		if err != nil {
+			t.Fatalf("GetRecommendedRepositories: %v", err)
+		}
+		var names []string
+		for _, r := range repos {
+			names = append(names, r.Name)
+		}
+		return names
+	}
+
+	if got := rank(&fakeDB{}); !slices.Equal(got, []string{"popular", "strict"}) {
+		t.Fatalf("without analyses ranked %v, want the starrier repository first", got)
+	}
+
+	// An analysis of strict found strict TypeScript, boosting its score 10%
+	var analyzer *github.Analyzer
+	score := analyzer.ComputeActivityScore(&models.Repository{StargazersCount: 11, PushedAt: pushed.Time}) * 1.1
+	if got := rank(&fakeDB{activityScores: map[int64]float64{2: score}}); !slices.Equal(got, []string{"strict", "popular"}) {
+		t.Errorf("with strict analyzed ranked %v, want it first", got)
+	}
+}
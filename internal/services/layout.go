@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/krauzx/gitright/internal/i18n"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// SectionHero is the ID of the untitled block above the first heading.
+const SectionHero = "hero"
+
+// maxLayoutSections caps the section IDs a stored layout may list.
+const maxLayoutSections = 32
+
+var layoutSectionIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// defaultProfileLayout groups the standard sections for frontends that lay
+// the profile out in columns. Sections it doesn't list, i.e. custom
+// sections, go in the main column.
+var defaultProfileLayout = models.ProfileLayout{
+	SidebarSections:   []string{i18n.GitHubStats, i18n.Connect, i18n.TechStack},
+	MainSections:      []string{i18n.AboutMe, i18n.FeaturedProjects},
+	FullWidthSections: []string{SectionHero, i18n.ContributionActivity},
+}
+
+// ValidateProfileLayout rejects malformed section IDs and sections listed
+// more than once. IDs need not name a rendered section: custom sections are
+// referred to by the ID derived from their title.
+func ValidateProfileLayout(layout models.ProfileLayout) error {
+	seen := make(map[string]bool)
+	for _, ids := range [][]string{layout.SidebarSections, layout.MainSections, layout.FullWidthSections} {
+		for _, id := range ids {
+			if !layoutSectionIDPattern.MatchString(id) {
+				return fmt.Errorf("invalid section id %q: use lowercase letters, digits and dashes", id)
+			}
+			if seen[id] {
+				return fmt.Errorf("section %q is listed more than once", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) > maxLayoutSections {
+		return fmt.Errorf("layout may list at most %d sections", maxLayoutSections)
+	}
+	return nil
+}
+
+// profileLayout groups the sections rendered in markdown, in README order.
+// A section listed in the user's layout goes in that group, otherwise in its
+// defaultProfileLayout group.
+func profileLayout(markdown string, custom models.ProfileLayout) models.ProfileLayout {
+	const (
+		main = iota
+		sidebar
+		fullWidth
+	)
+	groups := make(map[string]int)
+	for _, layout := range []models.ProfileLayout{defaultProfileLayout, custom} {
+		for _, id := range layout.SidebarSections {
+			groups[id] = sidebar
+		}
+		for _, id := range layout.MainSections {
+			groups[id] = main
+		}
+		for _, id := range layout.FullWidthSections {
+			groups[id] = fullWidth
+		}
+	}
+
+	result := models.ProfileLayout{
+		SidebarSections:   []string{},
+		MainSections:      []string{},
+		FullWidthSections: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, section := range SplitMarkdownSections(markdown) {
+		if seen[section.ID] {
+			continue
+		}
+		seen[section.ID] = true
+		switch groups[section.ID] {
+		case sidebar:
+			result.SidebarSections = append(result.SidebarSections, section.ID)
+		case fullWidth:
+			result.FullWidthSections = append(result.FullWidthSections, section.ID)
+		default:
+			result.MainSections = append(result.MainSections, section.ID)
+		}
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"log/slog"
+	"strings"
+	"unicode"
+
+	"github.com/krauzx/gitright/internal/i18n"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// SplitMarkdownSections splits a generated README on its "## " headings.
+// Concatenating the sections' Markdown reproduces the input.
+func SplitMarkdownSections(markdown string) []models.RenderedSection {
+	var sections []models.RenderedSection
+	current := models.RenderedSection{ID: SectionHero}
+	var body strings.Builder
+
+	flush := func() {
+		current.Markdown = body.String()
+		if strings.TrimSpace(current.Markdown) != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			title := strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			current = models.RenderedSection{ID: sectionID(title), Title: title}
+		}
+		body.WriteString(line)
+	}
+	flush()
+
+	return sections
+}
+
+// sectionID turns "🚀 Featured Projects" into "featured-projects". Localized
+// standard headings map to their English ID.
+func sectionID(title string) string {
+	if id, ok := i18n.SectionID(title); ok {
+		return id
+	}
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// Minimum content a section needs to be kept by minContentCheck.
+const (
+	minConnectLinks    = 2 // not counting the GitHub link
+	minTechStackBadges = 3
+)
+
+// minContentCheck drops sections that would render sparse or empty: Connect
+// with fewer than minConnectLinks links besides GitHub, Tech Stack with fewer
+// than minTechStackBadges badges, Featured Projects without any summary, and
+// Contribution Activity when the projects have no commits. Sections forced
+// on through SectionToggles are kept.
+func minContentCheck(markdown string, req *models.ContentGenerationRequest, summaries []models.ProjectSummary) string {
+	var out strings.Builder
+
+	sections := SplitMarkdownSections(markdown)
+	for i, section := range sections {
+		var reason string
+		switch {
+		case req.SectionToggles[section.ID]:
+			// Forced on by the user
+		case section.ID == "connect":
+			links := strings.Count(section.Markdown, "[![") - strings.Count(section.Markdown, "[![GitHub]")
+			if links < minConnectLinks {
+				reason = "too few links"
+			}
+		case section.ID == "tech-stack":
+			if strings.Count(section.Markdown, "![") < minTechStackBadges {
+				reason = "too few badges"
+			}
+		case section.ID == "featured-projects":
+			if !hasProjectSummary(summaries) {
+				reason = "no project summaries"
+			}
+		case section.ID == "contribution-activity":
+			if totalCommitCount(req.Projects) == 0 {
+				reason = "no commits"
+			}
+		}
+
+		if reason == "" {
+			out.WriteString(section.Markdown)
+			continue
+		}
+
+		slog.Debug("Removed sparse profile section", "section", section.ID, "reason", reason)
+
+		// The footer follows the last section without a heading of its own
+		if j := strings.LastIndex(section.Markdown, "\n---\n\n"); j >= 0 && i == len(sections)-1 {
+			out.WriteString(section.Markdown[j+1:])
+		}
+	}
+
+	return out.String()
+}
+
+func hasProjectSummary(summaries []models.ProjectSummary) bool {
+	for _, sum := range summaries {
+		if sum.Repository == nil {
+			continue
+		}
+		if sum.Summary != "" || (sum.Project != nil && sum.Project.CustomSummary != "") {
+			return true
+		}
+	}
+	return false
+}
+
+func totalCommitCount(projects []models.RepositoryAnalysis) int {
+	total := 0
+	for _, p := range projects {
+		total += p.CommitCount
+	}
+	return total
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+)
+
+// systemMetricsTTL is how long a metrics snapshot is served before the
+// aggregate query runs again, so dashboards and scrapers polling often do
+// not each scan the event tables.
+const systemMetricsTTL = 60 * time.Second
+
+// MetricsService serves system-wide usage metrics.
+type MetricsService struct {
+	metricsRepo   *repository.MetricsRepository
+	githubService *GitHubService
+
+	mu        sync.Mutex
+	snapshot  *models.SystemMetrics
+	fetchedAt time.Time
+}
+
+func NewMetricsService(metricsRepo *repository.MetricsRepository, githubService *GitHubService) *MetricsService {
+	return &MetricsService{
+		metricsRepo:   metricsRepo,
+		githubService: githubService,
+	}
+}
+
+// SystemMetrics returns a snapshot at most systemMetricsTTL old.
+// GitHubAPICallsToday counts this process's calls only.
+func (s *MetricsService) SystemMetrics(ctx context.Context) (*models.SystemMetrics, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.snapshot != nil && time.Since(s.fetchedAt) < systemMetricsTTL {
+		return s.snapshot, nil
+	}
+
+	snapshot, err := s.metricsRepo.GetSystemMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.GitHubAPICallsToday = s.githubService.GitHubCallsToday()
+
+	s.snapshot, s.fetchedAt = snapshot, time.Now()
+	return snapshot, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+)
+
+type PreferencesService struct {
+	prefsRepo        *repository.PreferencesRepository
+	profileCacheRepo *repository.ProfileCacheRepository
+	scheduler        *RegenerationScheduler
+}
+
+func NewPreferencesService(
+	prefsRepo *repository.PreferencesRepository,
+	profileCacheRepo *repository.ProfileCacheRepository,
+	scheduler *RegenerationScheduler,
+) *PreferencesService {
+	return &PreferencesService{
+		prefsRepo:        prefsRepo,
+		profileCacheRepo: profileCacheRepo,
+		scheduler:        scheduler,
+	}
+}
+
+func (s *PreferencesService) GetPreferences(ctx context.Context, userID int64) (*models.UserPreferences, error) {
+	return s.prefsRepo.GetByUserID(ctx, userID)
+}
+
+// UpdateSchedule validates and stores the user's regeneration schedule and
+// applies it to the running scheduler. An empty schedule disables regeneration.
+func (s *PreferencesService) UpdateSchedule(ctx context.Context, userID int64, schedule string, autoDeploy bool) error {
+	schedule = strings.TrimSpace(schedule)
+	if schedule != "" {
+		if err := ValidateSchedule(schedule); err != nil {
+			return err
+		}
+	}
+
+	if err := s.prefsRepo.UpsertSchedule(ctx, userID, schedule, autoDeploy); err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+
+	return s.scheduler.Schedule(userID, schedule)
+}
+
+// UpdateTemplateVariables replaces the user's template variable overrides.
+// Cached profiles are dropped since they were rendered with the old values.
+func (s *PreferencesService) UpdateTemplateVariables(ctx context.Context, userID int64, vars map[string]string) error {
+	if err := ValidateTemplateVariables(vars); err != nil {
+		return err
+	}
+
+	if err := s.prefsRepo.UpsertTemplateVariables(ctx, userID, vars); err != nil {
+		return fmt.Errorf("failed to save template variables: %w", err)
+	}
+
+	if err := s.profileCacheRepo.InvalidateByUserID(ctx, userID); err != nil {
+		slog.Warn("Failed to invalidate cached profiles after template update", "userID", userID, "error", err)
+	}
+	return nil
+}
+
+// UpdateTypingSVG replaces the user's typing SVG configuration and drops
+// cached profiles rendered with the old banner.
+func (s *PreferencesService) UpdateTypingSVG(ctx context.Context, userID int64, cfg models.TypingSVGConfig) error {
+	if err := ValidateTypingSVGConfig(cfg); err != nil {
+		return err
+	}
+
+	if err := s.prefsRepo.UpsertTypingSVG(ctx, userID, cfg); err != nil {
+		return fmt.Errorf("failed to save typing SVG config: %w", err)
+	}
+
+	if err := s.profileCacheRepo.InvalidateByUserID(ctx, userID); err != nil {
+		slog.Warn("Failed to invalidate cached profiles after typing SVG update", "userID", userID, "error", err)
+	}
+	return nil
+}
+
+// UpdateLayout replaces the user's section layout and drops cached profiles
+// computed with the old one.
+func (s *PreferencesService) UpdateLayout(ctx context.Context, userID int64, layout models.ProfileLayout) error {
+	if err := ValidateProfileLayout(layout); err != nil {
+		return err
+	}
+
+	if err := s.prefsRepo.UpsertLayout(ctx, userID, layout); err != nil {
+		return fmt.Errorf("failed to save layout config: %w", err)
+	}
+
+	if err := s.profileCacheRepo.InvalidateByUserID(ctx, userID); err != nil {
+		slog.Warn("Failed to invalidate cached profiles after layout update", "userID", userID, "error", err)
+	}
+	return nil
+}
+
+// UpdateShowcaseOptIn sets whether the user's latest deployed profile is
+// listed in the public showcase. Listing takes effect immediately, for
+// profiles deployed before opting in too.
+func (s *PreferencesService) UpdateShowcaseOptIn(ctx context.Context, userID int64, optIn bool) error {
+	if err := s.prefsRepo.UpsertShowcaseOptIn(ctx, userID, optIn); err != nil {
+		return fmt.Errorf("failed to save showcase opt-in: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package services
+
+import "github.com/krauzx/gitright/internal/models"
+
+// privacyActive reports whether any privacy setting changes the profile.
+// Such profiles bypass the profile cache: its key ignores privacy, so a
+// cached profile could reveal hidden details or carry another request's
+// alias.
+func privacyActive(p models.PrivacySettings) bool {
+	return p.HideLocation || p.HideCompany || p.HideEmail || p.UseAlias != ""
+}
+
+// privacyView returns user with the details p hides cleared. The username is
+// kept for URLs; see displayName.
+func privacyView(user *models.User, p models.PrivacySettings) *models.User {
+	if !p.HideLocation && !p.HideCompany && !p.HideEmail {
+		return user
+	}
+	view := *user
+	if p.HideLocation {
+		view.Location = ""
+	}
+	if p.HideCompany {
+		view.Company = ""
+	}
+	if p.HideEmail {
+		view.Email = ""
+	}
+	return &view
+}
+
+// displayName is the name the profile greets with: the alias when set,
+// otherwise "@username".
+func displayName(user *models.User, p models.PrivacySettings) string {
+	if p.UseAlias != "" {
+		return p.UseAlias
+	}
+	return "@" + user.Username
+}
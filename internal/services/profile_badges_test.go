@@ -0,0 +1,103 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+func badgeNames(badges []models.Badge) []string {
+	names := make([]string, len(badges))
+	for i, b := range badges {
+		names[i] = b.Name
+	}
+	return names
+}
+
+func TestBuildBadgesFromProjectData(t *testing.T) {
+	tests := []struct {
+		name       string
+		projects   []models.RepositoryAnalysis
+		llmSkills  []string
+		emphasized []string
+		want       []string
+	}{
+		{
+			name:       "aliases merge into one badge",
+			llmSkills:  []string{"js", "JavaScript", "javascript"},
+			emphasized: []string{"golang", "Go"},
+			want:       []string{"Go", "JavaScript"},
+		},
+		{
+			name:      "alias keeps the higher priority",
+			projects:  []models.RepositoryAnalysis{{Languages: map[string]int{"JavaScript": 100}}},
+			llmSkills: []string{"js", "k8s"},
+			want:      []string{"JavaScript", "Kubernetes"},
+		},
+		{
+			name: "ordered by source priority",
+			projects: []models.RepositoryAnalysis{{
+				Languages:    map[string]int{"Python": 100},
+				Dependencies: map[string][]string{"npm": {"react"}},
+			}},
+			llmSkills:  []string{"Docker"},
+			emphasized: []string{"postgres"},
+			want:       []string{"PostgreSQL", "Python", "React", "Docker"},
+		},
+		{
+			name:      "ties ordered by name",
+			llmSkills: []string{"Rust", "Docker", "Go"},
+			want:      []string{"Docker", "Go", "Rust"},
+		},
+		{
+			name:       "emphasis outranks a language",
+			projects:   []models.RepositoryAnalysis{{Languages: map[string]int{"Go": 100}}},
+			llmSkills:  []string{"TypeScript"},
+			emphasized: []string{"ts"},
+			want:       []string{"TypeScript", "Go"},
+		},
+		{
+			name:     "trace languages skipped",
+			projects: []models.RepositoryAnalysis{{Languages: map[string]int{"Go": 990, "Shell": 10}}},
+			want:     []string{"Go"},
+		},
+		{
+			name:      "unknown skills skipped",
+			llmSkills: []string{"Leadership", "rust"},
+			want:      []string{"Rust"},
+		},
+	}
+
+	s := &ProfileService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := badgeNames(s.buildBadgesFromProjectData(tt.projects, tt.llmSkills, tt.emphasized))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("badges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountCustomSectionBadges(t *testing.T) {
+	sections := []models.CustomSection{{Content: "![a](https://badges.example.com:8443/badge/a-1-blue) " +
+		"![b](https://img.shields.io/badge/b-2-red) ![c](https://badgesXexample.com:8443/badge/c)"}}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		want    int
+	}{
+		{name: "default instance", baseURL: "https://img.shields.io", want: 1},
+		{name: "self-hosted instance", baseURL: "https://badges.example.com:8443", want: 1},
+		{name: "unset", baseURL: "", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countCustomSectionBadges(customBadgePattern(tt.baseURL), sections); got != tt.want {
+				t.Errorf("countCustomSectionBadges = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
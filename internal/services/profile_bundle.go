@@ -0,0 +1,218 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/pkg/logger"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrNoProfile is returned when the user has no generated profile to export.
+var ErrNoProfile = errors.New("no generated profile")
+
+const (
+	bundleDownloadTimeout = 30 * time.Second
+	maxBundleDownloads    = 10
+	maxBundleAssetSize    = 2 << 20
+)
+
+// defaultAssetHosts are the image services generated profiles embed.
+// Images from other hosts are left as remote links in bundles rather than
+// fetched server-side, and aren't loaded by previews.
+var defaultAssetHosts = map[string]bool{
+	"github.com":                              true,
+	"img.shields.io":                          true,
+	"komarev.com":                             true,
+	"github-readme-stats.vercel.app":          true,
+	"streak-stats.demolab.com":                true,
+	"readme-typing-svg.demolab.com":           true,
+	"github-profile-trophy.vercel.app":        true,
+	"github-readme-activity-graph.vercel.app": true,
+	"opengraph.githubassets.com":              true,
+	"repository-images.githubusercontent.com": true,
+}
+
+// bundleImagePattern matches markdown image targets, ![alt](url), and HTML
+// <img src="url"> attributes. The URL is in group 1 or 2.
+var bundleImagePattern = regexp.MustCompile(`!\[[^\]]*\]\((https?://[^)\s]+)\)|<img[^>]+src="(https?://[^"]+)"`)
+
+var bundleHTTPClient = &http.Client{
+	Transport: otelhttp.NewTransport(http.DefaultTransport),
+}
+
+// ExportBundle packages the user's latest generated profile as a ZIP holding
+// README.md and an assets/ directory with every badge and stats image it
+// embeds. Images that fail to download keep their remote URL.
+func (s *ProfileService) ExportBundle(ctx context.Context, user *models.User) ([]byte, error) {
+	profile, _, err := s.LatestProfile(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+	if profile == nil {
+		return nil, ErrNoProfile
+	}
+
+	markdown, assets := downloadBundleAssets(ctx, profile.Markdown, s.AssetHosts())
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := writeZipFile(zw, "README.md", []byte(markdown)); err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(assets)) {
+		if err := writeZipFile(zw, name, assets[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// AssetHosts returns the hosts of the images generated profiles embed:
+// defaultAssetHosts plus the configured self-hosted badge and stats
+// services.
+func (s *ProfileService) AssetHosts() map[string]bool {
+	hosts := maps.Clone(defaultAssetHosts)
+	for _, base := range []string{
+		s.generationCfg.ShieldsBaseURL,
+		s.generationCfg.GithubStatsBaseURL,
+		s.generationCfg.StreakStatsBaseURL,
+	} {
+		if u, err := url.Parse(base); err == nil && u.Hostname() != "" {
+			hosts[u.Hostname()] = true
+		}
+	}
+	return hosts
+}
+
+// downloadBundleAssets fetches the images markdown embeds from hosts and
+// returns the markdown rewritten to reference them locally, plus the assets
+// keyed by their path in the bundle.
+func downloadBundleAssets(ctx context.Context, markdown string, hosts map[string]bool) (string, map[string][]byte) {
+	urls := []string{}
+	seen := make(map[string]bool)
+	for _, m := range bundleImagePattern.FindAllStringSubmatch(markdown, -1) {
+		raw := m[1] + m[2]
+		if seen[raw] {
+			continue
+		}
+		seen[raw] = true
+		if u, err := url.Parse(html.UnescapeString(raw)); err == nil && hosts[u.Hostname()] {
+			urls = append(urls, raw)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, bundleDownloadTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	paths := make(map[string]string, len(urls)) // raw URL -> bundle path
+	assets := make(map[string][]byte, len(urls))
+
+	g := new(errgroup.Group)
+	g.SetLimit(maxBundleDownloads)
+	for _, raw := range urls {
+		g.Go(func() error {
+			data, ext, err := fetchBundleAsset(ctx, html.UnescapeString(raw))
+			if err != nil {
+				logger.FromContext(ctx).Warn("Skipping bundle asset", "url", raw, "error", err)
+				return nil
+			}
+
+			sum := sha256.Sum256([]byte(raw))
+			name := "assets/" + hex.EncodeToString(sum[:8]) + ext
+
+			mu.Lock()
+			defer mu.Unlock()
+			paths[raw] = name
+			assets[name] = data
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	// Rewrite each image target in place so identical URLs elsewhere in the
+	// text, e.g. in links, keep pointing at the remote image.
+	markdown = bundleImagePattern.ReplaceAllStringFunc(markdown, func(match string) string {
+		m := bundleImagePattern.FindStringSubmatch(match)
+		raw := m[1] + m[2]
+		if name, ok := paths[raw]; ok {
+			return strings.Replace(match, raw, "./"+name, 1)
+		}
+		return match
+	})
+	return markdown, assets
+}
+
+// fetchBundleAsset downloads one image and returns it with a file extension
+// derived from its content type, defaulting to .svg.
+func fetchBundleAsset(ctx context.Context, rawURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := bundleHTTPClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download asset: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBundleAssetSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read asset: %w", err)
+	}
+	if len(data) > maxBundleAssetSize {
+		return nil, "", fmt.Errorf("asset exceeds %d bytes", maxBundleAssetSize)
+	}
+
+	ext := ".svg"
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "image/png":
+		ext = ".png"
+	case "image/gif":
+		ext = ".gif"
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/webp":
+		ext = ".webp"
+	}
+	return data, ext, nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	return nil
+}
@@ -2,39 +2,186 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"html"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/krauzx/gitright/internal/audit"
+	"github.com/krauzx/gitright/internal/config"
+	"github.com/krauzx/gitright/internal/i18n"
 	"github.com/krauzx/gitright/internal/llm"
 	"github.com/krauzx/gitright/internal/models"
 	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/internal/validators"
+	"github.com/krauzx/gitright/pkg/logger"
+	"github.com/krauzx/gitright/pkg/telemetry"
+	"golang.org/x/sync/errgroup"
 )
 
+// LLMProviderInterface is the content generation surface ProfileService
+// uses. It is implemented by *llm.ContentGenerator and, for tests, by
+// testutil.FakeLLM.
+type LLMProviderInterface interface {
+	GenerateBatchedProfile(ctx context.Context, apiKey string, req llm.BatchProfileRequest) (*llm.BatchProfileResponse, error)
+	EstimateBatchedProfile(ctx context.Context, req llm.BatchProfileRequest) *llm.CostEstimate
+}
+
 type ProfileService struct {
-	contentGenerator *llm.ContentGenerator
+	contentGenerator LLMProviderInterface
 	projectRepo      *repository.ProjectRepository
 	githubService    *GitHubService
 	profileCacheRepo *repository.ProfileCacheRepository
+	prefsRepo        *repository.PreferencesRepository
+	emailService     *EmailService
+	auditService     *AuditService
+	cooldownRepo     *repository.CooldownRepository
+	abTestRepo       *repository.ABTestRepository
+	metricsRepo      *repository.MetricsRepository
+	generationCfg    config.GenerationConfig
+	// badgePattern matches badges from the configured shields.io instance
+	badgePattern *regexp.Regexp
+	// admins are exempt from generation cooldowns
+	admins map[string]bool
 }
 
 func NewProfileService(
-	contentGenerator *llm.ContentGenerator,
+	contentGenerator LLMProviderInterface,
 	projectRepo *repository.ProjectRepository,
 	githubService *GitHubService,
 	profileCacheRepo *repository.ProfileCacheRepository,
+	prefsRepo *repository.PreferencesRepository,
+	emailService *EmailService,
+	auditService *AuditService,
+	cooldownRepo *repository.CooldownRepository,
+	abTestRepo *repository.ABTestRepository,
+	metricsRepo *repository.MetricsRepository,
+	generationCfg config.GenerationConfig,
+	adminUsernames []string,
 ) *ProfileService {
+	admins := make(map[string]bool, len(adminUsernames))
+	for _, u := range adminUsernames {
+		admins[u] = true
+	}
 	return &ProfileService{
 		contentGenerator: contentGenerator,
 		projectRepo:      projectRepo,
 		githubService:    githubService,
 		profileCacheRepo: profileCacheRepo,
+		prefsRepo:        prefsRepo,
+		emailService:     emailService,
+		auditService:     auditService,
+		cooldownRepo:     cooldownRepo,
+		abTestRepo:       abTestRepo,
+		metricsRepo:      metricsRepo,
+		generationCfg:    generationCfg,
+		badgePattern:     customBadgePattern(generationCfg.ShieldsBaseURL),
+		admins:           admins,
 	}
 }
 
+// ErrPinnedCacheMiss is returned by GenerateProfile when PinToCache is set
+// and no cached result exists.
+var ErrPinnedCacheMiss = errors.New("no cached profile for pinned request")
+
 func (s *ProfileService) GenerateProfile(ctx context.Context, req *models.ContentGenerationRequest, user *models.User) (*models.ContentGenerationResponse, error) {
+	if req.PinToCache && req.DryRun {
+		return dryRunResponse(user), nil
+	}
+	// A pinned request never reaches the LLM, so it needs no key
+	if req.UserAPIKey == "" && !req.PinToCache {
+		return nil, fmt.Errorf("API key required - get free key: https://aistudio.google.com/app/apikey")
+	}
+	if len(req.Projects) == 0 {
+		return nil, fmt.Errorf("at least one project required")
+	}
+
+	cacheKey := s.cacheKey(req, user)
+	if !privacyActive(req.Privacy) {
+		if cached, needsRefresh, err := s.profileCacheRepo.Get(ctx, cacheKey); err == nil && cached != nil {
+			s.recordProfileEvent(ctx, user, models.ProfileEventCacheHit, 0)
+			// A pinned profile was reviewed, so it is never swapped out
+			if needsRefresh && !req.PinToCache {
+				s.refreshInBackground(ctx, req, user, cacheKey)
+			}
+			return cached, nil
+		}
+	}
+	if req.PinToCache {
+		return nil, ErrPinnedCacheMiss
+	}
+
+	// Cache hits cost nothing, so only generations are throttled
+	if err := s.checkCooldown(ctx, user); err != nil {
+		return nil, err
+	}
+	response, err := s.generate(ctx, req, user, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cooldownRepo.Record(ctx, user.ID); err != nil {
+		logger.FromContext(ctx).Warn("Failed to record generation cooldown", "username", user.Username, "error", err)
+	}
+	return response, nil
+}
+
+// refreshInBackground regenerates a stale cached profile after it was served,
+// so the next request gets a fresh one without waiting on the LLM. Only the
+// caller that claims the refresh regenerates; the rest keep serving the
+// stale profile. A refresh is a generation, so it waits out the cooldown
+// and counts towards it. The generation outlives the request, so it gets
+// its own context, and works on copies of req and user.
+func (s *ProfileService) refreshInBackground(ctx context.Context, req *models.ContentGenerationRequest, user *models.User, cacheKey string) {
+	if req.UserAPIKey == "" {
+		return
+	}
+	if err := s.checkCooldown(ctx, user); err != nil {
+		return
+	}
+	claimed, err := s.profileCacheRepo.ClaimRefresh(ctx, cacheKey, regenerationTimeout)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to claim profile refresh", "username", user.Username, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	if err := s.cooldownRepo.Record(ctx, user.ID); err != nil {
+		logger.FromContext(ctx).Warn("Failed to record generation cooldown", "username", user.Username, "error", err)
+	}
+
+	refreshReq := *req
+	refreshReq.Projects = slices.Clone(req.Projects)
+	refreshUser := *user
+	log := logger.FromContext(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(logger.WithContext(context.Background(), log), regenerationTimeout)
+		defer cancel()
+		if _, err := s.generate(ctx, &refreshReq, &refreshUser, cacheKey); err != nil {
+			log.Warn("Failed to refresh stale cached profile", "username", refreshUser.Username, "error", err)
+		}
+	}()
+}
+
+// dryRunResponse is the placeholder returned for pinned dry runs.
+func dryRunResponse(user *models.User) *models.ContentGenerationResponse {
+	return &models.ContentGenerationResponse{
+		Markdown:              fmt.Sprintf("# %s\n\nDry run placeholder: no profile was generated.\n", user.Username),
+		ExtractedSkills:       []string{},
+		SuggestedBadges:       []models.Badge{},
+		ConfidenceLevel:       confidenceLevel(0),
+		ConfidenceExplanation: "Dry run: no repositories were analyzed.",
+	}
+}
+
+// RegenerateProfile discards any cached result for req and generates afresh.
+func (s *ProfileService) RegenerateProfile(ctx context.Context, req *models.ContentGenerationRequest, user *models.User) (*models.ContentGenerationResponse, error) {
 	if req.UserAPIKey == "" {
 		return nil, fmt.Errorf("API key required - get free key: https://aistudio.google.com/app/apikey")
 	}
@@ -42,69 +189,509 @@ func (s *ProfileService) GenerateProfile(ctx context.Context, req *models.Conten
 		return nil, fmt.Errorf("at least one project required")
 	}
 
-	cacheKey := repository.GetCacheKey(user.Username, req.TargetRole, req.ToneOfVoice, len(req.Projects))
-	if cached, err := s.profileCacheRepo.Get(ctx, cacheKey); err == nil && cached != nil {
-		return cached, nil
+	cacheKey := s.cacheKey(req, user)
+	if privacyActive(req.Privacy) {
+		// The result won't replace the cached profile, so keep it
+		return s.generate(ctx, req, user, cacheKey)
+	}
+	if err := s.profileCacheRepo.Invalidate(ctx, cacheKey); err != nil {
+		logger.FromContext(ctx).Warn("Failed to invalidate cached profile", "username", user.Username, "error", err)
+	}
+
+	return s.generate(ctx, req, user, cacheKey)
+}
+
+func (s *ProfileService) cacheKey(req *models.ContentGenerationRequest, user *models.User) string {
+	return repository.GetCacheKey(user.Username, i18n.Normalize(req.Language), req)
+}
+
+// EstimateProfile prices a generation of req without calling the model or
+// requiring the user's API key.
+func (s *ProfileService) EstimateProfile(ctx context.Context, req *models.ContentGenerationRequest, user *models.User) (*llm.CostEstimate, error) {
+	if len(req.Projects) == 0 {
+		return nil, fmt.Errorf("at least one project required")
+	}
+	if err := s.resolveGists(ctx, req, user); err != nil {
+		return nil, err
+	}
+	return s.contentGenerator.EstimateBatchedProfile(ctx, s.batchRequest(ctx, req, user)), nil
+}
+
+// resolveGists fetches every project selected by gist_id that has not been
+// fetched yet.
+func (s *ProfileService) resolveGists(ctx context.Context, req *models.ContentGenerationRequest, user *models.User) error {
+	for i, project := range req.Projects {
+		if project.GistID == "" || project.Gist != nil {
+			continue
+		}
+		if user.Source == models.UserSourceEmail {
+			return fmt.Errorf("gists are not available to demo accounts")
+		}
+		analysis, err := s.githubService.AnalyzeGist(ctx, user.AccessToken, project.GistID)
+		if err != nil {
+			return fmt.Errorf("failed to load gist %s: %w", project.GistID, err)
+		}
+		req.Projects[i] = *analysis
+	}
+	return nil
+}
+
+func (s *ProfileService) batchRequest(ctx context.Context, req *models.ContentGenerationRequest, user *models.User) llm.BatchProfileRequest {
+	// Demo accounts have no GitHub activity to summarize
+	var activity *models.UserActivitySummary
+	if user.Source != models.UserSourceEmail {
+		var err error
+		activity, err = s.githubService.GetUserActivitySummary(ctx, user.ID, user.AccessToken, user.Username)
+		if err != nil {
+			logger.FromContext(ctx).Warn("Failed to fetch GitHub activity summary", "username", user.Username, "error", err)
+		}
+	}
+
+	username := user.Username
+	if req.Privacy.UseAlias != "" {
+		username = req.Privacy.UseAlias
 	}
+	shown := privacyView(user, req.Privacy)
 
-	batchReq := llm.BatchProfileRequest{
-		Username:         user.Username,
+	return llm.BatchProfileRequest{
+		Username:         username,
 		Bio:              user.Bio,
-		Location:         user.Location,
-		Company:          user.Company,
+		Location:         shown.Location,
+		Company:          shown.Company,
 		TargetRole:       req.TargetRole,
 		ToneOfVoice:      req.ToneOfVoice,
+		Language:         i18n.Name(req.Language),
 		EmphasizedSkills: req.EmphasizedSkills,
 		Projects:         req.Projects,
+		Activity:         activity,
+		Parameters:       req.Parameters,
+	}
+}
+
+// generate runs an LLM generation, recording its outcome, duration and token
+// usage for the system metrics. The result is cached under cacheKey unless
+// it is "" or the request sets privacy options.
+func (s *ProfileService) generate(ctx context.Context, req *models.ContentGenerationRequest, user *models.User, cacheKey string) (*models.ContentGenerationResponse, error) {
+	start := time.Now()
+	usageCtx := llm.WithUsageRecorder(ctx, func(promptTokens, outputTokens int) {
+		if err := s.metricsRepo.RecordLLMUsage(ctx, user.ID, promptTokens, outputTokens); err != nil {
+			logger.FromContext(ctx).Warn("Failed to record LLM usage", "username", user.Username, "error", err)
+		}
+	})
+
+	response, err := s.runGeneration(usageCtx, req, user, cacheKey)
+	event := models.ProfileEventGenerated
+	if err != nil {
+		event = models.ProfileEventFailed
+	}
+	s.recordProfileEvent(ctx, user, event, time.Since(start))
+	return response, err
+}
+
+func (s *ProfileService) recordProfileEvent(ctx context.Context, user *models.User, eventType string, duration time.Duration) {
+	if err := s.metricsRepo.RecordProfileEvent(ctx, user.ID, eventType, duration); err != nil {
+		logger.FromContext(ctx).Warn("Failed to record profile event", "username", user.Username, "event", eventType, "error", err)
+	}
+}
+
+func (s *ProfileService) runGeneration(ctx context.Context, req *models.ContentGenerationRequest, user *models.User, cacheKey string) (*models.ContentGenerationResponse, error) {
+	if err := s.resolveGists(ctx, req, user); err != nil {
+		return nil, err
+	}
+
+	savedProjects := make(map[int64]*models.Project)
+	if projects, err := s.projectRepo.GetByUserID(ctx, user.ID); err != nil {
+		logger.FromContext(ctx).Warn("Failed to load saved projects", "username", user.Username, "error", err)
+	} else {
+		for _, p := range projects {
+			savedProjects[p.GitHubID] = p
+		}
 	}
 
+	// Filter a copy so the cached request keeps every selected project for
+	// scheduled regeneration.
+	cachedReq := req
+	filtered := *req
+	var omitted []string
+	filtered.Projects, omitted = s.filterFeaturedProjects(req, savedProjects)
+	req = &filtered
+
+	batchReq := s.batchRequest(ctx, req, user)
+
 	batchResp, err := s.contentGenerator.GenerateBatchedProfile(ctx, req.UserAPIKey, batchReq)
 	if err != nil {
 		return nil, fmt.Errorf("generation failed: %w", err)
 	}
+	if n := len(batchResp.GroundingChunks); n > 0 {
+		logger.FromContext(ctx).Debug("Grounding used", "sources", n)
+	}
 
 	summaries := make([]models.ProjectSummary, 0, len(batchResp.ProjectSummaries))
 	for i, proj := range batchResp.ProjectSummaries {
 		if i >= len(req.Projects) {
 			break
 		}
-		summaries = append(summaries, models.ProjectSummary{
+		summary := models.ProjectSummary{
 			Repository: req.Projects[i].Repository,
+			Gist:       req.Projects[i].Gist,
 			Summary:    proj.Summary,
 			TechStack:  proj.Skills,
-		})
+		}
+		if repo := req.Projects[i].Repository; repo != nil {
+			summary.Project = savedProjects[repo.GitHubID]
+		}
+		summaries = append(summaries, summary)
+	}
+	if req.SectionToggles[SectionSocialPreviews] {
+		s.loadSocialPreviews(ctx, user, summaries)
+	}
+	if req.SectionToggles[SectionCoAuthors] {
+		s.loadCoAuthors(ctx, user, req.Projects)
+	}
+
+	var templateOverrides map[string]string
+	var typingSVG models.TypingSVGConfig
+	var layout models.ProfileLayout
+	if prefs, err := s.prefsRepo.GetByUserID(ctx, user.ID); err != nil {
+		logger.FromContext(ctx).Warn("Failed to load template variables", "username", user.Username, "error", err)
+	} else {
+		templateOverrides = prefs.TemplateVariables
+		typingSVG = prefs.TypingSVG
+		layout = prefs.Layout
 	}
 
 	config := &models.ProfileConfig{
-		TargetRole:     req.TargetRole,
-		SkillsEmphasis: req.EmphasizedSkills,
-		ToneOfVoice:    req.ToneOfVoice,
-		ContactPrefs:   req.ContactPrefs,
+		TargetRole:        req.TargetRole,
+		SkillsEmphasis:    req.EmphasizedSkills,
+		ToneOfVoice:       req.ToneOfVoice,
+		ContactPrefs:      req.ContactPrefs,
+		TemplateVariables: mergeTemplateVariables(s.generationCfg.DefaultTemplateVars, templateOverrides),
+		TypingSVG:         typingSVG,
+		Layout:            layout,
+	}
+	if req.Privacy.HideEmail {
+		config.ContactPrefs.Email = ""
 	}
 
 	badges := s.buildBadgesFromProjectData(req.Projects, batchResp.ExtractedSkills, req.EmphasizedSkills)
-	markdown := s.buildMarkdown(user, req, batchResp.ProfilePitch, summaries, badges, config)
+	badgeCategories, badgesOmitted := s.organizeBadgesByCategory(badges, req.EmphasizedSkills, collectTopLanguages(req.Projects, 10),
+		countCustomSectionBadges(s.badgePattern, req.CustomSections))
+	if badgesOmitted > 0 {
+		logger.FromContext(ctx).Info("Omitted badges over configured limits", "username", user.Username, "omitted", badgesOmitted)
+	}
+	shown := privacyView(user, req.Privacy)
+	markdown, sectionLayout := s.buildMarkdown(shown, req, batchResp.ProfilePitch, summaries, badgeCategories, config)
+	seo := s.ExtractSEOMetadata(markdown, shown, req, batchResp.ExtractedSkills)
+	if req.SectionToggles[SectionSEOMeta] {
+		markdown = seoComments(seo) + markdown
+	}
 
 	response := &models.ContentGenerationResponse{
-		Markdown:        markdown,
-		ExtractedSkills: batchResp.ExtractedSkills,
-		SuggestedBadges: badges,
-		Confidence:      batchResp.Confidence,
+		Markdown:            markdown,
+		ExtractedSkills:     batchResp.ExtractedSkills,
+		SuggestedBadges:     badges,
+		BadgesOmitted:       badgesOmitted,
+		OmittedRepositories: omitted,
+		Confidence:          batchResp.Confidence,
+		AIGrounded:          len(batchResp.GroundingChunks) > 0,
+		SEOMetadata:         &seo,
+		Layout:              &sectionLayout,
+	}
+	response.ConfidenceLevel = confidenceLevel(batchResp.Confidence)
+	response.ConfidenceExplanation = explainConfidence(req.Projects)
+
+	readability := ReadabilityScorer{}.Score(batchResp.ProfilePitch)
+	response.ReadabilityScore = &readability
+	response.ReadabilityWarnings = readabilityWarnings(readability)
+	response.StructureWarnings = structureWarnings(markdown)
+	response.VulnerabilityWarnings = s.ValidateGenerationRequest(cachedReq)
+
+	if cacheKey != "" && !privacyActive(req.Privacy) {
+		topLanguages := collectTopLanguages(req.Projects, showcaseTopLanguages)
+		if err := s.profileCacheRepo.Set(ctx, user.ID, 0, cacheKey, cachedReq, response, topLanguages, 24*time.Hour); err != nil {
+			logger.FromContext(ctx).Warn("Failed to cache profile generation result", "username", user.Username, "error", err)
+		}
 	}
 
-	if err := s.profileCacheRepo.Set(ctx, user.ID, 0, cacheKey, response, 24*time.Hour); err != nil {
-		slog.Warn("Failed to cache profile generation result", "username", user.Username, "error", err)
+	projectNames := make([]string, 0, len(req.Projects))
+	for _, p := range req.Projects {
+		if p.Repository != nil {
+			projectNames = append(projectNames, p.Repository.FullName)
+		}
 	}
+	telemetry.ProfilesGenerated.Add(ctx, 1)
+	s.auditService.Record(ctx, user.ID, audit.ActionProfileGenerated, "profile", 0, nil, map[string]interface{}{
+		"target_role":   req.TargetRole,
+		"tone_of_voice": req.ToneOfVoice,
+		"projects":      projectNames,
+		"confidence":    response.Confidence,
+	})
 	return response, nil
 }
 
-func (s *ProfileService) DeployProfile(ctx context.Context, accessToken, username, markdown string) error {
-	if err := s.githubService.DeployProfileREADME(ctx, accessToken, username, markdown); err != nil {
-		return fmt.Errorf("failed to deploy profile: %w", err)
+// maxSocialPreviewFetches bounds concurrent repository page fetches.
+const maxSocialPreviewFetches = 5
+
+// loadSocialPreviews sets SocialPreviewURL on the featured repositories.
+// Failures only leave the image out. Sample repositories of demo accounts
+// have no page to fetch.
+func (s *ProfileService) loadSocialPreviews(ctx context.Context, user *models.User, summaries []models.ProjectSummary) {
+	if user.Source == models.UserSourceEmail {
+		return
+	}
+	g := new(errgroup.Group)
+	g.SetLimit(maxSocialPreviewFetches)
+	for _, sum := range summaries {
+		repo := sum.Repository
+		if repo == nil || sum.Gist != nil || repo.SocialPreviewURL != "" {
+			continue
+		}
+		owner, name, ok := strings.Cut(repo.FullName, "/")
+		if !ok {
+			continue
+		}
+		g.Go(func() error {
+			previewURL, err := s.githubService.GetRepositorySocialPreview(ctx, user.AccessToken, owner, name)
+			if err != nil {
+				logger.FromContext(ctx).Warn("Failed to fetch social preview", "repo", repo.FullName, "error", err)
+				return nil
+			}
+			repo.SocialPreviewURL = previewURL
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// loadCoAuthors resolves CoAuthors from the trailers of each featured
+// repository that has none yet. Sample repositories of demo accounts have no
+// accounts to look up.
+func (s *ProfileService) loadCoAuthors(ctx context.Context, user *models.User, projects []models.RepositoryAnalysis) {
+	if user.Source == models.UserSourceEmail {
+		return
+	}
+	for i := range projects {
+		p := &projects[i]
+		if p.Repository == nil || len(p.CoAuthors) > 0 || len(p.CoAuthorTrailers) == 0 {
+			continue
+		}
+		owner, _, _ := strings.Cut(p.Repository.FullName, "/")
+		p.CoAuthors = s.githubService.ResolveCoAuthors(ctx, user.AccessToken, owner, p.CoAuthorTrailers)
+	}
+}
+
+// maxMinStarsOverride caps ContentGenerationRequest.MinStars.
+const maxMinStarsOverride = 100
+
+// filterFeaturedProjects drops repositories with fewer stars than the
+// request's MinStars, or the configured default, and returns the names of
+// those dropped. Gists and personal_favorite repositories are always kept,
+// and when every repository is below the threshold, e.g. for a new
+// developer, nothing is dropped.
+func (s *ProfileService) filterFeaturedProjects(req *models.ContentGenerationRequest, savedProjects map[int64]*models.Project) ([]models.RepositoryAnalysis, []string) {
+	threshold := s.generationCfg.MinStarsForFeatured
+	if req.MinStars > 0 {
+		threshold = min(req.MinStars, maxMinStarsOverride)
+	}
+	if threshold <= 0 {
+		return req.Projects, nil
+	}
+
+	var kept []models.RepositoryAnalysis
+	var omitted []string
+	anyAbove := false
+	for _, p := range req.Projects {
+		repo := p.Repository
+		switch {
+		case repo == nil || p.Gist != nil:
+			kept = append(kept, p)
+		case repo.StargazersCount >= threshold:
+			kept = append(kept, p)
+			anyAbove = true
+		case savedProjects[repo.GitHubID] != nil && savedProjects[repo.GitHubID].FocusTag == "personal_favorite":
+			kept = append(kept, p)
+		default:
+			omitted = append(omitted, repo.Name)
+		}
+	}
+
+	if !anyAbove {
+		return req.Projects, nil
+	}
+	return kept, omitted
+}
+
+// LatestProfile returns the user's most recent cached generation and its
+// cache key, or nil when nothing is cached.
+func (s *ProfileService) LatestProfile(ctx context.Context, user *models.User) (*models.ContentGenerationResponse, string, error) {
+	req, err := s.profileCacheRepo.GetLastGenerationRequest(ctx, user.ID)
+	if err != nil || req == nil {
+		return nil, "", err
+	}
+
+	cacheKey := s.cacheKey(req, user)
+	response, _, err := s.profileCacheRepo.Get(ctx, cacheKey)
+	if err != nil || response == nil {
+		return nil, "", err
+	}
+	return response, cacheKey, nil
+}
+
+// SearchHistory full-text searches the user's previously generated profiles.
+func (s *ProfileService) SearchHistory(ctx context.Context, userID int64, query string, limit int) ([]*models.GeneratedProfile, error) {
+	return s.profileCacheRepo.Search(ctx, userID, query, limit)
+}
+
+// DeployResult describes a successful deploy.
+type DeployResult struct {
+	StructureWarnings []string
+	// RepoCreated is set when the profile repository didn't exist and was
+	// created for the deploy.
+	RepoCreated bool
+	// ContentChanged is false when the file already held the markdown and
+	// nothing was committed.
+	ContentChanged bool
+}
+
+// DeployProfile commits markdown to targetPath in the user's profile
+// repository, models.DefaultTargetFilePath when empty, creating the
+// repository if needed. Content that fails
+// validators.ValidateMarkdownContent is returned as a
+// *validators.MarkdownContentError without calling GitHub. Structural
+// problems don't stop the deploy; they are returned as warnings. An
+// unchanged file is left alone and only marks the profile deployed.
+func (s *ProfileService) DeployProfile(ctx context.Context, user *models.User, markdown, targetPath string) (*DeployResult, error) {
+	if err := validators.ValidateMarkdownContent(markdown); err != nil {
+		return nil, err
+	}
+	warnings := structureWarnings(markdown)
+	if len(warnings) > 0 {
+		logger.FromContext(ctx).Warn("Deploying profile with structure warnings", "username", user.Username, "warnings", warnings)
+	}
+	repoCreated, contentChanged, err := s.githubService.DeployProfileREADME(ctx, user.AccessToken, user.Username, TargetFilePath(targetPath), markdown)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy profile: %w", err)
+	}
+
+	// Only the showcase reads the deployed flag, so failing to set it
+	// doesn't fail the deploy
+	if marked, err := s.profileCacheRepo.MarkDeployed(ctx, user.ID, markdown); err != nil {
+		logger.FromContext(ctx).Warn("Failed to record profile deployment", "username", user.Username, "error", err)
+	} else if !marked {
+		logger.FromContext(ctx).Debug("Deployed profile not in cache, not listed in showcase", "username", user.Username)
+	}
+	if !contentChanged {
+		return &DeployResult{StructureWarnings: warnings, RepoCreated: repoCreated}, nil
+	}
+
+	telemetry.ProfilesDeployed.Add(ctx, 1)
+	s.recordProfileEvent(ctx, user, models.ProfileEventDeployed, 0)
+	metadata := map[string]interface{}{
+		"url": "https://github.com/" + user.Username,
+	}
+	if repoCreated {
+		metadata["repo_created"] = true
+	}
+	s.auditService.Record(ctx, user.ID, audit.ActionProfileDeployed, "profile", 0, nil, metadata)
+	s.emailService.SendDeployNotification(user.Email, user.Username, "https://github.com/"+user.Username)
+	return &DeployResult{StructureWarnings: warnings, RepoCreated: repoCreated, ContentChanged: true}, nil
+}
+
+// TargetFilePath defaults an empty ContentGenerationRequest.TargetFilePath.
+func TargetFilePath(path string) string {
+	if path == "" {
+		return models.DefaultTargetFilePath
+	}
+	return path
+}
+
+func structureWarnings(markdown string) []string {
+	var warnings []string
+	for _, w := range validators.ValidateMarkdownStructure(markdown) {
+		warnings = append(warnings, string(w))
+	}
+	return warnings
+}
+
+
+// ValidateGenerationRequest returns advisory warnings about req that don't
+// block generation: selected repositories with open HIGH or CRITICAL
+// vulnerability alerts, which are only known when the analysis checked them.
+func (s *ProfileService) ValidateGenerationRequest(req *models.ContentGenerationRequest) []string {
+	var warnings []string
+	for _, p := range req.Projects {
+		if p.Repository == nil || len(p.VulnerabilityAlerts) == 0 {
+			continue
+		}
+		counts := make(map[string]int)
+		for _, alert := range p.VulnerabilityAlerts {
+			counts[alert.Severity]++
+		}
+		for _, severity := range []string{models.SeverityCritical, models.SeverityHigh} {
+			if n := counts[severity]; n > 0 {
+				warnings = append(warnings, fmt.Sprintf("repository '%s' has %d %s severity vulnerabilities; consider addressing them before showcasing", p.Repository.Name, n, severity))
+			}
+		}
+	}
+	return warnings
+}
+
+// confidenceLevel buckets the LLM confidence score for display.
+func confidenceLevel(confidence float64) string {
+	switch {
+	case confidence < 0.5:
+		return "low"
+	case confidence <= 0.75:
+		return "medium"
+	default:
+		return "high"
 	}
-	return nil
 }
 
+// explainConfidence describes which gaps in the repository data are likely to
+// limit profile quality, so users know what to fix before regenerating.
+func explainConfidence(projects []models.RepositoryAnalysis) string {
+	total := len(projects)
+	if total == 0 {
+		return "No projects were provided; add repositories to improve quality"
+	}
+
+	var noDescription, noReadme, noCommits int
+	for _, p := range projects {
+		if p.Repository == nil || p.Repository.Description == "" {
+			noDescription++
+		}
+		hasReadme := false
+		for path := range p.KeyFiles {
+			if strings.EqualFold(filepath.Base(path), "README.md") {
+				hasReadme = true
+				break
+			}
+		}
+		if !hasReadme {
+			noReadme++
+		}
+		if p.CommitCount == 0 {
+			noCommits++
+		}
+	}
+
+	var reasons []string
+	if noDescription > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d of %d repos have no description; adding descriptions will improve quality", noDescription, total))
+	}
+	if noReadme > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d of %d repos have no README; a README gives the AI more context", noReadme, total))
+	}
+	if noCommits > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d of %d repos report no commits; commit history helps describe your activity", noCommits, total))
+	}
+
+	if len(reasons) == 0 {
+		return "All projects have descriptions, READMEs and commit history"
+	}
+	return strings.Join(reasons, ". ")
+}
 
 // buildBadgesFromProjectData creates badges sourced from (in priority order):
 //  1. EmphasizedSkills from the request
@@ -116,31 +703,51 @@ func (s *ProfileService) buildBadgesFromProjectData(
 	llmSkills, emphasizedSkills []string,
 ) []models.Badge {
 	catalog := buildBadgeCatalog()
-	badgeMap := make(map[string]models.Badge)
 
-	add := func(key string) {
-		if badge, ok := catalog[strings.ToLower(key)]; ok {
-			if _, exists := badgeMap[badge.Name]; !exists {
-				badgeMap[badge.Name] = badge
-			}
+	// Lower priority value wins; a badge reached again through a
+	// higher-priority source (possibly via an alias) is re-ranked.
+	type rankedBadge struct {
+		priority int
+		badge    models.Badge
+	}
+	badgeMap := make(map[string]rankedBadge)
+
+	add := func(key string, priority int) {
+		badge, ok := catalog[strings.ToLower(key)]
+		if !ok {
+			return
+		}
+		if existing, exists := badgeMap[badge.Name]; !exists || priority < existing.priority {
+			badgeMap[badge.Name] = rankedBadge{priority: priority, badge: badge}
 		}
 	}
 
 	// Priority 1 – user-selected emphasis
 	for _, skill := range emphasizedSkills {
-		add(skill)
+		add(skill, 1)
 	}
 
-	// Priority 2 – every language detected in every repo
+	// Priority 2 – languages making up a real share of a repo; trace
+	// amounts from boilerplate or vendored scripts don't count
 	for _, p := range projects {
-		for lang := range p.Languages {
-			add(lang)
+		for lang, weight := range languageWeights(p) {
+			if weight >= minLanguageWeight {
+				add(lang, 2)
+			}
+		}
+		// A build target, so its share of the bytes doesn't matter
+		if p.CompilesToWASM {
+			add("WebAssembly", 2)
 		}
 	}
 
 	// Priority 3 – infer frameworks from dependency names
 	for _, p := range projects {
-		for _, deps := range p.Dependencies {
+		for ecosystem, deps := range p.Dependencies {
+			// Transitive Go modules say little about the author's stack
+			if ecosystem == "go-indirect" {
+				continue
+			}
 			for _, dep := range deps {
 				key := strings.ToLower(dep)
 				// Normalise scoped npm packages: @angular/core -> angular
@@ -148,19 +755,57 @@ func (s *ProfileService) buildBadgesFromProjectData(
 					parts := strings.SplitN(key[1:], "/", 2)
 					key = parts[0]
 				}
-				add(key)
+				switch ecosystem {
+				case "maven", "gradle":
+					key = javaArtifactKey(key)
+				case "composer":
+					key = composerPackageKey(key)
+				case "gem", "gemspec":
+					key = gemKey(key)
+				}
+				add(key, 3)
+			}
+			if ecosystem == "composer" && len(deps) > 0 {
+				add("composer", 3)
+			}
+		}
+		// Deno and Bun are runtimes worth a badge; npm, yarn and pnpm aren't
+		add(p.PackageManager, 3)
+		if p.HasMakefile {
+			add("GNU Make", 3)
+		}
+		for _, tool := range p.IaCTools {
+			add(iacToolBadgeKeys[tool], 3)
+		}
+		for _, tool := range p.DataTools {
+			add(dataToolBadgeKeys[tool], 3)
+		}
+		if p.DevContainer != nil {
+			for _, ext := range p.DevContainer.Extensions {
+				add(devContainerExtensionKeys[ext], 3)
 			}
 		}
 	}
 
 	// Priority 4 – LLM extracted skills fill remaining gaps
 	for _, skill := range llmSkills {
-		add(skill)
+		add(skill, 4)
 	}
 
-	badges := make([]models.Badge, 0, len(badgeMap))
-	for _, b := range badgeMap {
-		badges = append(badges, b)
+	ranked := make([]rankedBadge, 0, len(badgeMap))
+	for _, rb := range badgeMap {
+		ranked = append(ranked, rb)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].priority != ranked[j].priority {
+			return ranked[i].priority < ranked[j].priority
+		}
+		return ranked[i].badge.Name < ranked[j].badge.Name
+	})
+
+	badges := make([]models.Badge, len(ranked))
+	for i, rb := range ranked {
+		badges[i] = rb.badge
 	}
 	return badges
 }
@@ -174,9 +819,10 @@ func buildBadgeCatalog() map[string]models.Badge {
 		{Name: "JavaScript", Color: "F7DF1E"},
 		{Name: "TypeScript", Color: "3178C6"},
 		{Name: "Rust", Color: "000000"},
+		{Name: "WebAssembly", Color: "654FF0"},
 		{Name: "Java", Color: "ED8B00"},
 		{Name: "Kotlin", Color: "7F52FF"},
-		{Name: "Swift", Color: "FA7343"},
+		{Name: "Swift", Color: "F05138"},
 		{Name: "C++", Color: "00599C"},
 		{Name: "C", Color: "A8B9CC"},
 		{Name: "C#", Color: "239120"},
@@ -185,6 +831,7 @@ func buildBadgeCatalog() map[string]models.Badge {
 		{Name: "Dart", Color: "0175C2"},
 		{Name: "Scala", Color: "DC322F"},
 		{Name: "Elixir", Color: "4B275F"},
+		{Name: "Erlang", Color: "A90533"},
 		{Name: "Haskell", Color: "5D4F85"},
 		{Name: "Lua", Color: "2C2D72"},
 		{Name: "Shell", Color: "4EAA25"},
@@ -204,6 +851,8 @@ func buildBadgeCatalog() map[string]models.Badge {
 		{Name: "Vite", Color: "646CFF"},
 		// ---------- Backend ----------
 		{Name: "Node.js", Color: "339933"},
+		{Name: "Deno", Color: "000000"},
+		{Name: "Bun", Color: "FBF0DF"},
 		{Name: "Express.js", Color: "000000"},
 		{Name: "Fastify", Color: "000000"},
 		{Name: "NestJS", Color: "E0234E"},
@@ -211,14 +860,36 @@ func buildBadgeCatalog() map[string]models.Badge {
 		{Name: "Flask", Color: "000000"},
 		{Name: "FastAPI", Color: "009688"},
 		{Name: "Spring Boot", Color: "6DB33F"},
+		{Name: "Spring Security", Color: "6DB33F"},
+		{Name: "Hibernate", Color: "59666C"},
+		{Name: "Quarkus", Color: "4695EB"},
+		{Name: "Micronaut", Color: "1CBFA0"},
+		{Name: "Lombok", Color: "BC4521"},
 		{Name: "Laravel", Color: "FF2D20"},
+		{Name: "Symfony", Color: "000000"},
+		{Name: "Guzzle", Color: "2B5D80"},
+		{Name: "Doctrine", Color: "FC6A31"},
 		{Name: "Ruby on Rails", Color: "CC0000"},
+		{Name: "Puma", Color: "000000"},
+		{Name: "Sidekiq", Color: "B1003E"},
 		{Name: "Fiber", Color: "00ADD8"},
 		{Name: "Gin", Color: "00ADD8"},
 		{Name: "Echo", Color: "00ADD8"},
+		{Name: "Phoenix", Color: "FD4F00"},
+		{Name: "LiveView", Color: "FD4F00"},
+		{Name: "Ecto", Color: "4B275F"},
 		// ---------- Mobile ----------
 		{Name: "Flutter", Color: "02569B"},
 		{Name: "React Native", Color: "61DAFB"},
+		{Name: "SwiftUI", Color: "000000"},
+		{Name: "Combine", Color: "F05138"},
+		{Name: "Alamofire", Color: "F05138"},
+		{Name: "Jetpack Compose", Color: "4285F4"},
+		{Name: "Hilt", Color: "2196F3"},
+		{Name: "Dagger", Color: "2196F3"},
+		{Name: "Retrofit", Color: "48B983"},
+		{Name: "OkHttp", Color: "3F4042"},
+		{Name: "Kotlin Coroutines", Color: "7F52FF"},
 		// ---------- Databases ----------
 		{Name: "PostgreSQL", Color: "316192"},
 		{Name: "MySQL", Color: "00000F"},
@@ -240,12 +911,25 @@ func buildBadgeCatalog() map[string]models.Badge {
 		{Name: "AWS", Color: "FF9900"},
 		{Name: "GCP", Color: "4285F4"},
 		{Name: "Azure", Color: "0078D4"},
+		{Name: "Azure Bicep", Color: "0078D4"},
 		{Name: "Vercel", Color: "000000"},
 		{Name: "Netlify", Color: "00C7B7"},
 		{Name: "Heroku", Color: "430098"},
 		{Name: "Nginx", Color: "009639"},
+		// ---------- Data & ML ----------
+		{Name: "Apache Airflow", Color: "017CEE"},
+		{Name: "dbt", Color: "FF694B"},
+		{Name: "Prefect", Color: "024DFD"},
+		{Name: "Dagster", Color: "4A4A4A"},
+		{Name: "Great Expectations", Color: "FF6310"},
 		// ---------- Tooling ----------
 		{Name: "Git", Color: "F05032"},
+		{Name: "GNU Make", Color: "A42E2B"},
+		{Name: "JUnit", Color: "25A162"},
+		{Name: "PHPUnit", Color: "3C9CD7"},
+		{Name: "Composer", Color: "885630"},
+		{Name: "RSpec", Color: "CC342D"},
+		{Name: "RuboCop", Color: "000000"},
 		{Name: "GraphQL", Color: "E10098"},
 		{Name: "gRPC", Color: "244C5A"},
 		{Name: "Apache Kafka", Color: "231F20"},
@@ -253,6 +937,7 @@ func buildBadgeCatalog() map[string]models.Badge {
 		{Name: "Prometheus", Color: "E6522C"},
 		{Name: "Grafana", Color: "F46800"},
 		{Name: "Linux", Color: "FCC624"},
+		{Name: "NixOS", Color: "5277C3"},
 		{Name: "OpenAI", Color: "412991"},
 	}
 
@@ -284,6 +969,25 @@ func buildBadgeCatalog() map[string]models.Badge {
 		"kafka":        "Apache Kafka",
 		"grpc":         "gRPC",
 		"bash":         "Shell",
+		"make":         "GNU Make",
+		"bicep":        "Azure Bicep",
+		"makefile":     "GNU Make",
+		// PyPI package names
+		"apache-airflow":     "Apache Airflow",
+		"airflow":            "Apache Airflow",
+		"dbt-core":           "dbt",
+		"great-expectations": "Great Expectations",
+		"great_expectations": "Great Expectations",
+		// Hex package names
+		"phoenix_live_view": "LiveView",
+		"ecto_sql":          "Ecto",
+		// Kotlin and Android shorthand
+		"compose":    "Jetpack Compose",
+		"coroutines": "Kotlin Coroutines",
+		// Nix flake inputs
+		"nixpkgs":      "NixOS",
+		"flake-utils":  "NixOS",
+		"home-manager": "NixOS",
 	}
 
 	// Index entries by lowercased canonical name
@@ -303,38 +1007,163 @@ func buildBadgeCatalog() map[string]models.Badge {
 	return m
 }
 
+// iacToolBadgeKeys maps RepositoryAnalysis.IaCTools to badge catalog keys.
+// Bicep and CloudFormation credit the cloud they deploy to.
+var iacToolBadgeKeys = map[string]string{
+	models.IaCTerraform:      "terraform",
+	models.IaCAzureBicep:     "azure",
+	models.IaCCloudFormation: "aws",
+}
+
+// dataToolBadgeKeys maps RepositoryAnalysis.DataTools to badge catalog keys.
+var dataToolBadgeKeys = map[string]string{
+	models.DataToolAirflow:           "apache airflow",
+	models.DataToolDBT:               "dbt",
+	models.DataToolPrefect:           "prefect",
+	models.DataToolDagster:           "dagster",
+	models.DataToolGreatExpectations: "great expectations",
+}
+
+// devContainerExtensionKeys maps VS Code extension IDs from a dev container
+// to badge catalog keys. ESLint implies JavaScript, which the catalog lacks
+// a separate badge for.
+var devContainerExtensionKeys = map[string]string{
+	"golang.go":                                   "go",
+	"ms-python.python":                            "python",
+	"dbaeumer.vscode-eslint":                      "javascript",
+	"rust-lang.rust-analyzer":                     "rust",
+	"redhat.java":                                 "java",
+	"vscjava.vscode-java-pack":                    "java",
+	"ms-dotnettools.csharp":                       "c#",
+	"dart-code.flutter":                           "flutter",
+	"jakebecker.elixir-ls":                        "elixir",
+	"rebornix.ruby":                               "ruby",
+	"shopify.ruby-lsp":                            "ruby",
+	"vue.volar":                                   "vue",
+	"svelte.svelte-vscode":                        "svelte",
+	"bradlc.vscode-tailwindcss":                   "tailwind",
+	"prisma.prisma":                               "prisma",
+	"hashicorp.terraform":                         "terraform",
+	"ms-azuretools.vscode-docker":                 "docker",
+	"ms-kubernetes-tools.vscode-kubernetes-tools": "kubernetes",
+	"graphql.vscode-graphql":                      "graphql",
+}
+
+// javaArtifactPrefixes maps artifact ID prefixes to badge catalog keys, most
+// specific first, so spring-boot-starter-security counts as Spring Security
+// rather than Spring Boot.
+var javaArtifactPrefixes = []struct{ prefix, key string }{
+	{"spring-boot-starter-security", "spring security"},
+	{"spring-security", "spring security"},
+	{"spring-boot", "spring boot"},
+	{"hibernate", "hibernate"},
+	{"junit", "junit"},
+	{"lombok", "lombok"},
+	{"quarkus", "quarkus"},
+	{"micronaut", "micronaut"},
+	{"hilt", "hilt"},
+	{"dagger", "dagger"},
+	{"kotlinx-coroutines", "kotlin coroutines"},
+	{"retrofit", "retrofit"},
+	{"okhttp", "okhttp"},
+}
+
+// composerPackageKeys maps Composer packages to catalog keys where the
+// package name alone is ambiguous, e.g. laravel/framework.
+var composerPackageKeys = map[string]string{
+	"laravel/framework":        "laravel",
+	"symfony/symfony":          "symfony",
+	"symfony/framework-bundle": "symfony",
+	"guzzlehttp/guzzle":        "guzzle",
+	"doctrine/orm":             "doctrine",
+	"phpunit/phpunit":          "phpunit",
+}
+
+// composerPackageKey maps a vendor/package name to its catalog key, falling
+// back to the package name.
+func composerPackageKey(pkg string) string {
+	if key, ok := composerPackageKeys[pkg]; ok {
+		return key
+	}
+	_, name, _ := strings.Cut(pkg, "/")
+	return name
+}
+
+// gemKey maps plugin gems to the tool they extend, so rspec-rails counts as
+// RSpec and rubocop-performance as RuboCop.
+func gemKey(gem string) string {
+	for _, base := range []string{"rspec", "rubocop"} {
+		if strings.HasPrefix(gem, base+"-") {
+			return base
+		}
+	}
+	return gem
+}
+
+// javaGroupPrefixes maps group ID prefixes to catalog keys for libraries
+// whose artifact IDs say nothing on their own, like androidx.compose.ui:ui.
+var javaGroupPrefixes = []struct{ prefix, key string }{
+	{"androidx.compose", "jetpack compose"},
+}
+
+// javaArtifactKey maps a Maven or Gradle artifact ID, or a "group:artifact"
+// coordinate from a Kotlin DSL script, to its catalog key, returning the
+// artifact unchanged when no prefix matches.
+func javaArtifactKey(artifact string) string {
+	if group, id, ok := strings.Cut(artifact, ":"); ok {
+		for _, p := range javaGroupPrefixes {
+			if strings.HasPrefix(group, p.prefix) {
+				return p.key
+			}
+		}
+		artifact = id
+	}
+	for _, p := range javaArtifactPrefixes {
+		if strings.HasPrefix(artifact, p.prefix) {
+			return p.key
+		}
+	}
+	return artifact
+}
+
 // toLogoSlug converts a badge display name to its shields.io simple-icons slug.
 func toLogoSlug(name string) string {
 	special := map[string]string{
-		"C++":           "cplusplus",
-		"C#":            "csharp",
-		"Vue.js":        "vuedotjs",
-		"Next.js":       "nextdotjs",
-		"Nuxt.js":       "nuxtdotjs",
-		"Express.js":    "express",
-		"Node.js":       "nodedotjs",
-		"Ruby on Rails": "rubyonrails",
-		"React Native":  "react",
-		"Spring Boot":   "springboot",
-		"Apache Kafka":  "apachekafka",
-		"TailwindCSS":   "tailwindcss",
-		"HTML5":         "html5",
-		"CSS3":          "css3",
-		"gRPC":          "grpc",
-		"GCP":           "googlecloud",
-		"AWS":           "amazonaws",
-		"Drizzle":       "drizzle",
-		"Neon":          "neon",
-		"Vite":          "vite",
-		"Astro":         "astro",
-		"Gatsby":        "gatsby",
-		"OpenAI":        "openai",
-		"Prometheus":    "prometheus",
-		"Grafana":       "grafana",
-		"Elasticsearch": "elasticsearch",
-		"Supabase":      "supabase",
-		"Firebase":      "firebase",
-		"Prisma":        "prisma",
+		"C++":            "cplusplus",
+		"C#":             "csharp",
+		"Vue.js":         "vuedotjs",
+		"Next.js":        "nextdotjs",
+		"Nuxt.js":        "nuxtdotjs",
+		"Express.js":     "express",
+		"Node.js":        "nodedotjs",
+		"Ruby on Rails":  "rubyonrails",
+		"React Native":   "react",
+		"Spring Boot":    "springboot",
+		"JUnit":          "junit5",
+		"Apache Kafka":   "apachekafka",
+		"TailwindCSS":    "tailwindcss",
+		"HTML5":          "html5",
+		"CSS3":           "css3",
+		"gRPC":           "grpc",
+		"GCP":            "googlecloud",
+		"AWS":            "amazonaws",
+		"Drizzle":        "drizzle",
+		"Neon":           "neon",
+		"Vite":           "vite",
+		"Astro":          "astro",
+		"Gatsby":         "gatsby",
+		"OpenAI":         "openai",
+		"Prometheus":     "prometheus",
+		"Grafana":        "grafana",
+		"Elasticsearch":  "elasticsearch",
+		"Supabase":       "supabase",
+		"Firebase":       "firebase",
+		"Prisma":         "prisma",
+		"Phoenix":        "phoenixframework",
+		"LiveView":       "phoenixframework",
+		"GNU Make":       "gnu",
+		"Azure Bicep":    "microsoftazure",
+		"Apache Airflow": "apacheairflow",
 	}
 	if slug, ok := special[name]; ok {
 		return slug
@@ -348,23 +1177,29 @@ func toLogoSlug(name string) string {
 }
 
 
-// collectTopLanguages sums bytes per language across all repos, returns top-n names.
+// collectTopLanguages sums language weights across all repos, so each repo
+// counts equally whatever its size, and returns the top-n names.
 func collectTopLanguages(projects []models.RepositoryAnalysis, n int) []string {
-	totals := make(map[string]int)
+	totals := make(map[string]float64)
 	for _, p := range projects {
-		for lang, b := range p.Languages {
-			totals[lang] += b
+		for lang, w := range languageWeights(p) {
+			totals[lang] += w
 		}
 	}
 	type pair struct {
-		name  string
-		bytes int
+		name   string
+		weight float64
 	}
 	sorted := make([]pair, 0, len(totals))
-	for name, b := range totals {
-		sorted = append(sorted, pair{name, b})
+	for name, w := range totals {
+		sorted = append(sorted, pair{name, w})
 	}
-	sort.Slice(sorted, func(i, j int) bool { return sorted[i].bytes > sorted[j].bytes })
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].weight != sorted[j].weight {
+			return sorted[i].weight > sorted[j].weight
+		}
+		return sorted[i].name < sorted[j].name
+	})
 	result := make([]string, 0, n)
 	for i, p := range sorted {
 		if i >= n {
@@ -375,6 +1210,30 @@ func collectTopLanguages(projects []models.RepositoryAnalysis, n int) []string {
 	return result
 }
 
+// minLanguageWeight is the share of a repository's bytes a language needs
+// for a Tech Stack badge.
+const minLanguageWeight = 0.05
+
+// languageWeights returns the project's language shares, computing them
+// from byte counts for analyses cached before LanguageWeights existed.
+func languageWeights(p models.RepositoryAnalysis) map[string]float64 {
+	if p.LanguageWeights != nil {
+		return p.LanguageWeights
+	}
+	total := 0
+	for _, b := range p.Languages {
+		total += b
+	}
+	if total == 0 {
+		return nil
+	}
+	weights := make(map[string]float64, len(p.Languages))
+	for lang, b := range p.Languages {
+		weights[lang] = float64(b) / float64(total)
+	}
+	return weights
+}
+
 // collectAllTopics gathers unique topics across all repos.
 func collectAllTopics(projects []models.RepositoryAnalysis) []string {
 	seen := make(map[string]bool)
@@ -401,6 +1260,26 @@ func portfolioURL(config *models.ProfileConfig, user *models.User) string {
 	return user.Blog
 }
 
+// linkURL returns raw if it is an http(s) URL that can sit in a markdown
+// link, or "" otherwise, so contact links can't use javascript: or other
+// schemes or break out of the link. A bare hostname, as GitHub blog fields
+// often are, gets https://.
+func linkURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, " \t\r\n()<>\"'`") {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err == nil && u.Scheme == "" && !strings.HasPrefix(raw, "/") {
+		raw = "https://" + raw
+		u, err = url.Parse(raw)
+	}
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return raw
+}
+
 // buildTypingLines creates URL-encoded lines for the readme-typing-svg service.
 func buildTypingLines(config *models.ProfileConfig, topLangs, topics []string) []string {
 	encode := func(s string) string {
@@ -435,21 +1314,92 @@ func buildTypingLines(config *models.ProfileConfig, topLangs, topics []string) [
 }
 
 
-// buildMarkdown assembles the README from all pipeline data — no hardcoded content.
+// markdownNameEscaper keeps names taken from commit trailers from adding
+// links, emphasis or HTML to the README.
+var markdownNameEscaper = strings.NewReplacer(
+	`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", ">", "&gt;",
+)
+
+// Featured Projects show a repository's open issue count above
+// manyOpenIssues, and its resolved count when it exceeds the open count
+// resolvedIssueRatio times over.
+const (
+	manyOpenIssues     = 20
+	resolvedIssueRatio = 5
+)
+
+// coAuthorLinks joins co-authors for the Featured Projects stats, linking
+// the "@login" ones to their profiles.
+func coAuthorLinks(coAuthors []string) string {
+	links := make([]string, len(coAuthors))
+	for i, name := range coAuthors {
+		if login, ok := strings.CutPrefix(name, "@"); ok {
+			links[i] = fmt.Sprintf("[@%s](https://github.com/%s)", login, login)
+		} else {
+			links[i] = markdownNameEscaper.Replace(name)
+		}
+	}
+	return strings.Join(links, ", ")
+}
+
+// gistBadgeLabel names a gist by its file, or by file count when it has
+// several.
+func gistBadgeLabel(gist *models.Gist) string {
+	if len(gist.Files) == 1 {
+		for name := range gist.Files {
+			return name
+		}
+	}
+	return fmt.Sprintf("%d files", len(gist.Files))
+}
+
+// buildMarkdown assembles the README from all pipeline data — no hardcoded
+// content — and groups the sections it kept per the user's layout.
 func (s *ProfileService) buildMarkdown(
 	user *models.User,
 	req *models.ContentGenerationRequest,
 	pitch string,
 	summaries []models.ProjectSummary,
-	badges []models.Badge,
+	badgeCategories map[string][]models.Badge,
 	config *models.ProfileConfig,
-) string {
+) (string, models.ProfileLayout) {
 	var md strings.Builder
+	lang := req.Language
+
+	focusTagEmoji := map[string]string{
+		"best_performance":  "🏆 Best Performance",
+		"team_project":      "👥 Team Project",
+		"personal_favorite": "❤️ Personal Favorite",
+		"open_source":       "🌍 Open Source",
+	}
 
 	username := user.Username
+	shields := s.generationCfg.ShieldsBaseURL
+	githubStats := s.generationCfg.GithubStatsBaseURL
+	streakStats := s.generationCfg.StreakStatsBaseURL
 	topLangs := collectTopLanguages(req.Projects, 5)
 	allTopics := collectAllTopics(req.Projects)
-	siteURL := portfolioURL(config, user)
+	siteURL := linkURL(portfolioURL(config, user))
+	custom := placeCustomSections(req.CustomSections)
+	// Conditional blocks are for user-written markdown only; the standard
+	// sections decide what to show themselves
+	conditions := templateConditions(user, req, config)
+	templateVars := applyConditionalTemplateVariables(config.TemplateVariables, conditions)
+
+	var shownBadges []models.Badge
+	for _, catBadges := range badgeCategories {
+		shownBadges = append(shownBadges, catBadges...)
+	}
+	visible := func(section string) bool {
+		return sectionVisible(section, user, req, shownBadges, config)
+	}
+	writeCustom := func(after int) {
+		for _, section := range custom[after] {
+			section.Title = ApplyConditionalBlocks(section.Title, conditions)
+			section.Content = ApplyConditionalBlocks(section.Content, conditions)
+			md.WriteString(renderCustomSection(section))
+		}
+	}
 
 	// Prefer contact email, fall back to account email
 	contactEmail := config.ContactPrefs.Email
@@ -460,16 +1410,21 @@ func (s *ProfileService) buildMarkdown(
 	// ── HERO ──────────────────────────────────────────────────────────────
 	md.WriteString("<div align=\"center\">\n\n")
 
+	greetName := markdownNameEscaper.Replace(displayName(user, req.Privacy))
 	if user.AvatarURL != "" {
 		md.WriteString(fmt.Sprintf(
-			"<img src=\"%s\" width=\"120\" height=\"120\" style=\"border-radius:50%%\" alt=\"@%s\" />\n\n",
-			user.AvatarURL, username,
+			"<img src=\"%s\" width=\"120\" height=\"120\" style=\"border-radius:50%%\" alt=\"%s\" />\n\n",
+			user.AvatarURL, html.EscapeString(displayName(user, req.Privacy)),
 		))
 	}
 
+	if templateVars["COMPANY_LOGO"] != "" {
+		md.WriteString("<img src=\"{{COMPANY_LOGO}}\" height=\"48\" alt=\"Company logo\" />\n\n")
+	}
+
 	md.WriteString(fmt.Sprintf(
-		"# Hi, I'm @%s <img src=\"https://raw.githubusercontent.com/MartinHeinz/MartinHeinz/master/wave.gif\" width=\"28px\" />\n\n",
-		username,
+		"# Hi, I'm %s <img src=\"https://raw.githubusercontent.com/MartinHeinz/MartinHeinz/master/wave.gif\" width=\"28px\" />\n\n",
+		greetName,
 	))
 
 	if user.Bio != "" {
@@ -496,24 +1451,29 @@ func (s *ProfileService) buildMarkdown(
 	// Typing SVG built from real data
 	typingLines := buildTypingLines(config, topLangs, allTopics)
 	md.WriteString(fmt.Sprintf(
-		"[![Typing SVG](https://readme-typing-svg.demolab.com?font=Fira+Code&size=22&duration=3000&pause=1000&color=2E97F7&center=true&vCenter=true&width=650&height=80&lines=%s)](https://git.io/typing-svg)\n\n",
-		strings.Join(typingLines, ";"),
+		"[![Typing SVG](%s)](https://git.io/typing-svg)\n\n",
+		typingSVGURL(config.TypingSVG, typingLines),
 	))
 
 	// Profile counters
 	md.WriteString(fmt.Sprintf("![Profile Views](https://komarev.com/ghpvc/?username=%s&label=Profile%%20Views&color=0e75b6&style=flat)\n", username))
-	md.WriteString(fmt.Sprintf("[![Followers](https://img.shields.io/github/followers/%s?label=Followers&style=social)](https://github.com/%s?tab=followers)\n", username, username))
-	md.WriteString(fmt.Sprintf("[![Stars](https://img.shields.io/github/stars/%s?label=Stars&style=social)](https://github.com/%s)\n\n", username, username))
+	md.WriteString(fmt.Sprintf("[![Followers](%s/github/followers/%s?label=Followers&style=social)](https://github.com/%s?tab=followers)\n", shields, username, username))
+	md.WriteString(fmt.Sprintf("[![Stars](%s/github/stars/%s?label=Stars&style=social)](https://github.com/%s)\n", shields, username, username))
+	if templateVars["CUSTOM_BADGE_URL"] != "" {
+		md.WriteString("![Badge]({{CUSTOM_BADGE_URL}})\n")
+	}
+	md.WriteString("\n")
 	md.WriteString("</div>\n\n")
+	writeCustom(0)
 
 	// ── ABOUT ME ──────────────────────────────────────────────────────────
-	md.WriteString("## 👨‍💻 About Me\n\n")
+	md.WriteString("## " + i18n.T(lang, i18n.AboutMe) + "\n\n")
 	md.WriteString(pitch)
 	md.WriteString("\n\n")
 
 	// Dynamic bullets sourced from real data
 	if config.TargetRole != "" {
-		md.WriteString(fmt.Sprintf("- 🎯 Growing as a **%s**\n", config.TargetRole))
+		md.WriteString("- " + fmt.Sprintf(i18n.T(lang, i18n.GrowingAs), config.TargetRole) + "\n")
 	}
 	if len(summaries) > 0 {
 		names := make([]string, 0, min(2, len(summaries)))
@@ -523,59 +1483,75 @@ func (s *ProfileService) buildMarkdown(
 			}
 		}
 		if len(names) > 0 {
-			md.WriteString(fmt.Sprintf("- 🔭 Currently building **%s**\n", strings.Join(names, " & ")))
+			md.WriteString("- " + fmt.Sprintf(i18n.T(lang, i18n.CurrentlyBuilding), strings.Join(names, " & ")) + "\n")
 		}
 	}
 	if len(config.SkillsEmphasis) > 0 {
-		md.WriteString(fmt.Sprintf("- 🌱 Deepening expertise in **%s**\n",
-			strings.Join(config.SkillsEmphasis[:min(2, len(config.SkillsEmphasis))], " & ")))
+		md.WriteString("- " + fmt.Sprintf(i18n.T(lang, i18n.DeepeningExpertise),
+			strings.Join(config.SkillsEmphasis[:min(2, len(config.SkillsEmphasis))], " & ")) + "\n")
 	} else if len(topLangs) > 0 {
-		md.WriteString(fmt.Sprintf("- 🌱 Deepening expertise in **%s**\n", topLangs[0]))
+		md.WriteString("- " + fmt.Sprintf(i18n.T(lang, i18n.DeepeningExpertise), topLangs[0]) + "\n")
 	}
 	if len(topLangs) > 0 {
-		md.WriteString(fmt.Sprintf("- 💬 Ask me about **%s**\n",
-			strings.Join(topLangs[:min(3, len(topLangs))], ", ")))
+		md.WriteString("- " + fmt.Sprintf(i18n.T(lang, i18n.AskMeAbout),
+			strings.Join(topLangs[:min(3, len(topLangs))], ", ")) + "\n")
 	}
 	if contactEmail != "" {
-		md.WriteString(fmt.Sprintf("- 📫 Reach me at **%s**\n", contactEmail))
+		md.WriteString("- " + fmt.Sprintf(i18n.T(lang, i18n.ReachMeAt), contactEmail) + "\n")
 	}
 	md.WriteString("\n")
+	writeCustom(1)
 
 	// ── CONNECT ───────────────────────────────────────────────────────────
-	md.WriteString("## 🌐 Connect\n\n")
-	md.WriteString("<div align=\"center\">\n\n")
+	if visible(SectionConnect) {
+		md.WriteString("## " + i18n.T(lang, i18n.Connect) + "\n\n")
+		md.WriteString("<div align=\"center\">\n\n")
 
-	if config.ContactPrefs.LinkedIn != "" {
-		md.WriteString(fmt.Sprintf("[![LinkedIn](https://img.shields.io/badge/LinkedIn-0077B5?style=for-the-badge&logo=linkedin&logoColor=white)](%s)\n", config.ContactPrefs.LinkedIn))
-	}
-	if config.ContactPrefs.Twitter != "" {
-		md.WriteString(fmt.Sprintf("[![Twitter/X](https://img.shields.io/badge/Twitter-000000?style=for-the-badge&logo=x&logoColor=white)](%s)\n", config.ContactPrefs.Twitter))
-	}
-	if contactEmail != "" {
-		md.WriteString(fmt.Sprintf("[![Email](https://img.shields.io/badge/Email-D14836?style=for-the-badge&logo=gmail&logoColor=white)](mailto:%s)\n", contactEmail))
-	}
-	if siteURL != "" {
-		md.WriteString(fmt.Sprintf("[![Website](https://img.shields.io/badge/Website-FF5722?style=for-the-badge&logo=googlechrome&logoColor=white)](%s)\n", siteURL))
+		if link := linkURL(config.ContactPrefs.LinkedIn); link != "" {
+			md.WriteString(fmt.Sprintf("[![LinkedIn](%s/badge/LinkedIn-0077B5?style=for-the-badge&logo=linkedin&logoColor=white)](%s)\n", shields, link))
+		}
+		if link := linkURL(config.ContactPrefs.Twitter); link != "" {
+			md.WriteString(fmt.Sprintf("[![Twitter/X](%s/badge/Twitter-000000?style=for-the-badge&logo=x&logoColor=white)](%s)\n", shields, link))
+		}
+		if link := linkURL(config.ContactPrefs.Mastodon); link != "" {
+			md.WriteString(fmt.Sprintf("[![Mastodon](%s/badge/Mastodon-6364FF?style=for-the-badge&logo=mastodon&logoColor=white)](%s)\n", shields, link))
+		}
+		if link := linkURL(config.ContactPrefs.Bluesky); link != "" {
+			md.WriteString(fmt.Sprintf("[![Bluesky](%s/badge/Bluesky-0285FF?style=for-the-badge&logo=bluesky&logoColor=white)](%s)\n", shields, link))
+		}
+		if contactEmail != "" {
+			md.WriteString(fmt.Sprintf("[![Email](%s/badge/Email-D14836?style=for-the-badge&logo=gmail&logoColor=white)](mailto:%s)\n", shields, contactEmail))
+		}
+		if siteURL != "" {
+			md.WriteString(fmt.Sprintf("[![Website](%s/badge/Website-FF5722?style=for-the-badge&logo=googlechrome&logoColor=white)](%s)\n", shields, siteURL))
+		}
+		md.WriteString(fmt.Sprintf("[![GitHub](%s/badge/GitHub-100000?style=for-the-badge&logo=github&logoColor=white)](https://github.com/%s)\n\n", shields, username))
+		md.WriteString("</div>\n\n")
 	}
-	md.WriteString(fmt.Sprintf("[![GitHub](https://img.shields.io/badge/GitHub-100000?style=for-the-badge&logo=github&logoColor=white)](https://github.com/%s)\n\n", username))
-	md.WriteString("</div>\n\n")
+	writeCustom(2)
 
 	// ── TECH STACK ────────────────────────────────────────────────────────
-	if len(badges) > 0 {
-		md.WriteString("## 🛠️ Tech Stack\n\n")
+	if visible(SectionTechStack) && len(badgeCategories) > 0 {
+		md.WriteString("## " + i18n.T(lang, i18n.TechStack) + "\n\n")
 		md.WriteString("<div align=\"center\">\n\n")
 
-		categories := s.organizeBadgesByCategory(badges)
-		for _, cat := range []string{"Languages", "Frameworks & Libraries", "Databases", "Tools & Platforms"} {
-			catBadges, ok := categories[cat]
+		for _, cat := range []struct{ name, label string }{
+			{"Languages", i18n.Languages},
+			{"Frameworks & Libraries", i18n.Frameworks},
+			{"Databases", i18n.Databases},
+			{"Data & ML", i18n.DataML},
+			{"Tools & Platforms", i18n.Tools},
+		} {
+			catBadges, ok := badgeCategories[cat.name]
 			if !ok || len(catBadges) == 0 {
 				continue
 			}
-			md.WriteString(fmt.Sprintf("**%s**\n\n", cat))
+			md.WriteString(fmt.Sprintf("**%s**\n\n", i18n.T(lang, cat.label)))
 			for _, b := range catBadges {
 				md.WriteString(fmt.Sprintf(
-					"![%s](https://img.shields.io/badge/%s-%s?style=flat-square&logo=%s&logoColor=white) ",
+					"![%s](%s/badge/%s-%s?style=flat-square&logo=%s&logoColor=white) ",
 					b.Name,
+					shields,
 					strings.ReplaceAll(b.Name, " ", "%20"),
 					b.Color,
 					toLogoSlug(b.Name),
@@ -585,31 +1561,35 @@ func (s *ProfileService) buildMarkdown(
 		}
 		md.WriteString("</div>\n\n")
 	}
+	writeCustom(3)
 
 	// ── GITHUB STATS ──────────────────────────────────────────────────────
-	md.WriteString("## 📊 GitHub Stats\n\n")
+	md.WriteString("## " + i18n.T(lang, i18n.GitHubStats) + "\n\n")
 	md.WriteString("<div align=\"center\">\n\n")
 	md.WriteString(fmt.Sprintf(
-		"![%s's stats](https://github-readme-stats.vercel.app/api?username=%s&show_icons=true&count_private=true&theme=tokyonight&hide_border=true)\n",
-		username, username,
+		"![%s's stats](%s/api?username=%s&show_icons=true&count_private=true&theme=tokyonight&hide_border=true)\n",
+		username, githubStats, username,
 	))
 	md.WriteString(fmt.Sprintf(
-		"![Top langs](https://github-readme-stats.vercel.app/api/top-langs/?username=%s&layout=compact&theme=tokyonight&hide_border=true)\n\n",
-		username,
+		"![Top langs](%s/api/top-langs/?username=%s&layout=compact&theme=tokyonight&hide_border=true)\n\n",
+		githubStats, username,
 	))
 	md.WriteString(fmt.Sprintf(
-		"![Streak](https://streak-stats.demolab.com?user=%s&theme=tokyonight&hide_border=true)\n\n",
-		username,
-	))
-	md.WriteString(fmt.Sprintf(
-		"[![Trophies](https://github-profile-trophy.vercel.app/?username=%s&theme=tokyonight&no-frame=true&margin-w=4)](https://github.com/ryo-ma/github-profile-trophy)\n\n",
-		username,
+		"![Streak](%s?user=%s&theme=tokyonight&hide_border=true)\n\n",
+		streakStats, username,
 	))
+	if visible(SectionTrophies) {
+		md.WriteString(fmt.Sprintf(
+			"[![Trophies](https://github-profile-trophy.vercel.app/?username=%s&theme=tokyonight&no-frame=true&margin-w=4)](https://github.com/ryo-ma/github-profile-trophy)\n\n",
+			username,
+		))
+	}
 	md.WriteString("</div>\n\n")
+	writeCustom(4)
 
 	// ── FEATURED PROJECTS ─────────────────────────────────────────────────
 	if len(summaries) > 0 {
-		md.WriteString("## 🚀 Featured Projects\n\n")
+		md.WriteString("## " + i18n.T(lang, i18n.FeaturedProjects) + "\n\n")
 
 		for i, sum := range summaries {
 			if sum.Repository == nil {
@@ -623,45 +1603,87 @@ func (s *ProfileService) buildMarkdown(
 
 			md.WriteString(fmt.Sprintf("### [%s](%s)\n\n", repo.Name, repo.HTMLURL))
 
+			if sum.Project != nil && sum.Project.FocusTag != "" {
+				if label, ok := focusTagEmoji[sum.Project.FocusTag]; ok {
+					md.WriteString(fmt.Sprintf(
+						"![%s](%s/badge/%s-8A2BE2?style=flat-square)\n\n",
+						label, shields, url.PathEscape(strings.ReplaceAll(label, "-", "--")),
+					))
+				}
+			}
+
 			if repo.Description != "" {
 				md.WriteString(fmt.Sprintf("> %s\n\n", repo.Description))
 			}
 
-			md.WriteString(fmt.Sprintf(
-				"[![Repo Card](https://github-readme-stats.vercel.app/api/pin/?username=%s&repo=%s&theme=tokyonight&hide_border=true)](%s)\n\n",
-				owner, repo.Name, repo.HTMLURL,
-			))
+			if sum.Gist != nil {
+				md.WriteString(fmt.Sprintf(
+					"[![Gist](%s/badge/Gist-%s-181717?style=for-the-badge&logo=github&logoColor=white)](%s)\n\n",
+					shields, url.PathEscape(strings.ReplaceAll(gistBadgeLabel(sum.Gist), "-", "--")), sum.Gist.HTMLURL,
+				))
+			} else {
+				if repo.SocialPreviewURL != "" {
+					md.WriteString(fmt.Sprintf("[![%s](%s)](%s)\n\n", repo.Name, repo.SocialPreviewURL, repo.HTMLURL))
+				}
+				md.WriteString(fmt.Sprintf(
+					"[![Repo Card](%s/api/pin/?username=%s&repo=%s&theme=tokyonight&hide_border=true)](%s)\n\n",
+					githubStats, owner, repo.Name, repo.HTMLURL,
+				))
+				if i < len(req.Projects) && req.Projects[i].DevContainer != nil {
+					md.WriteString(fmt.Sprintf(
+						"[![Open in GitHub Codespaces](https://github.com/codespaces/badge.svg)](https://codespaces.new/%s/%s)\n\n",
+						owner, repo.Name,
+					))
+				}
+			}
 
-			// LLM summary
-			if sum.Summary != "" {
-				md.WriteString(sum.Summary + "\n\n")
+			// User-written summary wins over the LLM summary
+			summaryText := sum.Summary
+			if sum.Project != nil && sum.Project.CustomSummary != "" {
+				summaryText = sum.Project.CustomSummary
+			}
+			if summaryText != "" {
+				md.WriteString(summaryText + "\n\n")
 			}
 
 			// Tech stack from LLM
-			if len(sum.TechStack) > 0 {
-				md.WriteString("**Tech:** ")
+			usesConventional := i < len(req.Projects) && req.Projects[i].CommitConvention != nil &&
+				req.Projects[i].CommitConvention.UsesConventionalCommits
+			if len(sum.TechStack) > 0 || usesConventional {
+				md.WriteString("**" + i18n.T(lang, i18n.Tech) + "** ")
 				for _, tech := range sum.TechStack {
 					md.WriteString(fmt.Sprintf("`%s` ", tech))
 				}
+				if usesConventional {
+					md.WriteString("![Conventional Commits](" + shields + "/badge/Conventional%20Commits-1.0.0-%23FE5196?logo=conventionalcommits)")
+				}
 				md.WriteString("\n\n")
 			}
 
 			// Real stats from repo + analysis
 			var stats []string
 			if repo.StargazersCount > 0 {
-				stats = append(stats, fmt.Sprintf("⭐ %d stars", repo.StargazersCount))
+				stats = append(stats, fmt.Sprintf(i18n.T(lang, i18n.Stars), repo.StargazersCount))
 			}
 			if repo.ForksCount > 0 {
-				stats = append(stats, fmt.Sprintf("🍴 %d forks", repo.ForksCount))
+				stats = append(stats, fmt.Sprintf(i18n.T(lang, i18n.Forks), repo.ForksCount))
 			}
 			// Commit + contributor counts from the RepositoryAnalysis
 			if i < len(req.Projects) {
 				analysis := req.Projects[i]
 				if analysis.CommitCount > 0 {
-					stats = append(stats, fmt.Sprintf("📝 %d commits", analysis.CommitCount))
+					stats = append(stats, fmt.Sprintf(i18n.T(lang, i18n.Commits), analysis.CommitCount))
 				}
 				if analysis.ContributorCount > 0 {
-					stats = append(stats, fmt.Sprintf("👥 %d contributors", analysis.ContributorCount))
+					stats = append(stats, fmt.Sprintf(i18n.T(lang, i18n.Contributors), analysis.ContributorCount))
+				}
+				if is := analysis.IssueStats; is != nil {
+					if is.OpenIssues > manyOpenIssues {
+						stats = append(stats, fmt.Sprintf(i18n.T(lang, i18n.OpenIssues), is.OpenIssues))
+					}
+					if is.ClosedIssues > is.OpenIssues*resolvedIssueRatio {
+						stats = append(stats, fmt.Sprintf(i18n.T(lang, i18n.ResolvedIssues), is.ClosedIssues))
+					}
 				}
 				// Top 3 languages from actual language map
 				topProjLangs := collectTopLanguages([]models.RepositoryAnalysis{analysis}, 3)
@@ -669,6 +1691,9 @@ func (s *ProfileService) buildMarkdown(
 					stats = append(stats, "🔤 "+strings.Join(topProjLangs, " / "))
 				}
 			}
+			if i < len(req.Projects) && len(req.Projects[i].CoAuthors) > 0 && req.SectionToggles[SectionCoAuthors] {
+				stats = append(stats, fmt.Sprintf(i18n.T(lang, i18n.CoAuthoredWith), coAuthorLinks(req.Projects[i].CoAuthors)))
+			}
 			// Topics
 			if len(repo.Topics) > 0 {
 				shown := repo.Topics[:min(5, len(repo.Topics))]
@@ -684,46 +1709,66 @@ func (s *ProfileService) buildMarkdown(
 			}
 		}
 	}
+	writeCustom(5)
 
 	// ── ACTIVITY GRAPH ────────────────────────────────────────────────────
-	md.WriteString("## 📈 Contribution Activity\n\n")
-	md.WriteString("<div align=\"center\">\n\n")
-	md.WriteString(fmt.Sprintf(
-		"[![Activity Graph](https://github-readme-activity-graph.vercel.app/graph?username=%s&theme=tokyo-night&hide_border=true)](https://github.com/ashutosh00710/github-readme-activity-graph)\n\n",
-		username,
-	))
-	md.WriteString("</div>\n\n")
+	if visible(SectionActivity) {
+		md.WriteString("## " + i18n.T(lang, i18n.ContributionActivity) + "\n\n")
+		md.WriteString("<div align=\"center\">\n\n")
+		md.WriteString(fmt.Sprintf(
+			"[![Activity Graph](https://github-readme-activity-graph.vercel.app/graph?username=%s&theme=tokyo-night&hide_border=true)](https://github.com/ashutosh00710/github-readme-activity-graph)\n\n",
+			username,
+		))
+		md.WriteString("</div>\n\n")
+	}
+	writeCustom(6)
 
 	// ── FOOTER ────────────────────────────────────────────────────────────
 	md.WriteString("---\n\n")
 	md.WriteString("<div align=\"center\">\n\n")
 	md.WriteString(fmt.Sprintf(
-		"*Generated with [GitRight](https://github.com/%s) · ![](https://komarev.com/ghpvc/?username=%s&style=flat-square)*\n\n",
-		username, username,
+		"*%s [GitRight](https://github.com/%s) · ![](https://komarev.com/ghpvc/?username=%s&style=flat-square)*\n\n",
+		i18n.T(lang, i18n.GeneratedWith), username, username,
 	))
+	if templateVars["FOOTER_TEXT"] != "" {
+		md.WriteString("{{FOOTER_TEXT}}\n\n")
+	}
 	md.WriteString("</div>\n")
 
-	return md.String()
+	markdown := ApplyTemplateVariables(minContentCheck(md.String(), req, summaries), templateVars)
+	return markdown, profileLayout(markdown, config.Layout)
 }
 
 
-// organizeBadgesByCategory sorts badges into four display groups.
-func (s *ProfileService) organizeBadgesByCategory(badges []models.Badge) map[string][]models.Badge {
+// organizeBadgesByCategory sorts badges into five display groups, capping each
+// group and the overall total per the generation config. reservedBadges are
+// already used elsewhere in the README and count toward the total. It returns
+// the groups and the number of badges left out.
+func (s *ProfileService) organizeBadgesByCategory(badges []models.Badge, emphasizedSkills, topLangs []string, reservedBadges int) (map[string][]models.Badge, int) {
 	langSet := map[string]bool{
 		"Go": true, "Python": true, "JavaScript": true, "TypeScript": true,
 		"Rust": true, "Java": true, "Kotlin": true, "Swift": true,
 		"C++": true, "C": true, "C#": true, "PHP": true, "Ruby": true,
 		"Dart": true, "Scala": true, "Elixir": true, "Haskell": true,
 		"Lua": true, "Shell": true, "HTML5": true, "CSS3": true,
+		"Erlang": true,
 	}
 	frameworkSet := map[string]bool{
 		"React": true, "Vue.js": true, "Angular": true, "Svelte": true,
 		"Next.js": true, "Nuxt.js": true, "Gatsby": true, "Remix": true,
 		"Astro": true, "TailwindCSS": true, "Vite": true,
-		"Node.js": true, "Express.js": true, "Fastify": true, "NestJS": true,
+		"Node.js": true, "Deno": true, "Bun": true, "Express.js": true, "Fastify": true, "NestJS": true,
 		"Django": true, "Flask": true, "FastAPI": true, "Spring Boot": true,
 		"Laravel": true, "Ruby on Rails": true, "Fiber": true, "Gin": true,
+		"Puma": true, "Sidekiq": true,
+		"Symfony": true, "Guzzle": true, "Doctrine": true,
 		"Echo": true, "Flutter": true, "React Native": true,
+		"SwiftUI": true, "Combine": true, "Alamofire": true,
+		"Jetpack Compose": true, "Hilt": true, "Dagger": true, "Retrofit": true,
+		"OkHttp": true, "Kotlin Coroutines": true,
+		"Spring Security": true, "Hibernate": true, "Quarkus": true,
+		"Micronaut": true, "Lombok": true,
+		"Phoenix": true, "LiveView": true, "Ecto": true,
 	}
 	dbSet := map[string]bool{
 		"PostgreSQL": true, "MySQL": true, "MongoDB": true, "Redis": true,
@@ -731,11 +1776,16 @@ func (s *ProfileService) organizeBadgesByCategory(badges []models.Badge) map[str
 		"Supabase": true, "Firebase": true, "Neon": true,
 		"Prisma": true, "Drizzle": true,
 	}
+	dataSet := map[string]bool{
+		"Apache Airflow": true, "dbt": true, "Prefect": true,
+		"Dagster": true, "Great Expectations": true,
+	}
 
 	cats := map[string][]models.Badge{
 		"Languages":              {},
 		"Frameworks & Libraries": {},
 		"Databases":              {},
+		"Data & ML":              {},
 		"Tools & Platforms":      {},
 	}
 
@@ -747,16 +1797,72 @@ func (s *ProfileService) organizeBadgesByCategory(badges []models.Badge) map[str
 			cats["Frameworks & Libraries"] = append(cats["Frameworks & Libraries"], b)
 		case dbSet[b.Name]:
 			cats["Databases"] = append(cats["Databases"], b)
+		case dataSet[b.Name]:
+			cats["Data & ML"] = append(cats["Data & ML"], b)
 		default:
 			cats["Tools & Platforms"] = append(cats["Tools & Platforms"], b)
 		}
 	}
 
+	// Emphasized skills rank first, then languages by bytes of code, then
+	// the incoming (source priority) order.
+	catalog := buildBadgeCatalog()
+	rank := make(map[string]int)
+	for i, skill := range emphasizedSkills {
+		if b, ok := catalog[strings.ToLower(skill)]; ok {
+			if _, seen := rank[b.Name]; !seen {
+				rank[b.Name] = i
+			}
+		}
+	}
+	for i, lang := range topLangs {
+		if b, ok := catalog[strings.ToLower(lang)]; ok {
+			if _, seen := rank[b.Name]; !seen {
+				rank[b.Name] = len(emphasizedSkills) + i
+			}
+		}
+	}
+	rankOf := func(name string) int {
+		if r, ok := rank[name]; ok {
+			return r
+		}
+		return len(rank) + len(emphasizedSkills) + len(topLangs)
+	}
+
+	omitted := 0
+	maxPerCategory := s.generationCfg.MaxBadgesPerCategory
+	for k, v := range cats {
+		sort.SliceStable(v, func(i, j int) bool { return rankOf(v[i].Name) < rankOf(v[j].Name) })
+		if maxPerCategory > 0 && len(v) > maxPerCategory {
+			omitted += len(v) - maxPerCategory
+			v = v[:maxPerCategory]
+		}
+		cats[k] = v
+	}
+
+	// Trim the least important categories first; Languages are never trimmed.
+	if maxTotal := s.generationCfg.MaxTotalBadges; maxTotal > 0 {
+		total := reservedBadges
+		for _, v := range cats {
+			total += len(v)
+		}
+		for _, k := range []string{"Tools & Platforms", "Data & ML", "Frameworks & Libraries"} {
+			excess := total - maxTotal
+			if excess <= 0 {
+				break
+			}
+			trim := min(excess, len(cats[k]))
+			cats[k] = cats[k][:len(cats[k])-trim]
+			total -= trim
+			omitted += trim
+		}
+	}
+
 	// Drop empty buckets
 	for k, v := range cats {
 		if len(v) == 0 {
 			delete(cats, k)
 		}
 	}
-	return cats
+	return cats, omitted
 }
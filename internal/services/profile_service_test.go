@@ -0,0 +1,371 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krauzx/gitright/internal/config"
+	"github.com/krauzx/gitright/internal/llm"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+	"github.com/krauzx/gitright/internal/services"
+	"github.com/krauzx/gitright/internal/testutil"
+)
+
+// fakeDB stands in for Postgres behind the repositories: every query returns
+// no rows and every statement succeeds, except the generated_profiles lookup,
+// which returns cached (stale when stale is set), the cooldown lookup, which
+// returns cooldown when set, the analyzed activity score lookup, which
+// returns activityScores, and inserts returning an id, which get 1. It
+// records the statements it was given.
+type fakeDB struct {
+	cached         *models.ContentGenerationResponse
+	stale          bool
+	cooldown       *models.GenerationCooldown
+	activityScores map[int64]float64
+
+	mu         sync.Mutex
+	statements []string
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                            { return fakeDriver{f} }
+
+// executed reports whether a statement containing fragment was executed.
+func (f *fakeDB) executed(fragment string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.statements {
+		if strings.Contains(s, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{d.db}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	s.db.statements = append(s.db.statements, s.query)
+	s.db.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	s.db.statements = append(s.db.statements, s.query)
+	s.db.mu.Unlock()
+	if s.db.cached != nil && strings.Contains(s.query, "SELECT content, EXTRACT") {
+		content, err := json.Marshal(s.db.cached)
+		if err != nil {
+			return nil, err
+		}
+		return &fakeRows{values: [][]driver.Value{{string(content), float64(60), s.db.stale}}}, nil
+	}
+	switch {
+	case s.db.cooldown != nil && strings.Contains(s.query, "FROM generation_cooldowns"):
+		c := s.db.cooldown
+		return &fakeRows{values: [][]driver.Value{{c.UserID, c.LastGeneratedAt, int64(c.CountToday)}}}, nil
+	case strings.Contains(s.query, "activity_score"):
+		rows := &fakeRows{}
+		for id, score := range s.db.activityScores {
+			rows.values = append(rows.values, []driver.Value{id, score})
+		}
+		return rows, nil
+	case strings.Contains(s.query, "RETURNING id, status, created_at"):
+		return &fakeRows{values: [][]driver.Value{{int64(1), "pending", time.Now()}}}, nil
+	case strings.Contains(s.query, "RETURNING id"):
+		return &fakeRows{values: [][]driver.Value{{int64(1)}}}, nil
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newProfileService returns a ProfileService backed by fake GitHub, LLM and
+// database.
+func newProfileService(t *testing.T, db *fakeDB) (*services.ProfileService, *testutil.FakeLLM) {
+	t.Helper()
+	return newProfileServiceWithGitHub(t, db, testutil.NewFakeGitHub())
+}
+
+// newProfileServiceWithGitHub is newProfileService with a given fake GitHub.
+func newProfileServiceWithGitHub(t *testing.T, db *fakeDB, fakeGitHub *testutil.FakeGitHub) (*services.ProfileService, *testutil.FakeLLM) {
+	t.Helper()
+	conn := sql.OpenDB(db)
+	t.Cleanup(func() { conn.Close() })
+
+	auditService := services.NewAuditService(repository.NewAuditRepository(conn))
+	githubService := services.NewGitHubService(fakeGitHub, nil,
+		repository.NewRepositoryCacheRepository(conn), auditService, config.AnalysisConfig{})
+	fakeLLM := testutil.NewFakeLLM()
+	service := services.NewProfileService(
+		fakeLLM,
+		repository.NewProjectRepository(conn),
+		githubService,
+		repository.NewProfileCacheRepository(conn),
+		repository.NewPreferencesRepository(conn),
+		nil,
+		auditService,
+		repository.NewCooldownRepository(conn),
+		repository.NewABTestRepository(conn),
+		repository.NewMetricsRepository(conn),
+		config.GenerationConfig{},
+		nil,
+	)
+	return service, fakeLLM
+}
+
+func generationRequest() *models.ContentGenerationRequest {
+	return &models.ContentGenerationRequest{
+		UserAPIKey:  "test-key",
+		TargetRole:  "Backend Engineer",
+		ToneOfVoice: "professional",
+		Projects: []models.RepositoryAnalysis{{
+			Repository: &models.Repository{
+				GitHubID: 42, Name: "api", FullName: "octocat/api",
+				Language: "Go", StargazersCount: 10,
+			},
+			Languages: map[string]int{"Go": 9000},
+		}},
+	}
+}
+
+var testUser = &models.User{ID: 1, Username: "octocat", AccessToken: "token"}
+
+func TestGenerateProfileCacheHit(t *testing.T) {
+	cached := &models.ContentGenerationResponse{Markdown: "# cached profile"}
+	service, fakeLLM := newProfileService(t, &fakeDB{cached: cached})
+
+	got, err := service.GenerateProfile(context.Background(), generationRequest(), testUser)
+	if err != nil {
+		t.Fatalf("GenerateProfile: %v", err)
+	}
+	if got.Markdown != cached.Markdown {
+		t.Errorf("Markdown = %q, want the cached %q", got.Markdown, cached.Markdown)
+	}
+	if n := len(fakeLLM.Requests()); n != 0 {
+		t.Errorf("LLM called %d times on a cache hit, want 0", n)
+	}
+}
+
+// A stale profile is only refreshed when a generation would be allowed, and
+// the refresh counts towards the cooldown.
+func TestGenerateProfileStaleRefreshCooldown(t *testing.T) {
+	cached := &models.ContentGenerationResponse{Markdown: "# cached profile"}
+	recent := &models.GenerationCooldown{UserID: testUser.ID, LastGeneratedAt: time.Now(), CountToday: 1}
+	db := &fakeDB{cached: cached, stale: true, cooldown: recent}
+	service, _ := newProfileService(t, db)
+
+	if _, err := service.GenerateProfile(context.Background(), generationRequest(), testUser); err != nil {
+		t.Fatalf("GenerateProfile: %v", err)
+	}
+	if db.executed("SET stale_at") || db.executed("INSERT INTO generation_cooldowns") {
+		t.Error("stale profile refreshed during the cooldown")
+	}
+
+	db = &fakeDB{cached: cached, stale: true}
+	service, fakeLLM := newProfileService(t, db)
+	if _, err := service.GenerateProfile(context.Background(), generationRequest(), testUser); err != nil {
+		t.Fatalf("GenerateProfile: %v", err)
+	}
+	if !db.executed("INSERT INTO generation_cooldowns") {
+		t.Error("refresh started without recording the cooldown")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(fakeLLM.Requests()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(fakeLLM.Requests()); n != 1 {
+		t.Errorf("LLM called %d times for the refresh, want 1", n)
+	}
+}
+
+func TestGenerateProfileCacheMiss(t *testing.T) {
+	db := &fakeDB{}
+	service, fakeLLM := newProfileService(t, db)
+
+	got, err := service.GenerateProfile(context.Background(), generationRequest(), testUser)
+	if err != nil {
+		t.Fatalf("GenerateProfile: %v", err)
+	}
+	requests := fakeLLM.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("LLM called %d times, want 1", len(requests))
+	}
+	if requests[0].Username != testUser.Username {
+		t.Errorf("LLM request username = %q, want %q", requests[0].Username, testUser.Username)
+	}
+	if !strings.Contains(got.Markdown, fakeLLM.Response.ProfilePitch) {
+		t.Errorf("Markdown does not contain the generated pitch:\n%s", got.Markdown)
+	}
+	if !db.executed("INSERT INTO generated_profiles") {
+		t.Error("generated profile was not cached")
+	}
+}
+
+func TestGenerateProfileLLMFailure(t *testing.T) {
+	db := &fakeDB{}
+	service, fakeLLM := newProfileService(t, db)
+	fakeLLM.Err = errors.New("quota exceeded")
+
+	_, err := service.GenerateProfile(context.Background(), generationRequest(), testUser)
+	if !errors.Is(err, fakeLLM.Err) {
+		t.Fatalf("GenerateProfile error = %v, want it to wrap %v", err, fakeLLM.Err)
+	}
+	if db.executed("INSERT INTO generated_profiles") {
+		t.Error("failed generation was cached")
+	}
+}
+
+func TestGenerateProfileRejectsRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(req *models.ContentGenerationRequest)
+		wantErr string
+	}{
+		{
+			name:    "missing API key",
+			modify:  func(req *models.ContentGenerationRequest) { req.UserAPIKey = "" },
+			wantErr: "API key required",
+		},
+		{
+			name:    "empty projects",
+			modify:  func(req *models.ContentGenerationRequest) { req.Projects = nil },
+			wantErr: "at least one project required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{}
+			service, fakeLLM := newProfileService(t, db)
+			req := generationRequest()
+			tt.modify(req)
+
+			_, err := service.GenerateProfile(context.Background(), req, testUser)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("GenerateProfile error = %v, want %q", err, tt.wantErr)
+			}
+			if n := len(fakeLLM.Requests()); n != 0 {
+				t.Errorf("LLM called %d times, want 0", n)
+			}
+		})
+	}
+}
+
+func TestGenerateProfileDryRun(t *testing.T) {
+	db := &fakeDB{}
+	service, fakeLLM := newProfileService(t, db)
+	req := generationRequest()
+	req.UserAPIKey = ""
+	req.PinToCache = true
+	req.DryRun = true
+
+	got, err := service.GenerateProfile(context.Background(), req, testUser)
+	if err != nil {
+		t.Fatalf("GenerateProfile: %v", err)
+	}
+	if !strings.Contains(got.Markdown, "Dry run") {
+		t.Errorf("Markdown = %q, want the dry run placeholder", got.Markdown)
+	}
+	if n := len(fakeLLM.Requests()); n != 0 {
+		t.Errorf("LLM called %d times on a dry run, want 0", n)
+	}
+	if db.executed("") {
+		t.Error("dry run queried the database")
+	}
+}
+
+func TestGenerateProfileCoAuthorsSection(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		fakeGitHub := testutil.NewFakeGitHub()
+		service, fakeLLM := newProfileServiceWithGitHub(t, &fakeDB{}, fakeGitHub)
+		fakeLLM.Response.ProjectSummaries = []llm.ProjectSummaryData{{ProjectName: "api", Summary: "An API."}}
+		req := generationRequest()
+		req.Projects[0].CoAuthorTrailers = []models.CoAuthorTrailer{{Name: "Mona Lisa", Email: "mona@example.com"}}
+		req.SectionToggles = map[string]bool{services.SectionCoAuthors: enabled}
+
+		got, err := service.GenerateProfile(context.Background(), req, testUser)
+		if err != nil {
+			t.Fatalf("GenerateProfile: %v", err)
+		}
+		resolved := slices.Contains(fakeGitHub.Calls(), "ResolveCoAuthors")
+		if resolved != enabled {
+			t.Errorf("section enabled = %v: co-authors resolved = %v", enabled, resolved)
+		}
+		if shown := strings.Contains(got.Markdown, "Mona Lisa"); shown != enabled {
+			t.Errorf("section enabled = %v: co-author shown = %v\n%s", enabled, shown, got.Markdown)
+		}
+	}
+}
+
+func TestRunABTestSkipsCache(t *testing.T) {
+	db := &fakeDB{cached: &models.ContentGenerationResponse{Markdown: "# cached profile"}}
+	service, fakeLLM := newProfileService(t, db)
+
+	result, err := service.RunABTest(context.Background(), testUser, "tone", generationRequest(), generationRequest())
+	if err != nil {
+		t.Fatalf("RunABTest: %v", err)
+	}
+	if n := len(fakeLLM.Requests()); n != 2 {
+		t.Errorf("LLM called %d times, want once per variant", n)
+	}
+	if result.VariantA.Markdown == "# cached profile" || result.VariantB.Markdown == "# cached profile" {
+		t.Error("a variant was served from the profile cache")
+	}
+	if db.executed("INSERT INTO generated_profiles") {
+		t.Error("a variant overwrote the cached profile")
+	}
+}
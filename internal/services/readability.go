@@ -0,0 +1,157 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// Thresholds for readability warnings on the generated pitch.
+const (
+	minReadingEase  = 30 // Flesch reading ease below this is "very difficult"
+	maxPassiveVoice = 3
+)
+
+// jargonTerms are buzzwords that read as filler to recruiters.
+var jargonTerms = map[string]bool{
+	"leverage": true, "leveraging": true, "synergy": true, "synergies": true,
+	"paradigm": true, "utilize": true, "utilizing": true, "holistic": true,
+	"cutting-edge": true, "bleeding-edge": true, "best-of-breed": true,
+	"disruptive": true, "seamless": true, "seamlessly": true, "game-changer": true,
+	"rockstar": true, "ninja": true, "guru": true, "world-class": true,
+	"next-generation": true, "mission-critical": true, "value-add": true,
+	"thought-leader": true, "bandwidth": true, "ideate": true, "actionable": true,
+}
+
+// beVerbs introduce a passive construction when followed by a past participle.
+var beVerbs = map[string]bool{
+	"am": true, "is": true, "are": true, "was": true, "were": true,
+	"be": true, "been": true, "being": true,
+}
+
+// irregularParticiples are common past participles not ending in "-ed".
+var irregularParticiples = map[string]bool{
+	"built": true, "written": true, "made": true, "done": true, "given": true,
+	"known": true, "shown": true, "taken": true, "seen": true, "driven": true,
+	"chosen": true, "begun": true, "run": true, "held": true, "led": true,
+	"kept": true, "sent": true, "spent": true, "found": true, "brought": true,
+	"thought": true, "taught": true, "bought": true, "grown": true, "drawn": true,
+}
+
+// ReadabilityScorer computes plain-text readability metrics with simple
+// word and sentence segmentation. The zero value is ready to use.
+type ReadabilityScorer struct{}
+
+// Score measures text. FleschKincaid is the Flesch reading ease, where 0-30
+// is very difficult and 60-70 is plain English; syllables are estimated from
+// vowel groups.
+func (ReadabilityScorer) Score(text string) models.ReadabilityScore {
+	score := models.ReadabilityScore{JargonWords: []string{}}
+
+	words, syllables := 0, 0
+	seenJargon := make(map[string]bool)
+
+	for _, sentence := range splitSentences(text) {
+		tokens := sentenceWords(sentence)
+		if len(tokens) == 0 {
+			continue
+		}
+		score.SentenceCount++
+		words += len(tokens)
+
+		for i, w := range tokens {
+			syllables += countSyllables(w)
+			if jargonTerms[w] && !seenJargon[w] {
+				seenJargon[w] = true
+				score.JargonWords = append(score.JargonWords, w)
+			}
+			if beVerbs[w] && i+1 < len(tokens) && isPassiveParticiple(tokens[i+1:]) {
+				score.PassiveVoiceCount++
+			}
+		}
+	}
+
+	if score.SentenceCount == 0 {
+		return score
+	}
+	score.AvgWordsPerSentence = float64(words) / float64(score.SentenceCount)
+	score.FleschKincaid = 206.835 - 1.015*score.AvgWordsPerSentence - 84.6*float64(syllables)/float64(words)
+	return score
+}
+
+// readabilityWarnings turns a score into advice for the user.
+func readabilityWarnings(score models.ReadabilityScore) []string {
+	var warnings []string
+	if score.SentenceCount > 0 && score.FleschKincaid < minReadingEase {
+		warnings = append(warnings, "pitch may be too technical for non-technical recruiters")
+	}
+	if score.PassiveVoiceCount > maxPassiveVoice {
+		warnings = append(warnings, "pitch uses passive voice often; consider rewriting passive sentences in the active voice")
+	}
+	return warnings
+}
+
+// splitSentences splits on ., ! and ? followed by whitespace or the end of
+// the text, so "v1.2" and "e.g." mid-sentence stay intact.
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) {
+			sentences = append(sentences, string(runes[start:i+1]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// sentenceWords returns the lowercased words of a sentence. Hyphens and
+// apostrophes inside a word are kept; digits-only tokens are dropped.
+func sentenceWords(sentence string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(sentence, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	}) {
+		w := strings.ToLower(strings.Trim(field, "-'"))
+		if strings.IndexFunc(w, unicode.IsLetter) >= 0 {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// isPassiveParticiple reports whether the words following a form of "to be"
+// start with a past participle, allowing one "-ly" adverb in between.
+func isPassiveParticiple(rest []string) bool {
+	w := rest[0]
+	if strings.HasSuffix(w, "ly") && len(rest) > 1 {
+		w = rest[1]
+	}
+	return irregularParticiples[w] || (len(w) > 3 && strings.HasSuffix(w, "ed"))
+}
+
+// countSyllables estimates syllables as vowel groups, dropping a silent
+// trailing "e". Every word has at least one.
+func countSyllables(word string) int {
+	count := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	return max(count, 1)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+	"github.com/robfig/cron/v3"
+)
+
+// regenerationTimeout bounds a single scheduled regeneration run.
+const regenerationTimeout = 5 * time.Minute
+
+// RegenerationScheduler replays each user's last generation request on their
+// configured cron schedule. Schedules are loaded from the database on Start, so
+// restarts pick up every registered schedule without extra bookkeeping.
+type RegenerationScheduler struct {
+	cron             *cron.Cron
+	prefsRepo        *repository.PreferencesRepository
+	userRepo         *repository.UserRepository
+	profileCacheRepo *repository.ProfileCacheRepository
+	profileService   *ProfileService
+	githubService    *GitHubService
+	serverAPIKey     string
+
+	mu      sync.Mutex
+	entries map[int64]cron.EntryID
+}
+
+func NewRegenerationScheduler(
+	prefsRepo *repository.PreferencesRepository,
+	userRepo *repository.UserRepository,
+	profileCacheRepo *repository.ProfileCacheRepository,
+	profileService *ProfileService,
+	githubService *GitHubService,
+	serverAPIKey string,
+) *RegenerationScheduler {
+	return &RegenerationScheduler{
+		cron:             cron.New(cron.WithLocation(time.UTC)),
+		prefsRepo:        prefsRepo,
+		userRepo:         userRepo,
+		profileCacheRepo: profileCacheRepo,
+		profileService:   profileService,
+		githubService:    githubService,
+		serverAPIKey:     serverAPIKey,
+		entries:          make(map[int64]cron.EntryID),
+	}
+}
+
+// ValidateSchedule checks that expr is a standard 5-field cron expression.
+func ValidateSchedule(expr string) error {
+	if _, err := cron.ParseStandard(expr); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	return nil
+}
+
+// Start loads all stored schedules and starts the cron runner.
+func (s *RegenerationScheduler) Start(ctx context.Context) error {
+	prefs, err := s.prefsRepo.ListScheduled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load regeneration schedules: %w", err)
+	}
+
+	for _, p := range prefs {
+		if err := s.Schedule(p.UserID, p.RegenerationSchedule); err != nil {
+			slog.Warn("Skipping invalid regeneration schedule", "userID", p.UserID, "error", err)
+		}
+	}
+
+	s.cron.Start()
+	slog.Info("Regeneration scheduler started", "schedules", len(s.entries))
+	return nil
+}
+
+// Stop halts the runner and waits for in-flight jobs to finish or ctx to expire.
+func (s *RegenerationScheduler) Stop(ctx context.Context) {
+	select {
+	case <-s.cron.Stop().Done():
+	case <-ctx.Done():
+	}
+}
+
+// Schedule registers (or replaces) the user's schedule. An empty expr removes it.
+func (s *RegenerationScheduler) Schedule(userID int64, expr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.entries[userID]; ok {
+		s.cron.Remove(id)
+		delete(s.entries, userID)
+	}
+
+	if strings.TrimSpace(expr) == "" {
+		return nil
+	}
+
+	id, err := s.cron.AddFunc(expr, func() { s.run(userID) })
+	if err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	s.entries[userID] = id
+	return nil
+}
+
+func (s *RegenerationScheduler) run(userID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), regenerationTimeout)
+	defer cancel()
+
+	if s.serverAPIKey == "" {
+		slog.Warn("Skipping scheduled regeneration: no server API key configured", "userID", userID)
+		return
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		slog.Error("Scheduled regeneration failed to load user", "userID", userID, "error", err)
+		return
+	}
+
+	req, err := s.profileCacheRepo.GetLastGenerationRequest(ctx, userID)
+	if err != nil {
+		slog.Error("Scheduled regeneration failed to load last request", "userID", userID, "error", err)
+		return
+	}
+	if req == nil {
+		slog.Info("Skipping scheduled regeneration: no previous generation", "userID", userID)
+		return
+	}
+	req.UserAPIKey = s.serverAPIKey
+
+	s.refreshAnalyses(ctx, user, req)
+
+	response, err := s.profileService.RegenerateProfile(ctx, req, user)
+	if err != nil {
+		slog.Error("Scheduled regeneration failed", "userID", userID, "username", user.Username, "error", err)
+		return
+	}
+
+	prefs, err := s.prefsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		slog.Error("Scheduled regeneration failed to load preferences", "userID", userID, "error", err)
+		return
+	}
+
+	if prefs.AutoDeploy {
+		if _, err := s.profileService.DeployProfile(ctx, user, response.Markdown, req.TargetFilePath); err != nil {
+			slog.Error("Scheduled auto-deploy failed", "userID", userID, "username", user.Username, "error", err)
+			return
+		}
+	}
+
+	slog.Info("Scheduled regeneration completed", "userID", userID, "username", user.Username, "deployed", prefs.AutoDeploy)
+}
+
+// refreshAnalyses re-analyzes the stored projects so scheduled runs reflect
+// recent repository activity. Projects that fail keep their stored analysis.
+func (s *RegenerationScheduler) refreshAnalyses(ctx context.Context, user *models.User, req *models.ContentGenerationRequest) {
+	for i, project := range req.Projects {
+		if project.GistID != "" {
+			if analysis, err := s.githubService.AnalyzeGist(ctx, user.AccessToken, project.GistID); err != nil {
+				slog.Warn("Using stored gist for scheduled regeneration", "gist", project.GistID, "error", err)
+			} else {
+				req.Projects[i] = *analysis
+			}
+			continue
+		}
+		if project.Repository == nil {
+			continue
+		}
+		parts := strings.SplitN(project.Repository.FullName, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		analysis, err := s.githubService.AnalyzeRepository(ctx, user.ID, user.AccessToken, parts[0], parts[1])
+		if err != nil {
+			slog.Warn("Using stored analysis for scheduled regeneration", "repo", project.Repository.FullName, "error", err)
+			continue
+		}
+		if analysis.Repository == nil {
+			analysis.Repository = project.Repository
+		}
+		req.Projects[i] = *analysis
+	}
+}
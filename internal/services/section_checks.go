@@ -0,0 +1,79 @@
+package services
+
+import "github.com/krauzx/gitright/internal/models"
+
+// SectionDataCheck reports whether there is enough data to render a section.
+// badges are the Tech Stack badges left after limits are applied.
+type SectionDataCheck func(*models.User, *models.ContentGenerationRequest, []models.Badge, *models.ProfileConfig) bool
+
+// Section IDs with data checks. These are also the keys of
+// ContentGenerationRequest.SectionToggles.
+const (
+	SectionConnect   = "connect"
+	SectionTechStack = "tech-stack"
+	SectionActivity  = "contribution-activity"
+	SectionTrophies  = "trophies" // widget within GitHub Stats
+
+	// SectionSocialPreviews shows repository social preview images in
+	// Featured Projects. It costs a request per repository, so it has no
+	// data check and is only on when toggled.
+	SectionSocialPreviews = "social-previews"
+
+	// SectionCoAuthors credits Co-authored-by collaborators in Featured
+	// Projects. It names other people, so it is only on when toggled.
+	SectionCoAuthors = "co-authors"
+)
+
+// minActivityCommits is the total commit count across the selected projects
+// below which the contribution graph would look empty.
+const minActivityCommits = 50
+
+var sectionDataChecks = map[string]SectionDataCheck{
+	// The GitHub link is always present, so it needs one more
+	SectionConnect: func(user *models.User, _ *models.ContentGenerationRequest, _ []models.Badge, config *models.ProfileConfig) bool {
+		return len(validContacts(user, config)) > 1
+	},
+	SectionTechStack: func(_ *models.User, _ *models.ContentGenerationRequest, badges []models.Badge, _ *models.ProfileConfig) bool {
+		return len(badges) > 0
+	},
+	SectionActivity: func(_ *models.User, req *models.ContentGenerationRequest, _ []models.Badge, _ *models.ProfileConfig) bool {
+		return totalCommitCount(req.Projects) > minActivityCommits
+	},
+	SectionTrophies: func(user *models.User, _ *models.ContentGenerationRequest, _ []models.Badge, _ *models.ProfileConfig) bool {
+		return user.GitHubID > 0
+	},
+}
+
+// sectionVisible applies the request's SectionToggles override for id, then
+// falls back to the section's data check. Sections without a check are shown.
+func sectionVisible(id string, user *models.User, req *models.ContentGenerationRequest, badges []models.Badge, config *models.ProfileConfig) bool {
+	if show, ok := req.SectionToggles[id]; ok {
+		return show
+	}
+	check, ok := sectionDataChecks[id]
+	return !ok || check(user, req, badges, config)
+}
+
+// validContacts lists the contact links the Connect section renders,
+// including the GitHub profile.
+func validContacts(user *models.User, config *models.ProfileConfig) []string {
+	email := config.ContactPrefs.Email
+	if email == "" {
+		email = user.Email
+	}
+
+	contacts := []string{"https://github.com/" + user.Username}
+	for _, c := range []string{
+		config.ContactPrefs.LinkedIn,
+		config.ContactPrefs.Twitter,
+		config.ContactPrefs.Mastodon,
+		config.ContactPrefs.Bluesky,
+		email,
+		portfolioURL(config, user),
+	} {
+		if c != "" {
+			contacts = append(contacts, c)
+		}
+	}
+	return contacts
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krauzx/gitright/internal/i18n"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// SectionSEOMeta writes the SEO metadata as <!-- META: ... --> comments at the
+// top of the README, which GitHub does not render. It is only on when
+// toggled.
+const SectionSEOMeta = "seo-meta"
+
+const (
+	maxSEODescription = 160
+	maxSEOKeywords    = 20
+)
+
+// ExtractSEOMetadata derives page metadata for sites that republish the
+// profile. The description is the first two sentences of the About Me pitch
+// in markdown; keywords are the extracted skills, the user's location and the
+// projects' topics.
+func (s *ProfileService) ExtractSEOMetadata(markdown string, user *models.User, req *models.ContentGenerationRequest, skills []string) models.SEOMetadata {
+	title := user.Username
+	if req.Privacy.UseAlias != "" {
+		title = req.Privacy.UseAlias
+	}
+	if req.TargetRole != "" {
+		title += " - " + req.TargetRole
+	}
+
+	sentences := splitSentences(aboutMePitch(markdown))
+	for i := range sentences {
+		sentences[i] = strings.TrimSpace(sentences[i])
+	}
+	description := strings.Join(sentences[:min(2, len(sentences))], " ")
+
+	seen := make(map[string]bool)
+	keywords := []string{}
+	add := func(k string) {
+		k = strings.TrimSpace(k)
+		if k == "" || seen[strings.ToLower(k)] || len(keywords) >= maxSEOKeywords {
+			return
+		}
+		seen[strings.ToLower(k)] = true
+		keywords = append(keywords, k)
+	}
+	for _, skill := range skills {
+		add(skill)
+	}
+	add(user.Location)
+	for _, topic := range collectAllTopics(req.Projects) {
+		add(topic)
+	}
+
+	return models.SEOMetadata{
+		Title:        title,
+		Description:  truncateDescription(stripEmphasis(description), maxSEODescription),
+		Keywords:     keywords,
+		CanonicalURL: "https://github.com/" + user.Username,
+	}
+}
+
+// LatestSEOMetadata returns the SEO metadata of the user's latest generated
+// profile, or ErrNoProfile. Profiles cached before metadata was stored have
+// it extracted from their markdown.
+func (s *ProfileService) LatestSEOMetadata(ctx context.Context, user *models.User) (*models.SEOMetadata, error) {
+	req, err := s.profileCacheRepo.GetLastGenerationRequest(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last request: %w", err)
+	}
+	if req == nil {
+		return nil, ErrNoProfile
+	}
+	profile, _, err := s.profileCacheRepo.Get(ctx, s.cacheKey(req, user))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+	if profile == nil {
+		return nil, ErrNoProfile
+	}
+	if profile.SEOMetadata != nil {
+		return profile.SEOMetadata, nil
+	}
+	meta := s.ExtractSEOMetadata(profile.Markdown, user, req, profile.ExtractedSkills)
+	return &meta, nil
+}
+
+// aboutMePitch returns the first paragraph of the About Me section.
+func aboutMePitch(markdown string) string {
+	for _, section := range SplitMarkdownSections(markdown) {
+		if section.ID != i18n.AboutMe {
+			continue
+		}
+		_, body, _ := strings.Cut(section.Markdown, "\n")
+		paragraph, _, _ := strings.Cut(strings.TrimSpace(body), "\n\n")
+		return strings.Join(strings.Fields(paragraph), " ")
+	}
+	return ""
+}
+
+// stripEmphasis removes markdown bold, italic and code markers.
+func stripEmphasis(text string) string {
+	return strings.NewReplacer("**", "", "__", "", "*", "", "`", "").Replace(text)
+}
+
+// truncateDescription shortens text to at most limit runes, cutting at a
+// word boundary and ending with "...".
+func truncateDescription(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)[:limit-3]
+	cut := string(runes)
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "..."
+}
+
+// seoComments renders meta as HTML comments. "--" cannot appear inside a
+// comment, so runs of dashes are collapsed until none is left; a single pass
+// would turn "--->" into "-->".
+func seoComments(meta models.SEOMetadata) string {
+	clean := func(s string) string {
+		s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+		for strings.Contains(s, "--") {
+			s = strings.ReplaceAll(s, "--", "-")
+		}
+		return s
+	}
+	var b strings.Builder
+	b.WriteString("<!-- META: title=" + clean(meta.Title) + " -->\n")
+	b.WriteString("<!-- META: description=" + clean(meta.Description) + " -->\n")
+	b.WriteString("<!-- META: keywords=" + clean(strings.Join(meta.Keywords, ", ")) + " -->\n")
+	b.WriteString("<!-- META: canonical=" + clean(meta.CanonicalURL) + " -->\n")
+	return b.String()
+}
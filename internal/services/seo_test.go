@@ -0,0 +1,31 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+func TestSEOCommentsStayClosed(t *testing.T) {
+	for _, value := range []string{"--->", "<!--", "a---->b", "x--!>y", "line\n-->"} {
+		got := seoComments(models.SEOMetadata{
+			Title:        value,
+			Description:  value,
+			Keywords:     []string{value, value},
+			CanonicalURL: value,
+		})
+		lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+		if len(lines) != 4 {
+			t.Errorf("seoComments(%q) wrote %d lines, want 4:\n%s", value, len(lines), got)
+			continue
+		}
+		for _, line := range lines {
+			body, ok := strings.CutPrefix(line, "<!--")
+			body, ok2 := strings.CutSuffix(body, "-->")
+			if !ok || !ok2 || strings.Contains(body, "--") {
+				t.Errorf("seoComments(%q) line %q is not a single well-formed comment", value, line)
+			}
+		}
+	}
+}
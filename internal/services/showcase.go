@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// showcaseTopLanguages is how many of a profile's languages are stored for
+// the showcase.
+const showcaseTopLanguages = 5
+
+var ErrInvalidShowcaseCursor = errors.New("invalid showcase cursor")
+
+// ShowcasePage is a page of the public showcase. NextCursor continues the
+// listing and is empty on the last page.
+type ShowcasePage struct {
+	Profiles   []*models.ShowcaseEntry `json:"profiles"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
+
+// ListShowcase returns a page of the public showcase of deployed profiles
+// in sortBy order, a models.ShowcaseSort constant. A cursor from a previous
+// page continues after it and takes precedence over page, which otherwise
+// selects the 1-based page of perPage entries.
+func (s *ProfileService) ListShowcase(ctx context.Context, sortBy, language, cursor string, page, perPage int) (*ShowcasePage, error) {
+	var after *models.ShowcaseCursor
+	if cursor != "" {
+		c, err := decodeShowcaseCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = c
+	}
+
+	// One extra entry tells whether there is a next page
+	entries, err := s.profileCacheRepo.ListShowcase(ctx, sortBy, language, after, (page-1)*perPage, perPage+1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ShowcasePage{Profiles: entries}
+	if len(entries) > perPage {
+		result.Profiles = entries[:perPage]
+		result.NextCursor = encodeShowcaseCursor(entries[perPage-1])
+	}
+	if result.Profiles == nil {
+		result.Profiles = []*models.ShowcaseEntry{}
+	}
+	return result, nil
+}
+
+// encodeShowcaseCursor encodes the sort keys of the last entry of a page as
+// an opaque URL-safe token.
+func encodeShowcaseCursor(e *models.ShowcaseEntry) string {
+	raw := fmt.Sprintf("%d.%d.%d", e.DeployedAt.UnixNano(), e.UserID, e.CacheHits)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeShowcaseCursor(cursor string) (*models.ShowcaseCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidShowcaseCursor
+	}
+	var nanos int64
+	c := &models.ShowcaseCursor{}
+	if _, err := fmt.Sscanf(string(raw), "%d.%d.%d", &nanos, &c.UserID, &c.CacheHits); err != nil {
+		return nil, ErrInvalidShowcaseCursor
+	}
+	c.DeployedAt = time.Unix(0, nanos)
+	return c, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+)
+
+const maxTemplateVarLength = 500
+
+// templateVarNames is the allowlist of substitutable placeholders. Anything
+// else that looks like {{NAME}} is left untouched.
+var templateVarNames = map[string]bool{
+	"COMPANY_LOGO":     true,
+	"FOOTER_TEXT":      true,
+	"CUSTOM_BADGE_URL": true,
+}
+
+// templateVarURLs are the variables that must hold an https URL.
+var templateVarURLs = map[string]bool{
+	"COMPANY_LOGO":     true,
+	"CUSTOM_BADGE_URL": true,
+}
+
+var templateVarPattern = regexp.MustCompile(`\{\{([A-Z_]+)\}\}`)
+
+// ApplyTemplateVariables substitutes allowlisted {{NAME}} placeholders in
+// markdown with their HTML-escaped values. Unknown names and names without a
+// value are left as-is.
+func ApplyTemplateVariables(markdown string, vars map[string]string) string {
+	if len(vars) == 0 {
+		return markdown
+	}
+	return templateVarPattern.ReplaceAllStringFunc(markdown, func(match string) string {
+		name := match[2 : len(match)-2]
+		if !templateVarNames[name] {
+			return match
+		}
+		value, ok := vars[name]
+		if !ok || value == "" {
+			return match
+		}
+		return html.EscapeString(value)
+	})
+}
+
+// ValidateTemplateVariables rejects unknown names, overlong values and
+// non-https URLs.
+func ValidateTemplateVariables(vars map[string]string) error {
+	for name, value := range vars {
+		if !templateVarNames[name] {
+			return fmt.Errorf("unknown template variable %q", name)
+		}
+		if len(value) > maxTemplateVarLength {
+			return fmt.Errorf("template variable %s exceeds %d characters", name, maxTemplateVarLength)
+		}
+		if templateVarURLs[name] && value != "" {
+			u, err := url.Parse(value)
+			if err != nil || u.Scheme != "https" || u.Host == "" {
+				return fmt.Errorf("template variable %s must be an https URL", name)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeTemplateVariables overlays user overrides on the server defaults.
+func mergeTemplateVariables(defaults, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		if v != "" {
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// defaultTypingSVG matches the banner used before it was configurable.
+var defaultTypingSVG = models.TypingSVGConfig{
+	Font:     "Fira Code",
+	FontSize: 22,
+	Duration: 3000,
+	PauseMs:  1000,
+	Color:    "2E97F7",
+	Width:    650,
+	Height:   80,
+}
+
+var (
+	hexColorPattern = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
+	fontNamePattern = regexp.MustCompile(`^[A-Za-z0-9 ]{1,50}$`)
+)
+
+// ValidateTypingSVGConfig rejects malformed colors and fonts and
+// out-of-range sizes. Zero and empty fields are allowed and mean "default".
+func ValidateTypingSVGConfig(cfg models.TypingSVGConfig) error {
+	if cfg.Color != "" && !hexColorPattern.MatchString(cfg.Color) {
+		return fmt.Errorf("color must be a 6-digit hex value without '#'")
+	}
+	if cfg.Font != "" && !fontNamePattern.MatchString(cfg.Font) {
+		return fmt.Errorf("font must be 1-50 letters, digits or spaces")
+	}
+
+	ranges := []struct {
+		name     string
+		value    int
+		min, max int
+	}{
+		{"font_size", cfg.FontSize, 8, 100},
+		{"duration", cfg.Duration, 100, 20000},
+		{"pause_ms", cfg.PauseMs, 0, 20000},
+		{"width", cfg.Width, 100, 2000},
+		{"height", cfg.Height, 20, 500},
+	}
+	for _, r := range ranges {
+		if r.value != 0 && (r.value < r.min || r.value > r.max) {
+			return fmt.Errorf("%s must be between %d and %d", r.name, r.min, r.max)
+		}
+	}
+	return nil
+}
+
+// typingSVGURL builds the readme-typing-svg URL for lines, which must already
+// be URL-encoded (see buildTypingLines).
+func typingSVGURL(cfg models.TypingSVGConfig, lines []string) string {
+	pick := func(v, def int) int {
+		if v == 0 {
+			return def
+		}
+		return v
+	}
+	font := defaultTypingSVG.Font
+	if cfg.Font != "" {
+		font = cfg.Font
+	}
+	color := defaultTypingSVG.Color
+	if cfg.Color != "" {
+		color = strings.ToUpper(cfg.Color)
+	}
+
+	return fmt.Sprintf(
+		"https://readme-typing-svg.demolab.com?font=%s&size=%d&duration=%d&pause=%d&color=%s&center=true&vCenter=true&width=%d&height=%d&lines=%s",
+		strings.ReplaceAll(font, " ", "+"),
+		pick(cfg.FontSize, defaultTypingSVG.FontSize),
+		pick(cfg.Duration, defaultTypingSVG.Duration),
+		pick(cfg.PauseMs, defaultTypingSVG.PauseMs),
+		color,
+		pick(cfg.Width, defaultTypingSVG.Width),
+		pick(cfg.Height, defaultTypingSVG.Height),
+		strings.Join(lines, ";"),
+	)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/repository"
+)
+
+const (
+	// wizardSessionTTL is how long a session lives after its last update.
+	wizardSessionTTL = time.Hour
+	wizardSteps      = 4
+)
+
+var (
+	// ErrWizardSessionNotFound is returned for unknown, expired and other
+	// users' sessions alike.
+	ErrWizardSessionNotFound = errors.New("wizard session not found")
+	ErrInvalidWizardState    = errors.New("invalid wizard state")
+)
+
+// WizardService persists the multi-step profile wizard so selections survive
+// page reloads. Each step PATCHes the ContentGenerationRequest fields it sets.
+type WizardService struct {
+	wizardRepo *repository.WizardSessionRepository
+}
+
+func NewWizardService(wizardRepo *repository.WizardSessionRepository) *WizardService {
+	return &WizardService{wizardRepo: wizardRepo}
+}
+
+func (s *WizardService) CreateSession(ctx context.Context, user *models.User) (*models.WizardSession, error) {
+	return s.wizardRepo.Create(ctx, user.ID, wizardSessionTTL)
+}
+
+func (s *WizardService) GetSession(ctx context.Context, user *models.User, id string) (*models.WizardSession, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrWizardSessionNotFound
+	}
+	session, err := s.wizardRepo.Get(ctx, id, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrWizardSessionNotFound
+	}
+	return session, nil
+}
+
+// UpdateSession records step and merges state, a JSON object of
+// ContentGenerationRequest fields, into the session. The API key is never
+// stored; it is supplied when generating.
+func (s *WizardService) UpdateSession(ctx context.Context, user *models.User, id string, step int, state json.RawMessage) (*models.WizardSession, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrWizardSessionNotFound
+	}
+	if step < 1 || step > wizardSteps {
+		return nil, fmt.Errorf("%w: step must be between 1 and %d", ErrInvalidWizardState, wizardSteps)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(state, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("%w: state must be a JSON object", ErrInvalidWizardState)
+	}
+	delete(fields, "user_api_key")
+	state, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal wizard state: %w", err)
+	}
+	if err := json.Unmarshal(state, &models.ContentGenerationRequest{}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWizardState, err)
+	}
+
+	session, err := s.wizardRepo.Update(ctx, id, user.ID, step, state, wizardSessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrWizardSessionNotFound
+	}
+	return session, nil
+}
+
+// GenerationRequest builds the request the session's accumulated state
+// describes. The caller supplies the API key.
+func (s *WizardService) GenerationRequest(ctx context.Context, user *models.User, id string) (*models.ContentGenerationRequest, error) {
+	session, err := s.GetSession(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	var req models.ContentGenerationRequest
+	if err := json.Unmarshal(session.State, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode wizard state: %w", err)
+	}
+	return &req, nil
+}
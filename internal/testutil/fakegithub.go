@@ -0,0 +1,245 @@
+// Package testutil provides in-memory fakes of the external APIs services
+// depend on, so they can be exercised without network access.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	gogithub "github.com/google/go-github/v60/github"
+	"github.com/krauzx/gitright/internal/github"
+	"github.com/krauzx/gitright/internal/models"
+	"github.com/krauzx/gitright/internal/services"
+)
+
+var _ services.GitHubClientInterface = (*FakeGitHub)(nil)
+
+// FakeGitHub serves configured responses in place of the GitHub API and
+// records every call. Lookups that aren't configured behave like a 404:
+// repositories return GitHub's 404 error, gists an error and file SHAs "".
+// Set Err to fail every call.
+type FakeGitHub struct {
+	Repositories    map[string]*gogithub.Repository // "owner/repo"
+	Contents        map[string]string               // "owner/repo/path"
+	SocialPreviews  map[string]string               // "owner/repo" -> image URL
+	Gists           map[string]*models.Gist         // gist ID
+	Activity        *models.UserActivitySummary
+	OrgMembers      map[string]bool // "org/username"
+	APIAvailability github.APIAvailability
+	Err             error
+
+	mu    sync.Mutex
+	calls []string
+	// deployed holds the content written by CreateOrUpdateFile, by
+	// "owner/repo/path".
+	deployed map[string]string
+}
+
+// NewFakeGitHub returns a FakeGitHub serving repos, keyed by full name.
+func NewFakeGitHub(repos ...*gogithub.Repository) *FakeGitHub {
+	f := &FakeGitHub{
+		Repositories:   make(map[string]*gogithub.Repository),
+		Contents:       make(map[string]string),
+		SocialPreviews: make(map[string]string),
+		Gists:          make(map[string]*models.Gist),
+		OrgMembers:     make(map[string]bool),
+		deployed:       make(map[string]string),
+	}
+	for _, r := range repos {
+		f.Repositories[r.GetFullName()] = r
+	}
+	return f
+}
+
+// Calls returns the methods called so far, in order.
+func (f *FakeGitHub) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// CallCount returns how many times method was called.
+func (f *FakeGitHub) CallCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c == method {
+			n++
+		}
+	}
+	return n
+}
+
+// Deployed returns the content last written to path in owner/repo.
+func (f *FakeGitHub) Deployed(owner, repo, path string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.deployed[owner+"/"+repo+"/"+path]
+	return content, ok
+}
+
+func (f *FakeGitHub) record(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, method)
+	return f.Err
+}
+
+func (f *FakeGitHub) ListRepositories(ctx context.Context, token string, includePrivate bool) ([]*gogithub.Repository, error) {
+	if err := f.record("ListRepositories"); err != nil {
+		return nil, err
+	}
+	var repos []*gogithub.Repository
+	for _, r := range f.Repositories {
+		if includePrivate || !r.GetPrivate() {
+			repos = append(repos, r)
+		}
+	}
+	return repos, nil
+}
+
+func (f *FakeGitHub) GetRepository(ctx context.Context, token, owner, repo string) (*gogithub.Repository, error) {
+	if err := f.record("GetRepository"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	r, ok := f.Repositories[owner+"/"+repo]
+	f.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("failed to get repository: %w", notFound("repos/"+owner+"/"+repo))
+	}
+	return r, nil
+}
+
+// CreateRepository adds the repository as owned by a user of the same name,
+// as profile repositories are, with its README committed right away, as
+// AutoInit does.
+func (f *FakeGitHub) CreateRepository(ctx context.Context, token, name, description string) (*gogithub.Repository, error) {
+	if err := f.record("CreateRepository"); err != nil {
+		return nil, err
+	}
+	r := &gogithub.Repository{
+		Name:        gogithub.String(name),
+		FullName:    gogithub.String(name + "/" + name),
+		Description: gogithub.String(description),
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Repositories[r.GetFullName()] = r
+	f.deployed[r.GetFullName()+"/"+models.DefaultTargetFilePath] = "# " + name
+	return r, nil
+}
+
+// notFound is the error go-github returns for a 404 from path.
+func notFound(path string) error {
+	u := &url.URL{Scheme: "https", Host: "api.github.com", Path: "/" + path}
+	return &gogithub.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusNotFound, Request: &http.Request{Method: http.MethodGet, URL: u}},
+		Message:  "Not Found",
+	}
+}
+
+// GetRepositoryContent returns the content CreateOrUpdateFile deployed,
+// falling back to Contents.
+func (f *FakeGitHub) GetRepositoryContent(ctx context.Context, token, owner, repo, path string) (string, error) {
+	if err := f.record("GetRepositoryContent"); err != nil {
+		return "", err
+	}
+	key := owner + "/" + repo + "/" + path
+	f.mu.Lock()
+	content, ok := f.deployed[key]
+	f.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+	content, ok = f.Contents[key]
+	if !ok {
+		return "", fmt.Errorf("failed to get content: %s not found", path)
+	}
+	return content, nil
+}
+
+func (f *FakeGitHub) GetRepositorySocialPreview(ctx context.Context, token, owner, repo string) (string, error) {
+	if err := f.record("GetRepositorySocialPreview"); err != nil {
+		return "", err
+	}
+	return f.SocialPreviews[owner+"/"+repo], nil
+}
+
+// ResolveCoAuthors returns the name in each trailer, without looking up
+// accounts.
+func (f *FakeGitHub) ResolveCoAuthors(ctx context.Context, token, owner string, trailers []models.CoAuthorTrailer) []string {
+	if err := f.record("ResolveCoAuthors"); err != nil {
+		return nil
+	}
+	var names []string
+	for _, t := range trailers {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// GetFileContentSHA returns a fixed SHA for files in Contents or already
+// deployed, and "" for the rest.
+func (f *FakeGitHub) GetFileContentSHA(ctx context.Context, token, username, path string) (string, error) {
+	if err := f.record("GetFileContentSHA"); err != nil {
+		return "", err
+	}
+	key := username + "/" + username + "/" + path
+	f.mu.Lock()
+	_, deployed := f.deployed[key]
+	f.mu.Unlock()
+	if _, ok := f.Contents[key]; ok || deployed {
+		return "0123456789abcdef0123456789abcdef01234567", nil
+	}
+	return "", nil
+}
+
+func (f *FakeGitHub) CreateOrUpdateFile(ctx context.Context, token, owner, repo, path, message, content, sha string) error {
+	if err := f.record("CreateOrUpdateFile"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deployed[owner+"/"+repo+"/"+path] = content
+	return nil
+}
+
+func (f *FakeGitHub) GetGist(ctx context.Context, token, gistID string) (*models.Gist, error) {
+	if err := f.record("GetGist"); err != nil {
+		return nil, err
+	}
+	gist, ok := f.Gists[gistID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get gist: %s not found", gistID)
+	}
+	return gist, nil
+}
+
+func (f *FakeGitHub) GetUserActivitySummary(ctx context.Context, token, username string) (*models.UserActivitySummary, error) {
+	if err := f.record("GetUserActivitySummary"); err != nil {
+		return nil, err
+	}
+	return f.Activity, nil
+}
+
+func (f *FakeGitHub) IsOrganizationMember(ctx context.Context, token, org, username string) (bool, error) {
+	if err := f.record("IsOrganizationMember"); err != nil {
+		return false, err
+	}
+	return f.OrgMembers[org+"/"+username], nil
+}
+
+func (f *FakeGitHub) Availability() github.APIAvailability {
+	return f.APIAvailability
+}
+
+func (f *FakeGitHub) CallsToday() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
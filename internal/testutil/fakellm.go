@@ -0,0 +1,57 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	"github.com/krauzx/gitright/internal/llm"
+	"github.com/krauzx/gitright/internal/services"
+)
+
+var _ services.LLMProviderInterface = (*FakeLLM)(nil)
+
+// FakeLLM returns Response, or Err when set, from GenerateBatchedProfile and
+// records each request it is given.
+type FakeLLM struct {
+	Response *llm.BatchProfileResponse
+	Err      error
+	Estimate llm.CostEstimate
+
+	mu       sync.Mutex
+	requests []llm.BatchProfileRequest
+}
+
+// NewFakeLLM returns a FakeLLM generating a fixed pitch with full confidence.
+func NewFakeLLM() *FakeLLM {
+	return &FakeLLM{
+		Response: &llm.BatchProfileResponse{
+			ProfilePitch:    "I build reliable backend services and the tooling around them.",
+			ExtractedSkills: []string{"Go", "PostgreSQL"},
+			Confidence:      1,
+		},
+	}
+}
+
+// Requests returns the requests GenerateBatchedProfile was called with.
+func (f *FakeLLM) Requests() []llm.BatchProfileRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]llm.BatchProfileRequest(nil), f.requests...)
+}
+
+func (f *FakeLLM) GenerateBatchedProfile(ctx context.Context, apiKey string, req llm.BatchProfileRequest) (*llm.BatchProfileResponse, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	// Callers may modify the response, so each gets its own copy
+	response := *f.Response
+	return &response, nil
+}
+
+func (f *FakeLLM) EstimateBatchedProfile(ctx context.Context, req llm.BatchProfileRequest) *llm.CostEstimate {
+	estimate := f.Estimate
+	return &estimate
+}
@@ -0,0 +1,42 @@
+package validators
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+const (
+	MaxCustomSections       = 3
+	MaxCustomSectionTitle   = 100
+	MaxCustomSectionContent = 2000
+)
+
+// ValidateCustomSections checks the number of custom sections and that each
+// has a title and fits both its own MaxLength and the server limit. Lengths
+// count characters, not bytes.
+func ValidateCustomSections(sections []models.CustomSection) error {
+	if len(sections) > MaxCustomSections {
+		return fmt.Errorf("at most %d custom sections are allowed", MaxCustomSections)
+	}
+	for i, section := range sections {
+		title := strings.TrimSpace(section.Title)
+		if title == "" || utf8.RuneCountInString(title) > MaxCustomSectionTitle {
+			return fmt.Errorf("custom_sections[%d].title must be 1-%d characters", i, MaxCustomSectionTitle)
+		}
+		if section.MaxLength < 0 || section.MaxLength > MaxCustomSectionContent {
+			return fmt.Errorf("custom_sections[%d].max_length must be between 0 and %d", i, MaxCustomSectionContent)
+		}
+
+		limit := MaxCustomSectionContent
+		if section.MaxLength > 0 {
+			limit = section.MaxLength
+		}
+		if utf8.RuneCountInString(section.Content) > limit {
+			return fmt.Errorf("custom_sections[%d].content must be at most %d characters", i, limit)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Package validators holds input validation shared by handlers.
+package validators
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const maxOwnerRepoLength = 100
+
+var (
+	ownerRepoPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	gitSHAPattern    = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// ValidateOwnerRepo checks that owner and repo are plain GitHub names, so they
+// cannot alter the GitHub API path they are interpolated into.
+func ValidateOwnerRepo(owner, repo string) error {
+	if err := validateName("owner", owner); err != nil {
+		return err
+	}
+	return validateName("repo", repo)
+}
+
+func validateName(field, value string) error {
+	if value == "" || len(value) > maxOwnerRepoLength {
+		return fmt.Errorf("%s must be 1-%d characters", field, maxOwnerRepoLength)
+	}
+	// "." and ".." would name the parent path segment, not a repository
+	if !ownerRepoPattern.MatchString(value) || value == "." || strings.Contains(value, "..") {
+		return fmt.Errorf("%s contains invalid characters", field)
+	}
+	return nil
+}
+
+// maxProfilePathComponents allows one directory, e.g. docs/index.md.
+const maxProfilePathComponents = 2
+
+// ValidateProfileFilePath checks that p names a markdown file in the profile
+// repository: a relative .md or .markdown path at most one directory deep,
+// without ".." components.
+func ValidateProfileFilePath(p string) error {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, "\\") {
+		return fmt.Errorf("target path must be a relative path")
+	}
+	components := strings.Split(p, "/")
+	for _, c := range components {
+		if c == "" || c == "." || c == ".." {
+			return fmt.Errorf("target path contains invalid components")
+		}
+	}
+	if len(components) > maxProfilePathComponents {
+		return fmt.Errorf("target path must have at most %d path components", maxProfilePathComponents)
+	}
+	if ext := strings.ToLower(path.Ext(p)); ext != ".md" && ext != ".markdown" {
+		return fmt.Errorf("target path must end in .md or .markdown")
+	}
+	return nil
+}
+
+// ValidateGitSHA checks that sha is a full 40-character hex object ID, as
+// GitHub returns for file contents.
+func ValidateGitSHA(sha string) error {
+	if !gitSHAPattern.MatchString(sha) {
+		return fmt.Errorf("invalid git SHA %q", sha)
+	}
+	return nil
+}
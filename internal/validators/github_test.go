@@ -0,0 +1,44 @@
+package validators
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateOwnerRepo(t *testing.T) {
+	tests := []struct {
+		name    string
+		owner   string
+		repo    string
+		wantErr bool
+	}{
+		{name: "plain names", owner: "octocat", repo: "hello-world"},
+		{name: "dots underscores and digits", owner: "octo-cat2", repo: "my_repo.v2"},
+		{name: "leading dot repo", owner: "octocat", repo: ".github"},
+		{name: "max length", owner: strings.Repeat("a", maxOwnerRepoLength), repo: "repo"},
+		{name: "empty owner", owner: "", repo: "repo", wantErr: true},
+		{name: "empty repo", owner: "octocat", repo: "", wantErr: true},
+		{name: "overlong owner", owner: strings.Repeat("a", maxOwnerRepoLength+1), repo: "repo", wantErr: true},
+		{name: "overlong repo", owner: "octocat", repo: strings.Repeat("a", maxOwnerRepoLength+1), wantErr: true},
+		{name: "parent directory", owner: "octocat", repo: "..", wantErr: true},
+		{name: "current directory", owner: ".", repo: "repo", wantErr: true},
+		{name: "leading dots", owner: "octocat", repo: "..hidden", wantErr: true},
+		{name: "traversal", owner: "octocat", repo: "../x", wantErr: true},
+		{name: "traversal in owner", owner: "../admin", repo: "repo", wantErr: true},
+		{name: "slash", owner: "octocat", repo: "a/b", wantErr: true},
+		{name: "encoded slash", owner: "octocat", repo: "a%2Fb", wantErr: true},
+		{name: "null byte", owner: "octocat", repo: "repo\x00", wantErr: true},
+		{name: "backslash", owner: "octocat", repo: `a\b`, wantErr: true},
+		{name: "query", owner: "octocat", repo: "repo?ref=main", wantErr: true},
+		{name: "whitespace", owner: "octocat", repo: "my repo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOwnerRepo(tt.owner, tt.repo)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOwnerRepo(%q, %q) error = %v, wantErr %v", tt.owner, tt.repo, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package validators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/i18n"
+	"github.com/krauzx/gitright/internal/models"
+)
+
+// ResolveLanguage checks an explicit req.Language against i18n.Locales, or
+// fills it in from the request's Accept-Language header. It stays empty,
+// meaning English, when the header matches no supported locale.
+func ResolveLanguage(req *models.ContentGenerationRequest, acceptLanguage string) error {
+	if req.Language == "" {
+		req.Language = i18n.Match(acceptLanguage)
+		return nil
+	}
+	if !i18n.Supported(req.Language) {
+		return fmt.Errorf("language must be one of: %s", strings.Join(i18n.Locales, ", "))
+	}
+	return nil
+}
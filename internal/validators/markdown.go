@@ -0,0 +1,56 @@
+package validators
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxMarkdownSize = 512 << 10
+	// maxMarkdownLines is advisory; longer READMEs are deployed with a warning.
+	maxMarkdownLines = 500
+)
+
+// MarkdownContentError is returned by ValidateMarkdownContent.
+type MarkdownContentError struct {
+	Reason string
+}
+
+func (e *MarkdownContentError) Error() string {
+	return "profile content " + e.Reason
+}
+
+// ValidateMarkdownContent checks that a README can be committed as-is: it
+// must be non-empty, valid UTF-8 without null bytes and at most
+// MaxMarkdownSize bytes.
+func ValidateMarkdownContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return &MarkdownContentError{Reason: "is empty"}
+	}
+	if len(content) > MaxMarkdownSize {
+		return &MarkdownContentError{Reason: fmt.Sprintf("exceeds %d bytes", MaxMarkdownSize)}
+	}
+	if !utf8.ValidString(content) {
+		return &MarkdownContentError{Reason: fmt.Sprintf("contains invalid UTF-8 at byte offset %d", invalidUTF8Offset(content))}
+	}
+	if i := strings.IndexByte(content, 0); i >= 0 {
+		return &MarkdownContentError{Reason: fmt.Sprintf("contains a null byte at byte offset %d", i)}
+	}
+	if lines := strings.Count(content, "\n") + 1; lines > maxMarkdownLines {
+		slog.Warn("Profile README is unusually long", "lines", lines, "max_lines", maxMarkdownLines)
+	}
+	return nil
+}
+
+func invalidUTF8Offset(s string) int {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
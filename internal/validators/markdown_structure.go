@@ -0,0 +1,68 @@
+package validators
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// StructureWarning describes markdown that GitHub will render differently
+// than intended. Warnings never block a deploy.
+type StructureWarning string
+
+var (
+	divOpenPattern  = regexp.MustCompile(`(?i)<div\b`)
+	divClosePattern = regexp.MustCompile(`(?i)</div\s*>`)
+	// strippedTagPattern matches the tags GitHub's sanitizer removes along
+	// with their content.
+	strippedTagPattern = regexp.MustCompile(`(?i)<(script|style|iframe)\b`)
+	// emojiCodePattern matches :shortcode:-like words containing an
+	// uppercase letter; GitHub's shortcodes are lowercase, so these show as
+	// literal text.
+	emojiCodePattern = regexp.MustCompile(`(?:^|\s)(:[a-zA-Z0-9_+-]*[A-Z][a-zA-Z0-9_+-]*:)(?:\s|$)`)
+	imageURLPattern  = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)`)
+)
+
+// ValidateMarkdownStructure reports structural problems in a profile README:
+// unbalanced <div> tags, an unclosed HTML comment, <script>, <style> and
+// <iframe> tags, miscapitalized emoji codes and images not served over
+// https, which GitHub's image proxy refuses.
+func ValidateMarkdownStructure(markdown string) []StructureWarning {
+	var warnings []StructureWarning
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, StructureWarning(fmt.Sprintf(format, args...)))
+	}
+
+	open := len(divOpenPattern.FindAllStringIndex(markdown, -1))
+	closed := len(divClosePattern.FindAllStringIndex(markdown, -1))
+	if open != closed {
+		warn("unbalanced div tags: %d open, %d close", open, closed)
+	}
+
+	if i := strings.LastIndex(markdown, "<!--"); i >= 0 && !strings.Contains(markdown[i:], "-->") {
+		warn("unclosed HTML comment hides the rest of the profile")
+	}
+
+	seenTags := make(map[string]bool)
+	for _, m := range strippedTagPattern.FindAllStringSubmatch(markdown, -1) {
+		tag := strings.ToLower(m[1])
+		if !seenTags[tag] {
+			seenTags[tag] = true
+			warn("<%s> tags are removed by GitHub", tag)
+		}
+	}
+
+	for _, m := range emojiCodePattern.FindAllStringSubmatch(markdown, -1) {
+		warn("emoji code %s must be lowercase", m[1])
+	}
+
+	for _, m := range imageURLPattern.FindAllStringSubmatch(markdown, -1) {
+		// Relative URLs resolve to the profile repository, which is https
+		if u, err := url.Parse(m[1]); err == nil && u.Scheme != "" && u.Scheme != "https" {
+			warn("image %s is not served over https and will be blocked", m[1])
+		}
+	}
+
+	return warnings
+}
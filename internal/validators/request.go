@@ -0,0 +1,100 @@
+package validators
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/krauzx/gitright/internal/apierr"
+)
+
+var geminiAPIKeyPattern = regexp.MustCompile(`^AIza[0-9A-Za-z_-]{35}$`)
+
+// New returns a validator for request structs' validate tags. Field names in
+// its errors are the JSON names. It is safe for concurrent use and caches
+// struct metadata, so one instance should be shared.
+func New() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// Empty keys are left to required, so the rule also fits optional keys
+	_ = v.RegisterValidation("gemini_api_key", func(fl validator.FieldLevel) bool {
+		key := fl.Field().String()
+		return key == "" || geminiAPIKeyPattern.MatchString(key)
+	})
+	_ = v.RegisterValidation("profile_path", func(fl validator.FieldLevel) bool {
+		return ValidateProfileFilePath(fl.Field().String()) == nil
+	})
+	return v
+}
+
+// FieldErrors translates the validator.ValidationErrors in err into one
+// apierr.FieldError per failed field. It returns nil for any other error.
+func FieldErrors(err error) []apierr.FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+	fields := make([]apierr.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, apierr.FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Message: fieldMessage(fe),
+		})
+	}
+	return fields
+}
+
+// fieldPath drops the struct name from a namespace such as
+// "ContentGenerationRequest.custom_sections[0].title".
+func fieldPath(namespace string) string {
+	_, path, ok := strings.Cut(namespace, ".")
+	if !ok {
+		return namespace
+	}
+	return path
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_unless":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "gemini_api_key":
+		return "must be a Gemini API key (AIza followed by 35 characters)"
+	case "profile_path":
+		return "must be a relative .md or .markdown path at most one directory deep"
+	case "min", "max":
+		return boundMessage(fe)
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
+
+// boundMessage describes a min or max failure in the unit of the field's
+// kind: characters, items or a plain number.
+func boundMessage(fe validator.FieldError) string {
+	bound := "at least"
+	if fe.Tag() == "max" {
+		bound = "at most"
+	}
+	switch fe.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters", bound, fe.Param())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit := "items"
+		if fe.Param() == "1" {
+			unit = "item"
+		}
+		return fmt.Sprintf("must have %s %s %s", bound, fe.Param(), unit)
+	}
+	return fmt.Sprintf("must be %s %s", bound, fe.Param())
+}
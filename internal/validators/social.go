@@ -0,0 +1,76 @@
+package validators
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+var (
+	mastodonUserPattern = regexp.MustCompile(`^[a-zA-Z0-9_]{1,30}$`)
+	hostnamePattern     = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}$`)
+)
+
+const blueskyProfilePrefix = "https://bsky.app/profile/"
+
+// ValidateMastodonHandle accepts user@instance.social (optionally with a
+// leading @) or the profile URL https://instance.social/@user, and returns
+// the profile URL.
+func ValidateMastodonHandle(handle string) (string, error) {
+	handle = strings.TrimSpace(handle)
+	if strings.HasPrefix(handle, "https://") {
+		u, err := url.Parse(handle)
+		if err != nil || !strings.HasPrefix(u.Path, "/@") {
+			return "", fmt.Errorf("mastodon URL must look like https://instance.social/@user")
+		}
+		handle = strings.TrimSuffix(strings.TrimPrefix(u.Path, "/@"), "/") + "@" + u.Host
+	}
+
+	user, instance, ok := strings.Cut(strings.TrimPrefix(handle, "@"), "@")
+	if !ok || !mastodonUserPattern.MatchString(user) || !hostnamePattern.MatchString(instance) {
+		return "", fmt.Errorf("mastodon handle must look like user@instance.social")
+	}
+	return "https://" + strings.ToLower(instance) + "/@" + user, nil
+}
+
+// ValidateBlueskyHandle accepts a handle such as user.bsky.social or a
+// https://bsky.app/profile/ URL and returns the profile URL.
+func ValidateBlueskyHandle(handle string) (string, error) {
+	handle = strings.TrimSpace(handle)
+	if strings.HasPrefix(handle, "https://") {
+		u, err := url.Parse(handle)
+		if err != nil || u.Host != "bsky.app" || !strings.HasPrefix(u.Path, "/profile/") {
+			return "", fmt.Errorf("bluesky URL must look like %suser.bsky.social", blueskyProfilePrefix)
+		}
+		handle = strings.TrimSuffix(strings.TrimPrefix(u.Path, "/profile/"), "/")
+	}
+
+	handle = strings.TrimPrefix(handle, "@")
+	if !hostnamePattern.MatchString(handle) {
+		return "", fmt.Errorf("bluesky handle must look like user.bsky.social")
+	}
+	return blueskyProfilePrefix + strings.ToLower(handle), nil
+}
+
+// NormalizeSocialLinks validates the Mastodon and Bluesky handles in prefs
+// and replaces them with profile URLs. Empty fields are left alone.
+func NormalizeSocialLinks(prefs *models.ContactPreferences) error {
+	if prefs.Mastodon != "" {
+		u, err := ValidateMastodonHandle(prefs.Mastodon)
+		if err != nil {
+			return err
+		}
+		prefs.Mastodon = u
+	}
+	if prefs.Bluesky != "" {
+		u, err := ValidateBlueskyHandle(prefs.Bluesky)
+		if err != nil {
+			return err
+		}
+		prefs.Bluesky = u
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package validators
+
+import (
+	"testing"
+
+	"github.com/krauzx/gitright/internal/models"
+)
+
+func TestValidateMastodonHandle(t *testing.T) {
+	tests := []struct {
+		handle  string
+		want    string
+		wantErr bool
+	}{
+		{handle: "octocat@mastodon.social", want: "https://mastodon.social/@octocat"},
+		{handle: " @octocat@Mastodon.Social ", want: "https://mastodon.social/@octocat"},
+		{handle: "https://mastodon.social/@octocat", want: "https://mastodon.social/@octocat"},
+		{handle: "https://mastodon.social/@octocat/", want: "https://mastodon.social/@octocat"},
+		{handle: "octocat", wantErr: true},
+		{handle: "octocat@localhost", wantErr: true},
+		{handle: "https://mastodon.social/octocat", wantErr: true},
+		{handle: "https://mastodon.social/@octocat/statuses/1", wantErr: true},
+		{handle: "http://mastodon.social/@octocat", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ValidateMastodonHandle(tt.handle)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateMastodonHandle(%q) error = %v, wantErr %v", tt.handle, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ValidateMastodonHandle(%q) = %q, want %q", tt.handle, got, tt.want)
+		}
+	}
+}
+
+// Requests pass through NormalizeSocialLinks in more than one handler, so
+// normalizing an already normalized value must keep it.
+func TestNormalizeSocialLinksRoundTrip(t *testing.T) {
+	prefs := models.ContactPreferences{Mastodon: "@octocat@mastodon.social", Bluesky: "octocat.bsky.social"}
+	if err := NormalizeSocialLinks(&prefs); err != nil {
+		t.Fatalf("NormalizeSocialLinks: %v", err)
+	}
+	mastodon, bluesky := prefs.Mastodon, prefs.Bluesky
+
+	if err := NormalizeSocialLinks(&prefs); err != nil {
+		t.Fatalf("NormalizeSocialLinks of normalized links: %v", err)
+	}
+	if prefs.Mastodon != mastodon || prefs.Bluesky != bluesky {
+		t.Errorf("second NormalizeSocialLinks = %q, %q, want %q, %q", prefs.Mastodon, prefs.Bluesky, mastodon, bluesky)
+	}
+}
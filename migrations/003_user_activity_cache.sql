@@ -0,0 +1,33 @@
+-- Migration: User activity summary cache
+-- Purpose: Cache cross-repo GitHub activity summaries used to seed the profile pitch
+
+CREATE TABLE IF NOT EXISTS user_activity_cache (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    summary JSONB NOT NULL,
+    cached_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() + INTERVAL '1 hour'
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_activity_cache_expires_at ON user_activity_cache(expires_at);
+
+COMMENT ON TABLE user_activity_cache IS 'Caches GitHub activity summaries per user (1 hour TTL)';
+
+-- Include the new cache in the periodic cleanup
+CREATE OR REPLACE FUNCTION cleanup_expired_data()
+RETURNS void AS $$
+BEGIN
+  DELETE FROM sessions WHERE expires_at < NOW();
+
+  DELETE FROM generated_profiles
+  WHERE expires_at < NOW()
+    AND cache_key IS NOT NULL
+    AND NOT deployed;
+
+  DELETE FROM repository_list_cache WHERE expires_at < NOW();
+
+  DELETE FROM repository_analysis_cache WHERE expires_at < NOW();
+
+  DELETE FROM user_activity_cache WHERE expires_at < NOW();
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,25 @@
+-- Migration: Scheduled profile regeneration
+-- Purpose: Per-user preferences (starting with a cron regeneration schedule) and
+-- storage of the last generation request so it can be replayed by the scheduler
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    regeneration_schedule VARCHAR(100), -- cron expression evaluated in UTC, e.g. "0 9 * * 1"
+    auto_deploy BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_preferences_schedule ON user_preferences(user_id)
+WHERE regeneration_schedule IS NOT NULL;
+
+CREATE TRIGGER update_user_preferences_updated_at BEFORE UPDATE ON user_preferences
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE generated_profiles
+  ADD COLUMN IF NOT EXISTS last_generation_request JSONB;
+
+COMMENT ON TABLE user_preferences IS 'Stores per-user application preferences';
+COMMENT ON COLUMN user_preferences.regeneration_schedule IS 'Cron expression (UTC) for automatic profile regeneration';
+COMMENT ON COLUMN user_preferences.auto_deploy IS 'Deploy automatically after a scheduled regeneration succeeds';
+COMMENT ON COLUMN generated_profiles.last_generation_request IS 'ContentGenerationRequest that produced this profile, without the API key';
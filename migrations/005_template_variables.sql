@@ -0,0 +1,8 @@
+-- Migration: Markdown template variables
+-- Purpose: Per-user overrides for the branding placeholders substituted into
+-- generated profiles ({{COMPANY_LOGO}}, {{FOOTER_TEXT}}, {{CUSTOM_BADGE_URL}})
+
+ALTER TABLE user_preferences
+  ADD COLUMN IF NOT EXISTS template_variables JSONB DEFAULT '{}'::jsonb;
+
+COMMENT ON COLUMN user_preferences.template_variables IS 'User-level template variable overrides, keyed by placeholder name';
@@ -0,0 +1,21 @@
+-- Migration: Audit log
+-- Purpose: Record who changed what and when for profiles, projects, accounts and caches
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL, -- kept after account deletion
+    action VARCHAR(50) NOT NULL, -- e.g. "profile.deployed"
+    entity_type VARCHAR(50),
+    entity_id BIGINT,
+    old_value JSONB,
+    new_value JSONB,
+    ip_address INET,
+    user_agent TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_created ON audit_logs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action);
+
+COMMENT ON TABLE audit_logs IS 'Append-only trail of user-visible changes';
+COMMENT ON COLUMN audit_logs.action IS 'Dotted action name, see internal/audit';
@@ -0,0 +1,10 @@
+-- Migration: Full-text search over generated profiles
+-- Purpose: Let users find earlier profile versions by technology or phrase
+
+ALTER TABLE generated_profiles
+  ADD COLUMN IF NOT EXISTS content_search TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('english', content)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_generated_profiles_content_search ON generated_profiles USING GIN (content_search);
+
+COMMENT ON COLUMN generated_profiles.content_search IS 'English full-text index of content, maintained by Postgres';
@@ -0,0 +1,29 @@
+-- Migration: Scoped repository analysis cache
+-- Purpose: Share analyses of public repositories across users while keeping
+-- private repository analyses per user
+
+DO $$
+BEGIN
+  CREATE TYPE analysis_cache_scope AS ENUM ('user', 'global');
+EXCEPTION
+  WHEN duplicate_object THEN NULL;
+END $$;
+
+ALTER TABLE repository_analysis_cache
+  ADD COLUMN IF NOT EXISTS cache_scope analysis_cache_scope NOT NULL DEFAULT 'global',
+  ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+  ADD COLUMN IF NOT EXISTS cache_key VARCHAR(255);
+
+-- Existing rows were keyed by github_id alone; treat them as global
+UPDATE repository_analysis_cache
+SET cache_key = 'analysis:global:' || github_id
+WHERE cache_key IS NULL;
+
+ALTER TABLE repository_analysis_cache
+  ALTER COLUMN cache_key SET NOT NULL,
+  DROP CONSTRAINT IF EXISTS repository_analysis_cache_github_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_repository_analysis_cache_cache_key ON repository_analysis_cache(cache_key);
+
+COMMENT ON COLUMN repository_analysis_cache.cache_scope IS 'global for public repositories (24h TTL), user for private ones (7d TTL)';
+COMMENT ON COLUMN repository_analysis_cache.cache_key IS 'analysis:global:{github_id} or analysis:user:{user_id}:{github_id}';
@@ -0,0 +1,7 @@
+-- Migration: Typing SVG configuration
+-- Purpose: Per-user readme-typing-svg parameters for the profile hero section
+
+ALTER TABLE user_preferences
+  ADD COLUMN IF NOT EXISTS typing_svg JSONB DEFAULT '{}'::jsonb;
+
+COMMENT ON COLUMN user_preferences.typing_svg IS 'readme-typing-svg overrides; empty or zero fields use the built-in defaults';
@@ -0,0 +1,7 @@
+-- Migration: Webhook secrets
+-- Purpose: Per-user secret for validating GitHub webhook signatures
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS webhook_secret TEXT;
+
+COMMENT ON COLUMN users.webhook_secret IS 'HMAC-SHA256 key for X-Hub-Signature-256; NULL rejects all webhooks for the user';
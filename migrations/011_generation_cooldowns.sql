@@ -0,0 +1,10 @@
+-- Migration: Generation cooldowns
+-- Purpose: Per-user throttle on profile generations that reach the LLM
+
+CREATE TABLE IF NOT EXISTS generation_cooldowns (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_generated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    count_today INTEGER NOT NULL DEFAULT 0 -- generations on last_generated_at's UTC day
+);
+
+COMMENT ON TABLE generation_cooldowns IS 'Last LLM generation per user; cache hits are not recorded';
@@ -0,0 +1,27 @@
+-- Migration: Profile A/B tests
+-- Purpose: Compare two generated profile variants and record which one the user preferred
+
+CREATE TABLE IF NOT EXISTS ab_test_variants (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    request JSONB NOT NULL, -- ContentGenerationRequest without the API key
+    response JSONB NOT NULL, -- ContentGenerationResponse
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS ab_tests (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    test_name VARCHAR(100) NOT NULL,
+    variant_a_id BIGINT NOT NULL REFERENCES ab_test_variants(id) ON DELETE CASCADE,
+    variant_b_id BIGINT NOT NULL REFERENCES ab_test_variants(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, completed
+    selected_variant CHAR(1) CHECK (selected_variant IN ('a', 'b')),
+    selected_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ab_tests_user_created ON ab_tests(user_id, created_at DESC);
+
+COMMENT ON TABLE ab_tests IS 'Pairs of profile variants generated for comparison';
+COMMENT ON COLUMN ab_tests.selected_variant IS 'Variant the user preferred; NULL while pending';
@@ -0,0 +1,38 @@
+-- Migration: Profile wizard sessions
+-- Purpose: Keep the generation wizard's selections across requests and page reloads
+
+CREATE TABLE IF NOT EXISTS wizard_sessions (
+    id UUID PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    step INTEGER NOT NULL DEFAULT 1,
+    state JSONB NOT NULL DEFAULT '{}'::jsonb, -- partial ContentGenerationRequest, never holds the API key
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_wizard_sessions_user_id ON wizard_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_wizard_sessions_expires_at ON wizard_sessions(expires_at);
+
+COMMENT ON TABLE wizard_sessions IS 'Profile wizard progress; expires 1 hour after the last update';
+
+-- Include wizard sessions in the periodic cleanup
+CREATE OR REPLACE FUNCTION cleanup_expired_data()
+RETURNS void AS $$
+BEGIN
+  DELETE FROM sessions WHERE expires_at < NOW();
+
+  DELETE FROM generated_profiles
+  WHERE expires_at < NOW()
+    AND cache_key IS NOT NULL
+    AND NOT deployed;
+
+  DELETE FROM repository_list_cache WHERE expires_at < NOW();
+
+  DELETE FROM repository_analysis_cache WHERE expires_at < NOW();
+
+  DELETE FROM user_activity_cache WHERE expires_at < NOW();
+
+  DELETE FROM wizard_sessions WHERE expires_at < NOW();
+END;
+$$ LANGUAGE plpgsql;
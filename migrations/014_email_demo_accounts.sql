@@ -0,0 +1,47 @@
+-- Migration: Email login for demo accounts
+-- Purpose: Let people try GitRight with a one-time email code instead of GitHub OAuth
+
+-- Demo accounts have no GitHub identity
+ALTER TABLE users ALTER COLUMN github_id DROP NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'github'
+    CHECK (source IN ('github', 'email'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE; -- NULL for GitHub accounts
+
+CREATE INDEX IF NOT EXISTS idx_users_demo_email ON users(email) WHERE source = 'email';
+
+COMMENT ON COLUMN users.source IS 'github for OAuth accounts; email for demo accounts, which expire and cannot deploy';
+
+CREATE TABLE IF NOT EXISTS email_login_codes (
+    email VARCHAR(255) PRIMARY KEY,
+    code_hash VARCHAR(64) NOT NULL, -- hex SHA-256 of the 6-digit code
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_login_codes_expires_at ON email_login_codes(expires_at);
+
+-- Include login codes and expired demo accounts in the periodic cleanup
+CREATE OR REPLACE FUNCTION cleanup_expired_data()
+RETURNS void AS $$
+BEGIN
+  DELETE FROM sessions WHERE expires_at < NOW();
+
+  DELETE FROM generated_profiles
+  WHERE expires_at < NOW()
+    AND cache_key IS NOT NULL
+    AND NOT deployed;
+
+  DELETE FROM repository_list_cache WHERE expires_at < NOW();
+
+  DELETE FROM repository_analysis_cache WHERE expires_at < NOW();
+
+  DELETE FROM user_activity_cache WHERE expires_at < NOW();
+
+  DELETE FROM wizard_sessions WHERE expires_at < NOW();
+
+  DELETE FROM email_login_codes WHERE expires_at < NOW();
+
+  DELETE FROM users WHERE source = 'email' AND expires_at < NOW();
+END;
+$$ LANGUAGE plpgsql;
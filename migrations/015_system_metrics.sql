@@ -0,0 +1,54 @@
+-- Migration: System metrics
+-- Purpose: Record profile generation and LLM usage events for the admin metrics snapshot
+
+CREATE TABLE IF NOT EXISTS profile_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('generated', 'cache_hit', 'deployed', 'failed')),
+    duration_ms INTEGER, -- LLM generations only
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_events_created_at ON profile_events(created_at);
+
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_created_at ON llm_usage(created_at);
+
+COMMENT ON TABLE profile_events IS 'One row per profile generation, cache hit, failure or deploy; kept 90 days';
+COMMENT ON TABLE llm_usage IS 'Token counts reported by the LLM per request; kept 90 days';
+
+-- Keep 90 days of events
+CREATE OR REPLACE FUNCTION cleanup_expired_data()
+RETURNS void AS $$
+BEGIN
+  DELETE FROM sessions WHERE expires_at < NOW();
+
+  DELETE FROM generated_profiles
+  WHERE expires_at < NOW()
+    AND cache_key IS NOT NULL
+    AND NOT deployed;
+
+  DELETE FROM repository_list_cache WHERE expires_at < NOW();
+
+  DELETE FROM repository_analysis_cache WHERE expires_at < NOW();
+
+  DELETE FROM user_activity_cache WHERE expires_at < NOW();
+
+  DELETE FROM wizard_sessions WHERE expires_at < NOW();
+
+  DELETE FROM email_login_codes WHERE expires_at < NOW();
+
+  DELETE FROM users WHERE source = 'email' AND expires_at < NOW();
+
+  DELETE FROM profile_events WHERE created_at < NOW() - INTERVAL '90 days';
+
+  DELETE FROM llm_usage WHERE created_at < NOW() - INTERVAL '90 days';
+END;
+$$ LANGUAGE plpgsql;
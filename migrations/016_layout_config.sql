@@ -0,0 +1,7 @@
+-- Migration: Profile layout configuration
+-- Purpose: Per-user grouping of profile sections into sidebar, main and full-width columns
+
+ALTER TABLE user_preferences
+  ADD COLUMN IF NOT EXISTS layout_config JSONB DEFAULT '{}'::jsonb;
+
+COMMENT ON COLUMN user_preferences.layout_config IS 'Section groupings overriding the default layout; unlisted sections keep their default group';
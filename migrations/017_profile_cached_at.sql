@@ -0,0 +1,12 @@
+-- Migration: Profile cache timestamps
+-- Purpose: Record when a cached profile was last stored, for X-Cache-Age
+
+ALTER TABLE generated_profiles
+  ADD COLUMN IF NOT EXISTS cached_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE generated_profiles SET cached_at = created_at WHERE cached_at IS NULL;
+
+ALTER TABLE generated_profiles
+  ALTER COLUMN cached_at SET DEFAULT NOW();
+
+COMMENT ON COLUMN generated_profiles.cached_at IS 'When the content was last stored; created_at keeps the first generation';
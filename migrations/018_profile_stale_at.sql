@@ -0,0 +1,9 @@
+-- Migration: Profile cache stale-while-revalidate
+-- Purpose: Mark when a cached profile should be regenerated in the background
+
+ALTER TABLE generated_profiles
+  ADD COLUMN IF NOT EXISTS stale_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE generated_profiles SET stale_at = expires_at - INTERVAL '4 hours' WHERE stale_at IS NULL;
+
+COMMENT ON COLUMN generated_profiles.stale_at IS 'After this, cache hits are still served but trigger a background regeneration';
@@ -0,0 +1,11 @@
+-- Migration: Encrypted OAuth tokens
+-- Purpose: Track which users' tokens are stored AES-256-GCM encrypted
+
+-- access_token and refresh_token are TEXT, which fits the base64
+-- ciphertext. Tokens stored before this migration stay readable as plain
+-- text; the server encrypts them at startup, since the key isn't available
+-- to SQL.
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS tokens_encrypted BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN users.tokens_encrypted IS 'Whether access_token and refresh_token are encrypted with TOKEN_ENCRYPTION_KEY';
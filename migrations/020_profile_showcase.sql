@@ -0,0 +1,14 @@
+-- Migration: Public profile showcase
+-- Purpose: Let users opt in to listing their deployed profile in the public showcase
+
+ALTER TABLE user_preferences
+  ADD COLUMN IF NOT EXISTS showcase_opt_in BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE generated_profiles
+  ADD COLUMN IF NOT EXISTS top_languages JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_generated_profiles_showcase
+  ON generated_profiles(user_id, deployed_at DESC) WHERE deployed;
+
+COMMENT ON COLUMN user_preferences.showcase_opt_in IS 'List the latest deployed profile in the public showcase';
+COMMENT ON COLUMN generated_profiles.top_languages IS 'The projects'' languages by share of code, most used first';
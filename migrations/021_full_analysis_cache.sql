@@ -0,0 +1,11 @@
+-- Migration: Full repository analysis cache
+-- Purpose: Cache the whole RepositoryAnalysis, so cache hits and stale
+-- fallbacks keep repository metadata and every analysis signal
+
+ALTER TABLE repository_analysis_cache
+  ADD COLUMN IF NOT EXISTS analysis JSONB;
+
+-- Rows cached before this migration have no analysis and are read from the
+-- original columns until they are refreshed. The original columns are
+-- still written so instances not yet upgraded can read new rows.
+COMMENT ON COLUMN repository_analysis_cache.analysis IS 'The complete RepositoryAnalysis as JSON; NULL for rows cached before migration 021';
@@ -0,0 +1,42 @@
+-- Migration: Email login limits
+-- Purpose: Throttle login code emails and code guesses per address and per
+-- client IP
+
+CREATE TABLE IF NOT EXISTS email_login_limits (
+    subject VARCHAR(300) PRIMARY KEY, -- e.g. 'send:email:<address>' or 'verify:ip:<client IP>'
+    last_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    count_today INTEGER NOT NULL DEFAULT 0 -- events on last_at's UTC day
+);
+
+COMMENT ON TABLE email_login_limits IS 'Login code sends and verifications per email address and per client IP';
+
+-- Counts only matter for a day
+CREATE OR REPLACE FUNCTION cleanup_expired_data()
+RETURNS void AS $$
+BEGIN
+  DELETE FROM sessions WHERE expires_at < NOW();
+
+  DELETE FROM generated_profiles
+  WHERE expires_at < NOW()
+    AND cache_key IS NOT NULL
+    AND NOT deployed;
+
+  DELETE FROM repository_list_cache WHERE expires_at < NOW();
+
+  DELETE FROM repository_analysis_cache WHERE expires_at < NOW();
+
+  DELETE FROM user_activity_cache WHERE expires_at < NOW();
+
+  DELETE FROM wizard_sessions WHERE expires_at < NOW();
+
+  DELETE FROM email_login_codes WHERE expires_at < NOW();
+
+  DELETE FROM email_login_limits WHERE last_at < NOW() - INTERVAL '2 days';
+
+  DELETE FROM users WHERE source = 'email' AND expires_at < NOW();
+
+  DELETE FROM profile_events WHERE created_at < NOW() - INTERVAL '90 days';
+
+  DELETE FROM llm_usage WHERE created_at < NOW() - INTERVAL '90 days';
+END;
+$$ LANGUAGE plpgsql;
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"strings"
@@ -73,3 +74,33 @@ func Middleware() echo.MiddlewareFunc {
 		},
 	})
 }
+
+type loggerKey struct{}
+
+// WithContext returns a copy of ctx carrying l for FromContext.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger stored by WithContext, or the default
+// logger for background work such as scheduled regeneration.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// ContextMiddleware stores a logger tagged with the request ID in the request
+// context so service and repository logs can be correlated with the access
+// log. It must run after middleware.RequestID.
+func ContextMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := c.Response().Header().Get(echo.HeaderXRequestID)
+			l := slog.Default().With("request_id", id)
+			c.SetRequest(c.Request().WithContext(WithContext(c.Request().Context(), l)))
+			return next(c)
+		}
+	}
+}
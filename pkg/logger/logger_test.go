@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func TestContextMiddlewareTagsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	e := echo.New()
+	e.Use(middleware.RequestID(), ContextMiddleware())
+	e.GET("/", func(c echo.Context) error {
+		FromContext(c.Request().Context()).Info("handled")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-123")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log entry %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "handled" {
+		t.Errorf("msg = %v, want handled", entry["msg"])
+	}
+	if entry["request_id"] != "req-123" {
+		t.Errorf("request_id = %v, want req-123", entry["request_id"])
+	}
+}
+
+func TestFromContextDefault(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if FromContext(req.Context()) != slog.Default() {
+		t.Error("FromContext without a stored logger did not return the default logger")
+	}
+}
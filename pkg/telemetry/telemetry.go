@@ -0,0 +1,151 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krauzx/gitright/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	StatusDisabled = "disabled"
+	StatusHealthy  = "healthy"
+	StatusDegraded = "degraded"
+
+	// errorWindow is how long an export error keeps Status degraded.
+	errorWindow = 5 * time.Minute
+
+	meterName = "github.com/krauzx/gitright"
+)
+
+// Counters are created against the global MeterProvider, which forwards to
+// the SDK provider once Setup installs it and is a no-op otherwise.
+var (
+	ProfilesGenerated = mustCounter("profile.generated.total", "Profiles generated")
+	ProfilesDeployed  = mustCounter("profile.deployed.total", "Profiles deployed to GitHub")
+	LLMErrors         = mustCounter("llm.errors.total", "Failed LLM requests")
+)
+
+var state struct {
+	mu        sync.Mutex
+	enabled   bool
+	lastError time.Time
+}
+
+// Setup installs OTLP gRPC trace and metric exporters as the global
+// providers. With no endpoint configured it does nothing and returns a no-op
+// shutdown. The returned shutdown flushes pending telemetry.
+func Setup(cfg config.TelemetryConfig) (shutdown func(), err error) {
+	if cfg.OTLPEndpoint == "" {
+		return func() {}, nil
+	}
+
+	ctx := context.Background()
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build telemetry resource: %w", err)
+	}
+
+	traceOpts := []otlptracegrpc.Option{}
+	metricOpts := []otlpmetricgrpc.Option{}
+	if strings.Contains(cfg.OTLPEndpoint, "://") {
+		traceOpts = append(traceOpts, otlptracegrpc.WithEndpointURL(cfg.OTLPEndpoint))
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithEndpointURL(cfg.OTLPEndpoint))
+	} else {
+		traceOpts = append(traceOpts, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint))
+	}
+	if cfg.Insecure {
+		traceOpts = append(traceOpts, otlptracegrpc.WithInsecure())
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithInsecure())
+	}
+
+	traceExporter, err := otlptracegrpc.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	metricExporter, err := otlpmetricgrpc.New(ctx, metricOpts...)
+	if err != nil {
+		_ = traceExporter.Shutdown(ctx)
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		state.mu.Lock()
+		state.lastError = time.Now()
+		state.mu.Unlock()
+		slog.Warn("Telemetry export failed", "error", err)
+	}))
+
+	state.mu.Lock()
+	state.enabled = true
+	state.mu.Unlock()
+
+	slog.Info("Telemetry enabled", "endpoint", cfg.OTLPEndpoint, "service", cfg.ServiceName)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx)); err != nil {
+			slog.Warn("Failed to shut down telemetry", "error", err)
+		}
+	}, nil
+}
+
+// Status reports whether telemetry is disabled, exporting normally, or has
+// failed to export within the last few minutes.
+func Status() string {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	switch {
+	case !state.enabled:
+		return StatusDisabled
+	case time.Since(state.lastError) < errorWindow:
+		return StatusDegraded
+	default:
+		return StatusHealthy
+	}
+}
+
+func mustCounter(name, description string) metric.Int64Counter {
+	counter, err := otel.Meter(meterName).Int64Counter(name, metric.WithDescription(description))
+	if err != nil {
+		panic(fmt.Sprintf("telemetry: invalid counter %s: %v", name, err))
+	}
+	return counter
+}